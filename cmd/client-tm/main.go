@@ -108,6 +108,7 @@ type MirageAdmin interface {
 	ReconcileIntent(intentID string) (session.Report, error)
 	ReconcileAll() ([]session.Report, error)
 	SnapshotIntent(intentID string) (mirage.IntentSnapshot, bool, error)
+	TailProgress(intentID string, cursor uint64, limit int) (mirage.ProgressTail, error)
 	ListIntents() ([]string, error)
 	RecentReports(limit int) ([]session.Report, error)
 	SpawnLocalGhost(req mirage.SpawnGhostRequest) (mirage.SpawnGhostResult, error)
@@ -218,6 +219,7 @@ type mirageControlRequest struct {
 	Issue          MirageIssueRequest       `json:"issue,omitempty"`
 	Spawn          mirage.SpawnGhostRequest `json:"spawn,omitempty"`
 	GhostAdminAddr string                   `json:"ghost_admin_addr,omitempty"`
+	Cursor         uint64                   `json:"cursor,omitempty"`
 }
 
 type mirageControlResponse struct {
@@ -1332,8 +1334,9 @@ func (a *App) runMirageReconcileConsole(target MirageTarget) error {
 		fmt.Println("  1) List intents")
 		fmt.Println("  2) Reconcile one intent (*)")
 		fmt.Println("  3) Reconcile all intents (*)")
-		fmt.Println("  4) Back")
-		choice, err := a.promptInt("Choose", 1, 4, true, true)
+		fmt.Println("  4) Reconcile one intent and tail progress (*)")
+		fmt.Println("  5) Back")
+		choice, err := a.promptInt("Choose", 1, 5, true, true)
 		if err != nil {
 			return err
 		}
@@ -1352,6 +1355,10 @@ func (a *App) runMirageReconcileConsole(target MirageTarget) error {
 				logs.Errf("reconcile all failed: %v", err)
 			}
 		case 4:
+			if err := a.tailMirageIntentProgress(target); err != nil {
+				logs.Errf("tail progress failed: %v", err)
+			}
+		case 5:
 			return nil
 		}
 	}
@@ -1452,6 +1459,67 @@ func (a *App) reconcileMirageIntent(target MirageTarget) error {
 	return nil
 }
 
+// tailMirageIntentProgress reconciles one intent on a second admin connection and
+// prints its progress records until the reconcile returns and the tail is drained.
+func (a *App) tailMirageIntentProgress(target MirageTarget) error {
+	intentID, err := a.promptLine("intent_id")
+	if err != nil {
+		return err
+	}
+	intentID = strings.TrimSpace(intentID)
+
+	type reconcileResult struct {
+		report session.Report
+		err    error
+	}
+	done := make(chan reconcileResult, 1)
+	go func() {
+		admin := NewRemoteMirageAdmin(target.Admin.Address())
+		defer admin.Close()
+		report, err := admin.ReconcileIntent(intentID)
+		done <- reconcileResult{report: report, err: err}
+	}()
+
+	var cursor uint64
+	var result *reconcileResult
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		tail, err := target.Admin.TailProgress(intentID, cursor, 0)
+		if err != nil {
+			return err
+		}
+		for _, rec := range tail.Records {
+			printProgressRecord(rec)
+		}
+		cursor = tail.Cursor
+		if result != nil && len(tail.Records) == 0 {
+			break
+		}
+		select {
+		case res := <-done:
+			result = &res
+		case <-ticker.C:
+		}
+	}
+	if result.err != nil {
+		return result.err
+	}
+	printMirageReport("Reconcile Result", result.report)
+	return nil
+}
+
+func printProgressRecord(rec mirage.ObservedProgress) {
+	if len(rec.Chunk) > 0 {
+		prefix := fmt.Sprintf("[%s %s]", rec.CommandID, rec.Stream)
+		for _, line := range strings.Split(strings.TrimRight(string(rec.Chunk), "\n"), "\n") {
+			fmt.Printf("%s %s\n", prefix, line)
+		}
+		return
+	}
+	fmt.Printf("[%s] %3d%% %s\n", rec.CommandID, rec.Percent, rec.Message)
+}
+
 func (a *App) reconcileAllMirageIntents(target MirageTarget) error {
 	reports, err := target.Admin.ReconcileAll()
 	if err != nil {
@@ -1990,6 +2058,20 @@ func (c *RemoteMirageAdmin) SnapshotIntent(intentID string) (mirage.IntentSnapsh
 	return out.Snapshot, out.Found, nil
 }
 
+func (c *RemoteMirageAdmin) TailProgress(intentID string, cursor uint64, limit int) (mirage.ProgressTail, error) {
+	var out mirage.ProgressTail
+	req := mirageControlRequest{
+		Action:   "tail_progress",
+		IntentID: strings.TrimSpace(intentID),
+		Cursor:   cursor,
+		Limit:    limit,
+	}
+	if err := c.call(req, &out); err != nil {
+		return mirage.ProgressTail{}, err
+	}
+	return out, nil
+}
+
 func (c *RemoteMirageAdmin) ListIntents() ([]string, error) {
 	var out []string
 	if err := c.call(mirageControlRequest{Action: "list_intents"}, &out); err != nil {
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/mirage"
+	"github.com/danmuck/edgectl/internal/pki"
+)
+
+const usage = `usage: edgectl pki <command> [flags] [args]
+       edgectl join-token [-admin addr] [-ghost ghost_id] [-ttl 15m]
+
+pki commands:
+  init-ca                 create the Mirage CA, index, and empty CRL
+  issue-ghost <ghost_id>  issue a Ghost client cert (CN and DNS SAN = ghost_id)
+  issue-mirage <id>       issue a Mirage server cert
+  revoke <serial|name>    revoke certs and rewrite the CRL
+  list                    list issued certs
+
+join-token mints a one-time Ghost enrollment token on a running Mirage.`
+
+var errUsage = errors.New(usage)
+
+// edgectl entrypoint for operator tooling subcommands.
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "edgectl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// edgectl top-level dispatcher; split from main for tests.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "pki":
+		return runPKI(args[1:], stdout)
+	case "join-token":
+		return runJoinToken(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%w", args[0], errUsage)
+	}
+}
+
+// edgectl pki subcommand dispatcher.
+func runPKI(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("edgectl pki "+cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dir := fs.String("dir", "pki", "authority directory")
+	days := fs.Int("days", 0, "validity in days (0 uses the default)")
+	switch cmd {
+	case "init-ca":
+		cn := fs.String("cn", "edgectl-mirage-ca", "CA common name")
+		if err := parseArgs(fs, args, 0); err != nil {
+			return err
+		}
+		a, err := pki.Init(*dir, *cn, validity(*days))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "ca: %s\ncrl: %s\n", a.CAFile(), a.CRLFile())
+		return nil
+	case "issue-ghost":
+		if err := parseArgs(fs, args, 1); err != nil {
+			return err
+		}
+		a, err := pki.Open(*dir)
+		if err != nil {
+			return err
+		}
+		entry, err := a.IssueGhost(fs.Arg(0), validity(*days))
+		if err != nil {
+			return err
+		}
+		printEntry(stdout, entry)
+		fmt.Fprintf(stdout, "\n# ghostctl config.toml\nmirage_tls_enabled = true\nmirage_tls_mutual = true\n")
+		fmt.Fprintf(stdout, "mirage_tls_cert_file = %q\nmirage_tls_key_file = %q\nmirage_tls_ca_file = %q\n",
+			entry.CertFile, entry.KeyFile, a.CAFile())
+		return nil
+	case "issue-mirage":
+		dns := fs.String("dns", "", "comma-separated DNS SANs")
+		ips := fs.String("ip", "", "comma-separated IP SANs")
+		if err := parseArgs(fs, args, 1); err != nil {
+			return err
+		}
+		parsedIPs, err := parseIPs(*ips)
+		if err != nil {
+			return err
+		}
+		a, err := pki.Open(*dir)
+		if err != nil {
+			return err
+		}
+		entry, err := a.IssueMirage(fs.Arg(0), splitList(*dns), parsedIPs, validity(*days))
+		if err != nil {
+			return err
+		}
+		printEntry(stdout, entry)
+		fmt.Fprintf(stdout, "\n# miragectl config.toml\nsession_tls_enabled = true\nsession_tls_mutual = true\n")
+		fmt.Fprintf(stdout, "session_tls_cert_file = %q\nsession_tls_key_file = %q\nsession_tls_ca_file = %q\nsession_tls_crl_file = %q\n",
+			entry.CertFile, entry.KeyFile, a.CAFile(), a.CRLFile())
+		fmt.Fprintf(stdout, "# optional: sign enrolling Ghosts with this CA\nenroll_ca_dir = %q\n", *dir)
+		return nil
+	case "revoke":
+		if err := parseArgs(fs, args, 1); err != nil {
+			return err
+		}
+		a, err := pki.Open(*dir)
+		if err != nil {
+			return err
+		}
+		revoked, err := a.Revoke(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		for _, entry := range revoked {
+			fmt.Fprintf(stdout, "revoked %s %s serial=%s\n", entry.Kind, entry.Name, entry.Serial)
+		}
+		fmt.Fprintf(stdout, "crl: %s\n", a.CRLFile())
+		return nil
+	case "list":
+		if err := parseArgs(fs, args, 0); err != nil {
+			return err
+		}
+		a, err := pki.Open(*dir)
+		if err != nil {
+			return err
+		}
+		entries, err := a.List()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tNAME\tSERIAL\tNOT_AFTER\tSTATUS")
+		for _, entry := range entries {
+			status := "valid"
+			switch {
+			case entry.Revoked():
+				status = "revoked"
+			case time.Now().After(entry.NotAfter):
+				status = "expired"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				entry.Kind, entry.Name, entry.Serial, entry.NotAfter.Format(time.RFC3339), status)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown pki command %q\n%w", cmd, errUsage)
+	}
+}
+
+// edgectl join-token: asks the Mirage admin endpoint for a one-time enrollment token.
+func runJoinToken(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("edgectl join-token", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	admin := fs.String("admin", "127.0.0.1:7020", "Mirage admin_listen_addr")
+	ghostID := fs.String("ghost", "", "restrict the token to one ghost_id")
+	ttl := fs.String("ttl", "", "token lifetime (empty uses Mirage enroll_token_ttl)")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", *admin, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	req, err := json.Marshal(map[string]string{
+		"action":   "mint_join_token",
+		"ghost_id": strings.TrimSpace(*ghostID),
+		"ttl":      strings.TrimSpace(*ttl),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(req, '\n')); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		OK    bool                          `json:"ok"`
+		Error string                        `json:"error"`
+		Data  mirage.AdminJoinTokenResponse `json:"data"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("mirage: %s", resp.Error)
+	}
+	fmt.Fprintf(stdout, "token: %s\nexpires_at: %s\n", resp.Data.Token, resp.Data.ExpiresAt.Format(time.RFC3339))
+	fmt.Fprintf(stdout, "\n# ghostctl config.toml\nmirage_tls_enabled = true\nmirage_tls_mutual = true\nmirage_tls_join_token = %q\n", resp.Data.Token)
+	return nil
+}
+
+// edgectl flag parse requiring exactly positional trailing args.
+func parseArgs(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", fs.Name(), err)
+	}
+	if fs.NArg() != positional {
+		return fmt.Errorf("%s: expected %d argument(s), got %d\n%w", fs.Name(), positional, fs.NArg(), errUsage)
+	}
+	return nil
+}
+
+// edgectl days flag to a validity window; zero keeps the pki default.
+func validity(days int) time.Duration {
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// edgectl issued-cert summary.
+func printEntry(w io.Writer, entry pki.Entry) {
+	fmt.Fprintf(w, "issued %s %s serial=%s not_after=%s\ncert: %s\nkey: %s\n",
+		entry.Kind, entry.Name, entry.Serial, entry.NotAfter.Format(time.RFC3339), entry.CertFile, entry.KeyFile)
+}
+
+// edgectl comma-separated flag splitter dropping blanks.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// edgectl IP SAN flag parser.
+func parseIPs(raw string) ([]net.IP, error) {
+	var out []net.IP
+	for _, part := range splitList(raw) {
+		ip := net.ParseIP(part)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip SAN %q", part)
+		}
+		out = append(out, ip)
+	}
+	return out, nil
+}
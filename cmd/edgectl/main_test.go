@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPKICommandsIssueListAndRevoke(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pki")
+
+	steps := [][]string{
+		{"pki", "init-ca", "-dir", dir, "-cn", "edgectl-test-ca"},
+		{"pki", "issue-mirage", "-dir", dir, "-dns", "mirage.local", "-ip", "127.0.0.1", "mirage.main"},
+		{"pki", "issue-ghost", "-dir", dir, "-days", "30", "ghost.alpha"},
+		{"pki", "revoke", "-dir", dir, "ghost.alpha"},
+	}
+	var out bytes.Buffer
+	for _, args := range steps {
+		if err := run(args, &out); err != nil {
+			t.Fatalf("run %v: %v", args, err)
+		}
+	}
+	for _, want := range []string{"session_tls_crl_file", "mirage_tls_cert_file", "revoked ghost ghost.alpha"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"pki", "list", "-dir", dir}, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "revoked") || !strings.Contains(lines[2], "valid") {
+		t.Fatalf("unexpected list output:\n%s", out.String())
+	}
+
+	if err := run([]string{"pki", "issue-ghost", "-dir", dir}, &out); err == nil {
+		t.Fatalf("expected missing ghost_id rejected")
+	}
+}
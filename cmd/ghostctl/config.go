@@ -35,10 +35,35 @@ type fileConfig struct {
 	MirageTLSCAFile      string            `toml:"mirage_tls_ca_file"`
 	MirageTLSServerName  string            `toml:"mirage_tls_server_name"`
 	MirageTLSInsecure    bool              `toml:"mirage_tls_insecure_skip_verify"`
+	MirageTLSRehandshake bool              `toml:"mirage_tls_rehandshake_on_expiry"`
+	MirageTLSJoinToken   string            `toml:"mirage_tls_join_token"`
+	MirageOutboxPath     string            `toml:"mirage_outbox_path"`
+	MirageAuthRequired   bool              `toml:"mirage_auth_required"`
+	MirageAuthKeyID      string            `toml:"mirage_auth_key_id"`
+	MirageAuthKeyFile    string            `toml:"mirage_auth_key_file"`
+	MirageAuthMaxSkew    string            `toml:"mirage_auth_max_skew"`
+	MirageCompression    bool              `toml:"mirage_compression_enabled"`
+	MirageCompressMin    int               `toml:"mirage_compression_threshold"`
 	SeedInstallEnabled   bool              `toml:"seed_install_enabled"`
 	SeedInstallRoot      string            `toml:"seed_install_root"`
 	SeedInstallWhitelist []string          `toml:"seed_install_whitelist"`
 	SeedInstall          []fileSeedInstall `toml:"seed_install"`
+	ExecWorkers          int               `toml:"exec_workers"`
+	ExecQueueDepth       int               `toml:"exec_queue_depth"`
+	ExecSeedConcurrency  int               `toml:"exec_seed_concurrency"`
+	ExecLimits           []fileExecLimit   `toml:"exec_limit"`
+	ExecRetentionTTL     string            `toml:"exec_retention_ttl"`
+	ExecRetentionMax     int               `toml:"exec_retention_max_entries"`
+	ExecJournalPath      string            `toml:"exec_journal_path"`
+	ExecJournalSync      string            `toml:"exec_journal_sync"`
+	ExecJournalInterval  string            `toml:"exec_journal_sync_interval"`
+}
+
+// ghostctl execution-limit table mapping from config.toml.
+type fileExecLimit struct {
+	SeedID        string `toml:"seed_id"`
+	Operation     string `toml:"operation"`
+	MaxConcurrent int    `toml:"max_concurrent"`
 }
 
 // ghostctl seed-install table mapping from config.toml.
@@ -148,6 +173,37 @@ func loadServiceConfig(path string) (ghost.ServiceConfig, error) {
 	if meta.IsDefined("mirage_tls_insecure_skip_verify") {
 		cfg.Mirage.SessionConfig.TLS.InsecureSkipVerify = raw.MirageTLSInsecure
 	}
+	if meta.IsDefined("mirage_tls_rehandshake_on_expiry") {
+		cfg.Mirage.SessionConfig.TLS.RehandshakeOnExpiry = raw.MirageTLSRehandshake
+	}
+	if meta.IsDefined("mirage_tls_join_token") {
+		cfg.Mirage.SessionConfig.TLS.JoinToken = strings.TrimSpace(raw.MirageTLSJoinToken)
+	}
+	if meta.IsDefined("mirage_outbox_path") {
+		cfg.Mirage.OutboxPath = strings.TrimSpace(raw.MirageOutboxPath)
+	}
+	if meta.IsDefined("mirage_auth_required") {
+		cfg.Mirage.SessionConfig.Auth.Required = raw.MirageAuthRequired
+	}
+	if meta.IsDefined("mirage_auth_key_id") {
+		cfg.Mirage.SessionConfig.Auth.KeyID = strings.TrimSpace(raw.MirageAuthKeyID)
+	}
+	if meta.IsDefined("mirage_auth_key_file") {
+		cfg.Mirage.SessionConfig.Auth.KeyFile = strings.TrimSpace(raw.MirageAuthKeyFile)
+	}
+	if meta.IsDefined("mirage_auth_max_skew") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.MirageAuthMaxSkew))
+		if err != nil {
+			return ghost.ServiceConfig{}, fmt.Errorf("parse mirage_auth_max_skew: %w", err)
+		}
+		cfg.Mirage.SessionConfig.Auth.MaxSkew = d
+	}
+	if meta.IsDefined("mirage_compression_enabled") {
+		cfg.Mirage.SessionConfig.Compression.Enabled = raw.MirageCompression
+	}
+	if meta.IsDefined("mirage_compression_threshold") {
+		cfg.Mirage.SessionConfig.Compression.Threshold = raw.MirageCompressMin
+	}
 	if meta.IsDefined("seed_install_enabled") {
 		cfg.SeedInstall.Enabled = raw.SeedInstallEnabled
 	}
@@ -164,6 +220,51 @@ func loadServiceConfig(path string) (ghost.ServiceConfig, error) {
 		}
 		cfg.SeedInstall.Specs = specs
 	}
+	if meta.IsDefined("exec_workers") {
+		cfg.Execution.Workers = raw.ExecWorkers
+	}
+	if meta.IsDefined("exec_queue_depth") {
+		cfg.Execution.QueueDepth = raw.ExecQueueDepth
+	}
+	if meta.IsDefined("exec_seed_concurrency") {
+		cfg.Execution.SeedConcurrency = raw.ExecSeedConcurrency
+	}
+	if meta.IsDefined("exec_limit") {
+		limits, err := parseExecLimits(raw.ExecLimits)
+		if err != nil {
+			return ghost.ServiceConfig{}, err
+		}
+		cfg.Execution.Limits = limits
+	}
+	if meta.IsDefined("exec_retention_ttl") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.ExecRetentionTTL))
+		if err != nil {
+			return ghost.ServiceConfig{}, fmt.Errorf("parse exec_retention_ttl: %w", err)
+		}
+		if d < ghost.MinDuplicateWindow {
+			return ghost.ServiceConfig{}, fmt.Errorf("parse exec_retention_ttl: %s is below the %s duplicate window", d, ghost.MinDuplicateWindow)
+		}
+		cfg.Retention.TTL = d
+	}
+	if meta.IsDefined("exec_retention_max_entries") {
+		cfg.Retention.MaxEntries = raw.ExecRetentionMax
+	}
+	if meta.IsDefined("exec_journal_path") {
+		cfg.Journal.Path = strings.TrimSpace(raw.ExecJournalPath)
+	}
+	if meta.IsDefined("exec_journal_sync") {
+		cfg.Journal.Sync = ghost.JournalSyncPolicy(strings.TrimSpace(raw.ExecJournalSync))
+		if err := cfg.Journal.Validate(); err != nil {
+			return ghost.ServiceConfig{}, fmt.Errorf("parse exec_journal_sync: %w", err)
+		}
+	}
+	if meta.IsDefined("exec_journal_sync_interval") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.ExecJournalInterval))
+		if err != nil {
+			return ghost.ServiceConfig{}, fmt.Errorf("parse exec_journal_sync_interval: %w", err)
+		}
+		cfg.Journal.SyncInterval = d
+	}
 
 	return cfg, nil
 }
@@ -229,6 +330,26 @@ func parseSeedInstallSpecs(in []fileSeedInstall) ([]seeds.InstallSpec, error) {
 	return out, nil
 }
 
+// ghostctl exec-limit parser from config table entries into scheduler caps.
+func parseExecLimits(in []fileExecLimit) ([]ghost.ExecutionLimit, error) {
+	out := make([]ghost.ExecutionLimit, 0, len(in))
+	for _, row := range in {
+		seedID := strings.TrimSpace(row.SeedID)
+		if seedID == "" {
+			return nil, fmt.Errorf("parse exec_limit: missing seed_id")
+		}
+		if row.MaxConcurrent <= 0 {
+			return nil, fmt.Errorf("parse exec_limit seed_id=%q: max_concurrent must be positive", seedID)
+		}
+		out = append(out, ghost.ExecutionLimit{
+			SeedID:        seedID,
+			Operation:     strings.TrimSpace(row.Operation),
+			MaxConcurrent: row.MaxConcurrent,
+		})
+	}
+	return out, nil
+}
+
 // ghostctl workspace-root resolver using nearest parent directory with go.mod.
 func resolveWorkspaceRoot(configPath string) string {
 	start := filepath.Dir(configPath)
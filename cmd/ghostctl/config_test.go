@@ -60,9 +60,24 @@ func TestLoadServiceConfigDefaultsAndOverrides(t *testing.T) {
 	if cfg.Mirage.SessionConfig.TLS.CAFile != "" {
 		t.Fatalf("unexpected ca file: %q", cfg.Mirage.SessionConfig.TLS.CAFile)
 	}
+	if cfg.Mirage.SessionConfig.TLS.JoinToken != "" {
+		t.Fatalf("unexpected join token: %q", cfg.Mirage.SessionConfig.TLS.JoinToken)
+	}
 	if cfg.Mirage.SessionConfig.TLS.ServerName != "" {
 		t.Fatalf("unexpected server name: %q", cfg.Mirage.SessionConfig.TLS.ServerName)
 	}
+	if cfg.Mirage.OutboxPath != "local/outbox/events.log" {
+		t.Fatalf("unexpected outbox path: %q", cfg.Mirage.OutboxPath)
+	}
+	if cfg.Mirage.SessionConfig.Auth.Required || cfg.Mirage.SessionConfig.Auth.KeyFile != "" {
+		t.Fatalf("expected frame auth disabled: %+v", cfg.Mirage.SessionConfig.Auth)
+	}
+	if cfg.Mirage.SessionConfig.Auth.MaxSkew != 30*time.Second {
+		t.Fatalf("unexpected auth max skew: %s", cfg.Mirage.SessionConfig.Auth.MaxSkew)
+	}
+	if !cfg.Mirage.SessionConfig.Compression.Enabled || cfg.Mirage.SessionConfig.Compression.Threshold != 1024 {
+		t.Fatalf("unexpected compression config: %+v", cfg.Mirage.SessionConfig.Compression)
+	}
 	if !cfg.SeedInstall.Enabled {
 		t.Fatalf("expected seed install enabled")
 	}
@@ -99,6 +114,24 @@ func TestLoadServiceConfigDefaultsAndOverrides(t *testing.T) {
 	if len(cfg.SeedInstall.Specs[1].BootstrapCommand) != 3 {
 		t.Fatalf("unexpected bootstrap command: %+v", cfg.SeedInstall.Specs[1].BootstrapCommand)
 	}
+	if cfg.Execution.Workers != 8 || cfg.Execution.QueueDepth != 256 || cfg.Execution.SeedConcurrency != 0 {
+		t.Fatalf("unexpected execution config: %+v", cfg.Execution)
+	}
+	if len(cfg.Execution.Limits) != 2 {
+		t.Fatalf("unexpected exec limits: %+v", cfg.Execution.Limits)
+	}
+	if got := cfg.Execution.Limits[0]; got.SeedID != "seed.mongod" || got.Operation != "" || got.MaxConcurrent != 2 {
+		t.Fatalf("unexpected seed exec limit: %+v", got)
+	}
+	if got := cfg.Execution.Limits[1]; got.SeedID != "seed.fs" || got.Operation != "write" || got.MaxConcurrent != 1 {
+		t.Fatalf("unexpected operation exec limit: %+v", got)
+	}
+	if cfg.Retention.TTL != 15*time.Minute || cfg.Retention.MaxEntries != 16384 {
+		t.Fatalf("unexpected retention config: %+v", cfg.Retention)
+	}
+	if cfg.Journal.Path != "local/journal/executions.log" || cfg.Journal.Sync != "always" || cfg.Journal.SyncInterval != time.Second {
+		t.Fatalf("unexpected journal config: %+v", cfg.Journal)
+	}
 }
 
 func TestLoadServiceConfigHeartbeatMillis(t *testing.T) {
@@ -120,6 +153,36 @@ heartbeat_interval_ms = 1200
 	}
 }
 
+func TestLoadServiceConfigRejectsRetentionBelowDuplicateWindow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+exec_retention_ttl = "5m"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if _, err := loadServiceConfig(path); err == nil {
+		t.Fatalf("expected retention ttl below the duplicate window rejected")
+	}
+}
+
+func TestLoadServiceConfigRejectsUnknownJournalSyncPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+exec_journal_sync = "sometimes"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if _, err := loadServiceConfig(path); err == nil {
+		t.Fatalf("expected unknown journal sync policy rejected")
+	}
+}
+
 func TestLoadServiceConfigProjectFetchOverride(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.toml")
@@ -163,3 +226,10 @@ func TestParseSeedInstallSpecsMissingSeedID(t *testing.T) {
 		t.Fatalf("expected parse error")
 	}
 }
+
+func TestParseExecLimitsRejectsNonPositiveCap(t *testing.T) {
+	_, err := parseExecLimits([]fileExecLimit{{SeedID: "seed.mongod", Operation: "restart"}})
+	if err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
@@ -35,6 +35,21 @@ type fileConfig struct {
 	SessionTLSCertFile           string              `toml:"session_tls_cert_file"`
 	SessionTLSKeyFile            string              `toml:"session_tls_key_file"`
 	SessionTLSCAFile             string              `toml:"session_tls_ca_file"`
+	SessionTLSCRLFile            string              `toml:"session_tls_crl_file"`
+	SessionTLSRehandshake        bool                `toml:"session_tls_rehandshake_on_expiry"`
+	SessionAuthRequired          bool                `toml:"session_auth_required"`
+	SessionAuthKeyID             string              `toml:"session_auth_key_id"`
+	SessionAuthKeyFile           string              `toml:"session_auth_key_file"`
+	SessionAuthMaxSkew           string              `toml:"session_auth_max_skew"`
+	SessionCompression           bool                `toml:"session_compression_enabled"`
+	SessionCompressionThreshold  int                 `toml:"session_compression_threshold"`
+	IngressEventsPerSecond       float64             `toml:"ingress_events_per_second"`
+	IngressBurst                 int                 `toml:"ingress_burst"`
+	IngressQueueDepth            int                 `toml:"ingress_queue_depth"`
+	SeqStatePath                 string              `toml:"seq_state_path"`
+	EnrollCADir                  string              `toml:"enroll_ca_dir"`
+	EnrollTokenTTL               string              `toml:"enroll_token_ttl"`
+	EnrollCertValidity           string              `toml:"enroll_cert_validity"`
 }
 
 // preloadGhostAdmin maps one preload_ghost_admins TOML table row.
@@ -108,6 +123,63 @@ func loadServiceConfig(path string) (mirage.ServiceConfig, error) {
 	if meta.IsDefined("session_tls_ca_file") {
 		cfg.Session.TLS.CAFile = strings.TrimSpace(raw.SessionTLSCAFile)
 	}
+	if meta.IsDefined("session_tls_crl_file") {
+		cfg.Session.TLS.CRLFile = strings.TrimSpace(raw.SessionTLSCRLFile)
+	}
+	if meta.IsDefined("session_tls_rehandshake_on_expiry") {
+		cfg.Session.TLS.RehandshakeOnExpiry = raw.SessionTLSRehandshake
+	}
+	if meta.IsDefined("session_auth_required") {
+		cfg.Session.Auth.Required = raw.SessionAuthRequired
+	}
+	if meta.IsDefined("session_auth_key_id") {
+		cfg.Session.Auth.KeyID = strings.TrimSpace(raw.SessionAuthKeyID)
+	}
+	if meta.IsDefined("session_auth_key_file") {
+		cfg.Session.Auth.KeyFile = strings.TrimSpace(raw.SessionAuthKeyFile)
+	}
+	if meta.IsDefined("session_auth_max_skew") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.SessionAuthMaxSkew))
+		if err != nil {
+			return mirage.ServiceConfig{}, fmt.Errorf("parse session_auth_max_skew: %w", err)
+		}
+		cfg.Session.Auth.MaxSkew = d
+	}
+	if meta.IsDefined("session_compression_enabled") {
+		cfg.Session.Compression.Enabled = raw.SessionCompression
+	}
+	if meta.IsDefined("session_compression_threshold") {
+		cfg.Session.Compression.Threshold = raw.SessionCompressionThreshold
+	}
+	if meta.IsDefined("ingress_events_per_second") {
+		cfg.Ingress.EventsPerSecond = raw.IngressEventsPerSecond
+	}
+	if meta.IsDefined("ingress_burst") {
+		cfg.Ingress.Burst = raw.IngressBurst
+	}
+	if meta.IsDefined("ingress_queue_depth") {
+		cfg.Ingress.QueueDepth = raw.IngressQueueDepth
+	}
+	if meta.IsDefined("seq_state_path") {
+		cfg.SeqStatePath = strings.TrimSpace(raw.SeqStatePath)
+	}
+	if meta.IsDefined("enroll_ca_dir") {
+		cfg.Enrollment.CADir = strings.TrimSpace(raw.EnrollCADir)
+	}
+	if meta.IsDefined("enroll_token_ttl") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.EnrollTokenTTL))
+		if err != nil {
+			return mirage.ServiceConfig{}, fmt.Errorf("parse enroll_token_ttl: %w", err)
+		}
+		cfg.Enrollment.TokenTTL = d
+	}
+	if meta.IsDefined("enroll_cert_validity") {
+		d, err := time.ParseDuration(strings.TrimSpace(raw.EnrollCertValidity))
+		if err != nil {
+			return mirage.ServiceConfig{}, fmt.Errorf("parse enroll_cert_validity: %w", err)
+		}
+		cfg.Enrollment.CertValidity = d
+	}
 
 	if cfg.BuildlogPersistEnabled {
 		selector := strings.TrimSpace(cfg.BuildlogSeedSelector)
@@ -141,6 +213,8 @@ func loadServiceConfig(path string) (mirage.ServiceConfig, error) {
 	}
 
 	cfg.Session = cfg.Session.WithDefaults()
+	cfg.Ingress = cfg.Ingress.WithDefaults()
+	cfg.Enrollment = cfg.Enrollment.WithDefaults()
 	return cfg, nil
 }
 
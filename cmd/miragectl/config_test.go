@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/mirage"
 )
 
 func TestLoadServiceConfigDefaultsAndOverrides(t *testing.T) {
@@ -24,6 +27,19 @@ session_tls_mutual = true
 session_tls_cert_file = "/etc/mirage/server.crt"
 session_tls_key_file = "/etc/mirage/server.key"
 session_tls_ca_file = "/etc/mirage/ca.crt"
+session_tls_crl_file = "/etc/mirage/ca.crl"
+session_tls_rehandshake_on_expiry = true
+session_auth_required = true
+session_auth_key_id = "edge.k1"
+session_auth_key_file = "/etc/mirage/frame.key"
+session_auth_max_skew = "10s"
+session_compression_enabled = false
+session_compression_threshold = 4096
+ingress_events_per_second = 50.5
+ingress_queue_depth = 16
+seq_state_path = "/var/lib/mirage/seq.log"
+enroll_ca_dir = "/etc/mirage/pki"
+enroll_token_ttl = "10m"
 [[preload_ghost_admins]]
 ghost_id = "ghost.remote.a"
 admin_addr = "localhost:7011"
@@ -57,6 +73,33 @@ admin_addr = "localhost:7011"
 	if cfg.Session.SecurityMode != "production" {
 		t.Fatalf("unexpected security mode: %q", cfg.Session.SecurityMode)
 	}
+	if !cfg.Session.Auth.Required || cfg.Session.Auth.KeyID != "edge.k1" || cfg.Session.Auth.KeyFile != "/etc/mirage/frame.key" {
+		t.Fatalf("unexpected frame auth config: %+v", cfg.Session.Auth)
+	}
+	if cfg.Session.Auth.MaxSkew != 10*time.Second {
+		t.Fatalf("unexpected auth max skew: %s", cfg.Session.Auth.MaxSkew)
+	}
+	if cfg.Session.TLS.CRLFile != "/etc/mirage/ca.crl" {
+		t.Fatalf("unexpected tls crl file: %q", cfg.Session.TLS.CRLFile)
+	}
+	if cfg.Enrollment.CADir != "/etc/mirage/pki" || cfg.Enrollment.TokenTTL != 10*time.Minute {
+		t.Fatalf("unexpected enrollment config: %+v", cfg.Enrollment)
+	}
+	if cfg.Enrollment.CertValidity != mirage.DefaultEnrollmentConfig().CertValidity {
+		t.Fatalf("expected default enrolled cert validity, got %s", cfg.Enrollment.CertValidity)
+	}
+	if !cfg.Session.TLS.RehandshakeOnExpiry {
+		t.Fatalf("expected tls rehandshake on expiry enabled")
+	}
+	if cfg.Session.Compression.Enabled || cfg.Session.Compression.Threshold != 4096 {
+		t.Fatalf("unexpected compression config: %+v", cfg.Session.Compression)
+	}
+	if cfg.Ingress.EventsPerSecond != 50.5 || cfg.Ingress.QueueDepth != 16 || cfg.Ingress.Burst != mirage.DefaultIngressConfig().Burst {
+		t.Fatalf("unexpected ingress config: %+v", cfg.Ingress)
+	}
+	if cfg.SeqStatePath != "/var/lib/mirage/seq.log" {
+		t.Fatalf("unexpected seq state path: %q", cfg.SeqStatePath)
+	}
 	if len(cfg.PreloadGhostAdmins) != 1 {
 		t.Fatalf("expected one preload ghost admin, got %d", len(cfg.PreloadGhostAdmins))
 	}
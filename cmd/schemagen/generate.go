@@ -0,0 +1,613 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// schemagen header marking the output as generated for go tooling and reviewers.
+const generatedHeader = "// Code generated by cmd/schemagen from docs/architecture/definitions/tlv.toml. DO NOT EDIT.\n"
+
+// schemagen mirror of the tlv.toml tables consumed by the generator.
+type contract struct {
+	PrimitiveTypes struct {
+		Fields map[string]string `toml:"fields"`
+	} `toml:"primitive_types"`
+	MessageTypes struct {
+		Fields map[string]string `toml:"fields"`
+	} `toml:"message_types"`
+	FieldSections    map[string]map[string]string `toml:"field_sections"`
+	RequiredFields   map[string]string            `toml:"required_fields"`
+	OptionalFields   map[string]string            `toml:"optional_fields"`
+	ProtocolVersions map[string]map[string]string `toml:"protocol_versions"`
+	Patterns         map[string]string            `toml:"patterns"`
+	FieldRules       map[string]ruleSpec          `toml:"field_rules"`
+}
+
+// schemagen mirror of one [field_rules] inline table.
+type ruleSpec struct {
+	MaxLen  int      `toml:"max_len"`
+	Enum    []string `toml:"enum"`
+	Pattern string   `toml:"pattern"`
+}
+
+// schemagen field type: a primitive name, or a container name with element type.
+type fieldType struct {
+	base string
+	elem string
+}
+
+// schemagen message type resolved from [message_types.fields].
+type message struct {
+	key    string
+	id     uint32
+	goName string
+}
+
+// schemagen field resolved from one [field_sections.*] entry.
+type field struct {
+	section   string
+	key       string
+	id        uint16
+	typ       fieldType
+	constName string
+	goName    string
+}
+
+// schemagen field rule for one message at one protocol version.
+type requirement struct {
+	field    *field
+	typ      fieldType
+	optional bool
+}
+
+// schemagen fields grouped by contract section, in id order.
+type section struct {
+	name   string
+	fields []*field
+}
+
+// schemagen requirement additions for one protocol version.
+type version struct {
+	number uint16
+	reqs   map[string][]requirement
+}
+
+// schemagen named value pattern from [patterns].
+type pattern struct {
+	varName string
+	expr    string
+}
+
+// schemagen value rule resolved for one field.
+type fieldRule struct {
+	field   *field
+	spec    ruleSpec
+	pattern string
+}
+
+// schemagen resolved contract ready for rendering.
+type model struct {
+	types    map[string]bool
+	messages []message
+	sections []section
+	versions []version
+	patterns []pattern
+	rules    []fieldRule
+}
+
+// schemagen entrypoint from raw tlv.toml bytes to formatted Go source.
+func Generate(raw []byte) ([]byte, error) {
+	var c contract
+	if _, err := toml.Decode(string(raw), &c); err != nil {
+		return nil, fmt.Errorf("schemagen: parse contract: %w", err)
+	}
+	m, err := resolve(c)
+	if err != nil {
+		return nil, err
+	}
+	src := render(m)
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("schemagen: format output: %w", err)
+	}
+	return out, nil
+}
+
+// schemagen contract resolution: ids, names, types, and per-version requirements.
+func resolve(c contract) (model, error) {
+	m := model{types: map[string]bool{}}
+	for name := range c.PrimitiveTypes.Fields {
+		m.types[name] = true
+	}
+
+	byMessage := map[string]bool{}
+	for key, raw := range c.MessageTypes.Fields {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || id == 0 {
+			return model{}, fmt.Errorf("schemagen: message %q: invalid id %q", key, raw)
+		}
+		m.messages = append(m.messages, message{key: key, id: uint32(id), goName: camel(key)})
+		byMessage[key] = true
+	}
+	sort.Slice(m.messages, func(i, j int) bool { return m.messages[i].id < m.messages[j].id })
+	for i := 1; i < len(m.messages); i++ {
+		if m.messages[i].id == m.messages[i-1].id {
+			return model{}, fmt.Errorf("schemagen: duplicate message id %d", m.messages[i].id)
+		}
+	}
+
+	var all []*field
+	for name, entries := range c.FieldSections {
+		if name != "common" && !byMessage[name] {
+			return model{}, fmt.Errorf("schemagen: field section %q has no message type", name)
+		}
+		for key, spec := range entries {
+			f, err := parseField(name, key, spec, m.types)
+			if err != nil {
+				return model{}, err
+			}
+			all = append(all, f)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+	usedConst := map[string]bool{}
+	sectionIndex := map[string]int{}
+	for i, f := range all {
+		if i > 0 && all[i-1].id == f.id {
+			return model{}, fmt.Errorf("schemagen: duplicate field id %d (%s.%s, %s.%s)", f.id, all[i-1].section, all[i-1].key, f.section, f.key)
+		}
+		// The lowest id keeps the bare name; later sections reusing a key are prefixed.
+		f.goName = camel(f.key)
+		f.constName = "Field" + f.goName
+		if usedConst[f.constName] {
+			f.constName = "Field" + camel(f.section) + f.goName
+		}
+		usedConst[f.constName] = true
+		idx, ok := sectionIndex[f.section]
+		if !ok {
+			idx = len(m.sections)
+			sectionIndex[f.section] = idx
+			m.sections = append(m.sections, section{name: f.section})
+		}
+		m.sections[idx].fields = append(m.sections[idx].fields, f)
+	}
+
+	lookup := func(msg, key string) (*field, error) {
+		for _, name := range []string{msg, "common"} {
+			for _, f := range all {
+				if f.section == name && f.key == key {
+					return f, nil
+				}
+			}
+		}
+		var found *field
+		for _, f := range all {
+			if f.key != key {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("schemagen: message %q: field %q is ambiguous (%s, %s)", msg, key, found.section, f.section)
+			}
+			found = f
+		}
+		if found == nil {
+			return nil, fmt.Errorf("schemagen: message %q: unknown field %q", msg, key)
+		}
+		return found, nil
+	}
+
+	base := version{number: 1, reqs: map[string][]requirement{}}
+	optional := map[string]map[string]bool{}
+	for msg, list := range c.OptionalFields {
+		if !byMessage[msg] {
+			return model{}, fmt.Errorf("schemagen: optional_fields: unknown message %q", msg)
+		}
+		optional[msg] = map[string]bool{}
+		for _, key := range splitList(list) {
+			optional[msg][key] = true
+		}
+	}
+	for msg := range c.RequiredFields {
+		if !byMessage[msg] {
+			return model{}, fmt.Errorf("schemagen: required_fields: unknown message %q", msg)
+		}
+	}
+	for _, msg := range m.messages {
+		var reqs, opts []requirement
+		seen := map[string]bool{}
+		for _, key := range splitList(c.RequiredFields[msg.key]) {
+			if seen[key] || optional[msg.key][key] {
+				return model{}, fmt.Errorf("schemagen: message %q: field %q listed twice", msg.key, key)
+			}
+			seen[key] = true
+			f, err := lookup(msg.key, key)
+			if err != nil {
+				return model{}, err
+			}
+			reqs = append(reqs, requirement{field: f, typ: f.typ})
+		}
+		for key := range optional[msg.key] {
+			f, err := lookup(msg.key, key)
+			if err != nil {
+				return model{}, err
+			}
+			opts = append(opts, requirement{field: f, typ: f.typ, optional: true})
+		}
+		// Optional keys come from a map; order them by field id after the required ones.
+		sort.Slice(opts, func(i, j int) bool { return opts[i].field.id < opts[j].field.id })
+		if reqs = append(reqs, opts...); len(reqs) > 0 {
+			base.reqs[msg.key] = reqs
+		}
+	}
+	m.versions = append(m.versions, base)
+
+	for raw, entries := range c.ProtocolVersions {
+		n, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil || n < 2 {
+			return model{}, fmt.Errorf("schemagen: protocol version %q: expected an integer above 1", raw)
+		}
+		v := version{number: uint16(n), reqs: map[string][]requirement{}}
+		for msg, list := range entries {
+			if !byMessage[msg] {
+				return model{}, fmt.Errorf("schemagen: protocol version %d: unknown message %q", n, msg)
+			}
+			for _, spec := range splitList(list) {
+				key, typeSpec, ok := strings.Cut(spec, ":")
+				if !ok {
+					return model{}, fmt.Errorf("schemagen: protocol version %d: %s: expected name:type, got %q", n, msg, spec)
+				}
+				f, err := lookup(msg, strings.TrimSpace(key))
+				if err != nil {
+					return model{}, err
+				}
+				typ, err := parseType(strings.TrimSpace(typeSpec), m.types)
+				if err != nil {
+					return model{}, fmt.Errorf("schemagen: protocol version %d: %s.%s: %w", n, msg, f.key, err)
+				}
+				v.reqs[msg] = append(v.reqs[msg], requirement{field: f, typ: typ, optional: optional[msg][f.key]})
+			}
+		}
+		m.versions = append(m.versions, v)
+	}
+	sort.Slice(m.versions, func(i, j int) bool { return m.versions[i].number < m.versions[j].number })
+
+	patternVars := map[string]string{}
+	for name, expr := range c.Patterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			return model{}, fmt.Errorf("schemagen: pattern %q: %w", name, err)
+		}
+		p := pattern{varName: "pattern" + camel(name), expr: expr}
+		patternVars[name] = p.varName
+		m.patterns = append(m.patterns, p)
+	}
+	sort.Slice(m.patterns, func(i, j int) bool { return m.patterns[i].varName < m.patterns[j].varName })
+	for name, spec := range c.FieldRules {
+		var f *field
+		for _, candidate := range all {
+			if candidate.section+"."+candidate.key == name {
+				f = candidate
+				break
+			}
+		}
+		if f == nil {
+			return model{}, fmt.Errorf("schemagen: field_rules: unknown field %q", name)
+		}
+		if spec.MaxLen < 0 {
+			return model{}, fmt.Errorf("schemagen: field_rules: %s: negative max_len", name)
+		}
+		if len(spec.Enum) > 0 && f.typ.base != "string" {
+			return model{}, fmt.Errorf("schemagen: field_rules: %s: enum requires a string field", name)
+		}
+		rule := fieldRule{field: f, spec: spec}
+		if spec.Pattern != "" {
+			if f.typ.base != "string" {
+				return model{}, fmt.Errorf("schemagen: field_rules: %s: pattern requires a string field", name)
+			}
+			v, ok := patternVars[spec.Pattern]
+			if !ok {
+				return model{}, fmt.Errorf("schemagen: field_rules: %s: unknown pattern %q", name, spec.Pattern)
+			}
+			rule.pattern = v
+		}
+		m.rules = append(m.rules, rule)
+	}
+	sort.Slice(m.rules, func(i, j int) bool { return m.rules[i].field.id < m.rules[j].field.id })
+	return m, nil
+}
+
+// schemagen parser for one "id:type" field spec.
+func parseField(sectionName, key, spec string, types map[string]bool) (*field, error) {
+	rawID, typeSpec, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("schemagen: field %s.%s: expected id:type, got %q", sectionName, key, spec)
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(rawID), 10, 16)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("schemagen: field %s.%s: invalid id %q", sectionName, key, rawID)
+	}
+	typ, err := parseType(strings.TrimSpace(typeSpec), types)
+	if err != nil {
+		return nil, fmt.Errorf("schemagen: field %s.%s: %w", sectionName, key, err)
+	}
+	return &field{section: sectionName, key: key, id: uint16(id), typ: typ}, nil
+}
+
+// schemagen parser for a primitive, list<elem>, or map<elem> type spec.
+func parseType(spec string, types map[string]bool) (fieldType, error) {
+	base, rest, isContainer := strings.Cut(spec, "<")
+	if !isContainer {
+		if !types[spec] || spec == "list" || spec == "map" {
+			return fieldType{}, fmt.Errorf("unknown type %q", spec)
+		}
+		return fieldType{base: spec}, nil
+	}
+	elem, ok := strings.CutSuffix(rest, ">")
+	if !ok || (base != "list" && base != "map") || !types[base] {
+		return fieldType{}, fmt.Errorf("invalid container type %q", spec)
+	}
+	if !types[elem] || elem == "list" || elem == "map" {
+		return fieldType{}, fmt.Errorf("unsupported container element %q", elem)
+	}
+	return fieldType{base: base, elem: elem}, nil
+}
+
+// schemagen Go source renderer; output is gofmt'd by the caller.
+func render(m model) []byte {
+	var b bytes.Buffer
+	b.WriteString(generatedHeader)
+	b.WriteString("\npackage schema\n\n")
+	b.WriteString("import (\n")
+	if len(m.patterns) > 0 {
+		b.WriteString("\t\"regexp\"\n\n")
+	}
+	b.WriteString("\t\"github.com/danmuck/edgectl/internal/protocol/tlv\"\n)\n\n")
+
+	latest := m.versions[len(m.versions)-1].number
+	b.WriteString("// Schema newest protocol version described by the tlv contract.\n")
+	fmt.Fprintf(&b, "const contractVersion uint16 = %d\n\n", latest)
+
+	b.WriteString("// Message type IDs from tlv contract.\nconst (\n")
+	for _, msg := range m.messages {
+		fmt.Fprintf(&b, "\tMsg%s uint32 = %d\n", msg.goName, msg.id)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Field IDs from tlv contract.\nconst (\n")
+	for i, s := range m.sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, f := range s.fields {
+			fmt.Fprintf(&b, "\t%s uint16 = %d\n", f.constName, f.id)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Schema requirements keyed by the protocol version that introduced them.\n")
+	b.WriteString("// A version inherits every earlier version's message types and requirements;\n")
+	b.WriteString("// a later entry for the same field id replaces the earlier type.\n")
+	b.WriteString("var requirementsByVersion = map[uint16]map[uint32][]Requirement{\n")
+	for _, v := range m.versions {
+		fmt.Fprintf(&b, "\t%d: {\n", v.number)
+		for _, msg := range m.messages {
+			reqs, ok := v.reqs[msg.key]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "\t\tMsg%s: {\n", msg.goName)
+			for _, r := range reqs {
+				fmt.Fprintf(&b, "\t\t\t{ID: %s, Type: %s", r.field.constName, typeConst(r.typ.base))
+				if r.typ.elem != "" {
+					fmt.Fprintf(&b, ", Elem: %s", typeConst(r.typ.elem))
+				}
+				if r.optional {
+					b.WriteString(", Optional: true")
+				}
+				b.WriteString("},\n")
+			}
+			b.WriteString("\t\t},\n")
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+
+	if len(m.patterns) > 0 {
+		b.WriteString("\n// Schema value patterns from tlv contract.\nvar (\n")
+		for _, p := range m.patterns {
+			lit := "`" + p.expr + "`"
+			if strings.Contains(p.expr, "`") {
+				lit = strconv.Quote(p.expr)
+			}
+			fmt.Fprintf(&b, "\t%s = regexp.MustCompile(%s)\n", p.varName, lit)
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("\n// Schema value rules by field id, applied wherever the field is present.\n")
+	b.WriteString("var fieldRules = map[uint16]FieldRule{\n")
+	for _, r := range m.rules {
+		var parts []string
+		if r.spec.MaxLen > 0 {
+			parts = append(parts, fmt.Sprintf("MaxLen: %d", r.spec.MaxLen))
+		}
+		if len(r.spec.Enum) > 0 {
+			quoted := make([]string, len(r.spec.Enum))
+			for i, v := range r.spec.Enum {
+				quoted[i] = strconv.Quote(v)
+			}
+			parts = append(parts, "Enum: []string{"+strings.Join(quoted, ", ")+"}")
+		}
+		if r.pattern != "" {
+			parts = append(parts, "Pattern: "+r.pattern)
+		}
+		fmt.Fprintf(&b, "\t%s: {%s},\n", r.field.constName, strings.Join(parts, ", "))
+	}
+	b.WriteString("}\n")
+
+	for _, msg := range m.messages {
+		renderEnvelope(&b, msg, latestRequirements(m, msg.key))
+	}
+	return b.Bytes()
+}
+
+// schemagen requirement list for one message with every version's type changes applied.
+func latestRequirements(m model, msg string) []requirement {
+	var out []requirement
+	for _, v := range m.versions {
+		for _, r := range v.reqs[msg] {
+			replaced := false
+			for i := range out {
+				if out[i].field.id == r.field.id {
+					out[i] = r
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				out = append(out, r)
+			}
+		}
+	}
+	return out
+}
+
+// schemagen typed envelope with Encode/Decode for one message type.
+func renderEnvelope(b *bytes.Buffer, msg message, reqs []requirement) {
+	name := msg.goName + "Envelope"
+	fmt.Fprintf(b, "\n// Schema typed %s payload at the newest contract version.\n", msg.key)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, r := range reqs {
+		fmt.Fprintf(b, "\t%s %s\n", r.field.goName, goType(r.typ))
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "// MessageType returns Msg%s.\n", msg.goName)
+	fmt.Fprintf(b, "func (%s) MessageType() uint32 {\n\treturn Msg%s\n}\n\n", name, msg.goName)
+
+	b.WriteString("// Encode validates e and serializes it into one TLV payload.\n")
+	b.WriteString("// Optional fields holding their zero value are omitted.\n")
+	fmt.Fprintf(b, "func (e %s) Encode() ([]byte, error) {\n", name)
+	fmt.Fprintf(b, "\tb := tlv.NewBuilder(%d)\n", len(reqs))
+	for _, r := range reqs {
+		set := fmt.Sprintf("b.%s(%s, e.%s)", builderMethod(r.typ), r.field.constName, r.field.goName)
+		if r.optional {
+			fmt.Fprintf(b, "\tif %s {\n\t\t%s\n\t}\n", nonZero(r.typ, "e."+r.field.goName), set)
+		} else {
+			fmt.Fprintf(b, "\t%s\n", set)
+		}
+	}
+	fmt.Fprintf(b, "\tif err := ValidateVersion(contractVersion, Msg%s, b.Fields()); err != nil {\n", msg.goName)
+	b.WriteString("\t\treturn nil, err\n\t}\n\treturn b.Encode(), nil\n}\n\n")
+
+	b.WriteString("// Decode validates payload and replaces e with its fields.\n")
+	fmt.Fprintf(b, "func (e *%s) Decode(payload []byte) error {\n", name)
+	b.WriteString("\tr, err := tlv.ReadPayload(payload)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(b, "\tif err := ValidateVersion(contractVersion, Msg%s, r.Fields()); err != nil {\n\t\treturn err\n\t}\n", msg.goName)
+	fmt.Fprintf(b, "\tvar out %s\n", name)
+	for _, r := range reqs {
+		get := fmt.Sprintf("if out.%s, err = r.%s(%s); err != nil {\n\t\treturn err\n\t}", r.field.goName, readerMethod(r.typ), r.field.constName)
+		if r.optional {
+			fmt.Fprintf(b, "\tif r.Has(%s) {\n\t\t%s\n\t}\n", r.field.constName, strings.ReplaceAll(get, "\n", "\n\t"))
+		} else {
+			fmt.Fprintf(b, "\t%s\n", get)
+		}
+	}
+	b.WriteString("\t*e = out\n\treturn nil\n}\n")
+}
+
+// schemagen tlv type constant name for a contract type name.
+func typeConst(name string) string {
+	return "tlv.Type" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// schemagen Go type for one contract field type.
+func goType(t fieldType) string {
+	switch t.base {
+	case "u8":
+		return "uint8"
+	case "u16":
+		return "uint16"
+	case "u32":
+		return "uint32"
+	case "u64":
+		return "uint64"
+	case "bool":
+		return "bool"
+	case "string":
+		return "string"
+	case "list":
+		return "[]tlv.Field"
+	case "map":
+		if t.elem == "string" {
+			return "map[string]string"
+		}
+		return "[]tlv.MapEntry"
+	default:
+		return "[]byte"
+	}
+}
+
+// schemagen tlv.Builder setter for one contract field type.
+func builderMethod(t fieldType) string {
+	if t.base == "map" && t.elem == "string" {
+		return "StringMap"
+	}
+	return typeMethod(t.base)
+}
+
+// schemagen tlv.Reader getter for one contract field type.
+func readerMethod(t fieldType) string {
+	return builderMethod(t)
+}
+
+// schemagen Builder/Reader method name shared by primitive and container types.
+func typeMethod(base string) string {
+	return strings.ToUpper(base[:1]) + base[1:]
+}
+
+// schemagen presence test used to omit optional zero values.
+func nonZero(t fieldType, expr string) string {
+	switch t.base {
+	case "string":
+		return expr + ` != ""`
+	case "bool":
+		return expr
+	case "u8", "u16", "u32", "u64":
+		return expr + " != 0"
+	default:
+		return "len(" + expr + ") > 0"
+	}
+}
+
+// schemagen Go identifier for a snake_case or dotted contract key.
+func camel(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '.' || r == '-' })
+	var b strings.Builder
+	for _, p := range parts {
+		switch p {
+		case "id", "ms", "tls", "url", "json":
+			b.WriteString(strings.ToUpper(p))
+		default:
+			b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+		}
+	}
+	return b.String()
+}
+
+// schemagen comma-separated list parser ignoring blanks.
+func splitList(list string) []string {
+	var out []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// schemagen CLI flags for contract input, generated output, and drift checking.
+type options struct {
+	contract string
+	out      string
+	check    bool
+}
+
+// schemagen entrypoint regenerating schema constants, requirements, and envelopes
+// from the TLV contract so docs and schema.Validate cannot drift apart.
+func main() {
+	var opts options
+	flag.StringVar(&opts.contract, "contract", "docs/architecture/definitions/tlv.toml", "path to tlv contract toml")
+	flag.StringVar(&opts.out, "out", "internal/protocol/schema/schema_gen.go", "path to generated go file")
+	flag.BoolVar(&opts.check, "check", false, "fail when the generated file is out of date instead of writing it")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// schemagen generate-and-write (or compare) for one contract/output pair.
+func run(opts options) error {
+	raw, err := os.ReadFile(opts.contract)
+	if err != nil {
+		return fmt.Errorf("schemagen: %w", err)
+	}
+	src, err := Generate(raw)
+	if err != nil {
+		return err
+	}
+	current, err := os.ReadFile(opts.out)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if bytes.Equal(current, src) {
+		return nil
+	}
+	if opts.check {
+		return fmt.Errorf("schemagen: %s is out of date with %s; run go generate ./internal/protocol/schema", opts.out, opts.contract)
+	}
+	return os.WriteFile(opts.out, src, 0o644)
+}
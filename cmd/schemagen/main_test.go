@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testContract = `
+[primitive_types.fields]
+u32 = "3"
+string = "6"
+bytes = "7"
+map = "9"
+
+[message_types.fields]
+ping = "1"
+"ping.reply" = "2"
+
+[field_sections.common]
+session_id = "1:string"
+
+[field_sections.ping]
+note = "10:string"
+payload = "11:bytes"
+
+[field_sections."ping.reply"]
+note = "20:string"
+rtt_ms = "21:u32"
+
+[required_fields]
+ping = "session_id, payload"
+"ping.reply" = "session_id, note"
+
+[optional_fields]
+ping = "note"
+
+[protocol_versions."2"]
+ping = "payload:map<string>"
+
+[patterns]
+id = "^[a-z.]+$"
+
+[field_rules]
+"common.session_id" = { max_len = 64, pattern = "id" }
+"ping.reply.note" = { enum = ["up", "down"] }
+`
+
+func TestCheckedInSchemaMatchesContract(t *testing.T) {
+	err := run(options{
+		contract: "../../docs/architecture/definitions/tlv.toml",
+		out:      "../../internal/protocol/schema/schema_gen.go",
+		check:    true,
+	})
+	if err != nil {
+		t.Fatalf("generated schema drifted from contract: %v", err)
+	}
+}
+
+func TestGenerateResolvesNamesOptionalFieldsAndVersions(t *testing.T) {
+	src, err := Generate([]byte(testContract))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	out := string(src)
+	for _, want := range []string{
+		"MsgPingReply uint32 = 2",
+		"FieldNote    uint16 = 10",
+		"FieldPingReplyNote uint16 = 20",
+		"FieldRttMS         uint16 = 21",
+		"{ID: FieldNote, Type: tlv.TypeString, Optional: true}",
+		"2: {\n\t\tMsgPing: {\n\t\t\t{ID: FieldPayload, Type: tlv.TypeMap, Elem: tlv.TypeString},",
+		"const contractVersion uint16 = 2",
+		"Payload   map[string]string",
+		"if out.Note, err = r.String(FieldPingReplyNote); err != nil {",
+		"patternID = regexp.MustCompile(`^[a-z.]+$`)",
+		"FieldSessionID:     {MaxLen: 64, Pattern: patternID},",
+		`FieldPingReplyNote: {Enum: []string{"up", "down"}},`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected generated source to contain %q\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateRejectsInconsistentContracts(t *testing.T) {
+	cases := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{"duplicate field id", `rtt_ms = "21:u32"`, `rtt_ms = "20:u32"`, "duplicate field id 20"},
+		{"unknown type", `rtt_ms = "21:u32"`, `rtt_ms = "21:u128"`, "unknown type"},
+		{"unknown required field", `"ping.reply" = "session_id, note"`, `"ping.reply" = "session_id, nope"`, "unknown field"},
+		{"field listed twice", `ping = "note"`, `ping = "payload"`, "listed twice"},
+		{"unsupported element", `ping = "payload:map<string>"`, `ping = "payload:map<map>"`, "unsupported container element"},
+		{"unknown rule pattern", `pattern = "id" }`, `pattern = "uuid" }`, "unknown pattern"},
+		{"enum on non-string", `"ping.reply.note" = {`, `"ping.reply.rtt_ms" = {`, "enum requires a string field"},
+		{"unknown rule field", `"common.session_id" = {`, `"common.nope" = {`, "unknown field"},
+		{"unknown message section", `[field_sections."ping.reply"]`, `[field_sections.pong]`, "has no message type"},
+	}
+	for _, tc := range cases {
+		contract := strings.Replace(testContract, tc.old, tc.new, 1)
+		_, err := Generate([]byte(contract))
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -2,9 +2,9 @@ package ghost
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -65,10 +65,51 @@ type controlResponse struct {
 }
 
 // ExecuteAdminCommand maps one external admin request into Ghost command execution.
+// Blocks until the scheduled execution completes; concurrent calls run in parallel
+// within the scheduler's limits.
 func (s *Service) ExecuteAdminCommand(cmd AdminCommand) (ExecutionState, EventEnv, error) {
-	s.adminMu.Lock()
-	defer s.adminMu.Unlock()
+	env := s.adminCommandEnv(cmd)
+	event, err := s.server.HandleCommandAndExecute(env)
+	if err != nil {
+		return ExecutionState{}, EventEnv{}, err
+	}
+	state, ok := s.server.ExecutionByCommandID(env.CommandID)
+	if !ok {
+		return ExecutionState{}, EventEnv{}, fmt.Errorf("ghost: missing execution state for command_id=%q", env.CommandID)
+	}
 
+	s.recordExecution(env.GhostID, env.MessageID, state, event)
+	return state, event, nil
+}
+
+// SubmitAdminCommand queues one external admin request and returns without waiting.
+// Progress is visible through ExecutionByCommandID; the terminal event is recorded
+// in the recent-event and verification views once the execution completes.
+func (s *Service) SubmitAdminCommand(cmd AdminCommand) (ExecutionState, error) {
+	env := s.adminCommandEnv(cmd)
+	state, done, err := s.server.SubmitCommand(env)
+	if err != nil {
+		return ExecutionState{}, err
+	}
+	go func() {
+		res := <-done
+		if res.Err != nil {
+			logs.Warnf("ghost.Service.SubmitAdminCommand command_id=%q err=%v", env.CommandID, res.Err)
+			return
+		}
+		s.recordExecution(env.GhostID, env.MessageID, res.State, res.Event)
+	}()
+	return state, nil
+}
+
+// CancelCommand stops a queued or running execution by command_id.
+// The execution still completes with a terminal cancelled event.
+func (s *Service) CancelCommand(commandID string) (ExecutionState, error) {
+	return s.server.CancelExecution(commandID)
+}
+
+// Ghost admin request mapping into a command envelope with generated ids.
+func (s *Service) adminCommandEnv(cmd AdminCommand) CommandEnv {
 	status := s.server.Status()
 	messageID := s.adminSeq.Add(1)
 	commandID := strings.TrimSpace(cmd.CommandID)
@@ -79,8 +120,7 @@ func (s *Service) ExecuteAdminCommand(cmd AdminCommand) (ExecutionState, EventEn
 	if intentID == "" {
 		intentID = fmt.Sprintf("intent.%s.%d", status.GhostID, messageID)
 	}
-
-	env := CommandEnv{
+	return CommandEnv{
 		MessageID:    messageID,
 		CommandID:    commandID,
 		IntentID:     intentID,
@@ -89,27 +129,23 @@ func (s *Service) ExecuteAdminCommand(cmd AdminCommand) (ExecutionState, EventEn
 		Operation:    strings.TrimSpace(cmd.Operation),
 		Args:         cloneArgs(cmd.Args),
 	}
+}
 
-	event, err := s.server.HandleCommandAndExecute(env)
-	if err != nil {
-		return ExecutionState{}, EventEnv{}, err
-	}
-	state, ok := s.server.ExecutionByCommandID(commandID)
-	if !ok {
-		return ExecutionState{}, EventEnv{}, fmt.Errorf("ghost: missing execution state for command_id=%q", commandID)
-	}
-
+// Ghost admin/session execution recorder for recent events and verification.
+func (s *Service) recordExecution(ghostID string, messageID uint64, state ExecutionState, event EventEnv) {
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
 	s.adminEvents = append(s.adminEvents, event)
 	rec := VerificationRecord{
-		RequestID:          fmt.Sprintf("req.%s.%d", status.GhostID, messageID),
-		TraceID:            fmt.Sprintf("trace.%s.%d", status.GhostID, messageID),
+		RequestID:          fmt.Sprintf("req.%s.%d", ghostID, messageID),
+		TraceID:            fmt.Sprintf("trace.%s.%d", ghostID, messageID),
 		CommandMessageID:   messageID,
 		CommandMessageType: schema.MsgCommand,
 		EventMessageType:   schema.MsgEvent,
 		CommandID:          state.CommandID,
 		ExecutionID:        state.ExecutionID,
 		EventID:            event.EventID,
-		GhostID:            status.GhostID,
+		GhostID:            ghostID,
 		SeedID:             event.SeedID,
 		Operation:          state.Operation,
 		Outcome:            event.Outcome,
@@ -119,7 +155,61 @@ func (s *Service) ExecuteAdminCommand(cmd AdminCommand) (ExecutionState, EventEn
 		Status:             event.Outcome,
 	}
 	s.verificationEvents = append(s.verificationEvents, rec)
-	return state, event, nil
+	s.trimAdminLogsLocked()
+}
+
+// Ghost admin log retention sweep.
+func (s *Service) trimAdminLogs() {
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+	s.trimAdminLogsLocked()
+}
+
+// Ghost admin log retention using the execution store TTL and cap. Caller must hold adminMu.
+func (s *Service) trimAdminLogsLocked() {
+	cfg := s.server.retention
+	now := s.server.now()
+	var n int
+	s.adminEvents, n = trimRetained(s.adminEvents, cfg, now, func(e EventEnv) uint64 { return e.TimestampMS })
+	s.adminEvicted += uint64(n)
+	s.verificationEvents, n = trimRetained(s.verificationEvents, cfg, now, func(r VerificationRecord) uint64 { return r.TimestampMS })
+	s.verifyEvicted += uint64(n)
+}
+
+// RetentionStats reports execution store and admin log sizes and eviction counters.
+func (s *Service) RetentionStats() RetentionStats {
+	stats := s.server.RetentionStats()
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+	stats.AdminEvents = len(s.adminEvents)
+	stats.AdminEventsEvicted = s.adminEvicted
+	stats.Verification = len(s.verificationEvents)
+	stats.VerificationEvicted = s.verifyEvicted
+	return stats
+}
+
+// Ghost handler for command frames pushed by Mirage over the registered session.
+// A replayed command_id returns the stored terminal event instead of re-executing.
+func (s *Service) handleSessionCommand(_ context.Context, cmd CommandEnv) (EventEnv, error) {
+	event, err := s.server.HandleCommandAndExecute(cmd)
+	if errors.Is(err, ErrDuplicateCommandID) {
+		state, ok := s.server.ExecutionByCommandID(cmd.CommandID)
+		if ok && state.Phase == ExecutionComplete {
+			logs.Infof("ghost.Service.handleSessionCommand replay command_id=%q", state.CommandID)
+			return state.Event, nil
+		}
+		return EventEnv{}, err
+	}
+	if err != nil {
+		return EventEnv{}, err
+	}
+	state, ok := s.server.ExecutionByCommandID(cmd.CommandID)
+	if !ok {
+		return EventEnv{}, fmt.Errorf("ghost: missing execution state for command_id=%q", cmd.CommandID)
+	}
+
+	s.recordExecution(state.GhostID, cmd.MessageID, state, event)
+	return event, nil
 }
 
 func (s *Service) ListSeeds() []seeds.SeedMetadata {
@@ -230,6 +320,8 @@ func (s *Service) handleControlRequest(req controlRequest) controlResponse {
 		return controlResponse{OK: true, Data: s.server.Status()}
 	case "list_seeds":
 		return controlResponse{OK: true, Data: s.ListSeeds()}
+	case "retention":
+		return controlResponse{OK: true, Data: s.RetentionStats()}
 	case "execute":
 		state, event, err := s.ExecuteAdminCommand(req.Command)
 		if err != nil {
@@ -242,6 +334,18 @@ func (s *Service) handleControlRequest(req controlRequest) controlResponse {
 				"event":     event,
 			},
 		}
+	case "submit":
+		state, err := s.SubmitAdminCommand(req.Command)
+		if err != nil {
+			return controlResponse{OK: false, Error: err.Error()}
+		}
+		return controlResponse{OK: true, Data: map[string]any{"execution": state}}
+	case "cancel":
+		state, err := s.CancelCommand(req.CommandID)
+		if err != nil {
+			return controlResponse{OK: false, Error: err.Error()}
+		}
+		return controlResponse{OK: true, Data: map[string]any{"execution": state}}
 	case "execute_envelope":
 		out, err := s.executeAdminCommandEnvelope(req.CommandFrame)
 		if err != nil {
@@ -284,7 +388,7 @@ func (s *Service) executeAdminCommandEnvelope(commandFrame []byte) (executeEnvel
 	if len(commandFrame) == 0 {
 		return executeEnvelopeResponse{}, fmt.Errorf("ghost.admin: missing command_frame")
 	}
-	fr, err := frame.ReadFrame(bytes.NewReader(commandFrame), frame.DefaultLimits())
+	fr, err := frame.DecodeMessage(commandFrame, frame.DefaultLimits())
 	if err != nil {
 		return executeEnvelopeResponse{}, err
 	}
@@ -77,3 +77,39 @@ func TestHandleControlRequestExecuteEnvelopeMissingFrame(t *testing.T) {
 		t.Fatalf("expected missing command frame failure")
 	}
 }
+
+func TestHandleControlRequestCancelRunningCommand(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	defer func() { gate.release <- struct{}{} }()
+	svc := NewServiceWithConfig(DefaultServiceConfig())
+	svc.server = newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	resp := svc.handleControlRequest(controlRequest{
+		Action: "submit",
+		Command: AdminCommand{
+			CommandID:    "cmd.cancel.1",
+			SeedSelector: "seed.gate",
+			Operation:    "restart",
+		},
+	})
+	if !resp.OK {
+		t.Fatalf("submit failed: %s", resp.Error)
+	}
+	waitStarted(t, gate, "restart")
+
+	resp = svc.handleControlRequest(controlRequest{Action: "cancel", CommandID: "cmd.cancel.1"})
+	if !resp.OK {
+		t.Fatalf("cancel failed: %s", resp.Error)
+	}
+	waitPhase(t, svc.server, "exec.cmd.cancel.1", ExecutionComplete)
+	state, _ := svc.ExecutionByCommandID("cmd.cancel.1")
+	if state.Outcome != OutcomeCancelled {
+		t.Fatalf("unexpected outcome: %q", state.Outcome)
+	}
+
+	resp = svc.handleControlRequest(controlRequest{Action: "cancel", CommandID: "cmd.cancel.1"})
+	if resp.OK {
+		t.Fatalf("cancel of completed command should fail")
+	}
+}
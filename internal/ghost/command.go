@@ -12,6 +12,9 @@ var (
 	ErrCommandTargetMismatch = errors.New("ghost: command target mismatch")
 	ErrDuplicateCommandID    = errors.New("ghost: duplicate command_id")
 	ErrDuplicateMessageID    = errors.New("ghost: duplicate message_id")
+	ErrExecutionNotFound     = errors.New("ghost: execution not found")
+	ErrExecutionFinished     = errors.New("ghost: execution already complete")
+	ErrExecutionStoreFull    = errors.New("ghost: execution store full")
 )
 
 // Ghost command boundary envelope received from Mirage or a direct terminal client.
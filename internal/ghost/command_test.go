@@ -151,9 +151,50 @@ func TestHandleCommandDuplicateIDs(t *testing.T) {
 	}
 }
 
-func newRadiatingServer(t *testing.T, ghostID string) *Server {
+// radiatingServerSetup collects newRadiatingServer options; the zero value is NewServer
+// with only the flow seed.
+type radiatingServerSetup struct {
+	config  *ServerConfig
+	seeds   []seeds.Seed
+	clock   *testClock
+	journal string
+}
+
+type radiatingServerOption func(*radiatingServerSetup)
+
+// withServerConfig builds the server with NewServerWithConfig(cfg).
+func withServerConfig(cfg ServerConfig) radiatingServerOption {
+	return func(setup *radiatingServerSetup) { setup.config = &cfg }
+}
+
+// withSeeds registers extra seeds next to the flow seed.
+func withSeeds(extra ...seeds.Seed) radiatingServerOption {
+	return func(setup *radiatingServerSetup) { setup.seeds = append(setup.seeds, extra...) }
+}
+
+// withClock drives the server's retention and journal timestamps from clock.
+func withClock(clock *testClock) radiatingServerOption {
+	return func(setup *radiatingServerSetup) { setup.clock = clock }
+}
+
+// withJournal opens an execution journal at path before radiating and closes it at cleanup.
+func withJournal(path string) radiatingServerOption {
+	return func(setup *radiatingServerSetup) { setup.journal = path }
+}
+
+func newRadiatingServer(t *testing.T, ghostID string, opts ...radiatingServerOption) *Server {
 	t.Helper()
+	var setup radiatingServerSetup
+	for _, opt := range opts {
+		opt(&setup)
+	}
 	s := NewServer()
+	if setup.config != nil {
+		s = NewServerWithConfig(*setup.config)
+	}
+	if setup.clock != nil {
+		s.now = setup.clock.now
+	}
 	if err := s.Appear(GhostConfig{GhostID: ghostID}); err != nil {
 		t.Fatalf("appear failed: %v", err)
 	}
@@ -161,9 +202,20 @@ func newRadiatingServer(t *testing.T, ghostID string) *Server {
 	if err := reg.Register(seedflow.NewSeed()); err != nil {
 		t.Fatalf("register flow seed: %v", err)
 	}
+	for _, seed := range setup.seeds {
+		if err := reg.Register(seed); err != nil {
+			t.Fatalf("register seed: %v", err)
+		}
+	}
 	if err := s.Seed(reg); err != nil {
 		t.Fatalf("seed failed: %v", err)
 	}
+	if setup.journal != "" {
+		if err := s.OpenJournal(JournalConfig{Path: setup.journal}); err != nil {
+			t.Fatalf("open journal: %v", err)
+		}
+		t.Cleanup(func() { _ = s.CloseJournal() })
+	}
 	if err := s.Radiate(); err != nil {
 		t.Fatalf("radiate failed: %v", err)
 	}
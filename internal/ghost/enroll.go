@@ -0,0 +1,144 @@
+package ghost
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+// Ghost check for a pending join: mTLS with a join token and no client cert on disk yet.
+func (c *MirageClient) needsEnrollment() bool {
+	tlsCfg := c.cfg.Session.TLS
+	if !tlsCfg.Mutual || strings.TrimSpace(tlsCfg.JoinToken) == "" {
+		return false
+	}
+	_, err := os.Stat(tlsCfg.CertFile)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// Ghost ghost.enroll exchange over a cert-less TLS connection.
+// Mirage is authenticated by the CA pin in the join token before the secret is sent.
+// The new key, signed cert, and CA are written to the configured TLS paths so the
+// next dial runs normal mTLS; the cert file is written last because its presence
+// marks enrollment complete.
+func (c *MirageClient) enroll(ctx context.Context) error {
+	token, err := session.ParseJoinToken(c.cfg.Session.TLS.JoinToken)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEnrollmentRejected, err)
+	}
+	serverName, err := c.serverName()
+	if err != nil {
+		return err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("ghost: generate enrollment key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: c.cfg.GhostID},
+		DNSNames: []string{c.cfg.GhostID},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("ghost: create csr: %w", err)
+	}
+
+	dialer := net.Dialer{Timeout: c.cfg.Session.ConnectTimeout}
+	rawConn, err := dialer.DialContext(ctx, "tcp", c.cfg.Address)
+	if err != nil {
+		return err
+	}
+	conn := tls.Client(rawConn, &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// The pinned verifier replaces chain verification; no CA file exists yet.
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: session.PinnedCAVerifier(token.CAHash, serverName),
+	})
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(c.cfg.Session.HandshakeTimeout))
+	if err := conn.HandshakeContext(ctx); err != nil {
+		if errors.Is(err, session.ErrEnrollCAPinMismatch) {
+			return fmt.Errorf("%w: %v", ErrEnrollmentRejected, err)
+		}
+		return err
+	}
+	if err := session.WriteEnrollRequest(conn, session.EnrollRequest{
+		TokenID:     token.ID,
+		TokenSecret: token.Secret,
+		GhostID:     c.cfg.GhostID,
+		CSR:         csr,
+	}); err != nil {
+		return err
+	}
+	ack, err := session.ReadEnrollAck(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	if ack.Status != session.AckStatusAccepted {
+		return fmt.Errorf("%w: code=%d message=%q", ErrEnrollmentRejected, ack.Code, ack.Message)
+	}
+	if err := c.storeEnrollment(key, ack); err != nil {
+		return err
+	}
+	logs.Warnf("ghost.MirageClient enrolled ghost_id=%q cert=%q", c.cfg.GhostID, c.cfg.Session.TLS.CertFile)
+	return nil
+}
+
+// Ghost enrollment result check and write-out to the configured TLS file paths.
+func (c *MirageClient) storeEnrollment(key *ecdsa.PrivateKey, ack session.EnrollAck) error {
+	cert, err := x509.ParseCertificate(ack.Certificate)
+	if err != nil {
+		return fmt.Errorf("ghost: parse enrolled certificate: %w", err)
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !pub.Equal(&key.PublicKey) {
+		return fmt.Errorf("%w: certificate does not match enrollment key", ErrEnrollmentRejected)
+	}
+	if cert.Subject.CommonName != c.cfg.GhostID {
+		return fmt.Errorf("%w: certificate bound to %q", ErrEnrollmentRejected, cert.Subject.CommonName)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	tlsCfg := c.cfg.Session.TLS
+	for _, out := range []struct {
+		path      string
+		blockType string
+		der       []byte
+		perm      os.FileMode
+	}{
+		{tlsCfg.KeyFile, "PRIVATE KEY", keyDER, 0o600},
+		{tlsCfg.CAFile, "CERTIFICATE", ack.CACertificate, 0o644},
+		{tlsCfg.CertFile, "CERTIFICATE", ack.Certificate, 0o644},
+	} {
+		if err := os.MkdirAll(filepath.Dir(out.path), 0o700); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := pem.Encode(&buf, &pem.Block{Type: out.blockType, Bytes: out.der}); err != nil {
+			return err
+		}
+		if err := os.WriteFile(out.path, buf.Bytes(), out.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
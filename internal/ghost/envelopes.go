@@ -3,14 +3,20 @@ package ghost
 import (
 	"fmt"
 	"strings"
+
+	"github.com/danmuck/edgectl/internal/seeds"
 )
 
 const (
-	OutcomeSuccess = "success"
-	OutcomeError   = "error"
+	OutcomeSuccess   = "success"
+	OutcomeError     = "error"
+	OutcomeTimeout   = "timeout"
+	OutcomeCancelled = "cancelled"
 
-	SeedStatusOK    = "ok"
-	SeedStatusError = "error"
+	SeedStatusOK        = "ok"
+	SeedStatusError     = "error"
+	SeedStatusTimeout   = "timeout"
+	SeedStatusCancelled = "cancelled"
 )
 
 // Ghost normalized seed.execute request envelope for seed dispatch.
@@ -91,7 +97,9 @@ func (e EventEnv) Validate() error {
 	if strings.TrimSpace(e.SeedID) == "" {
 		return fmt.Errorf("%w: missing seed_id", ErrInvalidCommandEnv)
 	}
-	if e.Outcome != OutcomeSuccess && e.Outcome != OutcomeError {
+	switch e.Outcome {
+	case OutcomeSuccess, OutcomeError, OutcomeTimeout, OutcomeCancelled:
+	default:
 		return fmt.Errorf("%w: invalid outcome", ErrInvalidCommandEnv)
 	}
 	if e.TimestampMS == 0 {
@@ -100,6 +108,57 @@ func (e EventEnv) Validate() error {
 	return nil
 }
 
+// Ghost non-terminal progress envelope emitted while a seed operation runs.
+// Seq orders records within one execution starting at 1; Chunk carries Stream output
+// and is empty for percent/message-only updates.
+type ProgressEnv struct {
+	ExecutionID string
+	CommandID   string
+	IntentID    string
+	GhostID     string
+	Seq         uint64
+	Stream      string
+	Chunk       []byte
+	Percent     uint32
+	Message     string
+	TimestampMS uint64
+}
+
+// Ghost progress validator for required envelope fields.
+func (p ProgressEnv) Validate() error {
+	if strings.TrimSpace(p.ExecutionID) == "" {
+		return fmt.Errorf("%w: missing execution_id", ErrInvalidCommandEnv)
+	}
+	if strings.TrimSpace(p.CommandID) == "" {
+		return fmt.Errorf("%w: missing command_id", ErrInvalidCommandEnv)
+	}
+	if strings.TrimSpace(p.IntentID) == "" {
+		return fmt.Errorf("%w: missing intent_id", ErrInvalidCommandEnv)
+	}
+	if strings.TrimSpace(p.GhostID) == "" {
+		return fmt.Errorf("%w: missing ghost_id", ErrInvalidCommandEnv)
+	}
+	if p.Seq == 0 {
+		return fmt.Errorf("%w: missing progress seq", ErrInvalidCommandEnv)
+	}
+	if p.Percent > 100 {
+		return fmt.Errorf("%w: invalid percent", ErrInvalidCommandEnv)
+	}
+	switch p.Stream {
+	case "":
+		if len(p.Chunk) > 0 {
+			return fmt.Errorf("%w: chunk missing stream", ErrInvalidCommandEnv)
+		}
+	case seeds.StreamStdout, seeds.StreamStderr:
+	default:
+		return fmt.Errorf("%w: invalid stream", ErrInvalidCommandEnv)
+	}
+	if p.TimestampMS == 0 {
+		return fmt.Errorf("%w: missing timestamp_ms", ErrInvalidCommandEnv)
+	}
+	return nil
+}
+
 // Ghost deterministic event_id builder derived from command_id.
 func eventIDForCommand(commandID string) string {
 	return "evt." + strings.TrimSpace(commandID)
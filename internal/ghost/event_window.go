@@ -0,0 +1,132 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+// Ghost outbound message handed to the session writer goroutine.
+type outboundFrame struct {
+	msg      frame.Frame
+	deadline time.Time
+	result   chan error
+}
+
+// Ghost terminal outcome for one event delivery attempt: an ack or a Mirage error envelope.
+type ackResult struct {
+	ack session.EventAck
+	err error
+}
+
+// Ghost session writer loop; the only goroutine that writes to the stream.
+// Each message is built once by the session encoder and written with one Write.
+func (s *MirageSession) writeLoop() {
+	enc := frame.NewEncoderWithOptions(s.conn, frame.DefaultLimits(), s.wire)
+	defer enc.Release()
+	for {
+		select {
+		case out := <-s.writes:
+			if err := s.conn.SetWriteDeadline(out.deadline); err != nil {
+				out.result <- err
+				continue
+			}
+			out.result <- enc.EncodeMessage(out.msg)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Ghost in-flight window slot acquisition bounded by the event ack deadline.
+func (s *MirageSession) acquireWindow(ctx context.Context, deadline time.Time) error {
+	select {
+	case s.window <- struct{}{}:
+		return nil
+	default:
+	}
+	logs.Debugf("ghost.MirageSession event window full size=%d", cap(s.window))
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case s.window <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return ErrSessionClosed
+	case <-timer.C:
+		return ErrAckTimeout
+	}
+}
+
+// Ghost ack waiter registration; one delivery per event_id may be in flight.
+func (s *MirageSession) addAckWaiter(eventID string) (<-chan ackResult, error) {
+	s.ackMu.Lock()
+	defer s.ackMu.Unlock()
+	if _, exists := s.ackWait[eventID]; exists {
+		return nil, fmt.Errorf("%w: event_id=%q", ErrEventInFlight, eventID)
+	}
+	waiter := make(chan ackResult, 1)
+	s.ackWait[eventID] = waiter
+	return waiter, nil
+}
+
+// Ghost ack waiter release that also forgets every message_id sent for the event.
+func (s *MirageSession) dropAckWaiter(eventID string) {
+	s.ackMu.Lock()
+	defer s.ackMu.Unlock()
+	delete(s.ackWait, eventID)
+	for messageID, id := range s.ackMsgs {
+		if id == eventID {
+			delete(s.ackMsgs, messageID)
+		}
+	}
+}
+
+// Ghost message_id to event_id mapping used to route error envelopes.
+func (s *MirageSession) trackAckMessage(messageID uint64, eventID string) {
+	s.ackMu.Lock()
+	defer s.ackMu.Unlock()
+	if _, ok := s.ackWait[eventID]; ok {
+		s.ackMsgs[messageID] = eventID
+	}
+}
+
+// Ghost inbound routing to the waiter for event_id; returns false when none is pending.
+// Extra results for the same event are dropped because the first one wins.
+func (s *MirageSession) deliverAck(eventID string, res ackResult) bool {
+	s.ackMu.Lock()
+	defer s.ackMu.Unlock()
+	waiter, ok := s.ackWait[eventID]
+	if !ok {
+		return false
+	}
+	select {
+	case waiter <- res:
+	default:
+	}
+	return true
+}
+
+// Ghost inbound error-envelope routing to the event that sent message_id.
+func (s *MirageSession) deliverAckError(env session.ErrorEnvelope) bool {
+	s.ackMu.Lock()
+	eventID, ok := s.ackMsgs[env.MessageID]
+	s.ackMu.Unlock()
+	if !ok {
+		return false
+	}
+	return s.deliverAck(eventID, ackResult{err: env})
+}
+
+// Ghost retry delay; the shared rng is not safe for concurrent deliveries.
+func (s *MirageSession) backoffDelay(attempt int) time.Duration {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return session.NextBackoffDelay(s.cfg.Backoff, attempt, s.rng)
+}
@@ -5,15 +5,18 @@ import (
 	"strings"
 )
 
-// Ghost in-memory command lifecycle phase marker.
+// Ghost in-memory command lifecycle phase marker: accepted -> queued -> running -> complete.
 type ExecutionPhase string
 
 const (
 	ExecutionAccepted ExecutionPhase = "accepted"
+	ExecutionQueued   ExecutionPhase = "queued"
+	ExecutionRunning  ExecutionPhase = "running"
 	ExecutionComplete ExecutionPhase = "complete"
 )
 
 // Ghost in-memory execution record keyed by command and message ids.
+// Percent and ProgressSeq track the latest progress record emitted while running.
 type ExecutionState struct {
 	MessageID    uint64
 	CommandID    string
@@ -28,6 +31,8 @@ type ExecutionState struct {
 	Event        EventEnv
 	Outcome      string
 	Phase        ExecutionPhase
+	Percent      uint32
+	ProgressSeq  uint64
 }
 
 // Ghost execution-state constructor from accepted command input.
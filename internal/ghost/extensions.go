@@ -0,0 +1,70 @@
+package ghost
+
+import (
+	"context"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	logs "github.com/danmuck/smplog"
+)
+
+// ExtensionHandler receives one validated extension message from Mirage.
+// It runs on the session read loop, so it must not block; fields are owned by the handler.
+type ExtensionHandler func(messageType uint32, fields []tlv.Field)
+
+// Schema returns the service-owned schema registry shared by every Mirage session.
+func (s *Service) Schema() *schema.Registry {
+	return s.schema
+}
+
+// HandleExtension registers an extension message type on this service's registry
+// and routes inbound frames of that type to handler.
+func (s *Service) HandleExtension(messageType uint32, reqs []schema.Requirement, handler ExtensionHandler) error {
+	if err := s.schema.Register(messageType, reqs); err != nil {
+		return err
+	}
+	s.extMu.Lock()
+	s.extHandlers[messageType] = handler
+	s.extMu.Unlock()
+	return nil
+}
+
+// Ghost service extension router used as the session ExtensionHandler.
+func (s *Service) handleExtension(messageType uint32, fields []tlv.Field) {
+	s.extMu.RLock()
+	handler := s.extHandlers[messageType]
+	s.extMu.RUnlock()
+	if handler == nil {
+		logs.Debugf("ghost.Service extension without handler message_type=%d", messageType)
+		return
+	}
+	handler(messageType, fields)
+}
+
+// SendExtension writes one registry-validated extension message to Mirage.
+// Delivery is fire-and-forget: extension messages carry no event.ack.
+func (s *MirageSession) SendExtension(ctx context.Context, messageType uint32, fields []tlv.Field) error {
+	messageID := s.nextMessageID.Add(1)
+	msg, err := session.NewExtensionFrame(s.schema, s.version, messageID, messageType, fields)
+	if err != nil {
+		return err
+	}
+	return s.writeFrame(ctx, msg)
+}
+
+// Ghost extension frame validation and handler dispatch; invalid frames get an error envelope.
+func (s *MirageSession) dispatchExtension(fr frame.Frame) {
+	fields, err := session.DecodeExtensionFrame(s.schema, fr)
+	if err != nil {
+		logs.Warnf("ghost.MirageSession.readLoop decode extension message_type=%d err=%v", fr.Header.MessageType, err)
+		s.replyError(fr, err)
+		return
+	}
+	if s.onExt == nil {
+		logs.Debugf("ghost.MirageSession.readLoop extension without handler message_type=%d", fr.Header.MessageType)
+		return
+	}
+	s.onExt(fr.Header.MessageType, fields)
+}
@@ -0,0 +1,95 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+// Ghost heartbeat bookkeeping for one Mirage session.
+// Pings in flight are keyed by message_id so pongs yield a monotonic RTT.
+type heartbeatState struct {
+	pings    map[uint64]time.Time
+	lastPong time.Time
+	rtt      time.Duration
+}
+
+// Supports reports whether Mirage enabled an optional session capability at registration.
+func (s *MirageSession) Supports(capability string) bool {
+	return slices.Contains(s.caps, capability)
+}
+
+// Ping sends one heartbeat ping to Mirage without waiting for its pong.
+// It returns ErrHeartbeatTimeout once no pong has arrived within SessionDeadAfter,
+// and ErrHeartbeatUnsupported when Mirage did not negotiate the heartbeat capability.
+func (s *MirageSession) Ping(ctx context.Context) error {
+	if !s.Supports(session.CapabilityHeartbeat) {
+		return ErrHeartbeatUnsupported
+	}
+	now := time.Now()
+	messageID := s.nextMessageID.Add(1)
+
+	s.hbMu.Lock()
+	if age := now.Sub(s.hb.lastPong); age > s.cfg.SessionDeadAfter {
+		s.hbMu.Unlock()
+		return fmt.Errorf("%w: last_pong_age=%s dead_after=%s", ErrHeartbeatTimeout, age, s.cfg.SessionDeadAfter)
+	}
+	for id, sentAt := range s.hb.pings {
+		if now.Sub(sentAt) > s.cfg.SessionDeadAfter {
+			delete(s.hb.pings, id)
+		}
+	}
+	s.hb.pings[messageID] = now
+	rtt := s.hb.rtt
+	s.hbMu.Unlock()
+
+	msg, err := session.NewPingFrame(messageID, session.Ping{
+		TimestampMS: uint64(now.UnixMilli()),
+		RTTMS:       uint64(rtt.Milliseconds()),
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeFrame(ctx, msg)
+}
+
+// RTT returns the round trip measured by the most recent pong, or 0 before the first one.
+func (s *MirageSession) RTT() time.Duration {
+	s.hbMu.Lock()
+	defer s.hbMu.Unlock()
+	return s.hb.rtt
+}
+
+// LastPong returns when Mirage last answered a ping; registration counts as the first.
+func (s *MirageSession) LastPong() time.Time {
+	s.hbMu.Lock()
+	defer s.hbMu.Unlock()
+	return s.hb.lastPong
+}
+
+// Ghost pong handling that refreshes liveness and records RTT.
+// Pongs for pruned pings still prove liveness; RTT then falls back to the echoed timestamp.
+func (s *MirageSession) handlePong(fr frame.Frame) {
+	pong, err := session.DecodePongFrame(fr)
+	if err != nil {
+		logs.Warnf("ghost.MirageSession.readLoop decode pong err=%v", err)
+		s.replyError(fr, err)
+		return
+	}
+	now := time.Now()
+	s.hbMu.Lock()
+	defer s.hbMu.Unlock()
+	if sentAt, ok := s.hb.pings[fr.Header.MessageID]; ok {
+		delete(s.hb.pings, fr.Header.MessageID)
+		s.hb.rtt = now.Sub(sentAt)
+	} else if echoed := int64(pong.PingTimestampMS); echoed <= now.UnixMilli() {
+		s.hb.rtt = time.Duration(now.UnixMilli()-echoed) * time.Millisecond
+	}
+	s.hb.lastPong = now
+	logs.Debugf("ghost.MirageSession.readLoop pong message_id=%d rtt=%s", fr.Header.MessageID, s.hb.rtt)
+}
@@ -0,0 +1,304 @@
+package ghost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/journal"
+	logs "github.com/danmuck/smplog"
+)
+
+const (
+	journalOpAccept   = "accept"
+	journalOpComplete = "complete"
+	journalOpDrop     = "drop"
+)
+
+var (
+	ErrJournalPathRequired = errors.New("ghost: execution journal path required")
+	ErrJournalSyncPolicy   = errors.New("ghost: invalid execution journal sync policy")
+	ErrJournalOpen         = errors.New("ghost: execution journal already open")
+	ErrJournalWrite        = errors.New("ghost: execution journal write failed")
+)
+
+// JournalSyncPolicy selects when execution journal appends are fsynced.
+type JournalSyncPolicy string
+
+const (
+	// Every record is fsynced before the command is accepted or completed.
+	JournalSyncAlways JournalSyncPolicy = "always"
+	// Records are fsynced at most once per SyncInterval; a power loss may drop the tail.
+	JournalSyncInterval JournalSyncPolicy = "interval"
+	// Records are left to the OS; they survive a process crash but not a power loss.
+	JournalSyncNever JournalSyncPolicy = "never"
+)
+
+// Ghost execution journal settings.
+// The journal is an append-only record of accepted and completed executions replayed
+// into the execution store on boot, so duplicate keys survive restarts.
+type JournalConfig struct {
+	Path         string
+	Sync         JournalSyncPolicy
+	SyncInterval time.Duration
+}
+
+// Ghost journal config with zero values replaced by defaults.
+func (c JournalConfig) WithDefaults() JournalConfig {
+	c.Path = strings.TrimSpace(c.Path)
+	if strings.TrimSpace(string(c.Sync)) == "" {
+		c.Sync = JournalSyncAlways
+	}
+	if c.SyncInterval <= 0 {
+		c.SyncInterval = time.Second
+	}
+	return c
+}
+
+// Ghost journal config validation for the sync policy.
+func (c JournalConfig) Validate() error {
+	switch c.Sync {
+	case JournalSyncAlways, JournalSyncInterval, JournalSyncNever:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrJournalSyncPolicy, c.Sync)
+	}
+}
+
+// Ghost journal record appended for each execution store mutation.
+// AtMS is the acceptance or completion time; drop records only carry ExecutionID.
+type journalRecord struct {
+	Op          string          `json:"op"`
+	AtMS        uint64          `json:"at_ms,omitempty"`
+	ExecutionID string          `json:"execution_id,omitempty"`
+	State       *ExecutionState `json:"state,omitempty"`
+}
+
+// Ghost execution state recovered from the journal with its last record time.
+type journalEntry struct {
+	state ExecutionState
+	atMS  uint64
+}
+
+// Ghost execution journal file with its sync policy bookkeeping.
+type executionJournal struct {
+	file     *journal.Journal
+	sync     JournalSyncPolicy
+	interval time.Duration
+	lastSync time.Time
+}
+
+// Ghost journal open and replay into the execution store; must run before Radiate.
+// Completed executions still inside the retention TTL are restored with their
+// duplicate keys. Executions accepted but not completed before the restart are
+// restored as complete error outcomes and never re-run, since their side effects
+// are unknown. The journal is then compacted to the restored entries.
+func (s *Server) OpenJournal(cfg JournalConfig) error {
+	cfg = cfg.WithDefaults()
+	if cfg.Path == "" {
+		return ErrJournalPathRequired
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	entries, err := loadExecutionJournal(cfg.Path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.journal != nil {
+		return ErrJournalOpen
+	}
+	if s.phase == PhaseRadiating {
+		return fmt.Errorf("%w: execution journal must open before radiate", ErrLifecycleOrder)
+	}
+
+	now := s.now()
+	restored, interrupted, expired := 0, 0, 0
+	for _, entry := range entries {
+		state := entry.state
+		at := time.UnixMilli(int64(entry.atMS))
+		if state.Phase != ExecutionComplete {
+			state = interruptedExecution(state)
+			at = now
+			interrupted++
+		}
+		expiresAt := at.Add(s.retention.TTL)
+		if !now.Before(expiresAt) {
+			expired++
+			continue
+		}
+		if _, exists := s.executionByCmdID[state.CommandID]; exists {
+			continue
+		}
+		s.executionByID[state.ExecutionID] = state
+		s.executionByCmdID[state.CommandID] = state
+		s.commandByMessageID[state.MessageID] = state.CommandID
+		s.retained = append(s.retained, retainedExecution{executionID: state.ExecutionID, expiresAt: expiresAt})
+		restored++
+	}
+	sort.SliceStable(s.retained, func(a, b int) bool {
+		return s.retained[a].expiresAt.Before(s.retained[b].expiresAt)
+	})
+
+	file, err := journal.Open(cfg.Path, s.journalSnapshotLocked())
+	if err != nil {
+		return fmt.Errorf("ghost: execution journal: %w", err)
+	}
+	s.journal = &executionJournal{
+		file:     file,
+		sync:     cfg.Sync,
+		interval: cfg.SyncInterval,
+		lastSync: now,
+	}
+	logs.Infof(
+		"ghost.Server.OpenJournal path=%q sync=%s restored=%d interrupted=%d expired=%d",
+		cfg.Path,
+		cfg.Sync,
+		restored,
+		interrupted,
+		expired,
+	)
+	return nil
+}
+
+// Ghost journal close; a running compaction finishes and pending appends are fsynced first.
+func (s *Server) CloseJournal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	err := s.journal.file.Close()
+	s.journal = nil
+	return err
+}
+
+// Ghost completed state for an execution whose outcome was lost to a restart.
+func interruptedExecution(state ExecutionState) ExecutionState {
+	seedExec := state.SeedExecute
+	if strings.TrimSpace(seedExec.ExecutionID) == "" {
+		seedExec = buildSeedExecute(state)
+	}
+	seedResult := errorSeedResult(seedExec, "ghost restarted before execution completed; outcome unknown", 1)
+	event := buildEvent(state, seedResult)
+	state.SeedExecute = seedExec
+	state.SeedResult = seedResult
+	state.Event = event
+	state.Outcome = event.Outcome
+	state.Phase = ExecutionComplete
+	return state
+}
+
+// Ghost highest message_id in the execution store, so generated ids can skip past
+// keys restored from the journal.
+func (s *Server) maxMessageID() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out uint64
+	for id := range s.commandByMessageID {
+		out = max(out, id)
+	}
+	return out
+}
+
+// Ghost journal append for one store mutation; caller must hold mu.
+// Returns nil when no journal is open.
+func (s *Server) journalLocked(op string, state ExecutionState) error {
+	if s.journal == nil {
+		return nil
+	}
+	now := s.now()
+	rec := journalRecord{Op: op, AtMS: uint64(now.UnixMilli())}
+	if op == journalOpDrop {
+		rec.ExecutionID = state.ExecutionID
+	} else {
+		rec.State = &state
+	}
+	if err := s.journal.append(rec, now); err != nil {
+		return err
+	}
+	// Compaction runs in the background on a snapshot, so the accept path never
+	// waits on the rewrite.
+	if s.journal.file.NeedsCompaction(len(s.executionByID)) {
+		s.journal.file.Compact(s.journalSnapshotLocked())
+	}
+	return nil
+}
+
+// Ghost journal records rebuilding the live execution store.
+// Finished executions keep their completion time so expiry survives another restart.
+// Caller must hold mu.
+func (s *Server) journalSnapshotLocked() []any {
+	now := s.now()
+	finishedAt := make(map[string]time.Time, len(s.retained))
+	for _, r := range s.retained {
+		finishedAt[r.executionID] = r.expiresAt.Add(-s.retention.TTL)
+	}
+	states := make([]ExecutionState, 0, len(s.executionByID))
+	for _, state := range s.executionByID {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(a, b int) bool {
+		return states[a].ExecutionID < states[b].ExecutionID
+	})
+	recs := make([]any, 0, len(states))
+	for i := range states {
+		rec := journalRecord{Op: journalOpAccept, AtMS: uint64(now.UnixMilli()), State: &states[i]}
+		if states[i].Phase == ExecutionComplete {
+			rec.Op = journalOpComplete
+			if at, ok := finishedAt[states[i].ExecutionID]; ok {
+				rec.AtMS = uint64(at.UnixMilli())
+			}
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// Ghost journal replay into per-execution entries keyed by execution_id.
+// A torn trailing record from an interrupted write is ignored.
+func loadExecutionJournal(path string) (map[string]journalEntry, error) {
+	entries := make(map[string]journalEntry)
+	err := journal.Replay(path, func(raw []byte) error {
+		var rec journalRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+		applyJournalRecord(entries, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ghost: execution journal: %w", err)
+	}
+	return entries, nil
+}
+
+// Ghost journal record application during replay.
+func applyJournalRecord(entries map[string]journalEntry, rec journalRecord) {
+	switch rec.Op {
+	case journalOpAccept, journalOpComplete:
+		if rec.State == nil || strings.TrimSpace(rec.State.ExecutionID) == "" {
+			return
+		}
+		entries[rec.State.ExecutionID] = journalEntry{state: *rec.State, atMS: rec.AtMS}
+	case journalOpDrop:
+		delete(entries, strings.TrimSpace(rec.ExecutionID))
+	}
+}
+
+// Ghost journal single-record append, fsynced per the sync policy.
+func (j *executionJournal) append(rec journalRecord, now time.Time) error {
+	sync := j.sync == JournalSyncAlways
+	if j.sync == JournalSyncInterval && now.Sub(j.lastSync) >= j.interval {
+		j.lastSync = now
+		sync = true
+	}
+	return j.file.Append(rec, sync)
+}
@@ -0,0 +1,188 @@
+package ghost
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/journal"
+	"github.com/danmuck/edgectl/internal/seeds"
+	seedflow "github.com/danmuck/edgectl/internal/seeds/flow"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestJournalRestoresDuplicateKeysAcrossRestart(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "executions.log")
+	clock := &testClock{base: time.Now()}
+
+	first := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	done, err := first.HandleCommandAndExecute(flowCommand(1))
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if _, err := first.HandleCommand(flowCommand(2)); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if err := first.CloseJournal(); err != nil {
+		t.Fatalf("close journal: %v", err)
+	}
+
+	second := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if _, err := second.HandleCommandAndExecute(flowCommand(1)); !errors.Is(err, ErrDuplicateCommandID) {
+		t.Fatalf("expected duplicate command_id after restart, got %v", err)
+	}
+	replay := flowCommand(3)
+	replay.MessageID = 1
+	if _, err := second.HandleCommand(replay); !errors.Is(err, ErrDuplicateMessageID) {
+		t.Fatalf("expected duplicate message_id after restart, got %v", err)
+	}
+	state, ok := second.ExecutionByCommandID("cmd.flow.1")
+	if !ok || state.Phase != ExecutionComplete || state.Event.EventID != done.EventID || state.Outcome != done.Outcome {
+		t.Fatalf("unexpected restored execution: ok=%v state=%+v", ok, state)
+	}
+
+	interrupted, ok := second.ExecutionByCommandID("cmd.flow.2")
+	if !ok || interrupted.Phase != ExecutionComplete || interrupted.Outcome != OutcomeError {
+		t.Fatalf("expected interrupted execution restored as error: ok=%v state=%+v", ok, interrupted)
+	}
+	if !strings.Contains(string(interrupted.SeedResult.Stderr), "outcome unknown") {
+		t.Fatalf("unexpected interrupted stderr: %q", interrupted.SeedResult.Stderr)
+	}
+	if _, err := second.HandleCommandAndExecute(flowCommand(2)); !errors.Is(err, ErrDuplicateCommandID) {
+		t.Fatalf("interrupted command must not re-run, got %v", err)
+	}
+	if _, err := second.HandleCommandAndExecute(flowCommand(4)); err != nil {
+		t.Fatalf("new command after restart: %v", err)
+	}
+}
+
+func TestJournalDropsExpiredAndQueueRejectedExecutions(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "executions.log")
+	clock := &testClock{base: time.Now()}
+
+	first := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if _, err := first.HandleCommandAndExecute(flowCommand(1)); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	state, err := first.HandleCommand(flowCommand(2))
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	first.dropExecution(state)
+	if err := first.CloseJournal(); err != nil {
+		t.Fatalf("close journal: %v", err)
+	}
+
+	clock.advance(MinDuplicateWindow + time.Second)
+	second := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if got := second.RetentionStats().Executions; got != 0 {
+		t.Fatalf("expected expired and dropped executions skipped, store=%d", got)
+	}
+	if _, err := second.HandleCommandAndExecute(flowCommand(1)); err != nil {
+		t.Fatalf("expired command_id should be accepted again: %v", err)
+	}
+	if _, err := second.HandleCommandAndExecute(flowCommand(2)); err != nil {
+		t.Fatalf("dropped command_id should be accepted again: %v", err)
+	}
+}
+
+func TestJournalCompactsAndToleratesTornTrailingRecord(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "executions.log")
+	clock := &testClock{base: time.Now()}
+
+	first := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	for n := uint64(1); n <= 200; n++ {
+		if _, err := first.HandleCommandAndExecute(flowCommand(n)); err != nil {
+			t.Fatalf("execute %d: %v", n, err)
+		}
+	}
+	clock.advance(MinDuplicateWindow + time.Second)
+	if _, err := first.HandleCommandAndExecute(flowCommand(201)); err != nil {
+		t.Fatalf("execute after window: %v", err)
+	}
+	if err := first.CloseJournal(); err != nil {
+		t.Fatalf("close journal: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if lines := strings.Count(string(raw), "\n"); lines >= journal.MinCompactRecords {
+		t.Fatalf("expected journal compacted after expiry, lines=%d", lines)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"accept","at_ms":1,"state":{"Comm`); err != nil {
+		t.Fatalf("write torn record: %v", err)
+	}
+	_ = f.Close()
+
+	second := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if got := second.RetentionStats().Executions; got != 1 {
+		t.Fatalf("expected only the live execution restored, store=%d", got)
+	}
+	if _, err := second.HandleCommand(flowCommand(201)); !errors.Is(err, ErrDuplicateCommandID) {
+		t.Fatalf("expected duplicate command_id after torn record, got %v", err)
+	}
+}
+
+func TestJournalKeepsRecordsAppendedDuringBackgroundCompaction(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "executions.log")
+	clock := &testClock{base: time.Now()}
+
+	first := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if _, err := first.HandleCommandAndExecute(flowCommand(1)); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	first.mu.Lock()
+	first.journal.file.Compact(first.journalSnapshotLocked())
+	first.mu.Unlock()
+	for n := uint64(2); n <= 20; n++ {
+		if _, err := first.HandleCommandAndExecute(flowCommand(n)); err != nil {
+			t.Fatalf("execute %d during compaction: %v", n, err)
+		}
+	}
+	if err := first.CloseJournal(); err != nil {
+		t.Fatalf("close journal: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected compaction temp file swapped in, stat err=%v", err)
+	}
+
+	second := newRadiatingServer(t, "ghost.alpha", withClock(clock), withJournal(path))
+	if got := second.RetentionStats().Executions; got != 20 {
+		t.Fatalf("expected every execution restored, store=%d", got)
+	}
+	for n := uint64(1); n <= 20; n++ {
+		if _, err := second.HandleCommand(flowCommand(n)); !errors.Is(err, ErrDuplicateCommandID) {
+			t.Fatalf("expected duplicate command_id %d after restart, got %v", n, err)
+		}
+	}
+}
+
+func TestNonIdempotentOperationsListsOperationsUnsafeWithoutJournal(t *testing.T) {
+	testlog.Start(t)
+	reg := seeds.NewRegistry()
+	if err := reg.Register(seedflow.NewSeed()); err != nil {
+		t.Fatalf("register flow seed: %v", err)
+	}
+	if got := nonIdempotentOperations(reg); len(got) != 0 {
+		t.Fatalf("expected no unsafe operations for flow, got %v", got)
+	}
+	if err := reg.Register(newGateSeed()); err != nil {
+		t.Fatalf("register gate seed: %v", err)
+	}
+	if got := nonIdempotentOperations(reg); len(got) != 1 || got[0] != "seed.gate.restart" {
+		t.Fatalf("unexpected unsafe operations: %v", got)
+	}
+}
@@ -4,18 +4,17 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net"
-	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
 	"github.com/danmuck/edgectl/internal/protocol/session"
 	"github.com/danmuck/edgectl/internal/seeds"
 	logs "github.com/danmuck/smplog"
@@ -26,10 +25,20 @@ var (
 	ErrGhostIDRequired       = errors.New("ghost: ghost_id required")
 	ErrRegistrationRejected  = errors.New("ghost: registration rejected")
 	ErrAckRejected           = errors.New("ghost: event.ack rejected")
+	ErrAckThrottled          = errors.New("ghost: event.ack throttled")
 	ErrAckTimeout            = errors.New("ghost: event.ack timeout")
 	ErrSessionClosed         = errors.New("ghost: mirage session closed")
+	ErrHeartbeatTimeout      = errors.New("ghost: mirage heartbeat timeout")
+	ErrHeartbeatUnsupported  = errors.New("ghost: mirage did not negotiate heartbeat")
+	ErrProgressUnsupported   = errors.New("ghost: mirage did not negotiate progress")
+	ErrEventInFlight         = errors.New("ghost: event already in flight")
+	ErrEnrollmentRejected    = errors.New("ghost: enrollment rejected")
 )
 
+// Ghost handler for command envelopes pushed by Mirage over a registered session.
+// The returned terminal event is delivered back to Mirage with event.ack retry.
+type SessionCommandHandler func(ctx context.Context, cmd CommandEnv) (EventEnv, error)
+
 // Ghost outbound Mirage session-client configuration.
 type MirageClientConfig struct {
 	Address            string
@@ -38,6 +47,15 @@ type MirageClientConfig struct {
 	SeedList           []session.SeedInfo
 	Session            session.Config
 	MaxConnectAttempts int
+	CommandHandler     SessionCommandHandler
+	// Outbox shared across sessions; nil uses a per-session in-memory outbox.
+	Outbox *session.EventOutbox
+	// Authenticator overrides Session.Auth; share one across reconnects to keep replay state.
+	Authenticator frame.Authenticator
+	// Schema validates extension frames; nil uses a registry with only the core contract.
+	Schema *schema.Registry
+	// ExtensionHandler receives validated inbound extension messages; nil drops them.
+	ExtensionHandler ExtensionHandler
 }
 
 // Ghost Mirage session-client defaults aligned with session defaults.
@@ -51,6 +69,9 @@ func DefaultMirageClientConfig() MirageClientConfig {
 type MirageClient struct {
 	cfg MirageClientConfig
 	rng *rand.Rand
+
+	tlsMu    sync.Mutex
+	tlsFiles *session.TLSReloader
 }
 
 // Ghost Mirage client constructor with transport/session validation.
@@ -65,6 +86,13 @@ func NewMirageClient(cfg MirageClientConfig) (*MirageClient, error) {
 		cfg.PeerIdentity = cfg.GhostID
 	}
 	cfg.Session = cfg.Session.WithDefaults()
+	if cfg.Authenticator == nil {
+		auth, err := session.NewFrameAuthenticator(cfg.Session.Auth)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Authenticator = auth
+	}
 	return &MirageClient{
 		cfg: cfg,
 		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
@@ -76,6 +104,18 @@ func (c *MirageClient) ConnectAndRegister(ctx context.Context) (*MirageSession,
 	var attempt int
 	for {
 		attempt++
+		if c.needsEnrollment() {
+			if err := c.enroll(ctx); err != nil {
+				logs.Warnf("ghost.MirageClient enroll attempt=%d addr=%q err=%v", attempt, c.cfg.Address, err)
+				if errors.Is(err, ErrEnrollmentRejected) || !c.shouldRetry(attempt) {
+					return nil, err
+				}
+				if err := c.sleepBackoff(ctx, attempt); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
 		conn, err := c.dial(ctx)
 		if err != nil {
 			logs.Warnf("ghost.MirageClient dial attempt=%d addr=%q err=%v", attempt, c.cfg.Address, err)
@@ -133,42 +173,80 @@ func (c *MirageClient) dial(ctx context.Context) (net.Conn, error) {
 }
 
 // Ghost TLS client config builder from session transport settings.
+// Built per dial; the client certificate and root CAs come from the shared file reloader.
 func (c *MirageClient) clientTLSConfig() (*tls.Config, error) {
+	files, err := c.tlsReloader()
+	if err != nil {
+		return nil, err
+	}
 	cfg := &tls.Config{
 		MinVersion:         tls.VersionTLS12,
 		InsecureSkipVerify: c.cfg.Session.TLS.InsecureSkipVerify,
+		RootCAs:            files.CAPool(),
 	}
 
-	serverName := strings.TrimSpace(c.cfg.Session.TLS.ServerName)
-	if serverName == "" {
-		host, _, err := net.SplitHostPort(c.cfg.Address)
-		if err != nil {
-			return nil, err
-		}
-		serverName = host
+	serverName, err := c.serverName()
+	if err != nil {
+		return nil, err
 	}
 	cfg.ServerName = serverName
 
-	if caPath := strings.TrimSpace(c.cfg.Session.TLS.CAFile); caPath != "" {
-		caPEM, err := os.ReadFile(caPath)
-		if err != nil {
-			return nil, err
-		}
-		pool := x509.NewCertPool()
-		if ok := pool.AppendCertsFromPEM(caPEM); !ok {
-			return nil, fmt.Errorf("ghost: parse tls ca bundle: %s", caPath)
+	if c.cfg.Session.TLS.Mutual {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return files.Certificate()
 		}
-		cfg.RootCAs = pool
 	}
+	return cfg, nil
+}
+
+// Ghost TLS server name: the configured override or the Mirage address host.
+func (c *MirageClient) serverName() (string, error) {
+	if serverName := strings.TrimSpace(c.cfg.Session.TLS.ServerName); serverName != "" {
+		return serverName, nil
+	}
+	host, _, err := net.SplitHostPort(c.cfg.Address)
+	if err != nil {
+		return "", err
+	}
+	return host, nil
+}
 
+// Ghost TLS file reloader, created on first dial and shared by every reconnect.
+func (c *MirageClient) tlsReloader() (*session.TLSReloader, error) {
+	c.tlsMu.Lock()
+	defer c.tlsMu.Unlock()
+	if c.tlsFiles != nil {
+		return c.tlsFiles, nil
+	}
+	var certFile, keyFile string
 	if c.cfg.Session.TLS.Mutual {
-		cert, err := tls.LoadX509KeyPair(c.cfg.Session.TLS.CertFile, c.cfg.Session.TLS.KeyFile)
-		if err != nil {
-			return nil, err
+		certFile, keyFile = c.cfg.Session.TLS.CertFile, c.cfg.Session.TLS.KeyFile
+	}
+	files, err := session.NewTLSReloader(session.TLSFiles{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		CAFile:   c.cfg.Session.TLS.CAFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.tlsFiles = files
+	return files, nil
+}
+
+// Ghost expiry of the TLS certificates one connection was opened with; zero without TLS.
+func (c *MirageClient) certExpiry(conn net.Conn) time.Time {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return time.Time{}
+	}
+	var local *tls.Certificate
+	if c.cfg.Session.TLS.Mutual {
+		if files, err := c.tlsReloader(); err == nil {
+			local, _ = files.Certificate()
 		}
-		cfg.Certificates = []tls.Certificate{cert}
 	}
-	return cfg, nil
+	return session.CertificateExpiry(tlsConn.ConnectionState(), local)
 }
 
 // Ghost retry decision helper for connect/register attempts.
@@ -196,10 +274,24 @@ func (c *MirageClient) sleepBackoff(ctx context.Context, attempt int) error {
 func (c *MirageClient) register(conn net.Conn) (*MirageSession, error) {
 	_ = conn.SetDeadline(time.Now().Add(c.cfg.Session.HandshakeTimeout))
 	reader := bufio.NewReader(conn)
+	versions := c.cfg.Session.Versions.WithDefaults()
+	outbox := c.cfg.Outbox
+	if outbox == nil {
+		outbox = session.NewEventOutbox()
+	}
 	reg := session.Registration{
-		GhostID:      c.cfg.GhostID,
-		PeerIdentity: c.cfg.PeerIdentity,
-		SeedList:     copySeedList(c.cfg.SeedList),
+		GhostID:            c.cfg.GhostID,
+		PeerIdentity:       c.cfg.PeerIdentity,
+		SeedList:           copySeedList(c.cfg.SeedList),
+		MinProtocolVersion: versions.Min,
+		MaxProtocolVersion: versions.Max,
+		Compression:        c.cfg.Session.Compression.Offer(),
+		SessionEpoch:       outbox.Epoch(),
+		Capabilities:       session.SupportedCapabilities(),
+	}
+	reg, err := session.SignRegistration(reg, c.cfg.Authenticator)
+	if err != nil {
+		return nil, err
 	}
 	if err := session.WriteRegistration(conn, reg); err != nil {
 		return nil, err
@@ -211,27 +303,117 @@ func (c *MirageClient) register(conn net.Conn) (*MirageSession, error) {
 	if ack.Status != session.AckStatusAccepted {
 		return nil, fmt.Errorf("%w: code=%d message=%q", ErrRegistrationRejected, ack.Code, ack.Message)
 	}
+	version := ack.NegotiatedVersion()
+	if !versions.Contains(version) {
+		return nil, fmt.Errorf(
+			"%w: mirage selected version=%d outside %d..%d",
+			session.ErrNoCommonProtocolVersion,
+			version,
+			versions.Min,
+			versions.Max,
+		)
+	}
+	if err := session.CheckCompression(c.cfg.Session.Compression, ack.Compression); err != nil {
+		return nil, err
+	}
+	logs.Debugf(
+		"ghost.MirageClient.register negotiated ghost_id=%q protocol_version=%d compression=%q",
+		c.cfg.GhostID,
+		version,
+		ack.Compression,
+	)
 	_ = conn.SetDeadline(time.Time{})
+	// Mirage echoes the epoch only when it tracks our sequence; anything else resumes from 0.
+	var resumeSeq uint64
+	if ack.SessionEpoch == reg.SessionEpoch {
+		resumeSeq = ack.LastSeq
+	}
+	registry := c.cfg.Schema
+	if registry == nil {
+		registry = schema.NewRegistry()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &MirageSession{
-		conn:   conn,
-		reader: reader,
-		cfg:    c.cfg.Session,
-		outbox: session.NewEventOutbox(),
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		conn:      conn,
+		reader:    reader,
+		cfg:       c.cfg.Session,
+		outbox:    outbox,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		handler:   c.cfg.CommandHandler,
+		auth:      c.cfg.Authenticator,
+		authReq:   c.cfg.Session.Auth.Required,
+		version:   version,
+		compress:  ack.Compression,
+		wire:      session.WireEncoderOptions(version, ack.Compression, c.cfg.Session.Compression.Threshold, c.cfg.Authenticator),
+		window:    make(chan struct{}, c.cfg.Session.EventWindow),
+		writes:    make(chan outboundFrame),
+		ackWait:   make(map[string]chan ackResult),
+		ackMsgs:   make(map[uint64]string),
+		done:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+		commands:  make(map[string]struct{}),
+		schema:    registry,
+		onExt:     c.cfg.ExtensionHandler,
+		resumeSeq: resumeSeq,
+		caps:      ack.Capabilities,
+		hb: heartbeatState{
+			pings:    make(map[uint64]time.Time),
+			lastPong: time.Now(),
+		},
 	}
 	s.nextMessageID.Store(uint64(time.Now().UnixNano()))
+	go s.readLoop()
+	go s.writeLoop()
+	if expiry := c.certExpiry(conn); c.cfg.Session.TLS.RehandshakeOnExpiry && !expiry.IsZero() {
+		go s.closeAtCertExpiry(expiry)
+	}
 	return s, nil
 }
 
-// Ghost-side registered Mirage stream with event ack/retry behavior.
+// Ghost-side registered Mirage stream with windowed event ack/retry behavior.
+// One reader goroutine demultiplexes inbound event.ack and command frames;
+// one writer goroutine serializes every outbound frame.
 type MirageSession struct {
 	conn          net.Conn
 	reader        *bufio.Reader
 	cfg           session.Config
 	outbox        *session.EventOutbox
 	nextMessageID atomic.Uint64
+	rngMu         sync.Mutex
 	rng           *rand.Rand
-	mu            sync.Mutex
+
+	handler  SessionCommandHandler
+	auth     frame.Authenticator
+	authReq  bool
+	version  uint16
+	compress string
+	wire     frame.EncoderOptions
+	window   chan struct{}
+	writes   chan outboundFrame
+	done     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	// Pending event deliveries keyed by event_id, plus every message_id sent for them.
+	ackMu   sync.Mutex
+	ackWait map[string]chan ackResult
+	ackMsgs map[uint64]string
+
+	commandsMu sync.Mutex
+	commands   map[string]struct{}
+
+	schema *schema.Registry
+	onExt  ExtensionHandler
+
+	// Event sequence Mirage reported as observed for this outbox epoch at registration.
+	resumeSeq uint64
+
+	// Optional session features Mirage enabled in the registration ack.
+	caps []string
+
+	hbMu sync.Mutex
+	hb   heartbeatState
 }
 
 // Ghost Mirage-session close operation for the underlying stream connection.
@@ -239,18 +421,278 @@ func (s *MirageSession) Close() error {
 	if s.conn == nil {
 		return nil
 	}
+	if s.cancel != nil {
+		s.cancel()
+	}
 	return s.conn.Close()
 }
 
+// Ghost session close once its TLS certificates expire so the run loop re-handshakes
+// with rotated material; Go TLS cannot renegotiate an open connection.
+func (s *MirageSession) closeAtCertExpiry(expiry time.Time) {
+	timer := time.NewTimer(time.Until(expiry))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		logs.Warnf("ghost.MirageSession tls certificate expired not_after=%s", expiry)
+		_ = s.Close()
+	case <-s.done:
+	}
+}
+
+// Ghost channel closed once the session reader exits on disconnect or close.
+func (s *MirageSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Ghost session reader loop routing inbound frames until the stream fails.
+func (s *MirageSession) readLoop() {
+	defer close(s.done)
+	limits := frame.DefaultLimits()
+	reasm := frame.NewReassembler(limits)
+	// Frames alias the decoder buffer; everything below copies out before the next read.
+	dec := frame.NewDecoder(s.reader, limits)
+	defer dec.Release()
+	for {
+		fr, err := dec.Decode()
+		if err != nil {
+			if code := session.ErrorCodeFor(err); session.ErrorClassForCode(code) == session.ErrorClassFraming {
+				// Stream is no longer aligned; report once, then drop the session.
+				s.sendError(session.NewErrorEnvelope(code, 0, 0, err.Error()))
+				_ = s.conn.Close()
+			}
+			logs.Debugf("ghost.MirageSession.readLoop exit err=%v", err)
+			return
+		}
+		if err := session.CheckFrameVersion(fr, s.version); err != nil {
+			logs.Warnf("ghost.MirageSession.readLoop version mismatch err=%v", err)
+			s.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeFramingInvalidHeader,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				err.Error(),
+			))
+			_ = s.conn.Close()
+			return
+		}
+		if err := frame.VerifyFrame(fr, s.auth, s.authReq); err != nil {
+			logs.Warnf(
+				"ghost.MirageSession.readLoop auth rejected message_id=%d message_type=%d err=%v",
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				err,
+			)
+			s.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeTransportFailure,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				err.Error(),
+			))
+			_ = s.conn.Close()
+			return
+		}
+		fr, complete, err := reasm.Accept(fr)
+		if err != nil {
+			logs.Warnf("ghost.MirageSession.readLoop reassembly err=%v", err)
+			s.sendError(session.NewErrorEnvelope(session.ErrorCodeFor(err), 0, 0, err.Error()))
+			_ = s.conn.Close()
+			return
+		}
+		if !complete {
+			continue
+		}
+		inflated, err := session.DecompressFrame(fr, s.compress, limits)
+		if err != nil {
+			code := session.ErrorCodeFor(err)
+			logs.Warnf("ghost.MirageSession.readLoop decompress error_code=%d err=%v", code, err)
+			s.sendError(session.NewErrorEnvelope(code, fr.Header.MessageID, fr.Header.MessageType, err.Error()))
+			if session.ErrorClassForCode(code) == session.ErrorClassFraming {
+				_ = s.conn.Close()
+				return
+			}
+			continue
+		}
+		fr = inflated
+		switch fr.Header.MessageType {
+		case schema.MsgEventAck:
+			ack, err := session.DecodeEventAckFrame(fr)
+			if err != nil {
+				logs.Warnf("ghost.MirageSession.readLoop decode event.ack err=%v", err)
+				s.replyError(fr, err)
+				continue
+			}
+			if !s.deliverAck(ack.EventID, ackResult{ack: ack}) {
+				logs.Debugf("ghost.MirageSession.readLoop stale event.ack event_id=%q", ack.EventID)
+			}
+		case schema.MsgCommand:
+			cmd, err := session.DecodeCommandFrame(fr)
+			if err != nil {
+				logs.Warnf("ghost.MirageSession.readLoop decode command err=%v", err)
+				s.replyError(fr, err)
+				continue
+			}
+			s.dispatchCommand(fr.Header.MessageID, cmd)
+		case schema.MsgPong:
+			s.handlePong(fr)
+		case schema.MsgError:
+			env, err := session.DecodeErrorFrame(fr)
+			if err != nil {
+				logs.Warnf("ghost.MirageSession.readLoop decode error envelope err=%v", err)
+				continue
+			}
+			logs.Warnf(
+				"ghost.MirageSession.readLoop remote error code=%d class=%s message_id=%d message_type=%d msg=%q",
+				env.Code,
+				env.Class,
+				env.MessageID,
+				env.MessageType,
+				env.Message,
+			)
+			if !s.deliverAckError(env) {
+				logs.Debugf("ghost.MirageSession.readLoop unmatched error envelope message_id=%d", env.MessageID)
+			}
+		default:
+			if s.schema.Extension(fr.Header.MessageType) {
+				s.dispatchExtension(fr)
+				continue
+			}
+			logs.Warnf("ghost.MirageSession.readLoop unexpected message_type=%d", fr.Header.MessageType)
+			s.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeSemanticValidationFailure,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				fmt.Sprintf("unexpected message_type=%d", fr.Header.MessageType),
+			))
+		}
+	}
+}
+
+// Ghost error reply for one inbound frame that failed decode or validation.
+func (s *MirageSession) replyError(fr frame.Frame, err error) {
+	s.sendError(session.NewErrorEnvelope(
+		session.ErrorCodeFor(err),
+		fr.Header.MessageID,
+		fr.Header.MessageType,
+		err.Error(),
+	))
+}
+
+// Ghost best-effort error envelope write; failures are logged only.
+func (s *MirageSession) sendError(env session.ErrorEnvelope) {
+	msg, err := session.NewErrorFrame(env.MessageID, env)
+	if err != nil {
+		logs.Warnf("ghost.MirageSession encode error envelope err=%v", err)
+		return
+	}
+	if err := s.writeFrame(s.ctx, msg); err != nil {
+		logs.Debugf("ghost.MirageSession write error envelope err=%v", err)
+	}
+}
+
+// Ghost wire error code for a failed session command.
+func commandErrorCode(err error) uint32 {
+	switch {
+	case errors.Is(err, ErrInvalidCommandEnv),
+		errors.Is(err, ErrCommandTargetMismatch),
+		errors.Is(err, ErrDuplicateCommandID),
+		errors.Is(err, ErrDuplicateMessageID):
+		return session.ErrorCodeSemanticValidationFailure
+	default:
+		return session.ErrorCodeRuntimeExecutionFailure
+	}
+}
+
+// Ghost async command dispatch: execute through handler and deliver the terminal event.
+func (s *MirageSession) dispatchCommand(messageID uint64, cmd session.Command) {
+	if s.handler == nil {
+		logs.Warnf("ghost.MirageSession command ignored reason=no_handler command_id=%q", cmd.CommandID)
+		return
+	}
+	key := strings.TrimSpace(cmd.CommandID)
+	s.commandsMu.Lock()
+	if _, inFlight := s.commands[key]; inFlight {
+		s.commandsMu.Unlock()
+		// The original's terminal event still answers the command; tell Mirage this push was a duplicate.
+		logs.Warnf("ghost.MirageSession command ignored reason=in_flight command_id=%q", key)
+		s.sendError(session.NewErrorEnvelope(
+			session.ErrorCodeCommandInFlight,
+			messageID,
+			schema.MsgCommand,
+			fmt.Sprintf("command already in flight command_id=%q", key),
+		))
+		return
+	}
+	s.commands[key] = struct{}{}
+	s.commandsMu.Unlock()
+
+	env := CommandEnv{
+		MessageID:    messageID,
+		CommandID:    key,
+		IntentID:     strings.TrimSpace(cmd.IntentID),
+		GhostID:      strings.TrimSpace(cmd.GhostID),
+		SeedSelector: strings.TrimSpace(cmd.SeedSelector),
+		Operation:    strings.TrimSpace(cmd.Operation),
+		Args:         cloneArgs(cmd.Args),
+	}
+	go func() {
+		defer func() {
+			s.commandsMu.Lock()
+			delete(s.commands, key)
+			s.commandsMu.Unlock()
+		}()
+		event, err := s.handler(s.ctx, env)
+		if err != nil {
+			logs.Warnf("ghost.MirageSession command failed command_id=%q err=%v", key, err)
+			s.sendError(session.NewErrorEnvelope(commandErrorCode(err), messageID, schema.MsgCommand, err.Error()))
+			return
+		}
+		if _, err := s.SendEventWithAck(s.ctx, event); err != nil {
+			logs.Warnf("ghost.MirageSession command event delivery failed command_id=%q err=%v", key, err)
+		}
+	}()
+}
+
+// Ghost replay of outbox events still awaiting event.ack, in queue order.
+// Events Mirage already observed (seq <= the registration ack's LastSeq) are
+// dropped without resending, so only the gap crosses the wire.
+// Stops at the first delivery failure so remaining events stay queued.
+func (s *MirageSession) ReplayPending(ctx context.Context) (int, error) {
+	if skipped := s.outbox.RemoveThrough(s.resumeSeq); len(skipped) > 0 {
+		logs.Infof("ghost.MirageSession.ReplayPending resumed last_seq=%d skipped=%d", s.resumeSeq, len(skipped))
+	}
+	replayed := 0
+	for _, item := range s.outbox.Pending() {
+		if strings.TrimSpace(item.Event.EventID) == "" {
+			logs.Warnf("ghost.MirageSession.ReplayPending drop event_id=%q reason=missing_payload", item.EventID)
+			s.outbox.Remove(item.EventID)
+			continue
+		}
+		_, err := s.SendEventWithAck(ctx, EventEnv{
+			EventID:     item.Event.EventID,
+			CommandID:   item.Event.CommandID,
+			IntentID:    item.Event.IntentID,
+			GhostID:     item.Event.GhostID,
+			SeedID:      item.Event.SeedID,
+			Outcome:     item.Event.Outcome,
+			TimestampMS: item.Event.TimestampMS,
+		})
+		var remote session.ErrorEnvelope
+		if err != nil && !errors.Is(err, ErrAckRejected) && !errors.As(err, &remote) {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
 // Ghost outbox snapshot for pending event retry diagnostics/tests.
 func (s *MirageSession) OutboxSnapshot() []session.PendingEvent {
 	return s.outbox.List()
 }
 
 // Ghost event delivery with retry-until-accepted-ack or timeout.
+// Up to Session.EventWindow events are in flight at once; further calls wait for a slot.
 func (s *MirageSession) SendEventWithAck(ctx context.Context, event EventEnv) (session.EventAck, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.conn == nil {
 		return session.EventAck{}, ErrSessionClosed
 	}
@@ -273,20 +715,47 @@ func (s *MirageSession) SendEventWithAck(ctx context.Context, event EventEnv) (s
 
 	start := time.Now()
 	deadline := start.Add(s.cfg.AckTimeout)
-	s.outbox.Upsert(session.PendingEvent{
+	pending := session.PendingEvent{
 		EventID:       wireEvent.EventID,
 		CommandID:     wireEvent.CommandID,
 		GhostID:       wireEvent.GhostID,
+		Event:         wireEvent,
 		QueuedAt:      start,
 		AckDeadlineAt: deadline,
-	})
+	}
+	if prev, ok := s.outbox.Get(wireEvent.EventID); ok {
+		pending.QueuedAt = prev.QueuedAt
+		pending.Attempts = prev.Attempts
+		pending.LastAttemptAt = prev.LastAttemptAt
+		pending.LastError = prev.LastError
+	}
+	wireEvent.Seq = s.outbox.Upsert(pending).Seq
+
+	if err := s.acquireWindow(ctx, deadline); err != nil {
+		return session.EventAck{}, err
+	}
+	defer func() { <-s.window }()
+	waiter, err := s.addAckWaiter(wireEvent.EventID)
+	if err != nil {
+		return session.EventAck{}, err
+	}
+	defer s.dropAckWaiter(wireEvent.EventID)
 
 	attempt := 0
 	for {
 		attempt++
 		_, _ = s.outbox.MarkAttempt(wireEvent.EventID, time.Now(), "")
-		ack, err := s.sendEventOnce(ctx, wireEvent)
-		if err == nil {
+		ack, err := s.sendEventOnce(ctx, wireEvent, waiter)
+		delay := s.backoffDelay(attempt)
+		switch {
+		case err == nil && ack.AckStatus == session.AckStatusThrottled:
+			// Mirage did not ingest the event; keep it queued and honor the back-off hint.
+			err = fmt.Errorf("%w: retry_after_ms=%d", ErrAckThrottled, ack.RetryAfterMS)
+			if hint := time.Duration(ack.RetryAfterMS) * time.Millisecond; hint > delay {
+				delay = hint
+			}
+			logs.Debugf("ghost.MirageSession.SendEventWithAck throttled event_id=%q retry_in=%s", wireEvent.EventID, delay)
+		case err == nil:
 			s.outbox.Remove(wireEvent.EventID)
 			if ack.AckStatus == session.AckStatusAccepted {
 				return ack, nil
@@ -295,10 +764,15 @@ func (s *MirageSession) SendEventWithAck(ctx context.Context, event EventEnv) (s
 		}
 
 		_, _ = s.outbox.MarkAttempt(wireEvent.EventID, time.Now(), err.Error())
+		var remote session.ErrorEnvelope
+		if errors.As(err, &remote) {
+			// Mirage rejected the payload itself; resending the same bytes cannot succeed.
+			s.sendTombstone(ctx, wireEvent, waiter)
+			return session.EventAck{}, err
+		}
 		if time.Now().After(deadline) {
 			return session.EventAck{}, ErrAckTimeout
 		}
-		delay := session.NextBackoffDelay(s.cfg.Backoff, attempt, s.rng)
 		timer := time.NewTimer(delay)
 		select {
 		case <-ctx.Done():
@@ -309,53 +783,127 @@ func (s *MirageSession) SendEventWithAck(ctx context.Context, event EventEnv) (s
 	}
 }
 
-// Ghost one-shot event send/read for a matching event.ack.
-func (s *MirageSession) sendEventOnce(ctx context.Context, event session.Event) (session.EventAck, error) {
-	payload, err := session.EncodeEventFrame(s.nextMessageID.Add(1), event)
-	if err != nil {
-		return session.EventAck{}, err
+// Ghost replacement for an event Mirage rejected outright: the same event_id and seq
+// with an error outcome, so the command still terminates and Mirage's seq watermark
+// does not stall on the gap. The tombstone stays in the outbox for replay until Mirage
+// acks it; a rejected tombstone is dropped with a warning.
+func (s *MirageSession) sendTombstone(ctx context.Context, event session.Event, waiter <-chan ackResult) {
+	tomb := event
+	tomb.Outcome = OutcomeError
+	tomb.TimestampMS = uint64(time.Now().UnixMilli())
+	pending, ok := s.outbox.Get(event.EventID)
+	if !ok {
+		return
+	}
+	pending.Event = tomb
+	s.outbox.Upsert(pending)
+
+	ack, err := s.sendEventOnce(ctx, tomb, waiter)
+	var remote session.ErrorEnvelope
+	switch {
+	case err == nil && ack.AckStatus != session.AckStatusThrottled:
+		s.outbox.Remove(event.EventID)
+		logs.Warnf("ghost.MirageSession.sendTombstone event_id=%q seq=%d replaced rejected event", event.EventID, event.Seq)
+	case errors.As(err, &remote):
+		s.outbox.Remove(event.EventID)
+		logs.Warnf("ghost.MirageSession.sendTombstone event_id=%q seq=%d dropped err=%v", event.EventID, event.Seq, err)
+	default:
+		logs.Warnf("ghost.MirageSession.sendTombstone event_id=%q seq=%d kept for replay err=%v", event.EventID, event.Seq, err)
 	}
+}
 
-	if err := s.setWriteDeadline(ctx); err != nil {
-		return session.EventAck{}, err
+// Ghost best-effort progress send; progress is not acknowledged, queued, or replayed.
+// It returns ErrProgressUnsupported without writing when Mirage did not negotiate progress.
+func (s *MirageSession) SendProgress(ctx context.Context, p ProgressEnv) error {
+	if !s.Supports(session.CapabilityProgress) {
+		return ErrProgressUnsupported
+	}
+	msg, err := session.NewProgressFrame(s.nextMessageID.Add(1), session.Progress{
+		ExecutionID: p.ExecutionID,
+		CommandID:   p.CommandID,
+		IntentID:    p.IntentID,
+		GhostID:     p.GhostID,
+		Seq:         p.Seq,
+		Stream:      p.Stream,
+		Chunk:       p.Chunk,
+		Percent:     p.Percent,
+		Message:     p.Message,
+		TimestampMS: p.TimestampMS,
+	})
+	if err != nil {
+		return err
 	}
-	if _, err := s.conn.Write(payload); err != nil {
+	return s.writeFrame(ctx, msg)
+}
+
+// Ghost one-shot event send and wait for the matching event.ack or error envelope.
+// A late ack for an earlier attempt of the same event_id also completes the wait.
+func (s *MirageSession) sendEventOnce(ctx context.Context, event session.Event, waiter <-chan ackResult) (session.EventAck, error) {
+	messageID := s.nextMessageID.Add(1)
+	msg, err := session.NewEventFrame(messageID, event)
+	if err != nil {
 		return session.EventAck{}, err
 	}
+	s.trackAckMessage(messageID, event.EventID)
 
-	if err := s.setReadDeadline(ctx); err != nil {
+	if err := s.writeFrame(ctx, msg); err != nil {
 		return session.EventAck{}, err
 	}
-	fr, err := session.ReadFrame(s.reader, frame.DefaultLimits())
-	if err != nil {
-		return session.EventAck{}, err
+
+	timer := time.NewTimer(s.readWait(ctx))
+	defer timer.Stop()
+	select {
+	case res := <-waiter:
+		return res.ack, res.err
+	case <-s.done:
+		return session.EventAck{}, ErrSessionClosed
+	case <-ctx.Done():
+		return session.EventAck{}, ctx.Err()
+	case <-timer.C:
+		return session.EventAck{}, fmt.Errorf("ghost: event.ack read timeout event_id=%q", event.EventID)
 	}
-	ack, err := session.DecodeEventAckFrame(fr)
-	if err != nil {
-		return session.EventAck{}, err
+}
+
+// Ghost serialized frame writer shared by event delivery and command responses.
+// msg is one built message; writeLoop compresses, versions, and signs it as
+// negotiated while building the outbound frames.
+func (s *MirageSession) writeFrame(ctx context.Context, msg frame.Frame) error {
+	out := outboundFrame{
+		msg:      msg,
+		deadline: s.writeDeadline(ctx),
+		result:   make(chan error, 1),
 	}
-	if ack.EventID != event.EventID {
-		return session.EventAck{}, fmt.Errorf("ghost: ack/event mismatch event_id=%q ack_event_id=%q", event.EventID, ack.EventID)
+	select {
+	case s.writes <- out:
+	case <-s.done:
+		return ErrSessionClosed
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return ack, nil
+	return <-out.result
 }
 
 // Ghost write-deadline helper using stricter timeout vs context deadline.
-func (s *MirageSession) setWriteDeadline(ctx context.Context) error {
+func (s *MirageSession) writeDeadline(ctx context.Context) time.Time {
 	deadline := time.Now().Add(s.cfg.WriteTimeout)
 	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
 		deadline = ctxDeadline
 	}
-	return s.conn.SetWriteDeadline(deadline)
+	return deadline
 }
 
-// Ghost read-deadline helper using stricter timeout vs context deadline.
-func (s *MirageSession) setReadDeadline(ctx context.Context) error {
-	deadline := time.Now().Add(s.cfg.ReadTimeout)
-	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
-		deadline = ctxDeadline
+// Ghost ack-wait helper using stricter read timeout vs context deadline.
+func (s *MirageSession) readWait(ctx context.Context) time.Duration {
+	wait := s.cfg.ReadTimeout
+	if ctxDeadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(ctxDeadline); remaining < wait {
+			wait = remaining
+		}
+	}
+	if wait < 0 {
+		wait = 0
 	}
-	return s.conn.SetReadDeadline(deadline)
+	return wait
 }
 
 // Ghost seed metadata mapper for seed.register handshake descriptors.
@@ -4,7 +4,11 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -178,6 +182,617 @@ func TestMirageSessionSendEventWithAckDisconnect(t *testing.T) {
 	}
 }
 
+func TestMirageSessionExecutesPushedCommand(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cmd := session.Command{
+		CommandID:    "cmd.push.1",
+		IntentID:     "intent.push.1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+	}
+	events := make(chan session.Event, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- servePushCommandEndpoint(ln, 42, cmd, events)
+	}()
+
+	received := make(chan CommandEnv, 1)
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            session.DefaultConfig(),
+		MaxConnectAttempts: 1,
+		CommandHandler: func(_ context.Context, env CommandEnv) (EventEnv, error) {
+			received <- env
+			return EventEnv{
+				EventID:     eventIDForCommand(env.CommandID),
+				CommandID:   env.CommandID,
+				IntentID:    env.IntentID,
+				GhostID:     env.GhostID,
+				SeedID:      env.SeedSelector,
+				Outcome:     OutcomeSuccess,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		_ = ln.Close()
+		_ = <-done
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	select {
+	case env := <-received:
+		if env.MessageID != 42 || env.CommandID != cmd.CommandID || env.Operation != cmd.Operation {
+			t.Fatalf("unexpected command env: %+v", env)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for pushed command")
+	}
+	select {
+	case event := <-events:
+		if event.CommandID != cmd.CommandID || event.EventID != "evt.cmd.push.1" {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for command event")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("push endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionRepliesInFlightToDuplicateCommand(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cmd := session.Command{
+		CommandID:    "cmd.dup.1",
+		IntentID:     "intent.dup.1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+	}
+	release := make(chan struct{})
+	replies := make(chan frame.Frame, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveDuplicateCommandEndpoint(ln, cmd, release, replies)
+	}()
+
+	var runs atomic.Int32
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            session.DefaultConfig(),
+		MaxConnectAttempts: 1,
+		CommandHandler: func(_ context.Context, env CommandEnv) (EventEnv, error) {
+			runs.Add(1)
+			<-release
+			return EventEnv{
+				EventID:     eventIDForCommand(env.CommandID),
+				CommandID:   env.CommandID,
+				IntentID:    env.IntentID,
+				GhostID:     env.GhostID,
+				SeedID:      env.SeedSelector,
+				Outcome:     OutcomeSuccess,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	dup := <-replies
+	env, err := session.DecodeErrorFrame(dup)
+	if err != nil || env.Code != session.ErrorCodeCommandInFlight || env.MessageID != 2 {
+		t.Fatalf("expected in-flight error for duplicate push, env=%+v err=%v", env, err)
+	}
+	event, err := session.DecodeEventFrame(<-replies)
+	if err != nil || event.CommandID != cmd.CommandID {
+		t.Fatalf("expected original terminal event, event=%+v err=%v", event, err)
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("duplicate push executed: runs=%d", got)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("duplicate endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionReassemblesFragmentedCommand(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// Args larger than one frame payload force the command onto several fragments.
+	blob := strings.Repeat("x", int(frame.DefaultLimits().MaxPayloadBytes)+1024)
+	cmd := session.Command{
+		CommandID:    "cmd.large.1",
+		IntentID:     "intent.large.1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.fs",
+		Operation:    "write",
+		Args:         map[string]string{"content": blob},
+	}
+	events := make(chan session.Event, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- servePushCommandEndpoint(ln, 77, cmd, events)
+	}()
+
+	received := make(chan CommandEnv, 1)
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.fs", Name: "FS", Description: "Filesystem seed"}},
+		Session:            session.DefaultConfig(),
+		MaxConnectAttempts: 1,
+		CommandHandler: func(_ context.Context, env CommandEnv) (EventEnv, error) {
+			received <- env
+			return EventEnv{
+				EventID:     eventIDForCommand(env.CommandID),
+				CommandID:   env.CommandID,
+				IntentID:    env.IntentID,
+				GhostID:     env.GhostID,
+				SeedID:      env.SeedSelector,
+				Outcome:     OutcomeSuccess,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		_ = ln.Close()
+		_ = <-done
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	select {
+	case env := <-received:
+		if env.MessageID != 77 || len(env.Args["content"]) != len(blob) {
+			t.Fatalf("unexpected reassembled command: message_id=%d content_len=%d", env.MessageID, len(env.Args["content"]))
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for fragmented command")
+	}
+	select {
+	case event := <-events:
+		if event.CommandID != cmd.CommandID {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for command event")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("push endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionReplaysDurableOutboxAfterRestart(t *testing.T) {
+	testlog.Start(t)
+
+	outboxPath := filepath.Join(t.TempDir(), "local", "outbox", "events.log")
+	outbox, err := session.OpenEventOutbox(outboxPath)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+
+	noAck, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	noAckDone := make(chan error, 1)
+	go func() {
+		noAckDone <- serveNoAckEndpoint(noAck)
+	}()
+
+	cfg := session.DefaultConfig()
+	cfg.ReadTimeout = 40 * time.Millisecond
+	cfg.AckTimeout = 120 * time.Millisecond
+	cfg.Backoff.InitialDelay = 10 * time.Millisecond
+	cfg.Backoff.MaxDelay = 20 * time.Millisecond
+	cfg.Backoff.Jitter = false
+	clientCfg := MirageClientConfig{
+		Address:            noAck.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            cfg,
+		MaxConnectAttempts: 1,
+		Outbox:             outbox,
+	}
+	client, err := NewMirageClient(clientCfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	for _, id := range []string{"cmd.replay.2", "cmd.replay.1"} {
+		_, err := gs.SendEventWithAck(ctx, EventEnv{
+			EventID:     eventIDForCommand(id),
+			CommandID:   id,
+			IntentID:    "intent.replay",
+			GhostID:     "ghost.alpha",
+			SeedID:      "seed.flow",
+			Outcome:     OutcomeSuccess,
+			TimestampMS: uint64(time.Now().UnixMilli()),
+		})
+		if !errors.Is(err, ErrAckTimeout) {
+			t.Fatalf("expected ErrAckTimeout, got %v", err)
+		}
+	}
+	_ = gs.Close()
+	_ = <-noAckDone
+	if err := outbox.Close(); err != nil {
+		t.Fatalf("close outbox: %v", err)
+	}
+
+	// Restart: reopen the journal and reconnect to an acking Mirage.
+	reopened, err := session.OpenEventOutbox(outboxPath)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 2 {
+		t.Fatalf("expected 2 pending events after restart, got %d", reopened.Len())
+	}
+
+	acking, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	events := make(chan session.Event, 4)
+	ackDone := make(chan error, 1)
+	go func() {
+		ackDone <- serveAckingEndpoint(acking, events)
+	}()
+	clientCfg.Address = acking.Addr().String()
+	clientCfg.Outbox = reopened
+	clientCfg.Session = session.DefaultConfig()
+	client, err = NewMirageClient(clientCfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	gs, err = client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("reconnect and register: %v", err)
+	}
+	replayed, err := gs.ReplayPending(ctx)
+	if err != nil {
+		t.Fatalf("replay pending: %v", err)
+	}
+	if replayed != 2 {
+		t.Fatalf("expected 2 replayed events, got %d", replayed)
+	}
+	first, second := <-events, <-events
+	if first.CommandID != "cmd.replay.2" || second.CommandID != "cmd.replay.1" {
+		t.Fatalf("expected queue-order replay, got %q then %q", first.CommandID, second.CommandID)
+	}
+	if reopened.Len() != 0 {
+		t.Fatalf("expected empty outbox after replay, got %d", reopened.Len())
+	}
+	_ = gs.Close()
+	if err := <-ackDone; err != nil {
+		t.Fatalf("acking endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionReplaysOnlyUnobservedSequenceGap(t *testing.T) {
+	testlog.Start(t)
+
+	outbox := session.NewEventOutbox()
+	for i := 1; i <= 3; i++ {
+		commandID := fmt.Sprintf("cmd.gap.%d", i)
+		eventID := eventIDForCommand(commandID)
+		outbox.Upsert(session.PendingEvent{
+			EventID:   eventID,
+			CommandID: commandID,
+			GhostID:   "ghost.alpha",
+			Event: session.Event{
+				EventID:   eventID,
+				CommandID: commandID,
+				IntentID:  "intent.gap",
+				GhostID:   "ghost.alpha",
+				SeedID:    "seed.flow",
+				Outcome:   OutcomeSuccess,
+			},
+		})
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	events := make(chan session.Event, 4)
+	done := make(chan error, 1)
+	go func() {
+		// Mirage already observed seq 1 and 2 for this epoch before the reconnect.
+		done <- serveResumingEndpoint(ln, 2, events)
+	}()
+
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            session.DefaultConfig(),
+		MaxConnectAttempts: 1,
+		Outbox:             outbox,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	replayed, err := gs.ReplayPending(ctx)
+	if err != nil {
+		t.Fatalf("replay pending: %v", err)
+	}
+	if replayed != 1 {
+		t.Fatalf("expected only the unobserved gap replayed, got %d", replayed)
+	}
+	if got := <-events; got.CommandID != "cmd.gap.3" || got.Seq != 3 {
+		t.Fatalf("expected cmd.gap.3 at seq 3, got %q seq=%d", got.CommandID, got.Seq)
+	}
+	if outbox.Len() != 0 {
+		t.Fatalf("expected empty outbox after resume, got %d", outbox.Len())
+	}
+	_ = gs.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("resuming endpoint exit err: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no resend of observed events, got %d", len(events))
+	}
+}
+
+func TestMirageSessionResendsThrottledEventAfterHint(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	const retryAfter = 150 * time.Millisecond
+	attempts := make(chan time.Time, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveThrottlingEndpoint(ln, retryAfter, attempts)
+	}()
+
+	cfg := session.DefaultConfig()
+	cfg.Backoff.InitialDelay = 10 * time.Millisecond
+	cfg.Backoff.MaxDelay = 20 * time.Millisecond
+	cfg.Backoff.Jitter = false
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            cfg,
+		MaxConnectAttempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+
+	ack, err := gs.SendEventWithAck(ctx, EventEnv{
+		EventID:     "evt.throttle.1",
+		CommandID:   "cmd.throttle.1",
+		IntentID:    "intent.throttle",
+		GhostID:     "ghost.alpha",
+		SeedID:      "seed.flow",
+		Outcome:     OutcomeSuccess,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	})
+	if err != nil || ack.AckStatus != session.AckStatusAccepted {
+		t.Fatalf("expected accepted ack after throttle, got ack=%+v err=%v", ack, err)
+	}
+	first, second := <-attempts, <-attempts
+	if gap := second.Sub(first); gap < retryAfter {
+		t.Fatalf("resent after %s, before retry hint %s", gap, retryAfter)
+	}
+	if len(gs.OutboxSnapshot()) != 0 {
+		t.Fatalf("expected outbox drained after accepted ack")
+	}
+	_ = gs.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("throttling endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionTombstonesEventRejectedByMirage(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	received := make(chan session.Event, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveRejectFirstEventEndpoint(ln, received)
+	}()
+
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"}},
+		Session:            session.DefaultConfig(),
+		MaxConnectAttempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+
+	_, err = gs.SendEventWithAck(ctx, EventEnv{
+		EventID:     "evt.reject.1",
+		CommandID:   "cmd.reject.1",
+		IntentID:    "intent.reject",
+		GhostID:     "ghost.alpha",
+		SeedID:      "seed.flow",
+		Outcome:     OutcomeSuccess,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	})
+	var remote session.ErrorEnvelope
+	if !errors.As(err, &remote) {
+		t.Fatalf("expected rejection surfaced to caller, got %v", err)
+	}
+	original, tomb := <-received, <-received
+	if tomb.EventID != original.EventID || tomb.Seq != original.Seq || tomb.Seq == 0 {
+		t.Fatalf("tombstone must keep event_id and seq: original=%+v tombstone=%+v", original, tomb)
+	}
+	if tomb.Outcome != OutcomeError {
+		t.Fatalf("expected tombstone error outcome, got %q", tomb.Outcome)
+	}
+	if len(gs.OutboxSnapshot()) != 0 {
+		t.Fatalf("expected outbox drained after tombstone ack")
+	}
+	_ = gs.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("rejecting endpoint exit err: %v", err)
+	}
+}
+
+func TestMirageSessionPipelinesEventsWithinWindow(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	const window = 3
+	batches := make(chan int, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveWindowedAckEndpoint(ln, window, batches)
+	}()
+
+	cfg := session.DefaultConfig()
+	cfg.ConnectTimeout = 500 * time.Millisecond
+	cfg.HandshakeTimeout = 500 * time.Millisecond
+	cfg.ReadTimeout = time.Second
+	cfg.AckTimeout = 2 * time.Second
+	cfg.EventWindow = window
+
+	client, err := NewMirageClient(MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		Session:            cfg,
+		MaxConnectAttempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	gs, err := client.ConnectAndRegister(ctx)
+	if err != nil {
+		_ = ln.Close()
+		_ = <-done
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	const total = 2 * window
+	errs := make(chan error, total)
+	for i := 0; i < total; i++ {
+		go func(i int) {
+			ack, err := gs.SendEventWithAck(ctx, EventEnv{
+				EventID:   fmt.Sprintf("evt.window.%d", i),
+				CommandID: fmt.Sprintf("cmd.window.%d", i),
+				IntentID:  "intent.window",
+				GhostID:   "ghost.alpha",
+				SeedID:    "seed.flow",
+				Outcome:   OutcomeSuccess,
+			})
+			if err == nil && ack.EventID != fmt.Sprintf("evt.window.%d", i) {
+				err = fmt.Errorf("ack routed to wrong event: %+v", ack)
+			}
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < total; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("send event: %v", err)
+		}
+	}
+	// The endpoint holds acks until a full window is outstanding, so each batch proves pipelining.
+	for i := 0; i < total/window; i++ {
+		if got := <-batches; got != window {
+			t.Fatalf("batch %d: expected %d events in flight, got %d", i, window, got)
+		}
+	}
+
+	_ = gs.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("windowed endpoint exit err: %v", err)
+	}
+}
+
 func serveNoAckEndpoint(ln net.Listener) error {
 	defer ln.Close()
 
@@ -196,11 +811,12 @@ func serveNoAckEndpoint(ln net.Listener) error {
 		return err
 	}
 	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
-		Status:      session.AckStatusAccepted,
-		Code:        0,
-		Message:     "registered",
-		GhostID:     reg.GhostID,
-		TimestampMS: uint64(time.Now().UnixMilli()),
+		Status:          session.AckStatusAccepted,
+		Code:            0,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
 	}); err != nil {
 		return err
 	}
@@ -240,11 +856,12 @@ func serveDisconnectAfterFirstEvent(ln net.Listener) error {
 		return err
 	}
 	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
-		Status:      session.AckStatusAccepted,
-		Code:        0,
-		Message:     "registered",
-		GhostID:     reg.GhostID,
-		TimestampMS: uint64(time.Now().UnixMilli()),
+		Status:          session.AckStatusAccepted,
+		Code:            0,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
 	}); err != nil {
 		return err
 	}
@@ -257,3 +874,394 @@ func serveDisconnectAfterFirstEvent(ln net.Listener) error {
 	}
 	return conn.Close()
 }
+
+// Pushes cmd twice (message ids 1 and 2), reports the in-flight reply, then releases the
+// handler and acks the original's terminal event.
+func serveDuplicateCommandEndpoint(ln net.Listener, cmd session.Command, release chan<- struct{}, replies chan<- frame.Frame) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+	for _, messageID := range []uint64{1, 2} {
+		payload, err := session.EncodeCommandFrame(messageID, cmd)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(payload); err != nil {
+			return err
+		}
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		return err
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		return err
+	}
+	replies <- fr
+	close(release)
+	fr, err = session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		return err
+	}
+	replies <- fr
+	event, err := session.DecodeEventFrame(fr)
+	if err != nil {
+		return err
+	}
+	ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, session.EventAck{
+		EventID:     event.EventID,
+		CommandID:   event.CommandID,
+		GhostID:     event.GhostID,
+		AckStatus:   session.AckStatusAccepted,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(ackPayload)
+	return err
+}
+
+func servePushCommandEndpoint(ln net.Listener, messageID uint64, cmd session.Command, events chan<- session.Event) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Code:            0,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+
+	payload, err := session.EncodeCommandFrame(messageID, cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		return err
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		return err
+	}
+	event, err := session.DecodeEventFrame(fr)
+	if err != nil {
+		return err
+	}
+	ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, session.EventAck{
+		EventID:     event.EventID,
+		CommandID:   event.CommandID,
+		GhostID:     event.GhostID,
+		AckStatus:   session.AckStatusAccepted,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(ackPayload); err != nil {
+		return err
+	}
+	events <- event
+	return nil
+}
+
+func serveAckingEndpoint(ln net.Listener, events chan<- session.Event) error {
+	return serveResumingEndpoint(ln, 0, events)
+}
+
+// Acking endpoint whose registration ack resumes the Ghost epoch at lastSeq.
+func serveResumingEndpoint(ln net.Listener, lastSeq uint64, events chan<- session.Event) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Code:            0,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+		SessionEpoch:    reg.SessionEpoch,
+		LastSeq:         lastSeq,
+	}); err != nil {
+		return err
+	}
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			return err
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			return nil
+		}
+		event, err := session.DecodeEventFrame(fr)
+		if err != nil {
+			return err
+		}
+		ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, session.EventAck{
+			EventID:     event.EventID,
+			CommandID:   event.CommandID,
+			GhostID:     event.GhostID,
+			AckStatus:   session.AckStatusAccepted,
+			TimestampMS: uint64(time.Now().UnixMilli()),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(ackPayload); err != nil {
+			return err
+		}
+		events <- event
+	}
+}
+
+// Throttles the first delivery of each event with retryAfter, then accepts; reports each arrival.
+func serveThrottlingEndpoint(ln net.Listener, retryAfter time.Duration, attempts chan<- time.Time) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+
+	throttled := make(map[string]bool)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			return err
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			return nil
+		}
+		event, err := session.DecodeEventFrame(fr)
+		if err != nil {
+			return err
+		}
+		attempts <- time.Now()
+		ack := session.EventAck{
+			EventID:     event.EventID,
+			CommandID:   event.CommandID,
+			GhostID:     event.GhostID,
+			AckStatus:   session.AckStatusAccepted,
+			TimestampMS: uint64(time.Now().UnixMilli()),
+		}
+		if !throttled[event.EventID] {
+			throttled[event.EventID] = true
+			ack.AckStatus = session.AckStatusThrottled
+			ack.AckCode = session.ErrorCodeTransportThrottled
+			ack.RetryAfterMS = uint64(retryAfter.Milliseconds())
+		}
+		ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, ack)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(ackPayload); err != nil {
+			return err
+		}
+	}
+}
+
+// Answers the first event with an error envelope and acks every later one.
+func serveRejectFirstEventEndpoint(ln net.Listener, received chan<- session.Event) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+
+	rejected := false
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			return err
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			return nil
+		}
+		event, err := session.DecodeEventFrame(fr)
+		if err != nil {
+			return err
+		}
+		received <- event
+		var payload []byte
+		if !rejected {
+			rejected = true
+			payload, err = session.EncodeErrorFrame(fr.Header.MessageID, session.NewErrorEnvelope(
+				session.ErrorCodeSemanticValidationFailure,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				"rejected event payload",
+			))
+		} else {
+			payload, err = session.EncodeEventAckFrame(fr.Header.MessageID, session.EventAck{
+				EventID:     event.EventID,
+				CommandID:   event.CommandID,
+				GhostID:     event.GhostID,
+				AckStatus:   session.AckStatusAccepted,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(payload); err != nil {
+			return err
+		}
+	}
+}
+
+// Acks events in reverse order once window of them are outstanding; reports each batch size.
+// A frame arriving while the window is full is counted into the batch, failing the caller.
+func serveWindowedAckEndpoint(ln net.Listener, window int, batches chan<- int) error {
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+
+	type held struct {
+		messageID uint64
+		event     session.Event
+	}
+	var pending []held
+	for {
+		wait := 2 * time.Second
+		if len(pending) >= window {
+			wait = 100 * time.Millisecond
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+			return err
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		var netErr net.Error
+		if err != nil && !(errors.As(err, &netErr) && netErr.Timeout() && len(pending) >= window) {
+			return nil
+		}
+		if err == nil {
+			event, err := session.DecodeEventFrame(fr)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, held{messageID: fr.Header.MessageID, event: event})
+			if len(pending) <= window {
+				// Once the window is full, the next short read proves no further event is sent.
+				continue
+			}
+		}
+		batches <- len(pending)
+		for i := len(pending) - 1; i >= 0; i-- {
+			ackPayload, err := session.EncodeEventAckFrame(pending[i].messageID, session.EventAck{
+				EventID:     pending[i].event.EventID,
+				CommandID:   pending[i].event.CommandID,
+				GhostID:     pending[i].event.GhostID,
+				AckStatus:   session.AckStatusAccepted,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := conn.Write(ackPayload); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+	}
+}
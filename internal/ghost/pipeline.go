@@ -1,51 +1,152 @@
 package ghost
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/danmuck/edgectl/internal/seeds"
+	"github.com/danmuck/edgectl/internal/tools"
 	logs "github.com/danmuck/smplog"
 )
 
 const unknownSeedExitCode int32 = 127
 
-// Ghost full command pipeline: boundary accept -> seed.execute -> seed.result -> event.
+// Ghost terminal result of one scheduled execution.
+type ExecutionResult struct {
+	State ExecutionState
+	Event EventEnv
+	Err   error
+
+	// Closed once an abandoned legacy seed call returns; nil when nothing outlived the execution.
+	background <-chan struct{}
+}
+
+// Ghost full command pipeline: boundary accept -> queued -> running -> seed.execute -> seed.result -> event.
+// Blocks the caller until the scheduler has run the command to completion.
 func (s *Server) HandleCommandAndExecute(cmd CommandEnv) (EventEnv, error) {
 	logs.Debugf(
 		"ghost.Server.HandleCommandAndExecute message_id=%d command_id=%q",
 		cmd.MessageID,
 		cmd.CommandID,
 	)
-	state, err := s.HandleCommand(cmd)
+	_, done, err := s.SubmitCommand(cmd)
 	if err != nil {
 		return EventEnv{}, err
 	}
+	res := <-done
+	return res.Event, res.Err
+}
+
+// Ghost async pipeline entry: records the command and queues it on the scheduler.
+// Returns the queued state immediately; done receives the terminal result once.
+// A full queue drops the record so the same command_id can be retried.
+func (s *Server) SubmitCommand(cmd CommandEnv) (ExecutionState, <-chan ExecutionResult, error) {
+	state, err := s.HandleCommand(cmd)
+	if err != nil {
+		return ExecutionState{}, nil, err
+	}
+
+	seedID, idempotent := s.operationTraits(state.SeedSelector, state.Operation)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan ExecutionResult, 1)
+	executionID := state.ExecutionID
+	job := &scheduledJob{
+		seedID:     seedID,
+		operation:  state.Operation,
+		idempotent: idempotent,
+		run: func() {
+			res := s.runExecution(ctx, executionID)
+			s.mu.Lock()
+			delete(s.inflight, executionID)
+			s.retainLocked(executionID)
+			s.mu.Unlock()
+			cancel()
+			done <- res
+			if res.background != nil {
+				// Keep the worker and seed slots held until the abandoned call returns.
+				<-res.background
+			}
+		},
+	}
+	s.mu.Lock()
+	s.inflight[executionID] = inflightExecution{cancel: cancel, job: job}
+	s.mu.Unlock()
+	state = s.setExecutionPhase(executionID, ExecutionQueued)
+	if err := s.scheduler.submit(job); err != nil {
+		cancel()
+		s.dropExecution(state)
+		logs.Warnf("ghost.Server.SubmitCommand rejected command_id=%q err=%v", state.CommandID, err)
+		return ExecutionState{}, nil, err
+	}
+	logs.Debugf(
+		"ghost.Server.SubmitCommand queued command_id=%q seed_id=%q operation=%q idempotent=%v",
+		state.CommandID,
+		seedID,
+		state.Operation,
+		idempotent,
+	)
+	return state, done, nil
+}
+
+// Ghost worker-side pipeline for one queued execution.
+// ctx is cancelled by CancelExecution; the seed additionally runs under its
+// operation's default timeout.
+func (s *Server) runExecution(ctx context.Context, executionID string) ExecutionResult {
+	state := s.setExecutionPhase(executionID, ExecutionRunning)
 
 	seedExec := buildSeedExecute(state)
 	if err := seedExec.Validate(); err != nil {
-		return EventEnv{}, err
+		return ExecutionResult{State: state, Err: err}
 	}
 
-	seedResult := s.executeSeed(seedExec)
+	sink := s.newProgressSink(state)
+	seedResult, background := s.executeSeed(ctx, seedExec, sink)
+	sink.close()
 	if err := seedResult.Validate(); err != nil {
-		return EventEnv{}, err
+		return ExecutionResult{State: state, Err: err, background: background}
 	}
 
 	event := buildEvent(state, seedResult)
 	if err := event.Validate(); err != nil {
-		return EventEnv{}, err
+		return ExecutionResult{State: state, Err: err, background: background}
 	}
 
-	s.completeExecution(state.ExecutionID, seedExec, seedResult, event)
+	state = s.completeExecution(state.ExecutionID, seedExec, seedResult, event)
 	logs.Infof(
-		"ghost.Server.HandleCommandAndExecute complete command_id=%q execution_id=%q outcome=%q",
+		"ghost.Server.runExecution complete command_id=%q execution_id=%q outcome=%q",
 		state.CommandID,
 		state.ExecutionID,
 		event.Outcome,
 	)
-	return event, nil
+	return ExecutionResult{State: state, Event: event, background: background}
+}
+
+// Ghost scheduling traits for one command: resolved seed id and operation idempotency.
+// Unknown seeds and operations count as idempotent; they fail fast in executeSeed.
+func (s *Server) operationTraits(seedSelector string, operation string) (string, bool) {
+	s.mu.RLock()
+	reg := s.registry
+	s.mu.RUnlock()
+	if reg == nil {
+		return seedSelector, true
+	}
+	seed, ok := reg.Resolve(seedSelector)
+	if !ok {
+		return seedSelector, true
+	}
+	seedID := strings.TrimSpace(seed.Metadata().ID)
+	if seedID == "" {
+		seedID = seedSelector
+	}
+	for _, spec := range seed.Operations() {
+		if spec.Name == operation {
+			return seedID, spec.Idempotent
+		}
+	}
+	return seedID, true
 }
 
 // Ghost mapping from accepted command state to seed.execute payload.
@@ -60,18 +161,25 @@ func buildSeedExecute(state ExecutionState) SeedExecuteEnv {
 }
 
 // Ghost seed dispatch helper: resolve target seed and invoke requested operation.
-func (s *Server) executeSeed(exec SeedExecuteEnv) SeedResultEnv {
+// Streaming seeds report output and progress to sink while running; seeds without
+// context support run through the seeds.WithContext adapter. The returned channel is
+// non-nil when a stopped legacy call is still running and closes once it returns.
+func (s *Server) executeSeed(ctx context.Context, exec SeedExecuteEnv, sink seeds.OutputSink) (SeedResultEnv, <-chan struct{}) {
+	if err := ctx.Err(); err != nil {
+		return stoppedSeedResult(exec, SeedResultEnv{}, SeedStatusCancelled), nil
+	}
+
 	s.mu.RLock()
 	reg := s.registry
 	s.mu.RUnlock()
 
 	if reg == nil {
-		return errorSeedResult(exec, "seed registry unavailable", 1)
+		return errorSeedResult(exec, "seed registry unavailable", 1), nil
 	}
 
 	seed, ok := reg.Resolve(exec.SeedID)
 	if !ok {
-		return errorSeedResult(exec, fmt.Sprintf("unknown seed: %s", exec.SeedID), unknownSeedExitCode)
+		return errorSeedResult(exec, fmt.Sprintf("unknown seed: %s", exec.SeedID), unknownSeedExitCode), nil
 	}
 
 	meta := seed.Metadata()
@@ -81,8 +189,52 @@ func (s *Server) executeSeed(exec SeedExecuteEnv) SeedResultEnv {
 	}
 	exec.SeedID = seedID
 
-	result, err := seed.Execute(exec.Operation, cloneArgs(exec.Args))
-	return normalizeSeedResult(exec, result, err)
+	runCtx := ctx
+	if timeout := seeds.OperationTimeout(seed, exec.Operation); timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var result seeds.SeedResult
+	var err error
+	if streaming, ok := seed.(seeds.StreamingSeed); ok && sink != nil {
+		result, err = streaming.ExecuteStream(runCtx, exec.Operation, cloneArgs(exec.Args), sink)
+	} else {
+		result, err = seeds.WithContext(seed).ExecuteContext(runCtx, exec.Operation, cloneArgs(exec.Args))
+	}
+	var background <-chan struct{}
+	var abandoned *seeds.AbandonedError
+	if errors.As(err, &abandoned) {
+		background = abandoned.Done
+		logs.Warnf("ghost.Server.executeSeed legacy seed still running seed_id=%q operation=%q", exec.SeedID, exec.Operation)
+	}
+	out := normalizeSeedResult(exec, result, err)
+	switch {
+	case ctx.Err() != nil:
+		return stoppedSeedResult(exec, out, SeedStatusCancelled), background
+	case runCtx.Err() != nil:
+		return stoppedSeedResult(exec, out, SeedStatusTimeout), background
+	}
+	return out, background
+}
+
+// Ghost seed.result for an execution stopped by cancellation or its deadline.
+// Partial output is kept; the exit code follows the tools runner convention.
+func stoppedSeedResult(exec SeedExecuteEnv, partial SeedResultEnv, status string) SeedResultEnv {
+	exitCode := tools.ExitCodeCancelled
+	reason := "execution cancelled"
+	if status == SeedStatusTimeout {
+		exitCode = tools.ExitCodeTimeout
+		reason = "execution timed out"
+	}
+	return SeedResultEnv{
+		ExecutionID: exec.ExecutionID,
+		SeedID:      exec.SeedID,
+		Status:      status,
+		Stdout:      partial.Stdout,
+		Stderr:      append(cloneBytes(partial.Stderr), []byte(reason+"\n")...),
+		ExitCode:    exitCode,
+	}
 }
 
 // Ghost normalization of seed output/error into canonical seed.result fields.
@@ -135,8 +287,13 @@ func errorSeedResult(exec SeedExecuteEnv, reason string, exitCode int32) SeedRes
 // Ghost event envelope builder from terminal seed result state.
 func buildEvent(state ExecutionState, seedResult SeedResultEnv) EventEnv {
 	outcome := OutcomeError
-	if seedResult.Status == SeedStatusOK && seedResult.ExitCode == 0 {
+	switch {
+	case seedResult.Status == SeedStatusOK && seedResult.ExitCode == 0:
 		outcome = OutcomeSuccess
+	case seedResult.Status == SeedStatusTimeout:
+		outcome = OutcomeTimeout
+	case seedResult.Status == SeedStatusCancelled:
+		outcome = OutcomeCancelled
 	}
 
 	return EventEnv{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/danmuck/edgectl/internal/seeds"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
+	"github.com/danmuck/edgectl/internal/tools"
 )
 
 func TestHandleCommandAndExecuteSuccessEvent(t *testing.T) {
@@ -144,3 +145,69 @@ func newRadiatingServerWithRegistry(t *testing.T, ghostID string, reg *seeds.Reg
 	}
 	return s
 }
+
+func TestHandleCommandAndExecuteOperationTimeoutEmitsTimeoutEvent(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	defer func() { gate.release <- struct{}{} }()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	event, err := s.HandleCommandAndExecute(gateCommand(1, "hang"))
+	if err != nil {
+		t.Fatalf("handle and execute failed: %v", err)
+	}
+	if event.Outcome != OutcomeTimeout {
+		t.Fatalf("unexpected outcome: %q", event.Outcome)
+	}
+	state, _ := s.ExecutionByCommandID("cmd.gate.1")
+	if state.SeedResult.Status != SeedStatusTimeout || state.SeedResult.ExitCode != tools.ExitCodeTimeout {
+		t.Fatalf("unexpected seed result: %+v", state.SeedResult)
+	}
+}
+
+func TestCancelExecutionRunningAndQueued(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	defer func() { gate.release <- struct{}{} }()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	_, runningDone, err := s.SubmitCommand(gateCommand(1, "restart"))
+	if err != nil {
+		t.Fatalf("submit running: %v", err)
+	}
+	waitStarted(t, gate, "restart")
+	queued, queuedDone, err := s.SubmitCommand(gateCommand(2, "restart"))
+	if err != nil {
+		t.Fatalf("submit queued: %v", err)
+	}
+	assertPhaseHeld(t, s, queued.ExecutionID, ExecutionQueued)
+
+	if _, err := s.CancelExecution("cmd.gate.2"); err != nil {
+		t.Fatalf("cancel queued: %v", err)
+	}
+	if res := <-queuedDone; res.Err != nil || res.Event.Outcome != OutcomeCancelled {
+		t.Fatalf("unexpected queued cancel result: %+v", res)
+	}
+	if _, err := s.CancelExecution("cmd.gate.1"); err != nil {
+		t.Fatalf("cancel running: %v", err)
+	}
+	res := <-runningDone
+	if res.Err != nil || res.Event.Outcome != OutcomeCancelled {
+		t.Fatalf("unexpected running cancel result: %+v", res)
+	}
+	if res.State.SeedResult.ExitCode != tools.ExitCodeCancelled {
+		t.Fatalf("unexpected cancel exit code: %d", res.State.SeedResult.ExitCode)
+	}
+	select {
+	case op := <-gate.started:
+		t.Fatalf("cancelled queued execution should not start, started %q", op)
+	default:
+	}
+
+	if _, err := s.CancelExecution("cmd.gate.1"); !errors.Is(err, ErrExecutionFinished) {
+		t.Fatalf("expected ErrExecutionFinished, got %v", err)
+	}
+	if _, err := s.CancelExecution("cmd.gate.missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Fatalf("expected ErrExecutionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package ghost
+
+import (
+	"sync"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/seeds"
+	logs "github.com/danmuck/smplog"
+)
+
+// Upper bound for one progress chunk; larger seed writes are split.
+// Matches the progress.chunk max_len in the TLV contract.
+const maxProgressChunk = 32 * 1024
+
+// Progress records buffered for the Mirage sender; further records are dropped while full.
+const progressQueueDepth = 256
+
+// Ghost receiver for non-terminal progress records.
+// Called synchronously from the executing worker in seq order per execution;
+// handlers should hand off slow work instead of blocking the seed.
+type ProgressHandler func(ProgressEnv)
+
+// Ghost registration of the progress receiver; nil stops forwarding.
+// Progress is still tracked on ExecutionState without a handler.
+func (s *Server) SetProgressHandler(handler ProgressHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progressHandler = handler
+}
+
+// Ghost seeds.OutputSink for one running execution.
+// Assigns progress seq numbers and stops emitting once the execution has returned,
+// so no progress record follows the terminal event.
+type progressSink struct {
+	server *Server
+	state  ExecutionState
+
+	mu      sync.Mutex
+	seq     uint64
+	percent uint32
+	closed  bool
+}
+
+// Ghost progress sink constructor bound to one execution's identity fields.
+func (s *Server) newProgressSink(state ExecutionState) *progressSink {
+	return &progressSink{server: s, state: state}
+}
+
+// Ghost output forwarding: chunk is copied and split to the contract chunk limit.
+func (p *progressSink) Output(stream string, chunk []byte) {
+	switch stream {
+	case seeds.StreamStdout, seeds.StreamStderr:
+	default:
+		logs.Warnf("ghost.progressSink.Output dropped unknown stream=%q execution_id=%q", stream, p.state.ExecutionID)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(chunk) > 0 {
+		n := min(len(chunk), maxProgressChunk)
+		p.emitLocked(stream, cloneBytes(chunk[:n]), "")
+		chunk = chunk[n:]
+	}
+}
+
+// Ghost percent update clamped to 0-100.
+func (p *progressSink) Progress(percent int, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.percent = uint32(max(0, min(percent, 100)))
+	p.emitLocked("", nil, message)
+}
+
+// Ghost sink shutdown after the seed returns; later writes are dropped.
+func (p *progressSink) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+}
+
+// Ghost progress record emission. Caller must hold mu.
+func (p *progressSink) emitLocked(stream string, chunk []byte, message string) {
+	if p.closed {
+		return
+	}
+	p.seq++
+	env := ProgressEnv{
+		ExecutionID: p.state.ExecutionID,
+		CommandID:   p.state.CommandID,
+		IntentID:    p.state.IntentID,
+		GhostID:     p.state.GhostID,
+		Seq:         p.seq,
+		Stream:      stream,
+		Chunk:       chunk,
+		Percent:     p.percent,
+		Message:     truncateProgressMessage(message),
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	}
+	if err := env.Validate(); err != nil {
+		logs.Warnf("ghost.progressSink.emit invalid execution_id=%q err=%v", p.state.ExecutionID, err)
+		return
+	}
+	p.server.setExecutionProgress(env.ExecutionID, env.Seq, env.Percent)
+
+	p.server.mu.RLock()
+	handler := p.server.progressHandler
+	p.server.mu.RUnlock()
+	if handler != nil {
+		handler(env)
+	}
+}
+
+// Ghost progress message bound matching the progress.progress_message contract limit.
+func truncateProgressMessage(message string) string {
+	const maxLen = 1024
+	if len(message) <= maxLen {
+		return message
+	}
+	return message[:maxLen]
+}
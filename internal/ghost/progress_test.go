@@ -0,0 +1,98 @@
+package ghost
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/seeds"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+// streamSeed reports a large stdout chunk and percent updates while it runs.
+type streamSeed struct {
+	chunk []byte
+}
+
+func (s streamSeed) Metadata() seeds.SeedMetadata {
+	return seeds.SeedMetadata{ID: "seed.stream", Name: "stream", Description: "streaming test seed"}
+}
+
+func (s streamSeed) Operations() []seeds.OperationSpec {
+	return []seeds.OperationSpec{{Name: "copy", Description: "stream a large copy", Idempotent: true}}
+}
+
+func (s streamSeed) Execute(action string, args map[string]string) (seeds.SeedResult, error) {
+	return s.ExecuteContext(context.Background(), action, args)
+}
+
+func (s streamSeed) ExecuteContext(ctx context.Context, action string, args map[string]string) (seeds.SeedResult, error) {
+	return s.ExecuteStream(ctx, action, args, nil)
+}
+
+func (s streamSeed) ExecuteStream(_ context.Context, _ string, _ map[string]string, sink seeds.OutputSink) (seeds.SeedResult, error) {
+	seeds.ReportProgress(sink, 0, "starting copy")
+	_, _ = seeds.SinkWriter(sink, seeds.StreamStdout).Write(s.chunk)
+	seeds.ReportProgress(sink, 150, "")
+	return seeds.SeedResult{Status: "ok", Stdout: s.chunk}, nil
+}
+
+func TestHandleCommandAndExecuteStreamsProgressInSeqOrder(t *testing.T) {
+	testlog.Start(t)
+	reg := seeds.NewRegistry()
+	chunk := bytes.Repeat([]byte("x"), maxProgressChunk+10)
+	if err := reg.Register(streamSeed{chunk: chunk}); err != nil {
+		t.Fatalf("register stream seed: %v", err)
+	}
+	s := newRadiatingServerWithRegistry(t, "ghost.alpha", reg)
+
+	var mu sync.Mutex
+	var got []ProgressEnv
+	s.SetProgressHandler(func(p ProgressEnv) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, p)
+	})
+
+	event, err := s.HandleCommandAndExecute(CommandEnv{
+		MessageID:    1,
+		CommandID:    "cmd.stream.1",
+		IntentID:     "intent.stream.1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.stream",
+		Operation:    "copy",
+	})
+	if err != nil || event.Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected execution result: event=%+v err=%v", event, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 4 {
+		t.Fatalf("expected start, two chunks, and final percent; got %d records", len(got))
+	}
+	var streamed []byte
+	for i, p := range got {
+		if p.Seq != uint64(i+1) {
+			t.Fatalf("record %d has seq %d", i, p.Seq)
+		}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("record %d invalid: %v", i, err)
+		}
+		if len(p.Chunk) > maxProgressChunk {
+			t.Fatalf("record %d chunk exceeds limit: %d", i, len(p.Chunk))
+		}
+		streamed = append(streamed, p.Chunk...)
+	}
+	if !bytes.Equal(streamed, chunk) {
+		t.Fatalf("streamed output mismatch: got %d bytes want %d", len(streamed), len(chunk))
+	}
+	if got[0].Message != "starting copy" || got[3].Percent != 100 {
+		t.Fatalf("unexpected progress updates: first=%+v last=%+v", got[0], got[3])
+	}
+	state, _ := s.ExecutionByCommandID("cmd.stream.1")
+	if state.ProgressSeq != 4 || state.Percent != 100 {
+		t.Fatalf("unexpected execution progress: seq=%d percent=%d", state.ProgressSeq, state.Percent)
+	}
+}
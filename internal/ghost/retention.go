@@ -0,0 +1,133 @@
+package ghost
+
+import (
+	"time"
+
+	logs "github.com/danmuck/smplog"
+)
+
+// Minimum duplicate window required by the reliability contract (duplicate_window).
+const MinDuplicateWindow = 15 * time.Minute
+
+// Ghost execution store retention policy.
+// Finished executions keep their command_id/message_id duplicate keys for TTL; a TTL
+// below MinDuplicateWindow is raised to it. MaxEntries bounds stored executions,
+// including queued and running ones. Entries are only evicted once expired, so when
+// the store is full of entries inside the window new commands are rejected with
+// ErrExecutionStoreFull rather than weakening replay protection.
+// The admin event and verification logs use the same TTL and cap, dropping oldest first.
+type RetentionConfig struct {
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// Ghost retention defaults: the contract duplicate window and a fixed entry cap.
+func DefaultRetentionConfig() RetentionConfig {
+	return RetentionConfig{
+		TTL:        MinDuplicateWindow,
+		MaxEntries: 16384,
+	}
+}
+
+// Ghost retention config with zero values replaced by defaults.
+func (c RetentionConfig) WithDefaults() RetentionConfig {
+	def := DefaultRetentionConfig()
+	if c.TTL < MinDuplicateWindow {
+		c.TTL = def.TTL
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = def.MaxEntries
+	}
+	return c
+}
+
+// Ghost retention counters for the execution store and admin logs.
+type RetentionStats struct {
+	Executions          int    `json:"executions"`
+	ExecutionsEvicted   uint64 `json:"executions_evicted"`
+	ExecutionsRejected  uint64 `json:"executions_rejected"`
+	AdminEvents         int    `json:"admin_events"`
+	AdminEventsEvicted  uint64 `json:"admin_events_evicted"`
+	Verification        int    `json:"verification_records"`
+	VerificationEvicted uint64 `json:"verification_evicted"`
+}
+
+// Ghost finished execution awaiting expiry, in finish order.
+type retainedExecution struct {
+	executionID string
+	expiresAt   time.Time
+}
+
+// Ghost expiry schedule for one execution that left the scheduler.
+// Caller must hold mu.
+func (s *Server) retainLocked(executionID string) {
+	s.retained = append(s.retained, retainedExecution{
+		executionID: executionID,
+		expiresAt:   s.now().Add(s.retention.TTL),
+	})
+}
+
+// Ghost eviction of every finished execution past its TTL from all three indexes.
+// Caller must hold mu.
+func (s *Server) expireLocked() int {
+	now := s.now()
+	n := 0
+	for len(s.retained) > 0 && !now.Before(s.retained[0].expiresAt) {
+		id := s.retained[0].executionID
+		s.retained = s.retained[1:]
+		state, ok := s.executionByID[id]
+		if !ok {
+			continue
+		}
+		delete(s.executionByID, id)
+		if cur, ok := s.executionByCmdID[state.CommandID]; ok && cur.ExecutionID == id {
+			delete(s.executionByCmdID, state.CommandID)
+		}
+		if cmdID, ok := s.commandByMessageID[state.MessageID]; ok && cmdID == state.CommandID {
+			delete(s.commandByMessageID, state.MessageID)
+		}
+		n++
+	}
+	s.evicted += uint64(n)
+	return n
+}
+
+// Ghost sweep of expired executions; returns the number evicted.
+// Sweeps also run on every accepted command.
+func (s *Server) ExpireExecutions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.expireLocked()
+	if n > 0 {
+		logs.Debugf("ghost.Server.ExpireExecutions evicted=%d remaining=%d", n, len(s.executionByID))
+	}
+	return n
+}
+
+// Ghost execution store counters; admin log fields are filled by Service.
+func (s *Server) RetentionStats() RetentionStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return RetentionStats{
+		Executions:         len(s.executionByID),
+		ExecutionsEvicted:  s.evicted,
+		ExecutionsRejected: s.rejected,
+	}
+}
+
+// Ghost admin log trim: drops entries older than TTL, then the oldest over MaxEntries.
+// Returns the retained suffix and the number dropped.
+func trimRetained[T any](entries []T, cfg RetentionConfig, now time.Time, timestampMS func(T) uint64) ([]T, int) {
+	cutoff := uint64(now.Add(-cfg.TTL).UnixMilli())
+	drop := 0
+	for drop < len(entries) && timestampMS(entries[drop]) < cutoff {
+		drop++
+	}
+	if over := len(entries) - drop - cfg.MaxEntries; over > 0 {
+		drop += over
+	}
+	if drop == 0 {
+		return entries, 0
+	}
+	return entries[drop:], drop
+}
@@ -0,0 +1,138 @@
+package ghost
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+// testClock is a manually advanced clock safe to read from scheduler workers.
+type testClock struct {
+	base   time.Time
+	offset atomic.Int64
+}
+
+func (c *testClock) now() time.Time {
+	return c.base.Add(time.Duration(c.offset.Load()))
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.offset.Add(int64(d))
+}
+
+func flowCommand(n uint64) CommandEnv {
+	return CommandEnv{
+		MessageID:    n,
+		CommandID:    fmt.Sprintf("cmd.flow.%d", n),
+		IntentID:     fmt.Sprintf("intent.flow.%d", n),
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+	}
+}
+
+func TestRetentionKeepsDuplicateKeysInsideWindowAndEvictsAfter(t *testing.T) {
+	testlog.Start(t)
+	clock := &testClock{base: time.Now()}
+	s := newRadiatingServer(t, "ghost.alpha", withClock(clock))
+
+	if _, err := s.HandleCommandAndExecute(flowCommand(1)); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	clock.advance(MinDuplicateWindow - time.Second)
+	if _, err := s.HandleCommand(flowCommand(1)); !errors.Is(err, ErrDuplicateCommandID) {
+		t.Fatalf("expected duplicate command_id inside window, got %v", err)
+	}
+	replay := flowCommand(2)
+	replay.MessageID = 1
+	if _, err := s.HandleCommand(replay); !errors.Is(err, ErrDuplicateMessageID) {
+		t.Fatalf("expected duplicate message_id inside window, got %v", err)
+	}
+	if n := s.ExpireExecutions(); n != 0 {
+		t.Fatalf("nothing should expire inside the window, evicted %d", n)
+	}
+
+	clock.advance(time.Second)
+	if n := s.ExpireExecutions(); n != 1 {
+		t.Fatalf("expected one eviction after the window, got %d", n)
+	}
+	if _, ok := s.GetExecution("exec.cmd.flow.1"); ok {
+		t.Fatalf("execution_id index should be evicted")
+	}
+	if _, ok := s.ExecutionByCommandID("cmd.flow.1"); ok {
+		t.Fatalf("command_id index should be evicted")
+	}
+	if _, ok := s.ExecutionByMessageID(1); ok {
+		t.Fatalf("message_id index should be evicted")
+	}
+	stats := s.RetentionStats()
+	if stats.Executions != 0 || stats.ExecutionsEvicted != 1 {
+		t.Fatalf("unexpected retention stats: %+v", stats)
+	}
+}
+
+func TestRetentionRejectsWhenFullInsideWindow(t *testing.T) {
+	testlog.Start(t)
+	clock := &testClock{base: time.Now()}
+	s := newRadiatingServer(t, "ghost.alpha", withClock(clock), withServerConfig(ServerConfig{
+		Retention: RetentionConfig{MaxEntries: 2},
+	}))
+
+	for n := uint64(1); n <= 2; n++ {
+		if _, err := s.HandleCommandAndExecute(flowCommand(n)); err != nil {
+			t.Fatalf("execute %d: %v", n, err)
+		}
+	}
+	if _, err := s.HandleCommand(flowCommand(3)); !errors.Is(err, ErrExecutionStoreFull) {
+		t.Fatalf("expected ErrExecutionStoreFull, got %v", err)
+	}
+	if _, ok := s.ExecutionByCommandID("cmd.flow.1"); !ok {
+		t.Fatalf("entries inside the window must not be evicted to make room")
+	}
+
+	clock.advance(MinDuplicateWindow)
+	if _, err := s.HandleCommandAndExecute(flowCommand(3)); err != nil {
+		t.Fatalf("execute after expiry: %v", err)
+	}
+	stats := s.RetentionStats()
+	if stats.Executions != 1 || stats.ExecutionsEvicted != 2 || stats.ExecutionsRejected != 1 {
+		t.Fatalf("unexpected retention stats: %+v", stats)
+	}
+}
+
+func TestServiceAdminLogsBoundedByRetention(t *testing.T) {
+	testlog.Start(t)
+	cfg := DefaultServiceConfig()
+	cfg.Retention = RetentionConfig{MaxEntries: 2}
+	svc := NewServiceWithConfig(cfg)
+	clock := &testClock{base: time.Now()}
+	svc.server.now = clock.now
+
+	record := func(n uint64) {
+		event := EventEnv{
+			EventID:     fmt.Sprintf("evt.%d", n),
+			CommandID:   fmt.Sprintf("cmd.%d", n),
+			Outcome:     OutcomeSuccess,
+			TimestampMS: uint64(clock.now().UnixMilli()),
+		}
+		svc.recordExecution("ghost.alpha", n, ExecutionState{CommandID: event.CommandID}, event)
+	}
+	for n := uint64(1); n <= 3; n++ {
+		record(n)
+	}
+	events := svc.RecentAdminEvents(10)
+	if len(events) != 2 || events[0].EventID != "evt.2" {
+		t.Fatalf("expected the two newest admin events, got %+v", events)
+	}
+
+	clock.advance(MinDuplicateWindow + time.Second)
+	svc.trimAdminLogs()
+	stats := svc.RetentionStats()
+	if stats.AdminEvents != 0 || stats.AdminEventsEvicted != 3 || stats.Verification != 0 || stats.VerificationEvicted != 3 {
+		t.Fatalf("unexpected admin retention stats: %+v", stats)
+	}
+}
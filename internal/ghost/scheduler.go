@@ -0,0 +1,196 @@
+package ghost
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrExecutionQueueFull = errors.New("ghost: execution queue full")
+
+// Ghost execution scheduler settings.
+// Workers bounds concurrently running seed operations across the Ghost;
+// SeedConcurrency is the default per-seed cap (0 leaves seeds bounded only by Workers).
+// Limits override the cap for one seed, or for one seed operation when Operation is set.
+type SchedulerConfig struct {
+	Workers         int
+	QueueDepth      int
+	SeedConcurrency int
+	Limits          []ExecutionLimit
+}
+
+// Ghost concurrency cap for one seed (empty Operation) or one seed operation.
+type ExecutionLimit struct {
+	SeedID        string
+	Operation     string
+	MaxConcurrent int
+}
+
+// Ghost scheduler defaults: a small worker pool with a bounded pending queue.
+func DefaultSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		Workers:    8,
+		QueueDepth: 256,
+	}
+}
+
+// Ghost scheduler config with zero values replaced by defaults.
+func (c SchedulerConfig) WithDefaults() SchedulerConfig {
+	def := DefaultSchedulerConfig()
+	if c.Workers <= 0 {
+		c.Workers = def.Workers
+	}
+	if c.QueueDepth <= 0 {
+		c.QueueDepth = def.QueueDepth
+	}
+	if c.SeedConcurrency < 0 {
+		c.SeedConcurrency = 0
+	}
+	return c
+}
+
+// Ghost pending execution waiting for a worker and its seed/operation slots.
+// Non-idempotent operations hold the seed's exclusive slot so they run one at a time.
+type scheduledJob struct {
+	seedID     string
+	operation  string
+	idempotent bool
+	run        func()
+}
+
+// Ghost bounded worker pool with per-seed and per-operation admission.
+// Workers are started on demand; a job blocked on its seed caps does not hold
+// back jobs for other seeds queued behind it.
+type scheduler struct {
+	cfg        SchedulerConfig
+	seedLimits map[string]int
+	opLimits   map[string]int
+
+	mu        sync.Mutex
+	pending   []*scheduledJob
+	running   int
+	bySeed    map[string]int
+	byOp      map[string]int
+	exclusive map[string]bool
+}
+
+// Ghost scheduler constructor from config with defaults applied.
+func newScheduler(cfg SchedulerConfig) *scheduler {
+	cfg = cfg.WithDefaults()
+	s := &scheduler{
+		cfg:        cfg,
+		seedLimits: make(map[string]int),
+		opLimits:   make(map[string]int),
+		bySeed:     make(map[string]int),
+		byOp:       make(map[string]int),
+		exclusive:  make(map[string]bool),
+	}
+	for _, limit := range cfg.Limits {
+		seedID := strings.TrimSpace(limit.SeedID)
+		if seedID == "" || limit.MaxConcurrent <= 0 {
+			continue
+		}
+		if op := strings.TrimSpace(limit.Operation); op != "" {
+			s.opLimits[operationKey(seedID, op)] = limit.MaxConcurrent
+			continue
+		}
+		s.seedLimits[seedID] = limit.MaxConcurrent
+	}
+	return s
+}
+
+// Ghost queue admission; the job runs on a worker once its caps allow.
+func (s *scheduler) submit(job *scheduledJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) >= s.cfg.QueueDepth {
+		return ErrExecutionQueueFull
+	}
+	s.pending = append(s.pending, job)
+	s.dispatchLocked()
+	return nil
+}
+
+// Ghost removal of a job that has not started yet; reports whether it was pending.
+func (s *scheduler) withdraw(job *scheduledJob) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, pending := range s.pending {
+		if pending == job {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Ghost dispatch pass starting every admissible pending job in FIFO order.
+// Caller must hold mu.
+func (s *scheduler) dispatchLocked() {
+	for i := 0; i < len(s.pending) && s.running < s.cfg.Workers; {
+		job := s.pending[i]
+		if !s.admitLocked(job) {
+			i++
+			continue
+		}
+		s.pending = append(s.pending[:i], s.pending[i+1:]...)
+		s.running++
+		s.bySeed[job.seedID]++
+		s.byOp[operationKey(job.seedID, job.operation)]++
+		if !job.idempotent {
+			s.exclusive[job.seedID] = true
+		}
+		go s.work(job)
+	}
+}
+
+// Ghost cap check for one pending job. Caller must hold mu.
+func (s *scheduler) admitLocked(job *scheduledJob) bool {
+	if !job.idempotent && s.exclusive[job.seedID] {
+		return false
+	}
+	if limit := s.seedLimit(job.seedID); limit > 0 && s.bySeed[job.seedID] >= limit {
+		return false
+	}
+	key := operationKey(job.seedID, job.operation)
+	if limit := s.opLimits[key]; limit > 0 && s.byOp[key] >= limit {
+		return false
+	}
+	return true
+}
+
+// Ghost per-seed cap: explicit limit, else the configured default.
+func (s *scheduler) seedLimit(seedID string) int {
+	if limit, ok := s.seedLimits[seedID]; ok {
+		return limit
+	}
+	return s.cfg.SeedConcurrency
+}
+
+// Ghost worker body: run one job, release its slots, and admit waiting jobs.
+func (s *scheduler) work(job *scheduledJob) {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.running--
+		s.bySeed[job.seedID]--
+		if s.bySeed[job.seedID] == 0 {
+			delete(s.bySeed, job.seedID)
+		}
+		key := operationKey(job.seedID, job.operation)
+		s.byOp[key]--
+		if s.byOp[key] == 0 {
+			delete(s.byOp, key)
+		}
+		if !job.idempotent {
+			delete(s.exclusive, job.seedID)
+		}
+		s.dispatchLocked()
+	}()
+	job.run()
+}
+
+// Ghost scheduler key for one seed operation.
+func operationKey(seedID string, operation string) string {
+	return seedID + "/" + operation
+}
@@ -0,0 +1,232 @@
+package ghost
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/seeds"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+// gateSeed blocks every operation until released; restart is non-idempotent.
+type gateSeed struct {
+	started chan string
+	release chan struct{}
+}
+
+func newGateSeed() *gateSeed {
+	return &gateSeed{started: make(chan string, 16), release: make(chan struct{}, 16)}
+}
+
+func (s *gateSeed) Metadata() seeds.SeedMetadata {
+	return seeds.SeedMetadata{ID: "seed.gate", Name: "gate", Description: "blocking test seed"}
+}
+
+func (s *gateSeed) Operations() []seeds.OperationSpec {
+	return []seeds.OperationSpec{
+		{Name: "status", Description: "blocking status", Idempotent: true},
+		{Name: "restart", Description: "blocking restart", Idempotent: false},
+		{Name: "hang", Description: "blocking with a short deadline", Idempotent: true, Timeout: 50 * time.Millisecond},
+	}
+}
+
+func (s *gateSeed) Execute(action string, _ map[string]string) (seeds.SeedResult, error) {
+	s.started <- action
+	<-s.release
+	return seeds.SeedResult{Status: "ok", Stdout: []byte(action + "\n")}, nil
+}
+
+func TestSchedulerSlowSeedDoesNotBlockOtherSeeds(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	slow, done, err := s.SubmitCommand(gateCommand(1, "restart"))
+	if err != nil {
+		t.Fatalf("submit slow command: %v", err)
+	}
+	if slow.Phase != ExecutionQueued {
+		t.Fatalf("expected queued phase on submit, got %s", slow.Phase)
+	}
+	waitStarted(t, gate, "restart")
+	waitPhase(t, s, slow.ExecutionID, ExecutionRunning)
+
+	event, err := s.HandleCommandAndExecute(CommandEnv{
+		MessageID:    2,
+		CommandID:    "cmd.flow.2",
+		IntentID:     "intent.flow.2",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+	})
+	if err != nil {
+		t.Fatalf("flow command blocked or failed: %v", err)
+	}
+	if event.Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected flow outcome: %q", event.Outcome)
+	}
+
+	gate.release <- struct{}{}
+	res := <-done
+	if res.Err != nil || res.Event.Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected slow result: %+v", res)
+	}
+	waitPhase(t, s, slow.ExecutionID, ExecutionComplete)
+}
+
+func TestSchedulerSerializesNonIdempotentOperationsPerSeed(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	first, firstDone, err := s.SubmitCommand(gateCommand(1, "restart"))
+	if err != nil {
+		t.Fatalf("submit first restart: %v", err)
+	}
+	waitStarted(t, gate, "restart")
+	second, secondDone, err := s.SubmitCommand(gateCommand(2, "restart"))
+	if err != nil {
+		t.Fatalf("submit second restart: %v", err)
+	}
+	status, statusDone, err := s.SubmitCommand(gateCommand(3, "status"))
+	if err != nil {
+		t.Fatalf("submit status: %v", err)
+	}
+	waitStarted(t, gate, "status")
+	waitPhase(t, s, status.ExecutionID, ExecutionRunning)
+	assertPhaseHeld(t, s, second.ExecutionID, ExecutionQueued)
+
+	gate.release <- struct{}{}
+	gate.release <- struct{}{}
+	<-firstDone
+	<-statusDone
+	waitPhase(t, s, first.ExecutionID, ExecutionComplete)
+	waitStarted(t, gate, "restart")
+	waitPhase(t, s, second.ExecutionID, ExecutionRunning)
+	gate.release <- struct{}{}
+	if res := <-secondDone; res.Err != nil {
+		t.Fatalf("second restart failed: %v", res.Err)
+	}
+}
+
+func TestSchedulerHoldsExclusiveSlotUntilAbandonedLegacyCallReturns(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate))
+
+	_, firstDone, err := s.SubmitCommand(gateCommand(1, "restart"))
+	if err != nil {
+		t.Fatalf("submit first restart: %v", err)
+	}
+	waitStarted(t, gate, "restart")
+	if _, err := s.CancelExecution("cmd.gate.1"); err != nil {
+		t.Fatalf("cancel first restart: %v", err)
+	}
+	if res := <-firstDone; res.Event.Outcome != OutcomeCancelled {
+		t.Fatalf("expected cancelled outcome, got %+v", res)
+	}
+
+	// The cancelled Execute is still running; the next restart must not overlap it.
+	second, secondDone, err := s.SubmitCommand(gateCommand(2, "restart"))
+	if err != nil {
+		t.Fatalf("submit second restart: %v", err)
+	}
+	assertPhaseHeld(t, s, second.ExecutionID, ExecutionQueued)
+
+	gate.release <- struct{}{}
+	waitStarted(t, gate, "restart")
+	waitPhase(t, s, second.ExecutionID, ExecutionRunning)
+	gate.release <- struct{}{}
+	if res := <-secondDone; res.Err != nil || res.Event.Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected second restart result: %+v", res)
+	}
+}
+
+func TestSchedulerOperationLimitAndQueueDepth(t *testing.T) {
+	testlog.Start(t)
+	gate := newGateSeed()
+	s := newRadiatingServer(t, "ghost.alpha", withSeeds(gate), withServerConfig(ServerConfig{
+		Execution: SchedulerConfig{
+			QueueDepth: 1,
+			Limits:     []ExecutionLimit{{SeedID: "seed.gate", Operation: "status", MaxConcurrent: 1}},
+		},
+	}))
+
+	_, firstDone, err := s.SubmitCommand(gateCommand(1, "status"))
+	if err != nil {
+		t.Fatalf("submit first status: %v", err)
+	}
+	waitStarted(t, gate, "status")
+	second, secondDone, err := s.SubmitCommand(gateCommand(2, "status"))
+	if err != nil {
+		t.Fatalf("submit second status: %v", err)
+	}
+	assertPhaseHeld(t, s, second.ExecutionID, ExecutionQueued)
+
+	_, _, err = s.SubmitCommand(gateCommand(3, "status"))
+	if !errors.Is(err, ErrExecutionQueueFull) {
+		t.Fatalf("expected ErrExecutionQueueFull, got %v", err)
+	}
+	if _, ok := s.ExecutionByCommandID("cmd.gate.3"); ok {
+		t.Fatalf("rejected command should not be recorded")
+	}
+
+	gate.release <- struct{}{}
+	<-firstDone
+	waitStarted(t, gate, "status")
+	gate.release <- struct{}{}
+	if res := <-secondDone; res.Err != nil {
+		t.Fatalf("second status failed: %v", res.Err)
+	}
+	if _, _, err := s.SubmitCommand(gateCommand(3, "status")); err != nil {
+		t.Fatalf("retry after queue drained: %v", err)
+	}
+	gate.release <- struct{}{}
+}
+
+func gateCommand(n uint64, operation string) CommandEnv {
+	return CommandEnv{
+		MessageID:    100 + n,
+		CommandID:    fmt.Sprintf("cmd.gate.%d", n),
+		IntentID:     fmt.Sprintf("intent.gate.%d", n),
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.gate",
+		Operation:    operation,
+	}
+}
+
+func waitStarted(t *testing.T, gate *gateSeed, want string) {
+	t.Helper()
+	select {
+	case got := <-gate.started:
+		if got != want {
+			t.Fatalf("started %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q to start", want)
+	}
+}
+
+func waitPhase(t *testing.T, s *Server, executionID string, want ExecutionPhase) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, ok := s.GetExecution(executionID); ok && state.Phase == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	state, _ := s.GetExecution(executionID)
+	t.Fatalf("execution %q phase=%s, want %s", executionID, state.Phase, want)
+}
+
+func assertPhaseHeld(t *testing.T, s *Server, executionID string, want ExecutionPhase) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+	state, ok := s.GetExecution(executionID)
+	if !ok || state.Phase != want {
+		t.Fatalf("execution %q phase=%s, want %s", executionID, state.Phase, want)
+	}
+}
@@ -1,10 +1,12 @@
 package ghost
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/danmuck/edgectl/internal/seeds"
 	logs "github.com/danmuck/smplog"
@@ -49,6 +51,7 @@ type Lifecycle interface {
 type CommandBoundary interface {
 	HandleCommand(cmd CommandEnv) (ExecutionState, error)
 	HandleCommandAndExecute(cmd CommandEnv) (EventEnv, error)
+	SubmitCommand(cmd CommandEnv) (ExecutionState, <-chan ExecutionResult, error)
 	GetExecution(executionID string) (ExecutionState, bool)
 	GetByCommandID(commandID string) (ExecutionState, bool)
 	ExecutionByCommandID(commandID string) (ExecutionState, bool)
@@ -71,16 +74,51 @@ type Server struct {
 	executionByID      map[string]ExecutionState
 	executionByCmdID   map[string]ExecutionState
 	commandByMessageID map[uint64]string
+	scheduler          *scheduler
+	inflight           map[string]inflightExecution
+	progressHandler    ProgressHandler
+	retention          RetentionConfig
+	retained           []retainedExecution
+	evicted            uint64
+	rejected           uint64
+	journal            *executionJournal
+	now                func() time.Time
+}
+
+// Ghost server execution settings: scheduler limits and execution store retention.
+type ServerConfig struct {
+	Execution SchedulerConfig
+	Retention RetentionConfig
+}
+
+// Ghost cancellation handle for one queued or running execution.
+type inflightExecution struct {
+	cancel context.CancelFunc
+	job    *scheduledJob
 }
 
 // Ghost constructor for a server in boot phase with empty execution state.
 func NewServer() *Server {
+	return NewServerWithScheduler(DefaultSchedulerConfig())
+}
+
+// Ghost constructor with explicit execution scheduler limits.
+func NewServerWithScheduler(cfg SchedulerConfig) *Server {
+	return NewServerWithConfig(ServerConfig{Execution: cfg})
+}
+
+// Ghost constructor with explicit scheduler limits and retention policy.
+func NewServerWithConfig(cfg ServerConfig) *Server {
 	logs.Debug("ghost.NewServer")
 	return &Server{
 		phase:              PhaseBoot,
 		executionByID:      make(map[string]ExecutionState),
 		executionByCmdID:   make(map[string]ExecutionState),
 		commandByMessageID: make(map[uint64]string),
+		scheduler:          newScheduler(cfg.Execution),
+		inflight:           make(map[string]inflightExecution),
+		retention:          cfg.Retention.WithDefaults(),
+		now:                time.Now,
 	}
 }
 
@@ -205,6 +243,7 @@ func (s *Server) HandleCommand(cmd CommandEnv) (ExecutionState, error) {
 		return ExecutionState{}, ErrCommandTargetMismatch
 	}
 
+	s.expireLocked()
 	if _, exists := s.executionByCmdID[strings.TrimSpace(cmd.CommandID)]; exists {
 		logs.Errf("ghost.Server.HandleCommand duplicate command_id=%q", cmd.CommandID)
 		return ExecutionState{}, ErrDuplicateCommandID
@@ -215,10 +254,28 @@ func (s *Server) HandleCommand(cmd CommandEnv) (ExecutionState, error) {
 		return ExecutionState{}, ErrDuplicateMessageID
 	}
 
+	if len(s.executionByID) >= s.retention.MaxEntries {
+		s.rejected++
+		logs.Warnf(
+			"ghost.Server.HandleCommand store full command_id=%q entries=%d ttl=%s",
+			cmd.CommandID,
+			len(s.executionByID),
+			s.retention.TTL,
+		)
+		return ExecutionState{}, ErrExecutionStoreFull
+	}
+
 	state := newExecutionState(cmd)
 	s.executionByID[state.ExecutionID] = state
 	s.executionByCmdID[state.CommandID] = state
 	s.commandByMessageID[state.MessageID] = state.CommandID
+	if err := s.journalLocked(journalOpAccept, state); err != nil {
+		delete(s.executionByID, state.ExecutionID)
+		delete(s.executionByCmdID, state.CommandID)
+		delete(s.commandByMessageID, state.MessageID)
+		logs.Errf("ghost.Server.HandleCommand journal append command_id=%q err=%v", state.CommandID, err)
+		return ExecutionState{}, fmt.Errorf("%w: %v", ErrJournalWrite, err)
+	}
 	logs.Infof(
 		"ghost.Server.HandleCommand accepted command_id=%q execution_id=%q message_id=%d",
 		state.CommandID,
@@ -234,14 +291,14 @@ func (s *Server) completeExecution(
 	seedExec SeedExecuteEnv,
 	seedResult SeedResultEnv,
 	event EventEnv,
-) {
+) ExecutionState {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	state, ok := s.executionByID[executionID]
 	if !ok {
 		logs.Errf("ghost.Server.completeExecution missing execution_id=%q", executionID)
-		return
+		return ExecutionState{}
 	}
 
 	state.SeedExecute = seedExec
@@ -253,12 +310,84 @@ func (s *Server) completeExecution(
 	s.executionByID[state.ExecutionID] = state
 	s.executionByCmdID[state.CommandID] = state
 	s.commandByMessageID[state.MessageID] = state.CommandID
+	if err := s.journalLocked(journalOpComplete, state); err != nil {
+		logs.Warnf("ghost.Server.completeExecution journal append execution_id=%q err=%v", state.ExecutionID, err)
+	}
 	logs.Debugf(
 		"ghost.Server.completeExecution execution_id=%q command_id=%q outcome=%q",
 		state.ExecutionID,
 		state.CommandID,
 		state.Outcome,
 	)
+	return state
+}
+
+// Ghost execution-store phase transition for a queued or running execution.
+func (s *Server) setExecutionPhase(executionID string, phase ExecutionPhase) ExecutionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.executionByID[executionID]
+	if !ok {
+		logs.Errf("ghost.Server.setExecutionPhase missing execution_id=%q", executionID)
+		return ExecutionState{}
+	}
+	state.Phase = phase
+	s.executionByID[state.ExecutionID] = state
+	s.executionByCmdID[state.CommandID] = state
+	logs.Debugf("ghost.Server.setExecutionPhase execution_id=%q phase=%s", executionID, phase)
+	return state
+}
+
+// Ghost execution-store update recording the latest emitted progress record.
+func (s *Server) setExecutionProgress(executionID string, seq uint64, percent uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.executionByID[executionID]
+	if !ok || state.Phase == ExecutionComplete {
+		return
+	}
+	state.ProgressSeq = seq
+	state.Percent = percent
+	s.executionByID[state.ExecutionID] = state
+	s.executionByCmdID[state.CommandID] = state
+}
+
+// Ghost execution-store removal for a command that never reached the scheduler.
+func (s *Server) dropExecution(state ExecutionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.executionByID, state.ExecutionID)
+	delete(s.executionByCmdID, state.CommandID)
+	delete(s.commandByMessageID, state.MessageID)
+	if err := s.journalLocked(journalOpDrop, state); err != nil {
+		logs.Warnf("ghost.Server.dropExecution journal append execution_id=%q err=%v", state.ExecutionID, err)
+	}
+}
+
+// Ghost cancellation of a queued or running execution by command_id.
+// A queued execution completes immediately as cancelled without running its seed;
+// a running one has its context cancelled and completes once the seed returns.
+func (s *Server) CancelExecution(commandID string) (ExecutionState, error) {
+	key := strings.TrimSpace(commandID)
+	s.mu.RLock()
+	state, ok := s.executionByCmdID[key]
+	handle, inflight := s.inflight[state.ExecutionID]
+	s.mu.RUnlock()
+	if !ok {
+		return ExecutionState{}, fmt.Errorf("%w: command_id=%q", ErrExecutionNotFound, key)
+	}
+	if !inflight {
+		return state, fmt.Errorf("%w: command_id=%q", ErrExecutionFinished, key)
+	}
+
+	handle.cancel()
+	if s.scheduler.withdraw(handle.job) {
+		go handle.job.run()
+	}
+	logs.Infof("ghost.Server.CancelExecution command_id=%q execution_id=%q phase=%s", key, state.ExecutionID, state.Phase)
+	return state, nil
 }
 
 // Ghost execution lookup by execution_id.
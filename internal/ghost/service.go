@@ -13,6 +13,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
 	"github.com/danmuck/edgectl/internal/protocol/session"
 	"github.com/danmuck/edgectl/internal/seeds"
 	seedflow "github.com/danmuck/edgectl/internal/seeds/flow"
@@ -45,6 +47,8 @@ type MirageSessionConfig struct {
 	PeerIdentity       string
 	MaxConnectAttempts int
 	SessionConfig      session.Config
+	// OutboxPath enables the durable event outbox; relative paths resolve under ProjectRoot.
+	OutboxPath string
 }
 
 // Ghost seed-install configuration for whitelist-gated dependency installation.
@@ -66,7 +70,11 @@ type ServiceConfig struct {
 	HeartbeatInterval  time.Duration
 	AdminListenAddr    string
 	EnableClusterHost  bool
-	Mirage             MirageSessionConfig
+	Execution          SchedulerConfig
+	Retention          RetentionConfig
+	// Journal.Path enables the execution journal; relative paths resolve under ProjectRoot.
+	Journal JournalConfig
+	Mirage  MirageSessionConfig
 }
 
 // Ghost service defaults for standalone runtime configuration.
@@ -80,6 +88,9 @@ func DefaultServiceConfig() ServiceConfig {
 		HeartbeatInterval:  5 * time.Second,
 		AdminListenAddr:    "",
 		EnableClusterHost:  true,
+		Execution:          DefaultSchedulerConfig(),
+		Retention:          DefaultRetentionConfig(),
+		Journal:            JournalConfig{Sync: JournalSyncAlways},
 		Mirage: MirageSessionConfig{
 			Policy:        MiragePolicyHeadless,
 			SessionConfig: session.DefaultConfig(),
@@ -93,6 +104,8 @@ type Service struct {
 	cfg    ServiceConfig
 	mu     sync.RWMutex
 	mirage *MirageSession
+	outbox *session.EventOutbox
+	auth   frame.Authenticator
 	seq    atomic.Uint64
 
 	mirageAdminBound atomic.Bool
@@ -101,8 +114,16 @@ type Service struct {
 	adminSeq           atomic.Uint64
 	adminEvents        []EventEnv
 	verificationEvents []VerificationRecord
+	adminEvicted       uint64
+	verifyEvicted      uint64
 	adminClientCount   atomic.Int64
 	cluster            clusterHost
+
+	schema      *schema.Registry
+	extMu       sync.RWMutex
+	extHandlers map[uint32]ExtensionHandler
+
+	progress chan ProgressEnv
 }
 
 // Ghost service constructor using default standalone config.
@@ -116,12 +137,47 @@ func NewServiceWithConfig(cfg ServiceConfig) *Service {
 	if strings.TrimSpace(string(cfg.Mirage.Policy)) == "" {
 		cfg.Mirage.Policy = MiragePolicyHeadless
 	}
-	return &Service{
-		server:             NewServer(),
+	svc := &Service{
+		server:             NewServerWithConfig(ServerConfig{Execution: cfg.Execution, Retention: cfg.Retention}),
 		cfg:                cfg,
 		adminEvents:        make([]EventEnv, 0),
 		verificationEvents: make([]VerificationRecord, 0),
 		cluster:            newClusterHost(),
+		schema:             schema.NewRegistry(),
+		extHandlers:        make(map[uint32]ExtensionHandler),
+		progress:           make(chan ProgressEnv, progressQueueDepth),
+	}
+	svc.server.SetProgressHandler(svc.forwardProgress)
+	return svc
+}
+
+// Ghost progress hand-off from the seed output path to the Mirage sender.
+// Never blocks the seed: records are dropped while the queue is full.
+func (s *Service) forwardProgress(p ProgressEnv) {
+	select {
+	case s.progress <- p:
+	default:
+		logs.Debugf("ghost.Service.forwardProgress queue full dropped command_id=%q seq=%d", p.CommandID, p.Seq)
+	}
+}
+
+// Ghost progress sender writing queued records to the current Mirage session in order.
+// Best-effort: progress sent while disconnected, or to a Mirage without the progress
+// capability, is dropped; the terminal event still goes through the outbox.
+func (s *Service) runProgressSender(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-s.progress:
+			conn := s.MirageSession()
+			if conn == nil || !conn.Supports(session.CapabilityProgress) {
+				continue
+			}
+			if err := conn.SendProgress(conn.ctx, p); err != nil {
+				logs.Debugf("ghost.Service.runProgressSender dropped command_id=%q seq=%d err=%v", p.CommandID, p.Seq, err)
+			}
+		}
 	}
 }
 
@@ -167,6 +223,9 @@ func (s *Service) bootstrap() error {
 	if err := s.server.Seed(reg); err != nil {
 		return err
 	}
+	if err := s.openExecutionJournal(reg); err != nil {
+		return err
+	}
 	if err := s.server.Radiate(); err != nil {
 		return err
 	}
@@ -185,11 +244,13 @@ func (s *Service) bootstrap() error {
 func (s *Service) serve(ctx context.Context) error {
 	ticker := time.NewTicker(s.cfg.HeartbeatInterval)
 	defer ticker.Stop()
+	defer s.closeExecutionJournal()
 	defer s.clearMirageSession()
 	defer s.stopManagedGhosts()
 
 	sessionErr := make(chan error, 1)
 	controlErr := make(chan error, 1)
+	go s.runProgressSender(ctx)
 	if s.cfg.Mirage.Policy != MiragePolicyHeadless {
 		go func() {
 			sessionErr <- s.runMirageSessionLoop(ctx)
@@ -215,6 +276,8 @@ func (s *Service) serve(ctx context.Context) error {
 				return err
 			}
 		case <-ticker.C:
+			s.server.ExpireExecutions()
+			s.trimAdminLogs()
 			status := s.server.Status()
 			adminClients := s.AdminClientCount()
 			mirageConnected := s.IsMirageConnected()
@@ -236,6 +299,22 @@ func (s *Service) serve(ctx context.Context) error {
 
 // Ghost-side Mirage session manager with reconnect behavior.
 func (s *Service) runMirageSessionLoop(ctx context.Context) error {
+	auth, err := session.NewFrameAuthenticator(s.cfg.Mirage.SessionConfig.Auth)
+	if err != nil {
+		return err
+	}
+	s.auth = auth
+	outbox, err := s.openMirageOutbox()
+	if err != nil {
+		return err
+	}
+	s.outbox = outbox
+	defer func() {
+		if err := outbox.Close(); err != nil {
+			logs.Warnf("ghost.Service.runMirageSessionLoop outbox close err=%v", err)
+		}
+	}()
+
 	attempt := 0
 	connectedOnce := false
 	for {
@@ -277,6 +356,11 @@ func (s *Service) runMirageSessionLoop(ctx context.Context) error {
 			s.cfg.Mirage.Policy,
 			s.cfg.Mirage.Address,
 		)
+		if replayed, err := sessionConn.ReplayPending(ctx); err != nil {
+			logs.Warnf("ghost.Service.runMirageSessionLoop outbox replay replayed=%d err=%v", replayed, err)
+		} else if replayed > 0 {
+			logs.Infof("ghost.Service.runMirageSessionLoop outbox replay replayed=%d", replayed)
+		}
 
 		err = s.monitorMirageSession(ctx, sessionConn)
 		s.clearMirageSessionIf(sessionConn)
@@ -286,6 +370,76 @@ func (s *Service) runMirageSessionLoop(ctx context.Context) error {
 	}
 }
 
+// Ghost execution journal replay when Journal.Path is set.
+// Generated admin message ids resume past the restored ones so they never collide
+// with journaled duplicate keys. Without a journal, a boot warning names the
+// registered operations a restart could run twice.
+func (s *Service) openExecutionJournal(reg *seeds.Registry) error {
+	cfg := s.cfg.Journal
+	path := strings.TrimSpace(cfg.Path)
+	if path == "" {
+		if ops := nonIdempotentOperations(reg); len(ops) > 0 {
+			logs.Warnf(
+				"ghost.Service.openExecutionJournal journal disabled; duplicate commands for non-idempotent operations re-run after a restart operations=%v",
+				ops,
+			)
+		}
+		return nil
+	}
+	if !filepath.IsAbs(path) && strings.TrimSpace(s.cfg.ProjectRoot) != "" {
+		path = filepath.Join(strings.TrimSpace(s.cfg.ProjectRoot), path)
+	}
+	cfg.Path = path
+	if err := s.server.OpenJournal(cfg); err != nil {
+		return err
+	}
+	if restored := s.server.maxMessageID(); restored > s.adminSeq.Load() {
+		s.adminSeq.Store(restored)
+	}
+	return nil
+}
+
+// Ghost registered seed operations that are not safe to re-run, as seed_id.operation.
+func nonIdempotentOperations(reg *seeds.Registry) []string {
+	var out []string
+	for _, meta := range reg.ListMetadata() {
+		seed, ok := reg.Resolve(meta.ID)
+		if !ok {
+			continue
+		}
+		for _, spec := range seed.Operations() {
+			if !spec.Idempotent {
+				out = append(out, meta.ID+"."+spec.Name)
+			}
+		}
+	}
+	return out
+}
+
+// Ghost execution journal shutdown on service exit.
+func (s *Service) closeExecutionJournal() {
+	if err := s.server.CloseJournal(); err != nil {
+		logs.Warnf("ghost.Service.closeExecutionJournal err=%v", err)
+	}
+}
+
+// Ghost event outbox shared across Mirage reconnects, durable when OutboxPath is set.
+func (s *Service) openMirageOutbox() (*session.EventOutbox, error) {
+	path := strings.TrimSpace(s.cfg.Mirage.OutboxPath)
+	if path == "" {
+		return session.NewEventOutbox(), nil
+	}
+	if !filepath.IsAbs(path) && strings.TrimSpace(s.cfg.ProjectRoot) != "" {
+		path = filepath.Join(strings.TrimSpace(s.cfg.ProjectRoot), path)
+	}
+	outbox, err := session.OpenEventOutbox(path)
+	if err != nil {
+		return nil, err
+	}
+	logs.Infof("ghost.Service.openMirageOutbox path=%q pending=%d", path, outbox.Len())
+	return outbox, nil
+}
+
 // Ghost Mirage client dial/register wrapper using runtime seed metadata.
 func (s *Service) connectMirageSession(ctx context.Context) (*MirageSession, error) {
 	clientCfg := MirageClientConfig{
@@ -295,6 +449,11 @@ func (s *Service) connectMirageSession(ctx context.Context) (*MirageSession, err
 		SeedList:           SeedInfoFromMetadata(s.server.SeedMetadata()),
 		Session:            s.cfg.Mirage.SessionConfig,
 		MaxConnectAttempts: s.cfg.Mirage.MaxConnectAttempts,
+		CommandHandler:     s.handleSessionCommand,
+		Outbox:             s.outbox,
+		Authenticator:      s.auth,
+		Schema:             s.schema,
+		ExtensionHandler:   s.handleExtension,
 	}
 
 	client, err := NewMirageClient(clientCfg)
@@ -309,7 +468,9 @@ func (s *Service) connectMirageSession(ctx context.Context) (*MirageSession, err
 	return sessionConn, nil
 }
 
-// Ghost session health probe loop using heartbeat events.
+// Ghost session liveness loop sending ping frames; the session is dropped
+// once Mirage has not answered with a pong within SessionDeadAfter.
+// Mirages without the heartbeat capability are probed with acked heartbeat events instead.
 func (s *Service) monitorMirageSession(ctx context.Context, conn *MirageSession) error {
 	interval := s.cfg.Mirage.SessionConfig.HeartbeatInterval
 	if interval <= 0 {
@@ -322,17 +483,54 @@ func (s *Service) monitorMirageSession(ctx context.Context, conn *MirageSession)
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-conn.Done():
+			return ErrSessionClosed
 		case <-ticker.C:
-			probeCtx, cancel := context.WithTimeout(ctx, s.sessionProbeTimeout())
-			_, err := conn.SendEventWithAck(probeCtx, s.sessionProbeEvent())
-			cancel()
-			if err != nil {
+			if err := s.probeMirageSession(ctx, conn); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+// Ghost single liveness probe: a ping when Mirage negotiated heartbeat, otherwise
+// a synthetic heartbeat event acked by Mirages that predate ping frames.
+func (s *Service) probeMirageSession(ctx context.Context, conn *MirageSession) error {
+	if conn.Supports(session.CapabilityHeartbeat) {
+		return conn.Ping(ctx)
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, s.sessionProbeTimeout())
+	defer cancel()
+	_, err := conn.SendEventWithAck(probeCtx, s.sessionProbeEvent())
+	return err
+}
+
+// Ghost session probe timeout derived from session config.
+func (s *Service) sessionProbeTimeout() time.Duration {
+	if s.cfg.Mirage.SessionConfig.SessionDeadAfter > 0 {
+		return s.cfg.Mirage.SessionConfig.SessionDeadAfter
+	}
+	if s.cfg.Mirage.SessionConfig.AckTimeout > 0 {
+		return s.cfg.Mirage.SessionConfig.AckTimeout
+	}
+	return 5 * time.Second
+}
+
+// Ghost synthetic heartbeat event builder for legacy Mirage session probes.
+func (s *Service) sessionProbeEvent() EventEnv {
+	now := uint64(time.Now().UnixMilli())
+	seq := s.seq.Add(1)
+	return EventEnv{
+		EventID:     fmt.Sprintf("evt.session.%s.%d", s.cfg.GhostID, seq),
+		CommandID:   fmt.Sprintf("cmd.session.heartbeat.%d", seq),
+		IntentID:    "intent.session.heartbeat",
+		GhostID:     s.cfg.GhostID,
+		SeedID:      "seed.session",
+		Outcome:     OutcomeSuccess,
+		TimestampMS: now,
+	}
+}
+
 // Ghost runtime atomic swap for the active Mirage session pointer.
 func (s *Service) setMirageSession(conn *MirageSession) {
 	s.mu.Lock()
@@ -410,32 +608,6 @@ func (s *Service) ManagedGhostCount() int {
 	return s.cluster.count()
 }
 
-// Ghost session probe timeout derived from session config.
-func (s *Service) sessionProbeTimeout() time.Duration {
-	if s.cfg.Mirage.SessionConfig.SessionDeadAfter > 0 {
-		return s.cfg.Mirage.SessionConfig.SessionDeadAfter
-	}
-	if s.cfg.Mirage.SessionConfig.AckTimeout > 0 {
-		return s.cfg.Mirage.SessionConfig.AckTimeout
-	}
-	return 5 * time.Second
-}
-
-// Ghost synthetic heartbeat event builder for Mirage session probes.
-func (s *Service) sessionProbeEvent() EventEnv {
-	now := uint64(time.Now().UnixMilli())
-	seq := s.seq.Add(1)
-	return EventEnv{
-		EventID:     fmt.Sprintf("evt.session.%s.%d", s.cfg.GhostID, seq),
-		CommandID:   fmt.Sprintf("cmd.session.heartbeat.%d", seq),
-		IntentID:    "intent.session.heartbeat",
-		GhostID:     s.cfg.GhostID,
-		SeedID:      "seed.session",
-		Outcome:     OutcomeSuccess,
-		TimestampMS: now,
-	}
-}
-
 // Ghost reconnect backoff wait helper with deterministic delay.
 func (s *Service) waitReconnectBackoff(ctx context.Context, attempt int) error {
 	backoffCfg := s.cfg.Mirage.SessionConfig.Backoff
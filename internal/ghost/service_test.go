@@ -1,8 +1,10 @@
 package ghost
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"os/exec"
@@ -11,6 +13,8 @@ import (
 	"time"
 
 	"github.com/danmuck/edgectl/internal/mirage"
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
 	"github.com/danmuck/edgectl/internal/protocol/session"
 	"github.com/danmuck/edgectl/internal/seeds"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
@@ -402,6 +406,233 @@ func TestServiceServeAutoConnectsMirage(t *testing.T) {
 	}
 }
 
+func TestServiceHeartbeatsMeasureRTTWithoutEvents(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	mcfg := mirage.DefaultServiceConfig()
+	mcfg.Session.HandshakeTimeout = 2 * time.Second
+	mcfg.Session.SessionDeadAfter = 300 * time.Millisecond
+	msvc := mirage.NewServiceWithConfig(mcfg)
+
+	mctx, mcancel := context.WithCancel(context.Background())
+	defer mcancel()
+	mdone := make(chan error, 1)
+	go func() {
+		mdone <- msvc.Serve(mctx, ln)
+	}()
+
+	scfg := session.DefaultConfig()
+	scfg.HeartbeatInterval = 20 * time.Millisecond
+	svc := NewServiceWithConfig(ServiceConfig{
+		GhostID:           "ghost.alpha",
+		BuiltinSeedIDs:    []string{"seed.flow"},
+		HeartbeatInterval: 20 * time.Millisecond,
+		Mirage: MirageSessionConfig{
+			Policy:        MiragePolicyAuto,
+			Address:       ln.Addr().String(),
+			PeerIdentity:  "ghost.alpha",
+			SessionConfig: scfg,
+		},
+	})
+	if err := svc.bootstrap(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+
+	sctx, scancel := context.WithCancel(context.Background())
+	sdone := make(chan error, 1)
+	go func() {
+		sdone <- svc.serve(sctx)
+	}()
+
+	if !waitForCondition(2*time.Second, 20*time.Millisecond, func() bool {
+		conn := svc.MirageSession()
+		return conn != nil && conn.RTT() > 0
+	}) {
+		scancel()
+		_ = <-sdone
+		t.Fatalf("ghost did not measure heartbeat rtt")
+	}
+	// Outlive Mirage SessionDeadAfter to prove pings alone keep the session up.
+	time.Sleep(500 * time.Millisecond)
+	ghosts := msvc.SnapshotRegisteredGhosts()
+	scancel()
+	if err := <-sdone; err != nil {
+		t.Fatalf("ghost serve exit err: %v", err)
+	}
+	if len(ghosts) != 1 || !ghosts[0].Connected || ghosts[0].LastHeartbeatAt.IsZero() {
+		t.Fatalf("expected live ghost with heartbeat, got %+v", ghosts)
+	}
+	if ghosts[0].EventCount != 0 || !ghosts[0].LastEventAt.IsZero() {
+		t.Fatalf("heartbeats counted as events: %+v", ghosts[0])
+	}
+
+	mcancel()
+	if err := <-mdone; err != nil {
+		t.Fatalf("mirage serve exit err: %v", err)
+	}
+}
+
+func TestServiceFallsBackToLegacyMirageWithoutCapabilities(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	probes := make(chan session.Event, 4)
+	mdone := make(chan error, 1)
+	go func() {
+		mdone <- serveLegacyMirageEndpoint(ln, probes)
+	}()
+
+	scfg := session.DefaultConfig()
+	scfg.HeartbeatInterval = 20 * time.Millisecond
+	svc := NewServiceWithConfig(ServiceConfig{
+		GhostID:           "ghost.alpha",
+		BuiltinSeedIDs:    []string{"seed.flow"},
+		HeartbeatInterval: 20 * time.Millisecond,
+		Mirage: MirageSessionConfig{
+			Policy:        MiragePolicyAuto,
+			Address:       ln.Addr().String(),
+			PeerIdentity:  "ghost.alpha",
+			SessionConfig: scfg,
+		},
+	})
+	if err := svc.bootstrap(); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+
+	sctx, scancel := context.WithCancel(context.Background())
+	sdone := make(chan error, 1)
+	go func() {
+		sdone <- svc.serve(sctx)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case probe := <-probes:
+			if probe.IntentID != "intent.session.heartbeat" || probe.GhostID != "ghost.alpha" {
+				t.Fatalf("unexpected liveness probe: %+v", probe)
+			}
+		case err := <-mdone:
+			scancel()
+			_ = <-sdone
+			t.Fatalf("legacy mirage endpoint exited: %v", err)
+		case <-time.After(2 * time.Second):
+			scancel()
+			_ = <-sdone
+			t.Fatalf("ghost did not probe legacy mirage")
+		}
+	}
+	if conn := svc.MirageSession(); conn == nil || conn.Supports(session.CapabilityHeartbeat) {
+		t.Fatalf("expected live session without heartbeat capability")
+	} else if err := conn.Ping(context.Background()); !errors.Is(err, ErrHeartbeatUnsupported) {
+		t.Fatalf("expected ErrHeartbeatUnsupported, got %v", err)
+	} else if err := conn.SendProgress(context.Background(), ProgressEnv{CommandID: "cmd.legacy", Seq: 1}); !errors.Is(err, ErrProgressUnsupported) {
+		t.Fatalf("expected ErrProgressUnsupported, got %v", err)
+	}
+	// Forwarded progress is dropped before the wire; the endpoint fails on any non-event frame.
+	svc.forwardProgress(ProgressEnv{CommandID: "cmd.legacy", Seq: 2, Message: "dropped"})
+	for deadline := time.Now().Add(time.Second); len(svc.progress) > 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("progress sender did not drain the queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	scancel()
+	if err := <-sdone; err != nil {
+		t.Fatalf("ghost serve exit err: %v", err)
+	}
+	_ = ln.Close()
+	if err := <-mdone; err != nil {
+		t.Fatalf("legacy mirage endpoint err: %v", err)
+	}
+}
+
+func TestServiceForwardProgressDropsWhenQueueFull(t *testing.T) {
+	testlog.Start(t)
+
+	// No sender is running, so the queue fills and stays full.
+	svc := NewServiceWithConfig(ServiceConfig{GhostID: "ghost.alpha"})
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		for seq := uint64(1); seq <= progressQueueDepth+8; seq++ {
+			svc.forwardProgress(ProgressEnv{CommandID: "cmd.1", Seq: seq})
+		}
+	}()
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("forwardProgress blocked on a full queue")
+	}
+	if got := len(svc.progress); got != progressQueueDepth {
+		t.Fatalf("expected full progress queue, got %d", got)
+	}
+	if first := <-svc.progress; first.Seq != 1 {
+		t.Fatalf("expected oldest records kept, got seq=%d", first.Seq)
+	}
+}
+
+// serveLegacyMirageEndpoint mimics a Mirage predating heartbeat: it negotiates no
+// capabilities, acks events, and fails on any other frame type.
+func serveLegacyMirageEndpoint(ln net.Listener, probes chan<- session.Event) error {
+	conn, err := ln.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reg, err := session.ReadRegistration(reader)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteRegistrationAck(conn, session.RegistrationAck{
+		Status:          session.AckStatusAccepted,
+		Message:         "registered",
+		GhostID:         reg.GhostID,
+		ProtocolVersion: frame.ProtocolVersion,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	}); err != nil {
+		return err
+	}
+	for {
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			return nil
+		}
+		if fr.Header.MessageType != schema.MsgEvent {
+			return fmt.Errorf("unexpected message_type=%d from ghost", fr.Header.MessageType)
+		}
+		event, err := session.DecodeEventFrame(fr)
+		if err != nil {
+			return err
+		}
+		ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, session.EventAck{
+			EventID:     event.EventID,
+			CommandID:   event.CommandID,
+			GhostID:     event.GhostID,
+			AckStatus:   session.AckStatusAccepted,
+			TimestampMS: uint64(time.Now().UnixMilli()),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(ackPayload); err != nil {
+			return nil
+		}
+		select {
+		case probes <- event:
+		default:
+		}
+	}
+}
+
 func TestServiceServeAutoReconnectsAfterMirageRestart(t *testing.T) {
 	testlog.Start(t)
 
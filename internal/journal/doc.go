@@ -0,0 +1,12 @@
+// Package journal owns the append-only JSON-lines record log shared by durable stores.
+//
+// Ownership boundary:
+// - replay with torn trailing record tolerance
+//
+// - appends with caller-chosen fsync
+//
+// - background compaction, atomic swap, and directory fsync
+//
+// Record types, sync policy, and what a snapshot contains stay with each store;
+// the Ghost execution journal and the session event outbox both build on this package.
+package journal
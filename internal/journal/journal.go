@@ -0,0 +1,275 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	logs "github.com/danmuck/smplog"
+)
+
+// Minimum records before NeedsCompaction considers rewriting the file.
+const MinCompactRecords = 256
+
+var (
+	ErrPathRequired = errors.New("journal: path required")
+	ErrClosed       = errors.New("journal: closed")
+)
+
+// Journal append-only record file.
+// Appends go to the current file until a compaction's snapshot has been written
+// and fsynced; records appended meanwhile are kept in pending and carried into the
+// new file at the swap, so the old file stays valid until the rename succeeds.
+// Safe for concurrent use; compaction never needs the owning store's lock.
+type Journal struct {
+	path string
+
+	mu         sync.Mutex
+	file       *os.File
+	records    int
+	compacting chan struct{}
+	pending    [][]byte
+}
+
+// Journal replay of every record at path in append order; a missing file replays nothing.
+// A torn trailing record from an interrupted write is dropped with a warning.
+func Replay(path string, apply func(raw []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("journal: open: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	line := 0
+	for {
+		raw, readErr := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			line++
+			if err := apply(raw); err != nil {
+				if readErr == io.EOF {
+					logs.Warnf("journal.Replay dropped torn record path=%q line=%d", path, line)
+					return nil
+				}
+				return fmt.Errorf("journal: decode line=%d: %w", line, err)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("journal: read: %w", readErr)
+		}
+	}
+}
+
+// Journal open at path, replacing its contents with snapshot before the first append.
+// The parent directory is created when missing.
+func Open(path string, snapshot []any) (*Journal, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, ErrPathRequired
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("journal: mkdir: %w", err)
+	}
+	j := &Journal{path: path}
+	tmp, err := writeSnapshot(path, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.installLocked(tmp, nil, len(snapshot)); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Journal file path.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Journal single-record append; sync fsyncs the record before returning.
+func (j *Journal) Append(rec any, sync bool) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return ErrClosed
+	}
+	if _, err := j.file.Write(raw); err != nil {
+		return err
+	}
+	if j.compacting != nil {
+		j.pending = append(j.pending, raw)
+	}
+	j.records++
+	if sync {
+		return j.file.Sync()
+	}
+	return nil
+}
+
+// Journal fsync of every record appended so far.
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return ErrClosed
+	}
+	return j.file.Sync()
+}
+
+// Journal compaction check against live, the record count a snapshot would hold.
+// False while a compaction is already running.
+func (j *Journal) NeedsCompaction(live int) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file != nil && j.compacting == nil && j.records >= MinCompactRecords && j.records > 2*live
+}
+
+// Journal background compaction to snapshot, which must reflect every record
+// appended before the call. snapshot values are encoded off the caller goroutine,
+// so they must not be mutated afterwards. No-op while another compaction runs.
+func (j *Journal) Compact(snapshot []any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil || j.compacting != nil {
+		return
+	}
+	done := make(chan struct{})
+	j.compacting = done
+	j.pending = nil
+	go func() {
+		defer close(done)
+		tmp, err := writeSnapshot(j.path, snapshot)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		pending := j.pending
+		j.compacting = nil
+		j.pending = nil
+		if err == nil && j.file == nil {
+			discardSnapshot(tmp)
+			return
+		}
+		if err == nil {
+			err = j.installLocked(tmp, pending, len(snapshot)+len(pending))
+		}
+		if err != nil {
+			logs.Warnf("journal.Compact path=%q err=%v; keeping current file", j.path, err)
+		}
+	}()
+}
+
+// Journal close; waits for a running compaction, then fsyncs and closes the file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	done := j.compacting
+	j.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	syncErr := j.file.Sync()
+	err := j.file.Close()
+	j.file = nil
+	if syncErr != nil {
+		return syncErr
+	}
+	return err
+}
+
+// Journal swap to a written snapshot; caller must hold mu.
+// pending records are appended and fsynced before the rename, the parent directory
+// is fsynced after it, and the old file is only closed once the swap succeeded.
+func (j *Journal) installLocked(tmp *os.File, pending [][]byte, records int) error {
+	for _, raw := range pending {
+		if _, err := tmp.Write(raw); err != nil {
+			discardSnapshot(tmp)
+			return fmt.Errorf("journal: compact append: %w", err)
+		}
+	}
+	if len(pending) > 0 {
+		if err := tmp.Sync(); err != nil {
+			discardSnapshot(tmp)
+			return fmt.Errorf("journal: compact sync: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		discardSnapshot(tmp)
+		return fmt.Errorf("journal: compact rename: %w", err)
+	}
+	if err := SyncDir(filepath.Dir(j.path)); err != nil {
+		logs.Warnf("journal.Compact dir sync path=%q err=%v", j.path, err)
+	}
+	if j.file != nil {
+		_ = j.file.Close()
+	}
+	j.file = tmp
+	j.records = records
+	logs.Debugf("journal.Compact swapped path=%q records=%d", j.path, j.records)
+	return nil
+}
+
+// Journal snapshot write to the temp file beside path, fsynced and left open for the swap.
+func writeSnapshot(path string, snapshot []any) (*os.File, error) {
+	tmp, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("journal: compact create: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, rec := range snapshot {
+		if err := enc.Encode(rec); err != nil {
+			discardSnapshot(tmp)
+			return nil, fmt.Errorf("journal: compact encode: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		discardSnapshot(tmp)
+		return nil, fmt.Errorf("journal: compact flush: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		discardSnapshot(tmp)
+		return nil, fmt.Errorf("journal: compact sync: %w", err)
+	}
+	return tmp, nil
+}
+
+// Journal snapshot cleanup after a failed or abandoned compaction.
+func discardSnapshot(tmp *os.File) {
+	_ = tmp.Close()
+	_ = os.Remove(tmp.Name())
+}
+
+// Journal directory fsync so a rename inside dir is durable.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
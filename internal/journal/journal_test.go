@@ -0,0 +1,122 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+type testRecord struct {
+	N int `json:"n"`
+}
+
+func replayRecords(t *testing.T, path string) []int {
+	t.Helper()
+	var out []int
+	err := Replay(path, func(raw []byte) error {
+		var rec testRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+		out = append(out, rec.N)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	return out
+}
+
+func TestJournalCompactionKeepsRecordsAppendedMeanwhile(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "nested", "records.log")
+	j, err := Open(path, []any{testRecord{N: 0}})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for n := 1; n <= MinCompactRecords; n++ {
+		if err := j.Append(testRecord{N: n}, false); err != nil {
+			t.Fatalf("append %d: %v", n, err)
+		}
+	}
+	if !j.NeedsCompaction(1) {
+		t.Fatalf("expected compaction needed after %d records", MinCompactRecords)
+	}
+	j.Compact([]any{testRecord{N: -1}})
+	if j.NeedsCompaction(1) {
+		t.Fatalf("expected no second compaction while one runs")
+	}
+	for n := 1000; n < 1010; n++ {
+		if err := j.Append(testRecord{N: n}, true); err != nil {
+			t.Fatalf("append during compaction %d: %v", n, err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := j.Append(testRecord{N: 1}, false); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	got := replayRecords(t, path)
+	if len(got) != 11 || got[0] != -1 || got[10] != 1009 {
+		t.Fatalf("expected snapshot plus records appended meanwhile, got %v", got)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected temp file swapped in, stat err=%v", err)
+	}
+}
+
+func TestJournalFailedCompactionKeepsCurrentFile(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "records.log")
+	j, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// A directory in the way of the temp file makes the snapshot write fail.
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := j.Append(testRecord{N: 1}, true); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	j.Compact(nil)
+	if err := j.Append(testRecord{N: 2}, true); err != nil {
+		t.Fatalf("append after failed compaction: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := replayRecords(t, path); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("expected current file kept intact, got %v", got)
+	}
+}
+
+func TestReplayDropsTornTrailingRecord(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "records.log")
+	if got := replayRecords(t, path); len(got) != 0 {
+		t.Fatalf("expected missing file to replay nothing, got %v", got)
+	}
+	if err := os.WriteFile(path, []byte("{\"n\":1}\n{\"n\":2}\n{\"n\":"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := replayRecords(t, path); len(got) != 2 {
+		t.Fatalf("expected torn trailing record dropped, got %v", got)
+	}
+	if err := os.WriteFile(path, []byte("{\"n\":1}\nnot json\n{\"n\":3}\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	err := Replay(path, func(raw []byte) error {
+		return json.Unmarshal(raw, &testRecord{})
+	})
+	if err == nil || !strings.Contains(err.Error(), "line=2") {
+		t.Fatalf("expected corrupt middle record rejected, got %v", err)
+	}
+}
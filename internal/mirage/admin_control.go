@@ -52,6 +52,13 @@ type AdminAttachGhostResponse struct {
 	AdminAddr string `json:"admin_addr"`
 }
 
+// AdminJoinTokenResponse captures one minted Ghost enrollment token.
+type AdminJoinTokenResponse struct {
+	Token     string    `json:"token"`
+	GhostID   string    `json:"ghost_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type adminControlRequest struct {
 	Action         string            `json:"action"`
 	Limit          int               `json:"limit,omitempty"`
@@ -59,6 +66,9 @@ type adminControlRequest struct {
 	Issue          AdminIssueRequest `json:"issue,omitempty"`
 	Spawn          SpawnGhostRequest `json:"spawn,omitempty"`
 	GhostAdminAddr string            `json:"ghost_admin_addr,omitempty"`
+	GhostID        string            `json:"ghost_id,omitempty"`
+	TTL            string            `json:"ttl,omitempty"`
+	Cursor         uint64            `json:"cursor,omitempty"`
 }
 
 type adminControlResponse struct {
@@ -181,6 +191,16 @@ func (s *Service) handleAdminControlRequest(req adminControlRequest) adminContro
 			Found:    found,
 			Snapshot: snapshot,
 		}}
+	case "tail_progress":
+		intentID := strings.TrimSpace(req.IntentID)
+		if intentID == "" {
+			return adminControlResponse{OK: false, Error: "intent_id required"}
+		}
+		tail, err := s.server.TailProgress(intentID, req.Cursor, req.Limit)
+		if err != nil {
+			return adminControlResponse{OK: false, Error: err.Error()}
+		}
+		return adminControlResponse{OK: true, Data: tail}
 	case "list_intents":
 		return adminControlResponse{OK: true, Data: s.server.ListIntentIDs()}
 	case "recent_reports":
@@ -215,6 +235,25 @@ func (s *Service) handleAdminControlRequest(req adminControlRequest) adminContro
 		s.bindGhostAdmin(out.GhostID, out.AdminAddr)
 		logs.Warnf("mirage.admin spawned local ghost ghost_id=%q addr=%q", out.GhostID, out.AdminAddr)
 		return adminControlResponse{OK: true, Data: out}
+	case "mint_join_token":
+		var ttl time.Duration
+		if raw := strings.TrimSpace(req.TTL); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return adminControlResponse{OK: false, Error: fmt.Sprintf("parse ttl: %v", err)}
+			}
+			ttl = d
+		}
+		ghostID := strings.TrimSpace(req.GhostID)
+		token, expiresAt, err := s.MintJoinToken(ghostID, ttl)
+		if err != nil {
+			return adminControlResponse{OK: false, Error: err.Error()}
+		}
+		return adminControlResponse{OK: true, Data: AdminJoinTokenResponse{
+			Token:     token.String(),
+			GhostID:   ghostID,
+			ExpiresAt: expiresAt,
+		}}
 	default:
 		return adminControlResponse{OK: false, Error: fmt.Sprintf("unknown action: %s", req.Action)}
 	}
@@ -0,0 +1,238 @@
+package mirage
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/pki"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+var (
+	ErrEnrollmentDisabled = errors.New("mirage: enrollment disabled")
+	ErrJoinTokenInvalid   = errors.New("mirage: join token invalid")
+)
+
+// Mirage Ghost enrollment settings; an empty CADir disables enrollment.
+// CADir is an `edgectl pki` directory whose CA signs enrolled Ghost certificates;
+// the Mirage server cert must chain to the same CA so join-token pins match.
+type EnrollmentConfig struct {
+	CADir        string
+	TokenTTL     time.Duration
+	CertValidity time.Duration
+}
+
+// Mirage enrollment defaults: short-lived tokens, year-long Ghost certificates.
+func DefaultEnrollmentConfig() EnrollmentConfig {
+	return EnrollmentConfig{
+		TokenTTL:     15 * time.Minute,
+		CertValidity: pki.DefaultCertValidity,
+	}
+}
+
+// Mirage enrollment config with zero values replaced by defaults.
+func (c EnrollmentConfig) WithDefaults() EnrollmentConfig {
+	def := DefaultEnrollmentConfig()
+	c.CADir = strings.TrimSpace(c.CADir)
+	if c.TokenTTL <= 0 {
+		c.TokenTTL = def.TokenTTL
+	}
+	if c.CertValidity <= 0 {
+		c.CertValidity = def.CertValidity
+	}
+	return c
+}
+
+// Mirage enrollment toggle.
+func (c EnrollmentConfig) Enabled() bool {
+	return c.CADir != ""
+}
+
+// Mirage stored join token; only the secret hash is kept in memory.
+// An empty ghostID lets the enrolling Ghost choose its own identity.
+type joinTokenState struct {
+	secretHash [sha256.Size]byte
+	ghostID    string
+	expiresAt  time.Time
+}
+
+// Mirage enrollment authority with outstanding one-time join tokens.
+type enrollment struct {
+	cfg    EnrollmentConfig
+	ca     *pki.Authority
+	caHash string
+
+	mu     sync.Mutex
+	tokens map[string]joinTokenState
+}
+
+// Mirage enrollment constructor over an existing pki directory.
+func newEnrollment(cfg EnrollmentConfig) (*enrollment, error) {
+	ca, err := pki.Open(cfg.CADir)
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment{
+		cfg:    cfg,
+		ca:     ca,
+		caHash: session.CACertHash(ca.Certificate().Raw),
+		tokens: make(map[string]joinTokenState),
+	}, nil
+}
+
+// MintJoinToken creates a one-time join token valid for ttl (zero uses the configured TTL).
+// A non-empty ghostID restricts the token to that identity.
+func (s *Service) MintJoinToken(ghostID string, ttl time.Duration) (session.JoinToken, time.Time, error) {
+	e := s.enroll
+	if e == nil {
+		return session.JoinToken{}, time.Time{}, ErrEnrollmentDisabled
+	}
+	if ttl <= 0 {
+		ttl = e.cfg.TokenTTL
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		return session.JoinToken{}, time.Time{}, err
+	}
+	secret, err := randomHex(16)
+	if err != nil {
+		return session.JoinToken{}, time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl)
+	e.mu.Lock()
+	e.pruneLocked(time.Now())
+	e.tokens[id] = joinTokenState{
+		secretHash: sha256.Sum256([]byte(secret)),
+		ghostID:    strings.TrimSpace(ghostID),
+		expiresAt:  expiresAt,
+	}
+	e.mu.Unlock()
+	logs.Infof("mirage.enroll minted join token id=%q ghost_id=%q expires_at=%s", id, ghostID, expiresAt.Format(time.RFC3339))
+	return session.JoinToken{ID: id, Secret: secret, CAHash: e.caHash}, expiresAt, nil
+}
+
+// Mirage one-time token redemption; the token is taken out only when it matches.
+// Callers hand it back with restore when signing fails, so the enrollee can retry.
+func (e *enrollment) redeem(req session.EnrollRequest, now time.Time) (joinTokenState, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pruneLocked(now)
+	tok, ok := e.tokens[req.TokenID]
+	if !ok {
+		return joinTokenState{}, fmt.Errorf("%w: unknown or expired token id=%q", ErrJoinTokenInvalid, req.TokenID)
+	}
+	hash := sha256.Sum256([]byte(req.TokenSecret))
+	if subtle.ConstantTimeCompare(hash[:], tok.secretHash[:]) != 1 {
+		return joinTokenState{}, fmt.Errorf("%w: secret mismatch id=%q", ErrJoinTokenInvalid, req.TokenID)
+	}
+	if tok.ghostID != "" && tok.ghostID != req.GhostID {
+		return joinTokenState{}, fmt.Errorf("%w: token bound to ghost_id=%q", ErrJoinTokenInvalid, tok.ghostID)
+	}
+	delete(e.tokens, req.TokenID)
+	return tok, nil
+}
+
+// Mirage return of a redeemed token after a failed signing; expired tokens stay gone.
+func (e *enrollment) restore(id string, tok joinTokenState, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if now.After(tok.expiresAt) {
+		return
+	}
+	e.tokens[id] = tok
+}
+
+// Mirage expired-token sweep.
+func (e *enrollment) pruneLocked(now time.Time) {
+	for id, tok := range e.tokens {
+		if now.After(tok.expiresAt) {
+			delete(e.tokens, id)
+		}
+	}
+}
+
+// Mirage ghost.enroll handler for a TLS peer that presented no client certificate.
+// The connection carries exactly one enrollment exchange and is then closed; the
+// Ghost reconnects with the issued certificate and registers normally.
+func (s *Service) handleEnrollment(conn net.Conn, reader *bufio.Reader) {
+	_ = conn.SetDeadline(time.Now().Add(s.cfg.Session.HandshakeTimeout))
+	now := time.Now()
+	const (
+		errorCodeTransportFailure   = 1000
+		errorCodeSemanticValidation = 1300
+	)
+	reject := func(ghostID string, code uint32, message string) {
+		if ghostID == "" {
+			ghostID = "unknown"
+		}
+		_ = session.WriteEnrollAck(conn, session.EnrollAck{
+			Status:      session.AckStatusRejected,
+			Code:        code,
+			Message:     message,
+			GhostID:     ghostID,
+			TimestampMS: uint64(now.UnixMilli()),
+		})
+	}
+
+	req, err := session.ReadEnrollRequest(reader)
+	if err != nil {
+		logs.Warnf("mirage.handleEnrollment read remote=%q err=%v", conn.RemoteAddr().String(), err)
+		reject("", errorCodeSemanticValidation, "invalid enrollment payload")
+		return
+	}
+	tok, err := s.enroll.redeem(req, now)
+	if err != nil {
+		logs.Warnf("mirage.handleEnrollment ghost_id=%q err=%v", req.GhostID, err)
+		reject(req.GhostID, errorCodeTransportFailure, "invalid join token")
+		return
+	}
+	entry, der, err := s.enroll.ca.SignGhostCSR(req.GhostID, req.CSR, s.enroll.cfg.CertValidity)
+	if err != nil {
+		s.enroll.restore(req.TokenID, tok, time.Now())
+	}
+	if errors.Is(err, pki.ErrCertActive) {
+		logs.Warnf("mirage.handleEnrollment ghost_id=%q already enrolled remote=%q err=%v", req.GhostID, conn.RemoteAddr().String(), err)
+		reject(req.GhostID, errorCodeTransportFailure, "ghost_id already enrolled")
+		return
+	}
+	if err != nil {
+		logs.Warnf("mirage.handleEnrollment sign ghost_id=%q err=%v", req.GhostID, err)
+		reject(req.GhostID, errorCodeSemanticValidation, "csr rejected")
+		return
+	}
+	logs.Warnf(
+		"mirage.handleEnrollment enrolled ghost_id=%q serial=%s not_after=%s remote=%q",
+		entry.Name,
+		entry.Serial,
+		entry.NotAfter.Format(time.RFC3339),
+		conn.RemoteAddr().String(),
+	)
+	if err := session.WriteEnrollAck(conn, session.EnrollAck{
+		Status:        session.AckStatusAccepted,
+		GhostID:       req.GhostID,
+		TimestampMS:   uint64(now.UnixMilli()),
+		Certificate:   der,
+		CACertificate: s.enroll.ca.Certificate().Raw,
+	}); err != nil {
+		logs.Warnf("mirage.handleEnrollment write ack ghost_id=%q err=%v", req.GhostID, err)
+	}
+}
+
+// Mirage random hex string of n bytes.
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
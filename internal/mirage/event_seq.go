@@ -0,0 +1,134 @@
+package mirage
+
+import "slices"
+
+// Mirage bound on seqs held above a gap. Ghost keeps at most event_window events
+// in flight, so a gap outliving this many later seqs will not be filled soon.
+const maxEventSeqAbove = 4096
+
+// Mirage inclusive range of seqs the watermark moved past without observing.
+type eventSeqRange struct {
+	from uint64
+	to   uint64
+}
+
+// Mirage per-Ghost event sequence watermark for one session epoch.
+// through is the highest seq observed or skipped; seqs accepted out of order
+// (pipelined delivery) wait in above until the gap closes or above outgrows
+// maxEventSeqAbove. The watermark then moves past the oldest gap, but the gap
+// is kept in missing so those seqs are still ingested when they arrive and are
+// never reported as seen or as part of the resume point.
+type eventSeqTracker struct {
+	epoch   uint64
+	through uint64
+	above   map[uint64]struct{}
+	missing []eventSeqRange
+}
+
+// Mirage tracker switch to a new epoch; a different epoch restarts the sequence.
+func (t *eventSeqTracker) resume(epoch uint64) {
+	if epoch == t.epoch {
+		return
+	}
+	t.epoch = epoch
+	t.through = 0
+	t.above = nil
+	t.missing = nil
+}
+
+// Mirage record of one accepted seq; seq 0 is unsequenced and ignored.
+// Returns how many missing seqs the watermark moved past to keep above bounded.
+func (t *eventSeqTracker) observe(seq uint64) uint64 {
+	if seq == 0 {
+		return 0
+	}
+	if seq <= t.through {
+		t.fill(seq)
+		return 0
+	}
+	if seq != t.through+1 {
+		if t.above == nil {
+			t.above = make(map[uint64]struct{})
+		}
+		t.above[seq] = struct{}{}
+		if len(t.above) <= maxEventSeqAbove {
+			return 0
+		}
+		lowest := seq
+		for pending := range t.above {
+			lowest = min(lowest, pending)
+		}
+		delete(t.above, lowest)
+		t.missing = append(t.missing, eventSeqRange{from: t.through + 1, to: lowest - 1})
+		skipped := lowest - t.through - 1
+		t.through = lowest
+		t.fold()
+		return skipped
+	}
+	t.through = seq
+	t.fold()
+	return 0
+}
+
+// Mirage watermark advance over seqs already held above it.
+func (t *eventSeqTracker) fold() {
+	for {
+		if _, ok := t.above[t.through+1]; !ok {
+			return
+		}
+		delete(t.above, t.through+1)
+		t.through++
+	}
+}
+
+// Mirage removal of a late seq from the missing ranges it was skipped in.
+func (t *eventSeqTracker) fill(seq uint64) {
+	for i, r := range t.missing {
+		if seq < r.from || seq > r.to {
+			continue
+		}
+		switch {
+		case r.from == r.to:
+			t.missing = slices.Delete(t.missing, i, i+1)
+		case seq == r.from:
+			t.missing[i].from++
+		case seq == r.to:
+			t.missing[i].to--
+		default:
+			t.missing[i].to = seq - 1
+			t.missing = slices.Insert(t.missing, i+1, eventSeqRange{from: seq + 1, to: r.to})
+		}
+		return
+	}
+}
+
+// Mirage check for a seq already observed in the current epoch.
+// Skipped seqs are not seen; they fall back to event_id dedupe.
+func (t *eventSeqTracker) seen(seq uint64) bool {
+	if seq == 0 {
+		return false
+	}
+	if seq <= t.through {
+		return !t.isMissing(seq)
+	}
+	_, ok := t.above[seq]
+	return ok
+}
+
+// Mirage check for a seq inside a skipped range.
+func (t *eventSeqTracker) isMissing(seq uint64) bool {
+	for _, r := range t.missing {
+		if seq >= r.from && seq <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// Mirage highest seq observed with no gap at or below it; the safe resume point.
+func (t *eventSeqTracker) contiguous() uint64 {
+	if len(t.missing) > 0 {
+		return t.missing[0].from - 1
+	}
+	return t.through
+}
@@ -0,0 +1,161 @@
+package mirage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/journal"
+	logs "github.com/danmuck/smplog"
+)
+
+// Mirage cadence for persisting advanced seq watermarks outside session teardown.
+const eventSeqFlushInterval = time.Second
+
+// Mirage persisted seq watermark for one Ghost epoch; the latest record per ghost_id wins.
+type eventSeqRecord struct {
+	GhostID string `json:"ghost_id"`
+	Epoch   uint64 `json:"epoch"`
+	LastSeq uint64 `json:"last_seq"`
+}
+
+// Mirage durable store for per-Ghost seq watermarks.
+// Registration acks only advertise a watermark once it is on disk here, so a
+// Ghost never drops outbox events that a restarted Mirage would not remember.
+type eventSeqStore struct {
+	mu      sync.Mutex
+	file    *journal.Journal
+	written map[string]eventSeqRecord
+}
+
+// Mirage seq store open at path, returning the watermarks recovered from it.
+func openEventSeqStore(path string) (*eventSeqStore, map[string]eventSeqRecord, error) {
+	written := make(map[string]eventSeqRecord)
+	err := journal.Replay(path, func(raw []byte) error {
+		var rec eventSeqRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+		if rec.GhostID != "" {
+			written[rec.GhostID] = rec
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("mirage: seq state: %w", err)
+	}
+	st := &eventSeqStore{written: written}
+	file, err := journal.Open(path, st.snapshotLocked())
+	if err != nil {
+		return nil, nil, fmt.Errorf("mirage: seq state: %w", err)
+	}
+	st.file = file
+	restored := make(map[string]eventSeqRecord, len(written))
+	for id, rec := range written {
+		restored[id] = rec
+	}
+	return st, restored, nil
+}
+
+// Mirage append of the watermarks that changed since the last write, fsynced once.
+// Returns the records now durable.
+func (st *eventSeqStore) write(marks []eventSeqRecord) ([]eventSeqRecord, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.file == nil {
+		// Sessions ending during shutdown may flush after the final close.
+		return nil, nil
+	}
+	changed := make([]eventSeqRecord, 0, len(marks))
+	for _, rec := range marks {
+		if prev, ok := st.written[rec.GhostID]; ok && prev == rec {
+			continue
+		}
+		changed = append(changed, rec)
+	}
+	for i, rec := range changed {
+		if err := st.file.Append(rec, i == len(changed)-1); err != nil {
+			return nil, err
+		}
+		st.written[rec.GhostID] = rec
+	}
+	if st.file.NeedsCompaction(len(st.written)) {
+		st.file.Compact(st.snapshotLocked())
+	}
+	return changed, nil
+}
+
+// Mirage latest watermark per Ghost; caller must hold mu.
+func (st *eventSeqStore) snapshotLocked() []any {
+	recs := make([]any, 0, len(st.written))
+	for _, rec := range st.written {
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// Mirage seq store close once the final flush is written.
+func (st *eventSeqStore) close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.file == nil {
+		return nil
+	}
+	err := st.file.Close()
+	st.file = nil
+	return err
+}
+
+// Mirage seq store open for Serve when SeqStatePath is set.
+func (s *Service) openEventSeqStore() error {
+	path := s.cfg.SeqStatePath
+	if path == "" {
+		return nil
+	}
+	st, restored, err := openEventSeqStore(path)
+	if err != nil {
+		return err
+	}
+	s.seqStore = st
+	s.server.restoreEventSeqs(restored)
+	logs.Infof("mirage.Service.openEventSeqStore path=%q ghosts=%d", path, len(restored))
+	return nil
+}
+
+// Mirage periodic watermark flush until ctx ends, then a final flush and close.
+func (s *Service) runEventSeqFlusher(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(eventSeqFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flushEventSeqs()
+		case <-ctx.Done():
+			s.flushEventSeqs()
+			if err := s.seqStore.close(); err != nil {
+				logs.Warnf("mirage.Service seq state close err=%v", err)
+			}
+			return
+		}
+	}
+}
+
+// Mirage write of advanced seq watermarks, then advertised as durable resume points.
+func (s *Service) flushEventSeqs() {
+	if s.seqStore == nil {
+		return
+	}
+	marks := s.server.eventSeqMarks()
+	if len(marks) == 0 {
+		return
+	}
+	written, err := s.seqStore.write(marks)
+	if err != nil {
+		logs.Warnf("mirage.Service seq state write err=%v", err)
+		return
+	}
+	s.server.markEventSeqsDurable(written)
+}
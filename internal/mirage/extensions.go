@@ -0,0 +1,58 @@
+package mirage
+
+import (
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	logs "github.com/danmuck/smplog"
+)
+
+// ExtensionHandler receives one validated extension message from a Ghost session.
+// It runs on the session read loop, so it must not block; fields are owned by the handler.
+type ExtensionHandler func(ghostID string, messageType uint32, fields []tlv.Field)
+
+// Schema returns the service-owned schema registry used to validate Ghost frames.
+func (s *Service) Schema() *schema.Registry {
+	return s.schema
+}
+
+// HandleExtension registers an extension message type on this service's registry
+// and routes inbound frames of that type to handler.
+func (s *Service) HandleExtension(messageType uint32, reqs []schema.Requirement, handler ExtensionHandler) error {
+	if err := s.schema.Register(messageType, reqs); err != nil {
+		return err
+	}
+	s.extMu.Lock()
+	s.extHandlers[messageType] = handler
+	s.extMu.Unlock()
+	return nil
+}
+
+// Mirage extension frame validation and handler dispatch; invalid frames get an error envelope.
+func (s *Service) dispatchExtension(ghostID string, ghostSess *ghostSession, fr frame.Frame) {
+	fields, err := session.DecodeExtensionFrame(s.schema, fr)
+	if err != nil {
+		logs.Warnf(
+			"mirage.handleConn decode extension ghost_id=%q message_type=%d err=%v",
+			ghostID,
+			fr.Header.MessageType,
+			err,
+		)
+		ghostSess.sendError(session.NewErrorEnvelope(
+			session.ErrorCodeFor(err),
+			fr.Header.MessageID,
+			fr.Header.MessageType,
+			err.Error(),
+		))
+		return
+	}
+	s.extMu.RLock()
+	handler := s.extHandlers[fr.Header.MessageType]
+	s.extMu.RUnlock()
+	if handler == nil {
+		logs.Debugf("mirage.handleConn extension without handler ghost_id=%q message_type=%d", ghostID, fr.Header.MessageType)
+		return
+	}
+	handler(ghostID, fr.Header.MessageType, fields)
+}
@@ -2,7 +2,6 @@ package mirage
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -126,7 +125,7 @@ func (c *GhostControlClient) ExecuteAdminCommand(ctx context.Context, command gh
 	}, &out); err != nil {
 		return session.Event{}, err
 	}
-	fr, err := frame.ReadFrame(bytes.NewReader(out.EventFrame), frame.DefaultLimits())
+	fr, err := frame.DecodeMessage(out.EventFrame, frame.DefaultLimits())
 	if err != nil {
 		return session.Event{}, err
 	}
@@ -0,0 +1,237 @@
+package mirage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+var (
+	ErrGhostSessionClosed    = errors.New("mirage: ghost session closed")
+	ErrCommandAlreadyPending = errors.New("mirage: command already in flight")
+)
+
+// Mirage terminal outcome for one pushed command: an event or a Ghost error envelope.
+type commandResult struct {
+	event session.Event
+	err   error
+}
+
+// Mirage registered Ghost session used as the command executor for one ghost_id.
+// Commands are pushed as MsgCommand frames; terminal events are correlated by command_id.
+type ghostSession struct {
+	ghostID       string
+	conn          net.Conn
+	writeTimeout  time.Duration
+	wire          ghostWire
+	writeMu       sync.Mutex
+	enc           *frame.Encoder
+	nextMessageID atomic.Uint64
+
+	mu          sync.Mutex
+	waiters     map[string]chan commandResult
+	byMessageID map[uint64]string
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Mirage per-stream wire settings fixed at registration.
+// A nil auth leaves outbound frames unsigned.
+type ghostWire struct {
+	auth              frame.Authenticator
+	version           uint16
+	compression       string
+	compressThreshold int
+}
+
+// Mirage ghost-session constructor bound to one registered stream.
+func newGhostSession(ghostID string, conn net.Conn, writeTimeout time.Duration, wire ghostWire) *ghostSession {
+	gs := &ghostSession{
+		ghostID:      strings.TrimSpace(ghostID),
+		conn:         conn,
+		writeTimeout: writeTimeout,
+		wire:         wire,
+		enc: frame.NewEncoderWithOptions(conn, frame.DefaultLimits(), session.WireEncoderOptions(
+			wire.version,
+			wire.compression,
+			wire.compressThreshold,
+			wire.auth,
+		)),
+		waiters:     make(map[string]chan commandResult),
+		byMessageID: make(map[uint64]string),
+		done:        make(chan struct{}),
+	}
+	gs.nextMessageID.Store(uint64(time.Now().UnixNano()))
+	return gs
+}
+
+// ExecuteCommand pushes one command frame to Ghost and waits for its terminal event.
+// A Ghost error envelope for the command frame is returned as session.ErrorEnvelope.
+func (g *ghostSession) ExecuteCommand(ctx context.Context, cmd session.Command) (session.Event, error) {
+	commandID := strings.TrimSpace(cmd.CommandID)
+	messageID := g.nextMessageID.Add(1)
+	waiter := make(chan commandResult, 1)
+
+	g.mu.Lock()
+	select {
+	case <-g.done:
+		g.mu.Unlock()
+		return session.Event{}, ErrGhostSessionClosed
+	default:
+	}
+	if _, exists := g.waiters[commandID]; exists {
+		g.mu.Unlock()
+		return session.Event{}, fmt.Errorf("%w: command_id=%q", ErrCommandAlreadyPending, commandID)
+	}
+	g.waiters[commandID] = waiter
+	g.byMessageID[messageID] = commandID
+	g.mu.Unlock()
+
+	msg, err := session.NewCommandFrame(g.wire.version, messageID, cmd)
+	if err != nil {
+		g.dropWaiter(commandID, messageID, waiter)
+		return session.Event{}, err
+	}
+	if err := g.writeFrame(msg); err != nil {
+		g.dropWaiter(commandID, messageID, waiter)
+		return session.Event{}, err
+	}
+	logs.Debugf(
+		"mirage.ghostSession.ExecuteCommand sent ghost_id=%q command_id=%q message_id=%d",
+		g.ghostID,
+		commandID,
+		messageID,
+	)
+
+	select {
+	case res := <-waiter:
+		return res.event, res.err
+	case <-ctx.Done():
+		if res, ok := g.dropWaiter(commandID, messageID, waiter); ok {
+			return res.event, res.err
+		}
+		return session.Event{}, ctx.Err()
+	case <-g.done:
+		if res, ok := g.dropWaiter(commandID, messageID, waiter); ok {
+			return res.event, res.err
+		}
+		return session.Event{}, ErrGhostSessionClosed
+	}
+}
+
+// Mirage inbound event routing to a pending command waiter.
+// Returns false when no ExecuteCommand call is waiting on the event command_id.
+func (g *ghostSession) deliverEvent(event session.Event) bool {
+	commandID := strings.TrimSpace(event.CommandID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	waiter, ok := g.waiters[commandID]
+	if !ok {
+		return false
+	}
+	g.releaseLocked(commandID)
+	waiter <- commandResult{event: event}
+	return true
+}
+
+// Mirage inbound error-envelope routing to the waiter that sent message_id.
+// Returns false when the envelope does not reference a pending command frame.
+// An in-flight reply keeps the waiter: the original push's terminal event resolves it.
+func (g *ghostSession) deliverError(env session.ErrorEnvelope) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	commandID, ok := g.byMessageID[env.MessageID]
+	if !ok {
+		return false
+	}
+	waiter, ok := g.waiters[commandID]
+	if !ok {
+		delete(g.byMessageID, env.MessageID)
+		return false
+	}
+	if env.Code == session.ErrorCodeCommandInFlight {
+		logs.Debugf("mirage.ghostSession.deliverError command in flight ghost_id=%q command_id=%q", g.ghostID, commandID)
+		return true
+	}
+	g.releaseLocked(commandID)
+	waiter <- commandResult{err: env}
+	return true
+}
+
+// Mirage waiter removal that also returns a result delivered before removal.
+func (g *ghostSession) dropWaiter(commandID string, messageID uint64, waiter chan commandResult) (commandResult, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if current, ok := g.waiters[commandID]; ok && current == waiter {
+		delete(g.waiters, commandID)
+		delete(g.byMessageID, messageID)
+		return commandResult{}, false
+	}
+	select {
+	case res := <-waiter:
+		return res, true
+	default:
+		return commandResult{}, false
+	}
+}
+
+// Mirage waiter bookkeeping release; caller must hold g.mu.
+func (g *ghostSession) releaseLocked(commandID string) {
+	delete(g.waiters, commandID)
+	for id, cmd := range g.byMessageID {
+		if cmd == commandID {
+			delete(g.byMessageID, id)
+		}
+	}
+}
+
+// Mirage serialized frame writer shared by command pushes and event acks.
+// msg is one built message; the session encoder compresses, versions, and signs
+// it as negotiated while building the outbound frames.
+func (g *ghostSession) writeFrame(msg frame.Frame) error {
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	_ = g.conn.SetWriteDeadline(time.Now().Add(g.writeTimeout))
+	return g.enc.EncodeMessage(msg)
+}
+
+// Mirage best-effort error envelope write to Ghost; failures are logged only.
+func (g *ghostSession) sendError(env session.ErrorEnvelope) {
+	msg, err := session.NewErrorFrame(env.MessageID, env)
+	if err != nil {
+		logs.Warnf("mirage.ghostSession encode error envelope ghost_id=%q err=%v", g.ghostID, err)
+		return
+	}
+	if err := g.writeFrame(msg); err != nil {
+		logs.Debugf("mirage.ghostSession write error envelope ghost_id=%q err=%v", g.ghostID, err)
+	}
+}
+
+// Mirage registration ack write ordered ahead of any pushed command frame.
+func (g *ghostSession) writeRegistrationAck(ack session.RegistrationAck) error {
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	return session.WriteRegistrationAck(g.conn, ack)
+}
+
+// Mirage session shutdown that releases all pending command waiters.
+func (g *ghostSession) close() {
+	g.closeOnce.Do(func() {
+		g.mu.Lock()
+		close(g.done)
+		g.mu.Unlock()
+		g.writeMu.Lock()
+		g.enc.Release()
+		g.writeMu.Unlock()
+	})
+}
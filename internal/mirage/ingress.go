@@ -0,0 +1,196 @@
+package mirage
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	logs "github.com/danmuck/smplog"
+)
+
+// Mirage per-Ghost event ingress limits.
+// EventsPerSecond and Burst size a token bucket shared by every session of one Ghost;
+// QueueDepth bounds events waiting for ingestion on one session.
+type IngressConfig struct {
+	EventsPerSecond float64
+	Burst           int
+	QueueDepth      int
+}
+
+// Mirage ingress defaults sized well above steady-state Ghost event rates.
+func DefaultIngressConfig() IngressConfig {
+	return IngressConfig{
+		EventsPerSecond: 200,
+		Burst:           400,
+		QueueDepth:      64,
+	}
+}
+
+// Mirage ingress config with zero values replaced by defaults.
+func (c IngressConfig) WithDefaults() IngressConfig {
+	def := DefaultIngressConfig()
+	if c.EventsPerSecond <= 0 {
+		c.EventsPerSecond = def.EventsPerSecond
+	}
+	if c.Burst <= 0 {
+		c.Burst = def.Burst
+	}
+	if c.QueueDepth <= 0 {
+		c.QueueDepth = def.QueueDepth
+	}
+	return c
+}
+
+// Mirage token bucket refilled continuously at rate tokens per second up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Mirage token bucket constructor starting full.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Mirage token take; returns 0 when a token was consumed, otherwise the wait until one refills.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Mirage event decoded by the session reader and waiting for ingestion.
+// Only the message_id is kept because frames alias the decoder buffer.
+type ingressEvent struct {
+	messageID uint64
+	event     session.Event
+}
+
+// Mirage token bucket lookup; buckets outlive sessions so reconnect storms stay limited.
+func (s *Service) ingressBucket(ghostID string) *tokenBucket {
+	s.ingressMu.Lock()
+	defer s.ingressMu.Unlock()
+	bucket, ok := s.ingressBuckets[ghostID]
+	if !ok {
+		bucket = newTokenBucket(s.cfg.Ingress.EventsPerSecond, s.cfg.Ingress.Burst)
+		s.ingressBuckets[ghostID] = bucket
+	}
+	return bucket
+}
+
+// Mirage ingress admission for one event on the session reader goroutine.
+// Returns false with a retry hint when the Ghost is over its rate or its queue is full.
+func (s *Service) admitEvent(bucket *tokenBucket, queue chan<- ingressEvent, in ingressEvent) (time.Duration, bool) {
+	if len(queue) == cap(queue) {
+		// Drain time for the backlog at the configured rate.
+		return time.Duration(float64(cap(queue)) / s.cfg.Ingress.EventsPerSecond * float64(time.Second)), false
+	}
+	if wait := bucket.take(time.Now()); wait > 0 {
+		return wait, false
+	}
+	// The reader is the only producer, so the capacity check above guarantees room.
+	queue <- in
+	return 0, true
+}
+
+// Mirage ingress admission for Ghosts that did not negotiate the throttle capability.
+// Those Ghosts treat any non-accepted ack as final, so the reader waits for a token and
+// queue room instead; the unread socket pushes back on the Ghost.
+// Returns false once ingestion has stopped.
+func (s *Service) admitEventBlocking(ghostID string, bucket *tokenBucket, queue chan<- ingressEvent, in ingressEvent, stopped <-chan struct{}) bool {
+	throttled := false
+	for {
+		wait := bucket.take(time.Now())
+		if wait == 0 {
+			break
+		}
+		if !throttled {
+			throttled = true
+			s.server.ObserveThrottle(ghostID)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-stopped:
+			timer.Stop()
+			return false
+		}
+	}
+	select {
+	case queue <- in:
+		return true
+	default:
+	}
+	if !throttled {
+		s.server.ObserveThrottle(ghostID)
+	}
+	select {
+	case queue <- in:
+		return true
+	case <-stopped:
+		return false
+	}
+}
+
+// Mirage immediate event.ack for a resend of an already accepted event.
+// Runs on the reader before admission, so duplicates never spend ingress tokens.
+func (s *Service) ackDuplicateEvent(ghostID string, ghostSess *ghostSession, in ingressEvent) (bool, error) {
+	ack, duplicate := s.server.DuplicateEvent(ghostID, in.event)
+	if !duplicate {
+		return false, nil
+	}
+	logs.Debugf(
+		"mirage.ackDuplicateEvent ghost_id=%q event_id=%q seq=%d",
+		ghostID,
+		in.event.EventID,
+		in.event.Seq,
+	)
+	msg, err := session.NewEventAckFrame(in.messageID, ack)
+	if err != nil {
+		return true, fmt.Errorf("encode event.ack: %w", err)
+	}
+	if err := ghostSess.writeFrame(msg); err != nil {
+		return true, fmt.Errorf("write event.ack: %w", err)
+	}
+	return true, nil
+}
+
+// Mirage throttled event.ack telling the Ghost to resend after retryAfter.
+func (s *Service) throttleEvent(ghostID string, ghostSess *ghostSession, in ingressEvent, retryAfter time.Duration) error {
+	s.server.ObserveThrottle(ghostID)
+	retryMS := uint64(retryAfter.Milliseconds())
+	if retryMS == 0 {
+		retryMS = 1
+	}
+	msg, err := session.NewEventAckFrame(in.messageID, session.EventAck{
+		EventID:      in.event.EventID,
+		CommandID:    in.event.CommandID,
+		GhostID:      ghostID,
+		AckStatus:    session.AckStatusThrottled,
+		AckCode:      session.ErrorCodeTransportThrottled,
+		RetryAfterMS: retryMS,
+		TimestampMS:  uint64(time.Now().UnixMilli()),
+	})
+	if err != nil {
+		return err
+	}
+	return ghostSess.writeFrame(msg)
+}
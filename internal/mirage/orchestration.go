@@ -1,17 +1,14 @@
 package mirage
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/danmuck/edgectl/internal/protocol/frame"
 	"github.com/danmuck/edgectl/internal/protocol/session"
 )
 
@@ -29,8 +26,10 @@ const (
 	CompletionSatisfied  = "satisfied"
 	CompletionFailed     = "failed"
 
-	OutcomeSuccess = "success"
-	OutcomeError   = "error"
+	OutcomeSuccess   = "success"
+	OutcomeError     = "error"
+	OutcomeTimeout   = "timeout"
+	OutcomeCancelled = "cancelled"
 )
 
 // IssueCommand is one desired command step inside a complex intent plan.
@@ -111,11 +110,16 @@ type PlannedCommand struct {
 }
 
 // ObservedIntent stores event/report history for one intent reconcile flow.
+// Progress holds the most recent non-terminal progress records, oldest first.
 type ObservedIntent struct {
-	Events      []session.Event
-	Reports     []session.Report
-	ObservedAt  time.Time
-	ByCommandID map[string]session.Event
+	Events         []session.Event
+	Reports        []session.Report
+	Progress       []ObservedProgress
+	ProgressCursor uint64
+	ObservedAt     time.Time
+	ByCommandID    map[string]session.Event
+
+	progressSeq map[string]uint64
 }
 
 // IntentSnapshot is a read-only projection of desired and optional observed state.
@@ -138,7 +142,6 @@ type Orchestrator struct {
 	observed  map[string]*ObservedIntent
 	executors map[string]CommandExecutor
 	seedLocks map[string]seedLock
-	seq       atomic.Uint64
 }
 
 type seedLock struct {
@@ -199,6 +202,38 @@ func (o *Orchestrator) RegisterExecutor(ghostID string, exec CommandExecutor) er
 	return nil
 }
 
+// BindExecutor installs exec for ghost_id and returns the adapter it replaced.
+func (o *Orchestrator) BindExecutor(ghostID string, exec CommandExecutor) (CommandExecutor, error) {
+	key := strings.TrimSpace(ghostID)
+	if key == "" {
+		return nil, ErrTargetGhostRequired
+	}
+	if exec == nil {
+		return nil, fmt.Errorf("mirage: nil executor for ghost %q", key)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	prev := o.executors[key]
+	o.executors[key] = exec
+	return prev, nil
+}
+
+// ReleaseExecutor restores fallback for ghost_id only while exec is still bound.
+func (o *Orchestrator) ReleaseExecutor(ghostID string, exec CommandExecutor, fallback CommandExecutor) bool {
+	key := strings.TrimSpace(ghostID)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.executors[key] != exec {
+		return false
+	}
+	if fallback == nil {
+		delete(o.executors, key)
+		return true
+	}
+	o.executors[key] = fallback
+	return true
+}
+
 // SubmitIssue validates, normalizes, and persists desired state for one intent.
 func (o *Orchestrator) SubmitIssue(issue IssueEnv) error {
 	if err := issue.Validate(); err != nil {
@@ -372,52 +407,32 @@ func (o *Orchestrator) IngestObservedEvent(event session.Event) (session.Report,
 	return report, true, nil
 }
 
-// dispatchCommandEnvelope round-trips command payload through protocol framing.
+// dispatchCommandEnvelope validates the command envelope handed to the executor.
+// Args is never nil, matching a command decoded from the wire.
 func (o *Orchestrator) dispatchCommandEnvelope(command session.Command) (session.Command, error) {
-	payload, err := session.EncodeCommandFrame(o.seq.Add(1), command)
-	if err != nil {
+	if err := command.Validate(); err != nil {
 		return session.Command{}, err
 	}
-	fr, err := frame.ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
-	if err != nil {
-		return session.Command{}, err
+	if command.Args == nil {
+		command.Args = map[string]string{}
 	}
-	return session.DecodeCommandFrame(fr)
+	return command, nil
 }
 
-// ingestEventEnvelopeAndBuildReport ingests a framed event and emits a framed report.
+// ingestEventEnvelopeAndBuildReport validates an executor event and builds its report.
 func (o *Orchestrator) ingestEventEnvelopeAndBuildReport(
 	desired DesiredIntent,
 	observed *ObservedIntent,
 	event session.Event,
 ) (session.Report, session.Event, error) {
-	eventPayload, err := session.EncodeEventFrame(o.seq.Add(1), event)
-	if err != nil {
-		return session.Report{}, session.Event{}, err
-	}
-	eventFrame, err := frame.ReadFrame(bytes.NewReader(eventPayload), frame.DefaultLimits())
-	if err != nil {
-		return session.Report{}, session.Event{}, err
-	}
-	ingestedEvent, err := session.DecodeEventFrame(eventFrame)
-	if err != nil {
-		return session.Report{}, session.Event{}, err
-	}
-
-	report := buildReportFromObserved(desired, observed, ingestedEvent)
-	reportPayload, err := session.EncodeReportFrame(o.seq.Add(1), report)
-	if err != nil {
+	if err := event.Validate(); err != nil {
 		return session.Report{}, session.Event{}, err
 	}
-	reportFrame, err := frame.ReadFrame(bytes.NewReader(reportPayload), frame.DefaultLimits())
-	if err != nil {
-		return session.Report{}, session.Event{}, err
-	}
-	wireReport, err := session.DecodeReportFrame(reportFrame)
-	if err != nil {
+	report := buildReportFromObserved(desired, observed, event)
+	if err := report.Validate(); err != nil {
 		return session.Report{}, session.Event{}, err
 	}
-	return wireReport, ingestedEvent, nil
+	return report, event, nil
 }
 
 // normalizeIssueToCommands maps issue text or explicit command_plan to command steps.
@@ -493,7 +508,8 @@ func buildReportFromObserved(desired DesiredIntent, observed *ObservedIntent, ev
 		total,
 		event.GhostID,
 	)
-	if event.Outcome == OutcomeError {
+	if event.Outcome != OutcomeSuccess {
+		// error, timeout, and cancelled all fail the intent.
 		phase = ReportPhaseComplete
 		completion = CompletionFailed
 		summary = fmt.Sprintf("intent %s failed on %s", desired.Issue.IntentID, event.GhostID)
@@ -562,10 +578,12 @@ func pendingCommandCount(commands []PlannedCommand, byCommandID map[string]sessi
 // cloneObserved returns a defensive copy of observed state maps/slices.
 func cloneObserved(in ObservedIntent) ObservedIntent {
 	out := ObservedIntent{
-		Events:      append([]session.Event{}, in.Events...),
-		Reports:     append([]session.Report{}, in.Reports...),
-		ObservedAt:  in.ObservedAt,
-		ByCommandID: make(map[string]session.Event, len(in.ByCommandID)),
+		Events:         append([]session.Event{}, in.Events...),
+		Reports:        append([]session.Report{}, in.Reports...),
+		Progress:       append([]ObservedProgress{}, in.Progress...),
+		ProgressCursor: in.ProgressCursor,
+		ObservedAt:     in.ObservedAt,
+		ByCommandID:    make(map[string]session.Event, len(in.ByCommandID)),
 	}
 	for k, v := range in.ByCommandID {
 		out.ByCommandID[k] = v
@@ -21,8 +21,11 @@ type fakeExecutor struct {
 func (e *fakeExecutor) ExecuteCommand(_ context.Context, cmd session.Command) (session.Event, error) {
 	e.count++
 	outcome := OutcomeSuccess
-	if cmd.Operation == "fail" {
+	switch cmd.Operation {
+	case "fail":
 		outcome = OutcomeError
+	case "hang":
+		outcome = OutcomeTimeout
 	}
 	return session.Event{
 		EventID:     fmt.Sprintf("evt.%s", cmd.CommandID),
@@ -76,6 +79,37 @@ func TestOrchestratorMultiCommandProgressAndComplete(t *testing.T) {
 	}
 }
 
+func TestOrchestratorTimeoutOutcomeFailsIntent(t *testing.T) {
+	testlog.Start(t)
+
+	loop := NewOrchestrator()
+	if err := loop.RegisterExecutor("ghost.alpha", &fakeExecutor{}); err != nil {
+		t.Fatalf("register executor: %v", err)
+	}
+	if err := loop.SubmitIssue(IssueEnv{
+		IntentID:    "intent.timeout",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "timeout fails intent",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "hang"},
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+	rep, err := loop.ReconcileOnce(context.Background(), "intent.timeout")
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if rep.Phase != ReportPhaseComplete || rep.CompletionState != CompletionFailed {
+		t.Fatalf("expected failed completion, got phase=%q completion=%q", rep.Phase, rep.CompletionState)
+	}
+	if rep.Outcome != OutcomeTimeout {
+		t.Fatalf("unexpected report outcome: %q", rep.Outcome)
+	}
+}
+
 func TestOrchestratorBlockingSeedLockAcrossIntents(t *testing.T) {
 	testlog.Start(t)
 
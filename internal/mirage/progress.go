@@ -0,0 +1,101 @@
+package mirage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/session"
+)
+
+// Progress records kept per intent; the oldest are dropped first.
+const maxObservedProgress = 512
+
+// ObservedProgress is one Ghost progress record attached to an intent.
+// Cursor increases per intent and survives trimming, so tails resume where they left off.
+type ObservedProgress struct {
+	Cursor uint64 `json:"cursor"`
+	session.Progress
+}
+
+// ProgressTail is one page of intent progress after a caller-held cursor.
+// Complete reports that the intent has reached a terminal report phase.
+type ProgressTail struct {
+	Records  []ObservedProgress `json:"records"`
+	Cursor   uint64             `json:"cursor"`
+	Complete bool               `json:"complete"`
+}
+
+// IngestProgress attaches a Ghost progress record to its intent's observed state.
+// Returns false when the command is unknown; records for commands that already have a
+// terminal event, and repeated or stale seq numbers, are dropped.
+func (o *Orchestrator) IngestProgress(p session.Progress) (bool, error) {
+	if err := p.Validate(); err != nil {
+		return false, err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	intentID, plan, ok := o.findPlannedCommandByCommandID(p.CommandID)
+	if !ok {
+		return false, nil
+	}
+	if strings.TrimSpace(p.IntentID) != intentID || strings.TrimSpace(p.GhostID) != plan.Command.GhostID {
+		return true, fmt.Errorf(
+			"mirage: progress identity mismatch command_id=%q intent_id=%q ghost_id=%q",
+			p.CommandID,
+			p.IntentID,
+			p.GhostID,
+		)
+	}
+	observed := ensureObservedLocked(o, intentID)
+	if _, done := observed.ByCommandID[p.CommandID]; done {
+		return true, nil
+	}
+	if observed.progressSeq == nil {
+		observed.progressSeq = make(map[string]uint64)
+	}
+	if p.Seq <= observed.progressSeq[p.CommandID] {
+		return true, nil
+	}
+	observed.progressSeq[p.CommandID] = p.Seq
+
+	observed.ProgressCursor++
+	observed.Progress = append(observed.Progress, ObservedProgress{Cursor: observed.ProgressCursor, Progress: p})
+	if over := len(observed.Progress) - maxObservedProgress; over > 0 {
+		observed.Progress = append(observed.Progress[:0:0], observed.Progress[over:]...)
+	}
+	observed.ObservedAt = time.Now()
+	return true, nil
+}
+
+// TailProgress returns up to limit progress records with cursor greater than after.
+// limit <= 0 returns every retained record past the cursor.
+func (o *Orchestrator) TailProgress(intentID string, after uint64, limit int) (ProgressTail, error) {
+	key := strings.TrimSpace(intentID)
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if _, ok := o.desired[key]; !ok {
+		return ProgressTail{}, fmt.Errorf("%w: %s", ErrIntentNotFound, key)
+	}
+	out := ProgressTail{Cursor: after}
+	observed := o.observed[key]
+	if observed == nil {
+		return out, nil
+	}
+	for _, rec := range observed.Progress {
+		if rec.Cursor <= after {
+			continue
+		}
+		if limit > 0 && len(out.Records) >= limit {
+			break
+		}
+		out.Records = append(out.Records, rec)
+		out.Cursor = rec.Cursor
+	}
+	if n := len(observed.Reports); n > 0 {
+		out.Complete = observed.Reports[n-1].Phase == ReportPhaseComplete
+	}
+	return out, nil
+}
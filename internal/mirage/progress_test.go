@@ -0,0 +1,101 @@
+package mirage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestOrchestratorIngestAndTailProgress(t *testing.T) {
+	testlog.Start(t)
+
+	loop := NewOrchestrator()
+	if err := loop.RegisterExecutor("ghost.alpha", &fakeExecutor{}); err != nil {
+		t.Fatalf("register executor: %v", err)
+	}
+	if err := loop.SubmitIssue(IssueEnv{
+		IntentID:    "intent.progress.1",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "install",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+
+	progress := func(seq uint64, percent uint32, chunk string) session.Progress {
+		p := session.Progress{
+			ExecutionID: "exec.cmd.intent.progress.1.1",
+			CommandID:   "cmd.intent.progress.1.1",
+			IntentID:    "intent.progress.1",
+			GhostID:     "ghost.alpha",
+			Seq:         seq,
+			Percent:     percent,
+			TimestampMS: uint64(time.Now().UnixMilli()),
+		}
+		if chunk != "" {
+			p.Stream = session.ProgressStreamStdout
+			p.Chunk = []byte(chunk)
+		}
+		return p
+	}
+	for _, p := range []session.Progress{
+		progress(1, 0, "fetching\n"),
+		progress(2, 40, ""),
+		progress(2, 40, ""),
+		progress(3, 80, "unpacking\n"),
+	} {
+		matched, err := loop.IngestProgress(p)
+		if err != nil || !matched {
+			t.Fatalf("ingest progress seq=%d: matched=%v err=%v", p.Seq, matched, err)
+		}
+	}
+	if matched, _ := loop.IngestProgress(session.Progress{
+		ExecutionID: "exec.unknown",
+		CommandID:   "cmd.unknown",
+		IntentID:    "intent.progress.1",
+		GhostID:     "ghost.alpha",
+		Seq:         1,
+		TimestampMS: 1,
+	}); matched {
+		t.Fatalf("unknown command should not match")
+	}
+
+	tail, err := loop.TailProgress("intent.progress.1", 0, 2)
+	if err != nil {
+		t.Fatalf("tail progress: %v", err)
+	}
+	if len(tail.Records) != 2 || tail.Cursor != 2 || tail.Complete {
+		t.Fatalf("unexpected first page: %+v", tail)
+	}
+	tail, err = loop.TailProgress("intent.progress.1", tail.Cursor, 0)
+	if err != nil {
+		t.Fatalf("tail progress: %v", err)
+	}
+	if len(tail.Records) != 1 || tail.Records[0].Seq != 3 || string(tail.Records[0].Chunk) != "unpacking\n" {
+		t.Fatalf("duplicate seq should be dropped, got %+v", tail.Records)
+	}
+
+	if _, err := loop.ReconcileOnce(context.Background(), "intent.progress.1"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := loop.IngestProgress(progress(4, 100, "")); err != nil {
+		t.Fatalf("late progress: %v", err)
+	}
+	tail, err = loop.TailProgress("intent.progress.1", tail.Cursor, 0)
+	if err != nil {
+		t.Fatalf("tail progress: %v", err)
+	}
+	if len(tail.Records) != 0 || !tail.Complete {
+		t.Fatalf("late progress should be dropped and intent complete, got %+v", tail)
+	}
+	if _, err := loop.TailProgress("intent.missing", 0, 0); !errors.Is(err, ErrIntentNotFound) {
+		t.Fatalf("expected ErrIntentNotFound, got %v", err)
+	}
+}
@@ -73,6 +73,9 @@ type Server struct {
 
 	reports []session.Report
 	spawner GhostSpawner
+
+	// Watermarks loaded from the seq store, applied when their Ghost next registers.
+	restoredSeq map[string]eventSeqRecord
 }
 
 // NewServer constructs Mirage server state in boot phase.
@@ -169,6 +172,11 @@ func (s *Server) UpsertRegistration(remoteAddr string, reg session.Registration)
 	state, ok := s.registry[reg.GhostID]
 	if !ok {
 		state = &registeredGhostState{ackByEvent: make(map[string]session.EventAck)}
+		if rec, ok := s.restoredSeq[reg.GhostID]; ok {
+			state.seq = eventSeqTracker{epoch: rec.Epoch, through: rec.LastSeq}
+			state.durableSeq = rec.LastSeq
+			delete(s.restoredSeq, reg.GhostID)
+		}
 		s.registry[reg.GhostID] = state
 	}
 	if state.meta.RegisteredAt.IsZero() {
@@ -178,14 +186,58 @@ func (s *Server) UpsertRegistration(remoteAddr string, reg session.Registration)
 	registered.LastEventAt = state.meta.LastEventAt
 	registered.EventCount = state.meta.EventCount
 	state.meta = registered
+	if state.seq.epoch != reg.SessionEpoch {
+		state.durableSeq = 0
+	}
+	state.seq.resume(reg.SessionEpoch)
+	lastSeq := min(state.durableSeq, state.seq.contiguous())
 	s.mu.Unlock()
 
 	return session.RegistrationAck{
-		Status:      session.AckStatusAccepted,
-		Code:        0,
-		Message:     "registered",
-		GhostID:     reg.GhostID,
-		TimestampMS: now,
+		Status:       session.AckStatusAccepted,
+		Code:         0,
+		Message:      "registered",
+		GhostID:      reg.GhostID,
+		TimestampMS:  now,
+		SessionEpoch: reg.SessionEpoch,
+		LastSeq:      lastSeq,
+	}
+}
+
+// Mirage load of persisted seq watermarks for Ghosts not yet registered.
+func (s *Server) restoreEventSeqs(recs map[string]eventSeqRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restoredSeq = recs
+}
+
+// Mirage resume points that advanced past what the seq store holds.
+func (s *Server) eventSeqMarks() []eventSeqRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []eventSeqRecord
+	for id, state := range s.registry {
+		if state.seq.epoch == 0 {
+			continue
+		}
+		if last := state.seq.contiguous(); last > state.durableSeq {
+			out = append(out, eventSeqRecord{GhostID: id, Epoch: state.seq.epoch, LastSeq: last})
+		}
+	}
+	return out
+}
+
+// Mirage durable resume points for watermarks the seq store has written.
+// A record from an epoch the Ghost has since left is ignored.
+func (s *Server) markEventSeqsDurable(recs []eventSeqRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range recs {
+		state, ok := s.registry[rec.GhostID]
+		if !ok || state.seq.epoch != rec.Epoch {
+			continue
+		}
+		state.durableSeq = max(state.durableSeq, rec.LastSeq)
 	}
 }
 
@@ -201,6 +253,55 @@ func (s *Server) MarkGhostDisconnected(ghostID string) {
 	state.meta.RemoteAddr = ""
 }
 
+// ObserveHeartbeat records one Ghost ping; rtt is the Ghost-measured round trip, 0 when unknown.
+func (s *Server) ObserveHeartbeat(ghostID string, rtt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.registry[ghostID]
+	if !ok {
+		return
+	}
+	state.meta.LastHeartbeatAt = time.Now()
+	if rtt > 0 {
+		state.meta.HeartbeatRTT = rtt
+	}
+}
+
+// ObserveThrottle counts one event answered with a throttled ack instead of being ingested.
+func (s *Server) ObserveThrottle(ghostID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.registry[ghostID]; ok {
+		state.meta.ThrottledCount++
+	}
+}
+
+// DuplicateEvent reports whether event was already accepted, by event_id or by its
+// seq in the current session epoch, and returns the ack to repeat without reprocessing.
+func (s *Server) DuplicateEvent(ghostID string, event session.Event) (session.EventAck, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.registry[ghostID]
+	if !ok {
+		return session.EventAck{}, false
+	}
+	if ack, ok := state.ackByEvent[event.EventID]; ok {
+		return ack, true
+	}
+	if !state.seq.seen(event.Seq) {
+		return session.EventAck{}, false
+	}
+	ack := session.EventAck{
+		EventID:     event.EventID,
+		CommandID:   event.CommandID,
+		GhostID:     ghostID,
+		AckStatus:   session.AckStatusAccepted,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	}
+	state.ackByEvent[event.EventID] = ack
+	return ack, true
+}
+
 // AcceptEvent ingests one event and returns deterministic idempotent event.ack.
 func (s *Server) AcceptEvent(ghostID string, event session.Event) session.EventAck {
 	s.mu.Lock()
@@ -228,6 +329,7 @@ func (s *Server) AcceptEvent(ghostID string, event session.Event) session.EventA
 		TimestampMS: uint64(time.Now().UnixMilli()),
 	}
 	state.ackByEvent[event.EventID] = ack
+	state.meta.SkippedSeqCount += state.seq.observe(event.Seq)
 	state.meta.LastEventAt = time.Now()
 	state.meta.EventCount++
 	return ack
@@ -238,6 +340,16 @@ func (s *Server) RegisterExecutor(ghostID string, exec CommandExecutor) error {
 	return s.loop.RegisterExecutor(ghostID, exec)
 }
 
+// BindExecutor installs a session-scoped executor and returns the replaced adapter.
+func (s *Server) BindExecutor(ghostID string, exec CommandExecutor) (CommandExecutor, error) {
+	return s.loop.BindExecutor(ghostID, exec)
+}
+
+// ReleaseExecutor restores fallback when exec is still bound for ghost_id.
+func (s *Server) ReleaseExecutor(ghostID string, exec CommandExecutor, fallback CommandExecutor) bool {
+	return s.loop.ReleaseExecutor(ghostID, exec, fallback)
+}
+
 // SubmitIssue ingests desired state into Mirage orchestration.
 func (s *Server) SubmitIssue(issue IssueEnv) error {
 	return s.loop.SubmitIssue(issue)
@@ -273,6 +385,16 @@ func (s *Server) ObserveEvent(event session.Event) (session.Report, bool, error)
 	return report, matched, nil
 }
 
+// ObserveProgress attaches a ghost progress record to its intent when the command is known.
+func (s *Server) ObserveProgress(p session.Progress) (bool, error) {
+	return s.loop.IngestProgress(p)
+}
+
+// TailProgress returns intent progress records recorded after cursor.
+func (s *Server) TailProgress(intentID string, after uint64, limit int) (ProgressTail, error) {
+	return s.loop.TailProgress(intentID, after, limit)
+}
+
 // RecentReports returns bounded report history for user-boundary emission.
 func (s *Server) RecentReports(limit int) []session.Report {
 	s.mu.RLock()
@@ -3,6 +3,8 @@ package mirage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -213,3 +215,151 @@ func TestServerSpawnLocalGhostViaSpawner(t *testing.T) {
 		t.Fatalf("unexpected ghost id: %q", out.GhostID)
 	}
 }
+
+func TestEventSeqTrackerNeverCountsSkippedGapAsSeen(t *testing.T) {
+	testlog.Start(t)
+
+	var tracker eventSeqTracker
+	tracker.resume(7)
+	tracker.observe(1)
+	// Seqs 2 and 3 were not delivered yet; later seqs pile up above the gap.
+	for seq := uint64(4); seq < 4+maxEventSeqAbove; seq++ {
+		if skipped := tracker.observe(seq); skipped != 0 {
+			t.Fatalf("gap skipped early at seq=%d", seq)
+		}
+	}
+	if tracker.through != 1 || len(tracker.above) != maxEventSeqAbove {
+		t.Fatalf("unexpected tracker before overflow: through=%d above=%d", tracker.through, len(tracker.above))
+	}
+	last := uint64(4 + maxEventSeqAbove)
+	if skipped := tracker.observe(last); skipped != 2 {
+		t.Fatalf("expected two skipped seqs, got %d", skipped)
+	}
+	if tracker.through != last || len(tracker.above) != 0 {
+		t.Fatalf("expected watermark past the gap: through=%d above=%d", tracker.through, len(tracker.above))
+	}
+	if tracker.seen(2) || tracker.seen(3) || !tracker.seen(4) || tracker.seen(last+1) {
+		t.Fatalf("skipped seqs must not count as seen")
+	}
+	if got := tracker.contiguous(); got != 1 {
+		t.Fatalf("expected resume point below the gap, got %d", got)
+	}
+
+	tracker.observe(3)
+	if !tracker.seen(3) || tracker.seen(2) || tracker.contiguous() != 1 {
+		t.Fatalf("unexpected tracker after late seq 3: missing=%+v", tracker.missing)
+	}
+	tracker.observe(2)
+	if !tracker.seen(2) || tracker.contiguous() != last || len(tracker.missing) != 0 {
+		t.Fatalf("expected gap closed: contiguous=%d missing=%+v", tracker.contiguous(), tracker.missing)
+	}
+}
+
+func TestServerIngestsEventWhoseSeqWasSkipped(t *testing.T) {
+	testlog.Start(t)
+
+	s := NewServer()
+	s.UpsertRegistration("127.0.0.1:1", session.Registration{GhostID: "ghost.alpha", SessionEpoch: 9})
+	event := func(seq uint64) session.Event {
+		return session.Event{
+			EventID:   fmt.Sprintf("evt.%d", seq),
+			CommandID: fmt.Sprintf("cmd.%d", seq),
+			GhostID:   "ghost.alpha",
+			Seq:       seq,
+			Outcome:   "success",
+		}
+	}
+	for seq := uint64(3); seq <= 3+maxEventSeqAbove; seq++ {
+		s.AcceptEvent("ghost.alpha", event(seq))
+	}
+	if _, duplicate := s.DuplicateEvent("ghost.alpha", event(2)); duplicate {
+		t.Fatalf("skipped seq must be ingested, not acked as a duplicate")
+	}
+	if marks := s.eventSeqMarks(); len(marks) != 0 {
+		t.Fatalf("resume point must stay below the skipped gap, got %+v", marks)
+	}
+	s.AcceptEvent("ghost.alpha", event(1))
+	s.AcceptEvent("ghost.alpha", event(2))
+	if marks := s.eventSeqMarks(); len(marks) != 1 || marks[0].LastSeq != 3+maxEventSeqAbove {
+		t.Fatalf("expected resume point after gap filled, got %+v", marks)
+	}
+}
+
+func TestServiceAdvertisesOnlyDurableSeqWatermark(t *testing.T) {
+	testlog.Start(t)
+
+	path := filepath.Join(t.TempDir(), "seq.log")
+	reg := session.Registration{GhostID: "ghost.alpha", SessionEpoch: 5}
+	event := func(seq uint64) session.Event {
+		return session.Event{EventID: fmt.Sprintf("evt.%d", seq), GhostID: "ghost.alpha", Seq: seq, Outcome: "success"}
+	}
+
+	memory := NewServiceWithConfig(ServiceConfig{})
+	memory.server.UpsertRegistration("127.0.0.1:1", reg)
+	memory.server.AcceptEvent("ghost.alpha", event(1))
+	memory.flushEventSeqs()
+	if ack := memory.server.UpsertRegistration("127.0.0.1:1", reg); ack.LastSeq != 0 {
+		t.Fatalf("in-memory watermark must not be advertised, got %d", ack.LastSeq)
+	}
+
+	first := NewServiceWithConfig(ServiceConfig{SeqStatePath: path})
+	if err := first.openEventSeqStore(); err != nil {
+		t.Fatalf("open seq store: %v", err)
+	}
+	first.server.UpsertRegistration("127.0.0.1:1", reg)
+	for seq := uint64(1); seq <= 3; seq++ {
+		first.server.AcceptEvent("ghost.alpha", event(seq))
+	}
+	if ack := first.server.UpsertRegistration("127.0.0.1:1", reg); ack.LastSeq != 0 {
+		t.Fatalf("unflushed watermark must not be advertised, got %d", ack.LastSeq)
+	}
+	first.flushEventSeqs()
+	if ack := first.server.UpsertRegistration("127.0.0.1:1", reg); ack.LastSeq != 3 {
+		t.Fatalf("expected durable last_seq=3, got %d", ack.LastSeq)
+	}
+	if err := first.seqStore.close(); err != nil {
+		t.Fatalf("close seq store: %v", err)
+	}
+
+	restarted := NewServiceWithConfig(ServiceConfig{SeqStatePath: path})
+	if err := restarted.openEventSeqStore(); err != nil {
+		t.Fatalf("reopen seq store: %v", err)
+	}
+	defer restarted.seqStore.close()
+	if ack := restarted.server.UpsertRegistration("127.0.0.1:1", reg); ack.LastSeq != 3 {
+		t.Fatalf("expected last_seq=3 after restart, got %d", ack.LastSeq)
+	}
+	if _, duplicate := restarted.server.DuplicateEvent("ghost.alpha", event(2)); !duplicate {
+		t.Fatalf("expected seq below the restored watermark acked as duplicate")
+	}
+	next := reg
+	next.SessionEpoch = 6
+	if ack := restarted.server.UpsertRegistration("127.0.0.1:1", next); ack.LastSeq != 0 {
+		t.Fatalf("expected a new epoch to restart at 0, got %d", ack.LastSeq)
+	}
+}
+
+func TestGhostSessionKeepsWaiterOnInFlightError(t *testing.T) {
+	testlog.Start(t)
+
+	gs := newGhostSession("ghost.alpha", nil, time.Second, ghostWire{})
+	waiter := make(chan commandResult, 1)
+	gs.waiters["cmd.dup"] = waiter
+	gs.byMessageID[1] = "cmd.dup"
+	gs.byMessageID[2] = "cmd.dup"
+
+	if !gs.deliverError(session.NewErrorEnvelope(session.ErrorCodeCommandInFlight, 2, 0, "in flight")) {
+		t.Fatalf("expected in-flight envelope routed to pending command")
+	}
+	select {
+	case res := <-waiter:
+		t.Fatalf("in-flight envelope resolved the command: %+v", res)
+	default:
+	}
+	if !gs.deliverEvent(session.Event{EventID: "evt.dup", CommandID: "cmd.dup"}) {
+		t.Fatalf("expected terminal event routed to pending command")
+	}
+	if res := <-waiter; res.err != nil || res.event.EventID != "evt.dup" {
+		t.Fatalf("unexpected command result: %+v", res)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"os"
 	"os/signal"
 	"sort"
 	"strings"
@@ -38,6 +37,11 @@ type ServiceConfig struct {
 	BuildlogKeyPrefix      string
 	RootGhostAdminAddr     string
 	Session                session.Config
+	Ingress                IngressConfig
+	Enrollment             EnrollmentConfig
+	// SeqStatePath persists per-Ghost event seq watermarks; empty keeps them in memory
+	// and registration acks advertise last_seq=0, so Ghosts replay their whole outbox.
+	SeqStatePath string
 }
 
 // Mirage service defaults for session endpoint configuration.
@@ -54,6 +58,8 @@ func DefaultServiceConfig() ServiceConfig {
 		BuildlogKeyPrefix:      "local/buildlogs/",
 		RootGhostAdminAddr:     "",
 		Session:                session.DefaultConfig(),
+		Ingress:                DefaultIngressConfig(),
+		Enrollment:             DefaultEnrollmentConfig(),
 	}
 }
 
@@ -66,6 +72,13 @@ type RegisteredGhost struct {
 	LastEventAt  time.Time
 	EventCount   uint64
 	Connected    bool
+	// Heartbeat pings are tracked apart from events and never counted as one.
+	LastHeartbeatAt time.Time
+	HeartbeatRTT    time.Duration
+	// Events answered with a throttled ack instead of being ingested.
+	ThrottledCount uint64
+	// Event seqs the watermark moved past before they arrived; they are still ingested if they do.
+	SkippedSeqCount uint64
 }
 
 // GhostRoute maps one ghost identity to its admin endpoint routing entry.
@@ -85,12 +98,19 @@ type AvailableService struct {
 type registeredGhostState struct {
 	meta       RegisteredGhost
 	ackByEvent map[string]session.EventAck
+	seq        eventSeqTracker
+	// Resume point last written to the seq store; the only last_seq advertised.
+	durableSeq uint64
 }
 
 // Mirage internal transport-authenticated peer identity details.
+// CertExpiry is the earliest NotAfter of the certificates the session was opened with.
+// Enrolling peers presented no client certificate and may only run ghost.enroll.
 type peerAuth struct {
 	PeerIdentity  string
 	Authenticated bool
+	Enrolling     bool
+	CertExpiry    time.Time
 }
 
 // Mirage runtime service for session and handshake contracts.
@@ -110,6 +130,19 @@ type Service struct {
 
 	adminGhostMu    sync.RWMutex
 	adminGhostAddrs map[string]string
+
+	frameAuth frame.Authenticator
+	tlsFiles  *session.TLSReloader
+	enroll    *enrollment
+
+	schema      *schema.Registry
+	extMu       sync.RWMutex
+	extHandlers map[uint32]ExtensionHandler
+
+	seqStore *eventSeqStore
+
+	ingressMu      sync.Mutex
+	ingressBuckets map[string]*tokenBucket
 }
 
 // Mirage service constructor using default configuration.
@@ -123,11 +156,16 @@ func NewServiceWithConfig(cfg ServiceConfig) *Service {
 		cfg.ListenAddr = DefaultServiceConfig().ListenAddr
 	}
 	cfg.Session = cfg.Session.WithDefaults()
+	cfg.Ingress = cfg.Ingress.WithDefaults()
+	cfg.Enrollment = cfg.Enrollment.WithDefaults()
 	svc := &Service{
 		cfg:             cfg,
 		server:          NewServer(),
 		conns:           make(map[net.Conn]struct{}),
 		adminGhostAddrs: make(map[string]string),
+		schema:          schema.NewRegistry(),
+		extHandlers:     make(map[uint32]ExtensionHandler),
+		ingressBuckets:  make(map[string]*tokenBucket),
 	}
 	localAdminAddr := strings.TrimSpace(cfg.LocalGhostAdminAddr)
 	if localAdminAddr == "" {
@@ -229,12 +267,25 @@ func (s *Service) Serve(ctx context.Context, ln net.Listener) error {
 	if err := s.cfg.Session.ValidateServerTransport(); err != nil {
 		return err
 	}
+	frameAuth, err := session.NewFrameAuthenticator(s.cfg.Session.Auth)
+	if err != nil {
+		return err
+	}
+	s.frameAuth = frameAuth
 	defer ln.Close()
+	if err := s.openEventSeqStore(); err != nil {
+		return err
+	}
 	go func() {
 		<-ctx.Done()
 		s.closeAllConns()
 		_ = ln.Close()
 	}()
+	if s.seqStore != nil {
+		flushed := make(chan struct{})
+		go s.runEventSeqFlusher(ctx, flushed)
+		defer func() { <-flushed }()
+	}
 
 	for {
 		conn, err := ln.Accept()
@@ -436,76 +487,350 @@ func (s *Service) handleConn(conn net.Conn) {
 		logs.Warnf("mirage.handleConn transport auth err=%v", err)
 		return
 	}
+	if auth.Enrolling {
+		s.handleEnrollment(conn, reader)
+		return
+	}
 
 	reg, ack := s.handleRegistration(conn, reader, auth)
 	if ack.Status != session.AckStatusAccepted {
 		_ = session.WriteRegistrationAck(conn, ack)
 		return
 	}
-	if err := session.WriteRegistrationAck(conn, ack); err != nil {
+	ghostSess := newGhostSession(reg.GhostID, conn, s.cfg.Session.WriteTimeout, ghostWire{
+		auth:              s.frameAuth,
+		version:           ack.ProtocolVersion,
+		compression:       ack.Compression,
+		compressThreshold: s.cfg.Session.Compression.Threshold,
+	})
+	defer ghostSess.close()
+	// Ghosts without push_command ignore command frames; their previous executor stays bound.
+	if ack.Supports(session.CapabilityPushCommand) {
+		prevExec, err := s.server.BindExecutor(reg.GhostID, ghostSess)
+		if err != nil {
+			logs.Errf("mirage.handleConn bind executor ghost_id=%q err=%v", reg.GhostID, err)
+			return
+		}
+		defer s.server.ReleaseExecutor(reg.GhostID, ghostSess, prevExec)
+	}
+
+	if err := ghostSess.writeRegistrationAck(ack); err != nil {
 		logs.Errf("mirage.handleConn write registration ack err=%v", err)
 		return
 	}
 	logs.Warnf("mirage.handleConn registered ghost_id=%q peer=%q", reg.GhostID, conn.RemoteAddr().String())
 	defer s.server.MarkGhostDisconnected(reg.GhostID)
+	// Persists the watermark of everything ingested before the Ghost shows as disconnected.
+	defer s.flushEventSeqs()
 
 	if err := conn.SetDeadline(time.Time{}); err != nil {
 		logs.Warnf("mirage.handleConn clear deadline err=%v", err)
 	}
+	if s.cfg.Session.TLS.RehandshakeOnExpiry && !auth.CertExpiry.IsZero() {
+		// Go TLS cannot renegotiate; closing makes the Ghost reconnect with rotated material.
+		expired := time.AfterFunc(time.Until(auth.CertExpiry), func() {
+			logs.Warnf("mirage.handleConn tls certificate expired ghost_id=%q not_after=%s", reg.GhostID, auth.CertExpiry)
+			_ = conn.Close()
+		})
+		defer expired.Stop()
+	}
 
+	limits := frame.DefaultLimits()
+	reasm := frame.NewReassembler(limits)
+	// Frames alias the decoder buffer; everything below copies out before the next read.
+	dec := frame.NewDecoder(reader, limits)
+	defer dec.Release()
+	bucket := s.ingressBucket(reg.GhostID)
+	queue := make(chan ingressEvent, s.cfg.Ingress.QueueDepth)
+	ingestDone := make(chan struct{})
+	go func() {
+		defer close(ingestDone)
+		s.ingestLoop(reg.GhostID, ghostSess, queue)
+	}()
+	// Runs before the session teardown above so queued events are ingested first.
+	defer func() {
+		close(queue)
+		<-ingestDone
+	}()
+	// Ghosts that did not negotiate heartbeat never ping; only ReadTimeout bounds them.
+	heartbeat := ack.Supports(session.CapabilityHeartbeat)
+	throttle := ack.Supports(session.CapabilityThrottle)
+	lastPing := time.Now()
 	for {
-		_ = conn.SetReadDeadline(time.Now().Add(s.cfg.Session.ReadTimeout))
-		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		_ = conn.SetReadDeadline(s.readDeadline(lastPing, heartbeat))
+		fr, err := dec.Decode()
 		if err != nil {
+			if since := time.Since(lastPing); heartbeat && since >= s.cfg.Session.SessionDeadAfter {
+				logs.Warnf("mirage.handleConn heartbeat expired ghost_id=%q last_ping_age=%s", reg.GhostID, since)
+			}
+			if code := session.ErrorCodeFor(err); session.ErrorClassForCode(code) == session.ErrorClassFraming {
+				// Stream alignment is lost; report once and close the session.
+				logs.Warnf("mirage.handleConn framing failure ghost_id=%q error_code=%d err=%v", reg.GhostID, code, err)
+				ghostSess.sendError(session.NewErrorEnvelope(code, 0, 0, err.Error()))
+			}
 			return
 		}
-		if fr.Header.MessageType != schema.MsgEvent {
+		if err := session.CheckFrameVersion(fr, ghostSess.wire.version); err != nil {
+			logs.Warnf("mirage.handleConn version mismatch ghost_id=%q err=%v", reg.GhostID, err)
+			ghostSess.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeFramingInvalidHeader,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				err.Error(),
+			))
+			return
+		}
+		if err := frame.VerifyFrame(fr, s.frameAuth, s.cfg.Session.Auth.Required); err != nil {
+			// Unauthenticated peers are dropped rather than answered per frame.
+			logs.Warnf("mirage.handleConn frame auth failure ghost_id=%q message_id=%d err=%v", reg.GhostID, fr.Header.MessageID, err)
+			ghostSess.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeTransportFailure,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				"frame auth failure",
+			))
+			return
+		}
+		fr, complete, err := reasm.Accept(fr)
+		if err != nil {
+			logs.Warnf("mirage.handleConn reassembly failure ghost_id=%q err=%v", reg.GhostID, err)
+			ghostSess.sendError(session.NewErrorEnvelope(session.ErrorCodeFor(err), 0, 0, err.Error()))
+			return
+		}
+		if !complete {
+			continue
+		}
+		inflated, err := session.DecompressFrame(fr, ghostSess.wire.compression, limits)
+		if err != nil {
+			code := session.ErrorCodeFor(err)
+			logs.Warnf("mirage.handleConn decompress failure ghost_id=%q error_code=%d err=%v", reg.GhostID, code, err)
+			ghostSess.sendError(session.NewErrorEnvelope(code, fr.Header.MessageID, fr.Header.MessageType, err.Error()))
+			if session.ErrorClassForCode(code) == session.ErrorClassFraming {
+				return
+			}
+			continue
+		}
+		fr = inflated
+		switch fr.Header.MessageType {
+		case schema.MsgEvent:
+		case schema.MsgProgress:
+			s.observeProgress(reg.GhostID, ghostSess, fr)
+			continue
+		case schema.MsgPing:
+			if s.answerPing(reg.GhostID, ghostSess, fr) {
+				lastPing = time.Now()
+			}
+			continue
+		case schema.MsgError:
+			env, err := session.DecodeErrorFrame(fr)
+			if err != nil {
+				logs.Warnf("mirage.handleConn decode error envelope ghost_id=%q err=%v", reg.GhostID, err)
+				continue
+			}
+			logs.Warnf(
+				"mirage.handleConn ghost error ghost_id=%q message_id=%d message_type=%d error_code=%d class=%s msg=%q",
+				reg.GhostID,
+				env.MessageID,
+				env.MessageType,
+				env.Code,
+				env.Class,
+				env.Message,
+			)
+			ghostSess.deliverError(env)
+			continue
+		default:
+			if s.schema.Extension(fr.Header.MessageType) {
+				s.dispatchExtension(reg.GhostID, ghostSess, fr)
+				continue
+			}
 			logs.Warnf(
 				"mirage.handleConn unexpected message_type=%d ghost_id=%q",
 				fr.Header.MessageType,
 				reg.GhostID,
 			)
-			return
+			ghostSess.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeSemanticValidationFailure,
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				fmt.Sprintf("unexpected message_type=%d", fr.Header.MessageType),
+			))
+			continue
 		}
 
 		event, err := session.DecodeEventFrame(fr)
 		if err != nil {
-			logs.Warnf("mirage.handleConn decode event err=%v", err)
+			logs.Warnf("mirage.handleConn decode event ghost_id=%q err=%v", reg.GhostID, err)
+			ghostSess.sendError(session.NewErrorEnvelope(
+				session.ErrorCodeFor(err),
+				fr.Header.MessageID,
+				fr.Header.MessageType,
+				err.Error(),
+			))
+			continue
+		}
+		in := ingressEvent{messageID: fr.Header.MessageID, event: event}
+		if duplicate, err := s.ackDuplicateEvent(reg.GhostID, ghostSess, in); err != nil {
+			logs.Warnf("mirage.handleConn duplicate event.ack ghost_id=%q err=%v", reg.GhostID, err)
 			return
+		} else if duplicate {
+			continue
 		}
-		if report, matched, err := s.server.ObserveEvent(event); err != nil {
-			logs.Warnf("mirage.handleConn observe event err=%v", err)
-		} else if matched {
-			logs.Warnf(
-				"mirage.handleConn report intent_id=%q phase=%q completion_state=%q command_id=%q event_id=%q",
-				report.IntentID,
-				report.Phase,
-				report.CompletionState,
-				report.CommandID,
-				report.EventID,
+		if !throttle {
+			if !s.admitEventBlocking(reg.GhostID, bucket, queue, in, ingestDone) {
+				return
+			}
+			continue
+		}
+		if retryAfter, ok := s.admitEvent(bucket, queue, in); !ok {
+			logs.Debugf(
+				"mirage.handleConn throttled event ghost_id=%q event_id=%q retry_after=%s queued=%d",
+				reg.GhostID,
+				event.EventID,
+				retryAfter,
+				len(queue),
 			)
-			s.persistBuildlog("event_report", map[string]any{
-				"intent_id":        report.IntentID,
-				"phase":            report.Phase,
-				"completion_state": report.CompletionState,
-				"command_id":       report.CommandID,
-				"event_id":         report.EventID,
-			})
-		}
-		ack := s.server.AcceptEvent(reg.GhostID, event)
-		ackPayload, err := session.EncodeEventAckFrame(fr.Header.MessageID, ack)
-		if err != nil {
-			logs.Warnf("mirage.handleConn encode event.ack err=%v", err)
-			return
+			if err := s.throttleEvent(reg.GhostID, ghostSess, in, retryAfter); err != nil {
+				logs.Warnf("mirage.handleConn write throttled event.ack err=%v", err)
+				return
+			}
 		}
-		_ = conn.SetWriteDeadline(time.Now().Add(s.cfg.Session.WriteTimeout))
-		if _, err := conn.Write(ackPayload); err != nil {
-			logs.Warnf("mirage.handleConn write event.ack err=%v", err)
+	}
+}
+
+// Mirage handling of one non-terminal progress frame; progress is never acknowledged.
+// Records for commands outside orchestration are dropped.
+func (s *Service) observeProgress(ghostID string, ghostSess *ghostSession, fr frame.Frame) {
+	p, err := session.DecodeProgressFrame(fr)
+	if err != nil {
+		logs.Warnf("mirage.handleConn decode progress ghost_id=%q err=%v", ghostID, err)
+		ghostSess.sendError(session.NewErrorEnvelope(
+			session.ErrorCodeFor(err),
+			fr.Header.MessageID,
+			fr.Header.MessageType,
+			err.Error(),
+		))
+		return
+	}
+	if p.GhostID != ghostID {
+		logs.Warnf("mirage.handleConn progress ghost_id mismatch session=%q progress=%q", ghostID, p.GhostID)
+		return
+	}
+	matched, err := s.server.ObserveProgress(p)
+	if err != nil {
+		logs.Warnf("mirage.handleConn observe progress ghost_id=%q command_id=%q err=%v", ghostID, p.CommandID, err)
+		return
+	}
+	if !matched {
+		logs.Debugf("mirage.handleConn progress unmatched ghost_id=%q command_id=%q seq=%d", ghostID, p.CommandID, p.Seq)
+	}
+}
+
+// Mirage per-session ingestion worker draining the bounded ingress queue.
+// Runs apart from the reader so heartbeats and throttling stay responsive under load.
+func (s *Service) ingestLoop(ghostID string, ghostSess *ghostSession, queue <-chan ingressEvent) {
+	for in := range queue {
+		if err := s.ingestEvent(ghostID, ghostSess, in); err != nil {
+			logs.Warnf("mirage.ingestLoop ghost_id=%q err=%v", ghostID, err)
+			// Ends the reader too; events still queued are dropped and resent by Ghost.
+			_ = ghostSess.conn.Close()
 			return
 		}
 	}
 }
 
+// Mirage ingestion of one admitted event and its event.ack reply.
+// Duplicates are checked again since a resend can be admitted while the original is queued.
+func (s *Service) ingestEvent(ghostID string, ghostSess *ghostSession, in ingressEvent) error {
+	event := in.event
+	ack, duplicate := s.server.DuplicateEvent(ghostID, event)
+	if duplicate {
+		logs.Debugf(
+			"mirage.ingestEvent duplicate event ghost_id=%q event_id=%q seq=%d",
+			ghostID,
+			event.EventID,
+			event.Seq,
+		)
+	} else if ghostSess.deliverEvent(event) {
+		logs.Debugf(
+			"mirage.ingestEvent delivered command event ghost_id=%q command_id=%q event_id=%q",
+			ghostID,
+			event.CommandID,
+			event.EventID,
+		)
+	} else if report, matched, err := s.server.ObserveEvent(event); err != nil {
+		logs.Warnf("mirage.ingestEvent observe event err=%v", err)
+	} else if matched {
+		logs.Warnf(
+			"mirage.handleConn report intent_id=%q phase=%q completion_state=%q command_id=%q event_id=%q",
+			report.IntentID,
+			report.Phase,
+			report.CompletionState,
+			report.CommandID,
+			report.EventID,
+		)
+		s.persistBuildlog("event_report", map[string]any{
+			"intent_id":        report.IntentID,
+			"phase":            report.Phase,
+			"completion_state": report.CompletionState,
+			"command_id":       report.CommandID,
+			"event_id":         report.EventID,
+		})
+	}
+	if !duplicate {
+		ack = s.server.AcceptEvent(ghostID, event)
+	}
+	ackMsg, err := session.NewEventAckFrame(in.messageID, ack)
+	if err != nil {
+		return fmt.Errorf("encode event.ack: %w", err)
+	}
+	if err := ghostSess.writeFrame(ackMsg); err != nil {
+		return fmt.Errorf("write event.ack: %w", err)
+	}
+	return nil
+}
+
+// Mirage per-read deadline: the stricter of ReadTimeout and SessionDeadAfter since the last ping.
+// Without a negotiated heartbeat the deadline is ReadTimeout alone.
+func (s *Service) readDeadline(lastPing time.Time, heartbeat bool) time.Time {
+	deadline := time.Now().Add(s.cfg.Session.ReadTimeout)
+	if !heartbeat {
+		return deadline
+	}
+	if dead := lastPing.Add(s.cfg.Session.SessionDeadAfter); dead.Before(deadline) {
+		return dead
+	}
+	return deadline
+}
+
+// Mirage heartbeat reply for one ping frame; returns false when the ping was invalid.
+// Pings refresh Ghost liveness only and never reach event ingestion.
+func (s *Service) answerPing(ghostID string, ghostSess *ghostSession, fr frame.Frame) bool {
+	ping, err := session.DecodePingFrame(fr)
+	if err != nil {
+		logs.Warnf("mirage.handleConn decode ping ghost_id=%q err=%v", ghostID, err)
+		ghostSess.sendError(session.NewErrorEnvelope(
+			session.ErrorCodeFor(err),
+			fr.Header.MessageID,
+			fr.Header.MessageType,
+			err.Error(),
+		))
+		return false
+	}
+	s.server.ObserveHeartbeat(ghostID, time.Duration(ping.RTTMS)*time.Millisecond)
+	msg, err := session.NewPongFrame(fr.Header.MessageID, session.Pong{
+		PingTimestampMS: ping.TimestampMS,
+		TimestampMS:     uint64(time.Now().UnixMilli()),
+	})
+	if err != nil {
+		logs.Warnf("mirage.handleConn encode pong ghost_id=%q err=%v", ghostID, err)
+		return true
+	}
+	if err := ghostSess.writeFrame(msg); err != nil {
+		logs.Debugf("mirage.handleConn write pong ghost_id=%q err=%v", ghostID, err)
+	}
+	return true
+}
+
 func (s *Service) persistBuildlog(kind string, payload any) {
 	if s.buildlogStore == nil {
 		return
@@ -550,6 +875,16 @@ func (s *Service) handleRegistration(
 			TimestampMS: now,
 		}
 	}
+	if err := session.VerifyRegistration(reg, s.frameAuth, s.cfg.Session.Auth.Required); err != nil {
+		logs.Warnf("mirage.handleRegistration auth ghost_id=%q remote=%q err=%v", reg.GhostID, conn.RemoteAddr().String(), err)
+		return reg, session.RegistrationAck{
+			Status:      session.AckStatusRejected,
+			Code:        errorCodeTransportFailure,
+			Message:     "registration auth failure",
+			GhostID:     reg.GhostID,
+			TimestampMS: now,
+		}
+	}
 
 	if s.cfg.RequireIdentityBinding {
 		if auth.Authenticated {
@@ -598,7 +933,22 @@ func (s *Service) handleRegistration(
 		}
 	}
 
-	return reg, s.server.UpsertRegistration(conn.RemoteAddr().String(), reg)
+	version, err := session.NegotiateProtocolVersion(s.cfg.Session.Versions, reg.ProtocolVersions())
+	if err != nil {
+		logs.Warnf("mirage.handleRegistration version negotiation ghost_id=%q err=%v", reg.GhostID, err)
+		return reg, session.RegistrationAck{
+			Status:      session.AckStatusRejected,
+			Code:        session.ErrorCodeFramingInvalidHeader,
+			Message:     "no common protocol version",
+			GhostID:     reg.GhostID,
+			TimestampMS: now,
+		}
+	}
+	ack := s.server.UpsertRegistration(conn.RemoteAddr().String(), reg)
+	ack.ProtocolVersion = version
+	ack.Compression = session.NegotiateCompression(s.cfg.Session.Compression, reg.Compression)
+	ack.Capabilities = session.NegotiateCapabilities(reg.Capabilities)
+	return reg, ack
 }
 
 // Mirage transport-auth helper enforcing TLS/mTLS and extracting peer identity.
@@ -620,19 +970,27 @@ func (s *Service) authenticateConn(conn net.Conn) (peerAuth, error) {
 		return peerAuth{}, err
 	}
 	state := tlsConn.ConnectionState()
+	var local *tls.Certificate
+	if s.tlsFiles != nil {
+		local, _ = s.tlsFiles.Certificate()
+	}
+	expiry := session.CertificateExpiry(state, local)
 
 	needPeer := s.cfg.Session.TLS.Mutual || mode == session.SecurityModeProduction
 	if !needPeer && len(state.PeerCertificates) == 0 {
-		return peerAuth{}, nil
+		return peerAuth{CertExpiry: expiry}, nil
 	}
 	if len(state.PeerCertificates) == 0 {
+		if s.enroll != nil {
+			return peerAuth{Enrolling: true, CertExpiry: expiry}, nil
+		}
 		return peerAuth{}, session.ErrMTLSRequired
 	}
 	peerID := peerIdentityFromCert(state.PeerCertificates[0])
 	if peerID == "" {
 		return peerAuth{}, fmt.Errorf("mirage: empty peer identity from certificate")
 	}
-	return peerAuth{PeerIdentity: peerID, Authenticated: true}, nil
+	return peerAuth{PeerIdentity: peerID, Authenticated: true, CertExpiry: expiry}, nil
 }
 
 // Mirage certificate identity extractor using CN/URI/DNS preference order.
@@ -657,29 +1015,51 @@ func peerIdentityFromCert(cert *x509.Certificate) string {
 }
 
 // Mirage TLS server-config builder for listener transport enforcement.
+// Certificate and client CA pool resolve per handshake, so rotated files apply without restart.
 func (s *Service) serverTLSConfig() (*tls.Config, error) {
-	cert, err := tls.LoadX509KeyPair(s.cfg.Session.TLS.CertFile, s.cfg.Session.TLS.KeyFile)
+	files, err := session.NewTLSReloader(session.TLSFiles{
+		CertFile: s.cfg.Session.TLS.CertFile,
+		KeyFile:  s.cfg.Session.TLS.KeyFile,
+		CAFile:   s.cfg.Session.TLS.CAFile,
+		CRLFile:  s.cfg.Session.TLS.CRLFile,
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.tlsFiles = files
 	cfg := &tls.Config{
-		MinVersion:   tls.VersionTLS12,
-		Certificates: []tls.Certificate{cert},
-		ClientAuth:   tls.NoClientCert,
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return files.Certificate()
+		},
+		ClientAuth: tls.NoClientCert,
 	}
 
 	mode := session.NormalizeSecurityMode(s.cfg.Session.SecurityMode)
 	if s.cfg.Session.TLS.Mutual || mode == session.SecurityModeProduction {
-		cfg.ClientAuth = tls.RequireAndVerifyClientCert
-		caPEM, err := os.ReadFile(s.cfg.Session.TLS.CAFile)
-		if err != nil {
-			return nil, err
+		if files.CAPool() == nil {
+			return nil, session.ErrTLSCAFileRequired
 		}
-		pool := x509.NewCertPool()
-		if ok := pool.AppendCertsFromPEM(caPEM); !ok {
-			return nil, fmt.Errorf("mirage: parse tls ca bundle: %s", s.cfg.Session.TLS.CAFile)
+		// Chains are verified against the reloaded CA pool instead of a fixed ClientCAs.
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyPeerCertificate = files.VerifyClient
+		if s.cfg.Enrollment.Enabled() {
+			enroll, err := newEnrollment(s.cfg.Enrollment)
+			if err != nil {
+				return nil, err
+			}
+			s.enroll = enroll
+			// Cert-less peers get through the handshake but are confined to ghost.enroll.
+			cfg.ClientAuth = tls.RequestClientCert
+			cfg.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return nil
+				}
+				return files.VerifyClient(rawCerts, chains)
+			}
 		}
-		cfg.ClientCAs = pool
+	} else if s.cfg.Enrollment.Enabled() {
+		return nil, fmt.Errorf("%w: enrollment requires mutual tls", session.ErrMTLSRequired)
 	}
 	return cfg, nil
 }
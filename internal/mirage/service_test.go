@@ -1,15 +1,25 @@
 package mirage
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/danmuck/edgectl/internal/ghost"
+	"github.com/danmuck/edgectl/internal/pki"
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
 	"github.com/danmuck/edgectl/internal/protocol/session"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
 	"github.com/danmuck/edgectl/internal/testutil/tlstest"
 )
@@ -136,6 +146,163 @@ func TestServiceRegistrationIdentityMismatchRejected(t *testing.T) {
 	}
 }
 
+func TestServiceRegistrationNegotiatesProtocolVersion(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	register := func(ghostID string, minVersion uint16, maxVersion uint16) session.RegistrationAck {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+		if err := session.WriteRegistration(conn, session.Registration{
+			GhostID:            ghostID,
+			PeerIdentity:       ghostID,
+			SeedList:           []session.SeedInfo{},
+			MinProtocolVersion: minVersion,
+			MaxProtocolVersion: maxVersion,
+		}); err != nil {
+			t.Fatalf("write registration: %v", err)
+		}
+		ack, err := session.ReadRegistrationAck(bufio.NewReader(conn))
+		if err != nil {
+			t.Fatalf("read registration ack: %v", err)
+		}
+		return ack
+	}
+
+	// A newer Ghost advertising versions beyond ours settles on our highest.
+	ack := register("ghost.newer", frame.MinProtocolVersion, frame.MaxProtocolVersion+2)
+	if ack.Status != session.AckStatusAccepted || ack.ProtocolVersion != frame.MaxProtocolVersion {
+		t.Fatalf("unexpected negotiated ack: %+v", ack)
+	}
+	// A legacy Ghost without a range gets the legacy version.
+	ack = register("ghost.legacy", 0, 0)
+	if ack.Status != session.AckStatusAccepted || ack.NegotiatedVersion() != session.LegacyProtocolVersion {
+		t.Fatalf("unexpected legacy ack: %+v", ack)
+	}
+	// No overlap rejects the registration.
+	ack = register("ghost.future", frame.MaxProtocolVersion+1, frame.MaxProtocolVersion+2)
+	if ack.Status != session.AckStatusRejected || ack.Code != session.ErrorCodeFramingInvalidHeader {
+		t.Fatalf("expected version rejection, got %+v", ack)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceNegotiatesCompressionAndInflatesEvents(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Session.Compression = session.CompressionConfig{Enabled: true, Threshold: 64}
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+		Compression:        []string{session.CompressionDeflate},
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	ack, err := session.ReadRegistrationAck(reader)
+	if err != nil || ack.Status != session.AckStatusAccepted || ack.Compression != session.CompressionDeflate {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	limits := frame.DefaultLimits()
+	payload, err := session.EncodeEventFrame(21, session.Event{
+		EventID:   "evt.compressed",
+		CommandID: "cmd." + strings.Repeat("compressed.", 10),
+		IntentID:  "intent." + strings.Repeat("compressed.", 10),
+		GhostID:   "ghost.alpha",
+		SeedID:    "seed.flow",
+		Outcome:   ghost.OutcomeSuccess,
+	})
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+	msg, err := frame.DecodeMessage(payload, limits)
+	if err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	var compressed bytes.Buffer
+	enc := frame.NewEncoderWithOptions(&compressed, limits, session.WireEncoderOptions(ack.ProtocolVersion, ack.Compression, 64, nil))
+	defer enc.Release()
+	if err := enc.EncodeMessage(msg); err != nil {
+		t.Fatalf("compress event: %v", err)
+	}
+	if compressed.Len() >= len(payload) {
+		t.Fatalf("expected compressed event frame: raw=%d compressed=%d", len(payload), compressed.Len())
+	}
+	if _, err := conn.Write(compressed.Bytes()); err != nil {
+		t.Fatalf("write event: %v", err)
+	}
+	fr, err := session.ReadFrame(reader, limits)
+	if err != nil {
+		t.Fatalf("read ack frame: %v", err)
+	}
+	fr, err = session.DecompressFrame(fr, ack.Compression, limits)
+	if err != nil {
+		t.Fatalf("decompress ack: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgEventAck {
+		env, _ := session.DecodeErrorFrame(fr)
+		t.Fatalf("expected event ack, got message_type=%d env=%+v", fr.Header.MessageType, env)
+	}
+	eventAck, err := session.DecodeEventAckFrame(fr)
+	if err != nil || eventAck.EventID != "evt.compressed" {
+		t.Fatalf("event ack=%+v err=%v", eventAck, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
 func TestServiceEventAckReplayAcrossReconnect(t *testing.T) {
 	testlog.Start(t)
 
@@ -208,33 +375,1248 @@ func TestServiceEventAckReplayAcrossReconnect(t *testing.T) {
 	}) {
 		t.Fatalf("ghost state did not transition to disconnected with preserved event count")
 	}
-
-	connectCtxB, connectCancelB := context.WithTimeout(ctx, 3*time.Second)
-	defer connectCancelB()
-	gsB, err := client.ConnectAndRegister(connectCtxB)
+
+	connectCtxB, connectCancelB := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancelB()
+	gsB, err := client.ConnectAndRegister(connectCtxB)
+	if err != nil {
+		t.Fatalf("connect and register (B): %v", err)
+	}
+	defer gsB.Close()
+
+	ackB, err := gsB.SendEventWithAck(connectCtxB, event)
+	if err != nil {
+		t.Fatalf("send event replay (B): %v", err)
+	}
+	if ackA.AckStatus != ackB.AckStatus {
+		t.Fatalf("ack status mismatch across reconnect: a=%q b=%q", ackA.AckStatus, ackB.AckStatus)
+	}
+	if ackA.AckCode != ackB.AckCode {
+		t.Fatalf("ack code mismatch across reconnect: a=%d b=%d", ackA.AckCode, ackB.AckCode)
+	}
+	if ackA.TimestampMS != ackB.TimestampMS {
+		t.Fatalf("ack timestamp mismatch across reconnect: a=%d b=%d", ackA.TimestampMS, ackB.TimestampMS)
+	}
+
+	if !waitForGhostState(2*time.Second, 20*time.Millisecond, svc, "ghost.alpha", func(g RegisteredGhost) bool {
+		return g.Connected && g.EventCount == 1
+	}) {
+		t.Fatalf("ghost state did not preserve idempotent event count after replay")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceResumesSessionEpochAtContiguousSeq(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.SeqStatePath = filepath.Join(t.TempDir(), "seq.log")
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	register := func(epoch uint64) (net.Conn, *bufio.Reader, session.RegistrationAck) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+		reader := bufio.NewReader(conn)
+		if err := session.WriteRegistration(conn, session.Registration{
+			GhostID:            "ghost.alpha",
+			PeerIdentity:       "ghost.alpha",
+			SeedList:           []session.SeedInfo{},
+			MinProtocolVersion: frame.MinProtocolVersion,
+			MaxProtocolVersion: frame.MaxProtocolVersion,
+			SessionEpoch:       epoch,
+		}); err != nil {
+			t.Fatalf("write registration: %v", err)
+		}
+		ack, err := session.ReadRegistrationAck(reader)
+		if err != nil || ack.Status != session.AckStatusAccepted {
+			t.Fatalf("registration ack=%+v err=%v", ack, err)
+		}
+		return conn, reader, ack
+	}
+	sendEvent := func(conn net.Conn, reader *bufio.Reader, seq uint64) {
+		commandID := fmt.Sprintf("cmd.resume.%d", seq)
+		payload, err := session.EncodeEventFrame(seq, session.Event{
+			EventID:   "evt." + commandID,
+			CommandID: commandID,
+			IntentID:  "intent.resume",
+			GhostID:   "ghost.alpha",
+			SeedID:    "seed.flow",
+			Outcome:   "success",
+			Seq:       seq,
+		})
+		if err != nil {
+			t.Fatalf("encode event seq=%d: %v", seq, err)
+		}
+		if _, err := conn.Write(payload); err != nil {
+			t.Fatalf("write event seq=%d: %v", seq, err)
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			t.Fatalf("read ack seq=%d: %v", seq, err)
+		}
+		if ack, err := session.DecodeEventAckFrame(fr); err != nil || ack.AckStatus != session.AckStatusAccepted {
+			t.Fatalf("event ack seq=%d ack=%+v err=%v", seq, ack, err)
+		}
+	}
+
+	const epoch = 7001
+	conn, reader, ack := register(epoch)
+	if ack.SessionEpoch != epoch || ack.LastSeq != 0 {
+		t.Fatalf("expected fresh epoch with last_seq=0, got %+v", ack)
+	}
+	// Pipelined delivery: seq 3 lands before seq 2, and seq 5 stays beyond a gap.
+	for _, seq := range []uint64{1, 3, 2, 5} {
+		sendEvent(conn, reader, seq)
+	}
+	_ = conn.Close()
+	if !waitForGhostState(2*time.Second, 20*time.Millisecond, svc, "ghost.alpha", func(g RegisteredGhost) bool {
+		return !g.Connected && g.EventCount == 4
+	}) {
+		t.Fatalf("ghost did not disconnect with 4 events recorded")
+	}
+
+	conn, reader, ack = register(epoch)
+	if ack.SessionEpoch != epoch || ack.LastSeq != 3 {
+		t.Fatalf("expected resume at last_seq=3, got %+v", ack)
+	}
+	// Resending an event observed before the gap is acked without being ingested again.
+	sendEvent(conn, reader, 5)
+	sendEvent(conn, reader, 4)
+	_ = conn.Close()
+	if !waitForGhostState(2*time.Second, 20*time.Millisecond, svc, "ghost.alpha", func(g RegisteredGhost) bool {
+		return !g.Connected && g.EventCount == 5
+	}) {
+		t.Fatalf("expected only seq 4 ingested on resume, got %+v", svc.SnapshotRegisteredGhosts())
+	}
+
+	conn, _, ack = register(epoch + 1)
+	defer conn.Close()
+	if ack.SessionEpoch != epoch+1 || ack.LastSeq != 0 {
+		t.Fatalf("expected new epoch to restart sequence, got %+v", ack)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceThrottlesGhostOverIngressRate(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Ingress = IngressConfig{EventsPerSecond: 1, Burst: 2, QueueDepth: 4}
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	register := func(ghostID string) (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+		reader := bufio.NewReader(conn)
+		if err := session.WriteRegistration(conn, session.Registration{
+			GhostID:            ghostID,
+			PeerIdentity:       ghostID,
+			SeedList:           []session.SeedInfo{},
+			MinProtocolVersion: frame.MinProtocolVersion,
+			MaxProtocolVersion: frame.MaxProtocolVersion,
+			Capabilities:       []string{session.CapabilityThrottle},
+		}); err != nil {
+			t.Fatalf("write registration: %v", err)
+		}
+		if ack, err := session.ReadRegistrationAck(reader); err != nil || !ack.Supports(session.CapabilityThrottle) {
+			t.Fatalf("registration ack=%+v err=%v", ack, err)
+		}
+		return conn, reader
+	}
+	sendEvent := func(conn net.Conn, reader *bufio.Reader, ghostID string, n uint64) session.EventAck {
+		commandID := fmt.Sprintf("cmd.%s.%d", ghostID, n)
+		payload, err := session.EncodeEventFrame(n, session.Event{
+			EventID:   "evt." + commandID,
+			CommandID: commandID,
+			IntentID:  "intent.ingress",
+			GhostID:   ghostID,
+			SeedID:    "seed.flow",
+			Outcome:   "success",
+		})
+		if err != nil {
+			t.Fatalf("encode event: %v", err)
+		}
+		if _, err := conn.Write(payload); err != nil {
+			t.Fatalf("write event: %v", err)
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			t.Fatalf("read ack: %v", err)
+		}
+		ack, err := session.DecodeEventAckFrame(fr)
+		if err != nil {
+			t.Fatalf("decode ack: %v", err)
+		}
+		return ack
+	}
+
+	noisy, noisyReader := register("ghost.noisy")
+	defer noisy.Close()
+	for n := uint64(1); n <= 2; n++ {
+		if ack := sendEvent(noisy, noisyReader, "ghost.noisy", n); ack.AckStatus != session.AckStatusAccepted {
+			t.Fatalf("expected burst event %d accepted, got %+v", n, ack)
+		}
+	}
+	ack := sendEvent(noisy, noisyReader, "ghost.noisy", 3)
+	if ack.AckStatus != session.AckStatusThrottled || ack.AckCode != session.ErrorCodeTransportThrottled || ack.RetryAfterMS == 0 {
+		t.Fatalf("expected throttled ack with retry hint, got %+v", ack)
+	}
+	// A resend of an accepted event is acked as a duplicate without spending a token.
+	if ack := sendEvent(noisy, noisyReader, "ghost.noisy", 1); ack.AckStatus != session.AckStatusAccepted {
+		t.Fatalf("expected duplicate acked despite empty bucket, got %+v", ack)
+	}
+
+	// Throttling neither drops the session nor delays heartbeats.
+	payload, err := session.EncodePingFrame(90, session.Ping{TimestampMS: uint64(time.Now().UnixMilli())})
+	if err != nil {
+		t.Fatalf("encode ping: %v", err)
+	}
+	if _, err := noisy.Write(payload); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	if fr, err := session.ReadFrame(noisyReader, frame.DefaultLimits()); err != nil || fr.Header.MessageType != schema.MsgPong {
+		t.Fatalf("expected pong on throttled session, got header=%+v err=%v", fr.Header, err)
+	}
+
+	// Another Ghost has its own bucket.
+	quiet, quietReader := register("ghost.quiet")
+	defer quiet.Close()
+	if ack := sendEvent(quiet, quietReader, "ghost.quiet", 1); ack.AckStatus != session.AckStatusAccepted {
+		t.Fatalf("expected other ghost unaffected, got %+v", ack)
+	}
+
+	for _, g := range svc.SnapshotRegisteredGhosts() {
+		switch g.GhostID {
+		case "ghost.noisy":
+			if g.EventCount != 2 || g.ThrottledCount != 1 {
+				t.Fatalf("unexpected noisy ghost counters: %+v", g)
+			}
+		case "ghost.quiet":
+			if g.EventCount != 1 || g.ThrottledCount != 0 {
+				t.Fatalf("unexpected quiet ghost counters: %+v", g)
+			}
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceBlocksReaderForGhostWithoutThrottleCapability(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Ingress = IngressConfig{EventsPerSecond: 10, Burst: 1, QueueDepth: 4}
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.legacy",
+		PeerIdentity:       "ghost.legacy",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	if ack, err := session.ReadRegistrationAck(reader); err != nil || ack.Supports(session.CapabilityThrottle) {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	// Over the rate, a legacy Ghost sees slower acks, never a throttled one it would treat as final.
+	start := time.Now()
+	for n := uint64(1); n <= 3; n++ {
+		commandID := fmt.Sprintf("cmd.legacy.%d", n)
+		payload, err := session.EncodeEventFrame(n, session.Event{
+			EventID:   "evt." + commandID,
+			CommandID: commandID,
+			IntentID:  "intent.ingress",
+			GhostID:   "ghost.legacy",
+			SeedID:    "seed.flow",
+			Outcome:   "success",
+		})
+		if err != nil {
+			t.Fatalf("encode event: %v", err)
+		}
+		if _, err := conn.Write(payload); err != nil {
+			t.Fatalf("write event: %v", err)
+		}
+		fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+		if err != nil {
+			t.Fatalf("read ack: %v", err)
+		}
+		ack, err := session.DecodeEventAckFrame(fr)
+		if err != nil {
+			t.Fatalf("decode ack: %v", err)
+		}
+		if ack.AckStatus != session.AckStatusAccepted {
+			t.Fatalf("expected event %d accepted after backpressure, got %+v", n, ack)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected reader to wait for tokens, took %s", elapsed)
+	}
+	ghosts := svc.SnapshotRegisteredGhosts()
+	if len(ghosts) != 1 || ghosts[0].EventCount != 3 || ghosts[0].ThrottledCount != 2 {
+		t.Fatalf("unexpected legacy ghost counters: %+v", ghosts)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceAdmitEventThrottlesFullIngressQueue(t *testing.T) {
+	testlog.Start(t)
+
+	svc := NewServiceWithConfig(ServiceConfig{Ingress: IngressConfig{EventsPerSecond: 10, Burst: 10, QueueDepth: 1}})
+	bucket := svc.ingressBucket("ghost.alpha")
+	queue := make(chan ingressEvent, svc.cfg.Ingress.QueueDepth)
+	if _, ok := svc.admitEvent(bucket, queue, ingressEvent{messageID: 1}); !ok {
+		t.Fatalf("expected first event admitted")
+	}
+	wait, ok := svc.admitEvent(bucket, queue, ingressEvent{messageID: 2})
+	if ok || wait != 100*time.Millisecond {
+		t.Fatalf("expected full queue throttled with drain hint, got ok=%v wait=%s", ok, wait)
+	}
+	if len(queue) != 1 {
+		t.Fatalf("expected throttled event kept out of queue, got len=%d", len(queue))
+	}
+	if svc.ingressBucket("ghost.alpha") != bucket {
+		t.Fatalf("expected bucket reused across sessions")
+	}
+}
+
+func TestServiceIngestEventEmitsReportHistory(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.RequireIdentityBinding = true
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+
+	if err := svc.Server().SubmitIssue(IssueEnv{
+		IntentID:    "intent.1",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "status",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:      ln.Addr().String(),
+		GhostID:      "ghost.alpha",
+		PeerIdentity: "ghost.alpha",
+		SeedList: []session.SeedInfo{
+			{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"},
+		},
+		Session: session.DefaultConfig(),
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	event := ghost.EventEnv{
+		EventID:     "evt.cmd.intent.1.1",
+		CommandID:   "cmd.intent.1.1",
+		IntentID:    "intent.1",
+		GhostID:     "ghost.alpha",
+		SeedID:      "seed.flow",
+		Outcome:     ghost.OutcomeSuccess,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	}
+	if _, err := gs.SendEventWithAck(connectCtx, event); err != nil {
+		t.Fatalf("send event: %v", err)
+	}
+
+	if !waitForReportCount(2*time.Second, 25*time.Millisecond, svc, 1) {
+		t.Fatalf("expected report history entry after observed event")
+	}
+	reports := svc.RecentReports(10)
+	if len(reports) != 1 {
+		t.Fatalf("expected one report, got %d", len(reports))
+	}
+	if reports[0].IntentID != "intent.1" || reports[0].Phase != ReportPhaseComplete {
+		t.Fatalf("unexpected report payload: %+v", reports[0])
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceReconcileDispatchesCommandOverGhostSession(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.RequireIdentityBinding = true
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+
+	if err := svc.Server().SubmitIssue(IssueEnv{
+		IntentID:    "intent.session.1",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "status",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	received := make(chan ghost.CommandEnv, 1)
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:      ln.Addr().String(),
+		GhostID:      "ghost.alpha",
+		PeerIdentity: "ghost.alpha",
+		SeedList: []session.SeedInfo{
+			{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"},
+		},
+		Session: session.DefaultConfig(),
+		CommandHandler: func(_ context.Context, cmd ghost.CommandEnv) (ghost.EventEnv, error) {
+			received <- cmd
+			return ghost.EventEnv{
+				EventID:     "evt." + cmd.CommandID,
+				CommandID:   cmd.CommandID,
+				IntentID:    cmd.IntentID,
+				GhostID:     cmd.GhostID,
+				SeedID:      cmd.SeedSelector,
+				Outcome:     ghost.OutcomeSuccess,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer reconcileCancel()
+	report, err := svc.Server().ReconcileIntent(reconcileCtx, "intent.session.1")
+	if err != nil {
+		t.Fatalf("reconcile intent: %v", err)
+	}
+	if report.Phase != ReportPhaseComplete || report.CompletionState != CompletionSatisfied {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	select {
+	case cmd := <-received:
+		if cmd.MessageID == 0 || cmd.Operation != "status" || cmd.SeedSelector != "seed.flow" {
+			t.Fatalf("unexpected command envelope: %+v", cmd)
+		}
+	default:
+		t.Fatalf("expected command delivered over ghost session")
+	}
+	if got := len(svc.RecentReports(10)); got != 1 {
+		t.Fatalf("expected single report for session-delivered event, got %d", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceAttachesGhostProgressToIntent(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+
+	if err := svc.Server().SubmitIssue(IssueEnv{
+		IntentID:    "intent.progress.1",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "install",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	sessions := make(chan *ghost.MirageSession, 1)
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:      ln.Addr().String(),
+		GhostID:      "ghost.alpha",
+		PeerIdentity: "ghost.alpha",
+		SeedList: []session.SeedInfo{
+			{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"},
+		},
+		Session: session.DefaultConfig(),
+		CommandHandler: func(ctx context.Context, cmd ghost.CommandEnv) (ghost.EventEnv, error) {
+			gs := <-sessions
+			for i, chunk := range []string{"step one\n", "step two\n"} {
+				if err := gs.SendProgress(ctx, ghost.ProgressEnv{
+					ExecutionID: "exec." + cmd.CommandID,
+					CommandID:   cmd.CommandID,
+					IntentID:    cmd.IntentID,
+					GhostID:     cmd.GhostID,
+					Seq:         uint64(i + 1),
+					Stream:      session.ProgressStreamStdout,
+					Chunk:       []byte(chunk),
+					Percent:     uint32(50 * (i + 1)),
+					TimestampMS: uint64(time.Now().UnixMilli()),
+				}); err != nil {
+					return ghost.EventEnv{}, err
+				}
+			}
+			return ghost.EventEnv{
+				EventID:     "evt." + cmd.CommandID,
+				CommandID:   cmd.CommandID,
+				IntentID:    cmd.IntentID,
+				GhostID:     cmd.GhostID,
+				SeedID:      cmd.SeedSelector,
+				Outcome:     ghost.OutcomeSuccess,
+				TimestampMS: uint64(time.Now().UnixMilli()),
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+	sessions <- gs
+
+	reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer reconcileCancel()
+	if _, err := svc.Server().ReconcileIntent(reconcileCtx, "intent.progress.1"); err != nil {
+		t.Fatalf("reconcile intent: %v", err)
+	}
+
+	tail, err := svc.Server().TailProgress("intent.progress.1", 0, 0)
+	if err != nil {
+		t.Fatalf("tail progress: %v", err)
+	}
+	if len(tail.Records) != 2 || !tail.Complete {
+		t.Fatalf("unexpected progress tail: %+v", tail)
+	}
+	if string(tail.Records[0].Chunk) != "step one\n" || tail.Records[1].Percent != 100 {
+		t.Fatalf("unexpected progress records: %+v", tail.Records)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceEmitsErrorEnvelopeForInvalidEvent(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.RequireIdentityBinding = true
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	if ack, err := session.ReadRegistrationAck(reader); err != nil || ack.Status != session.AckStatusAccepted {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	// Event frame missing every required field except event_id.
+	var buf bytes.Buffer
+	if err := frame.WriteFrame(&buf, frame.Frame{
+		Header: frame.Header{MessageID: 91, MessageType: schema.MsgEvent},
+		Payload: tlv.EncodeFields([]tlv.Field{
+			{ID: schema.FieldEventID, Type: tlv.TypeString, Value: []byte("evt.bad")},
+		}),
+	}, frame.DefaultLimits()); err != nil {
+		t.Fatalf("encode invalid event: %v", err)
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("write invalid event: %v", err)
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read error frame: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgError {
+		t.Fatalf("expected error frame, got message_type=%d", fr.Header.MessageType)
+	}
+	env, err := session.DecodeErrorFrame(fr)
+	if err != nil {
+		t.Fatalf("decode error frame: %v", err)
+	}
+	if env.Code != session.ErrorCodeSemanticValidationFailure || env.MessageID != 91 || env.MessageType != schema.MsgEvent {
+		t.Fatalf("unexpected error envelope: %+v", env)
+	}
+
+	// Session stays usable after a recoverable error.
+	payload, err := session.EncodeEventFrame(92, session.Event{
+		EventID:   "evt.good",
+		CommandID: "cmd.good",
+		IntentID:  "intent.good",
+		GhostID:   "ghost.alpha",
+		SeedID:    "seed.flow",
+		Outcome:   ghost.OutcomeSuccess,
+	})
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("write event: %v", err)
+	}
+	fr, err = session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read ack frame: %v", err)
+	}
+	if ack, err := session.DecodeEventAckFrame(fr); err != nil || ack.EventID != "evt.good" {
+		t.Fatalf("expected event.ack for evt.good, ack=%+v err=%v", ack, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceDispatchesRegisteredExtensionMessages(t *testing.T) {
+	testlog.Start(t)
+
+	const (
+		msgProgress  = schema.ExtensionMessageTypeMin + 1
+		fieldPercent = schema.ExtensionFieldIDMin + 1
+	)
+	reqs := []schema.Requirement{
+		{ID: schema.FieldCommandID, Type: tlv.TypeString},
+		{ID: fieldPercent, Type: tlv.TypeU8},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+	type delivery struct {
+		ghostID string
+		fields  []tlv.Field
+	}
+	got := make(chan delivery, 1)
+	if err := svc.HandleExtension(msgProgress, reqs, func(ghostID string, _ uint32, fields []tlv.Field) {
+		got <- delivery{ghostID: ghostID, fields: fields}
+	}); err != nil {
+		t.Fatalf("handle extension: %v", err)
+	}
+	if err := svc.HandleExtension(schema.MsgEvent, reqs, nil); !errors.Is(err, schema.ErrReservedMessageType) {
+		t.Fatalf("expected core message type to be reserved, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	ack, err := session.ReadRegistrationAck(reader)
+	if err != nil || ack.Status != session.AckStatusAccepted {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	// Ghost side owns its own registry with the same definition.
+	ghostSchema := schema.NewRegistry()
+	if err := ghostSchema.Register(msgProgress, reqs); err != nil {
+		t.Fatalf("register ghost extension: %v", err)
+	}
+	payload, err := session.EncodeExtensionFrame(ghostSchema, ack.ProtocolVersion, 31, msgProgress, tlv.NewBuilder(2).
+		String(schema.FieldCommandID, "cmd.progress").
+		U8(fieldPercent, 40).
+		Fields())
+	if err != nil {
+		t.Fatalf("encode extension: %v", err)
+	}
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("write extension: %v", err)
+	}
+	select {
+	case d := <-got:
+		r := tlv.NewReader(d.fields)
+		commandID, _ := r.String(schema.FieldCommandID)
+		percent, _ := r.U8(fieldPercent)
+		if d.ghostID != "ghost.alpha" || commandID != "cmd.progress" || percent != 40 {
+			t.Fatalf("unexpected extension delivery ghost_id=%q command_id=%q percent=%d", d.ghostID, commandID, percent)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for extension handler")
+	}
+
+	// Registered extension missing a required field gets an error envelope.
+	var buf bytes.Buffer
+	if err := frame.WriteFrame(&buf, frame.Frame{
+		Header:  frame.Header{Version: ack.ProtocolVersion, MessageID: 32, MessageType: msgProgress},
+		Payload: tlv.NewBuilder(1).U8(fieldPercent, 50).Encode(),
+	}, frame.DefaultLimits()); err != nil {
+		t.Fatalf("encode invalid extension: %v", err)
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		t.Fatalf("write invalid extension: %v", err)
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read error frame: %v", err)
+	}
+	env, err := session.DecodeErrorFrame(fr)
+	if err != nil || env.Code != session.ErrorCodeSemanticValidationFailure || env.MessageID != 32 || env.MessageType != msgProgress {
+		t.Fatalf("unexpected error envelope=%+v err=%v", env, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceAnswersPingAndExpiresSilentGhost(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 5 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Session.SessionDeadAfter = 300 * time.Millisecond
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+		Capabilities:       []string{session.CapabilityHeartbeat},
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	if ack, err := session.ReadRegistrationAck(reader); err != nil || ack.Status != session.AckStatusAccepted ||
+		ack.ProtocolVersion != frame.ProtocolVersion || !ack.Supports(session.CapabilityHeartbeat) {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	sentMS := uint64(time.Now().UnixMilli())
+	payload, err := session.EncodePingFrame(51, session.Ping{TimestampMS: sentMS, RTTMS: 7})
+	if err != nil {
+		t.Fatalf("encode ping: %v", err)
+	}
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read pong: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgPong || fr.Header.MessageID != 51 {
+		t.Fatalf("unexpected pong header: %+v", fr.Header)
+	}
+	pong, err := session.DecodePongFrame(fr)
+	if err != nil || pong.PingTimestampMS != sentMS {
+		t.Fatalf("unexpected pong=%+v err=%v", pong, err)
+	}
+	ghosts := svc.SnapshotRegisteredGhosts()
+	if len(ghosts) != 1 || ghosts[0].LastHeartbeatAt.IsZero() || ghosts[0].HeartbeatRTT != 7*time.Millisecond {
+		t.Fatalf("expected heartbeat recorded, got %+v", ghosts)
+	}
+	if ghosts[0].EventCount != 0 {
+		t.Fatalf("ping counted as event: %+v", ghosts[0])
+	}
+
+	// No further pings: Mirage drops the session after SessionDeadAfter, well before ReadTimeout.
+	start := time.Now()
+	if _, err := session.ReadFrame(reader, frame.DefaultLimits()); err == nil {
+		t.Fatalf("expected session closed after heartbeat expiry")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("session outlived SessionDeadAfter: %s", elapsed)
+	}
+	if !waitForGhostState(2*time.Second, 20*time.Millisecond, svc, "ghost.alpha", func(g RegisteredGhost) bool {
+		return !g.Connected
+	}) {
+		t.Fatalf("ghost not marked disconnected after heartbeat expiry")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceKeepsGhostWithoutHeartbeatCapabilityPastSessionDeadAfter(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 5 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Session.SessionDeadAfter = 200 * time.Millisecond
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	// A Ghost predating heartbeat advertises no capabilities and never pings.
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	ack, err := session.ReadRegistrationAck(reader)
+	if err != nil || ack.Status != session.AckStatusAccepted || len(ack.Capabilities) != 0 {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
+	}
+
+	time.Sleep(3 * cfg.Session.SessionDeadAfter)
+	payload, err := session.EncodeEventFrame(61, session.Event{
+		EventID:   "evt.legacy",
+		CommandID: "cmd.legacy",
+		IntentID:  "intent.legacy",
+		GhostID:   "ghost.alpha",
+		SeedID:    "seed.flow",
+		Outcome:   ghost.OutcomeSuccess,
+	})
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("write event: %v", err)
+	}
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("session dropped without negotiated heartbeat: %v", err)
+	}
+	if eventAck, err := session.DecodeEventAckFrame(fr); err != nil || eventAck.EventID != "evt.legacy" {
+		t.Fatalf("expected event.ack for evt.legacy, ack=%+v err=%v", eventAck, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceReconcileSurfacesGhostErrorEnvelope(t *testing.T) {
+	testlog.Start(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.RequireIdentityBinding = true
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+	if err := svc.Server().SubmitIssue(IssueEnv{
+		IntentID:    "intent.err.1",
+		Actor:       "user:dan",
+		TargetScope: "ghost:ghost.alpha",
+		Objective:   "status",
+		CommandPlan: []IssueCommand{
+			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+		},
+	}); err != nil {
+		t.Fatalf("submit issue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:      ln.Addr().String(),
+		GhostID:      "ghost.alpha",
+		PeerIdentity: "ghost.alpha",
+		SeedList:     []session.SeedInfo{},
+		Session:      session.DefaultConfig(),
+		CommandHandler: func(_ context.Context, _ ghost.CommandEnv) (ghost.EventEnv, error) {
+			return ghost.EventEnv{}, ghost.ErrCommandTargetMismatch
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer reconcileCancel()
+	_, err = svc.Server().ReconcileIntent(reconcileCtx, "intent.err.1")
+	var remote session.ErrorEnvelope
+	if !errors.As(err, &remote) {
+		t.Fatalf("expected session.ErrorEnvelope, got %v", err)
+	}
+	if remote.Code != session.ErrorCodeSemanticValidationFailure || remote.MessageType != schema.MsgCommand {
+		t.Fatalf("unexpected remote error: %+v", remote)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceFrameAuthRequiredAcceptsSignedAndRejectsUnsigned(t *testing.T) {
+	testlog.Start(t)
+
+	keyPath := filepath.Join(t.TempDir(), "frame.key")
+	if err := os.WriteFile(keyPath, []byte("shared-frame-secret\n"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	auth := session.AuthConfig{Required: true, KeyID: "edge.k1", KeyFile: keyPath}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Session.ReadTimeout = 2 * time.Second
+	cfg.Session.WriteTimeout = 2 * time.Second
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Session.Auth = auth
+	svc := NewServiceWithConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:      ln.Addr().String(),
+		GhostID:      "ghost.alpha",
+		PeerIdentity: "ghost.alpha",
+		SeedList:     []session.SeedInfo{},
+		Session: session.Config{
+			ConnectTimeout:   2 * time.Second,
+			HandshakeTimeout: 2 * time.Second,
+			ReadTimeout:      2 * time.Second,
+			WriteTimeout:     2 * time.Second,
+			AckTimeout:       3 * time.Second,
+			Auth:             auth,
+			Backoff:          session.DefaultConfig().Backoff,
+		},
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+	ack, err := gs.SendEventWithAck(connectCtx, ghost.EventEnv{
+		EventID:   "evt.signed",
+		CommandID: "cmd.signed",
+		IntentID:  "intent.signed",
+		GhostID:   "ghost.alpha",
+		SeedID:    "seed.flow",
+		Outcome:   ghost.OutcomeSuccess,
+	})
+	if err != nil || ack.AckStatus != session.AckStatusAccepted {
+		t.Fatalf("signed event ack=%+v err=%v", ack, err)
+	}
+
+	// Registrations are signed with the frame key: unsigned or tampered ones are refused.
+	signer, err := session.NewFrameAuthenticator(auth)
+	if err != nil {
+		t.Fatalf("new frame authenticator: %v", err)
+	}
+	register := func(reg session.Registration) (net.Conn, *bufio.Reader, session.RegistrationAck) {
+		t.Helper()
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+		reader := bufio.NewReader(conn)
+		if err := session.WriteRegistration(conn, reg); err != nil {
+			t.Fatalf("write registration: %v", err)
+		}
+		regAck, err := session.ReadRegistrationAck(reader)
+		if err != nil {
+			t.Fatalf("read registration ack: %v", err)
+		}
+		return conn, reader, regAck
+	}
+	betaReg := session.Registration{
+		GhostID:            "ghost.beta",
+		PeerIdentity:       "ghost.beta",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}
+	if _, _, regAck := register(betaReg); regAck.Status != session.AckStatusRejected {
+		t.Fatalf("expected unsigned registration rejected, got %+v", regAck)
+	}
+	signedBeta, err := session.SignRegistration(betaReg, signer)
 	if err != nil {
-		t.Fatalf("connect and register (B): %v", err)
+		t.Fatalf("sign registration: %v", err)
+	}
+	hijack := signedBeta
+	hijack.GhostID = "ghost.alpha"
+	hijack.PeerIdentity = "ghost.alpha"
+	if _, _, regAck := register(hijack); regAck.Status != session.AckStatusRejected {
+		t.Fatalf("expected tampered registration rejected, got %+v", regAck)
 	}
-	defer gsB.Close()
 
-	ackB, err := gsB.SendEventWithAck(connectCtxB, event)
+	// A signed registration is accepted, but an unsigned frame afterwards closes the stream.
+	conn, reader, regAck := register(signedBeta)
+	if regAck.Status != session.AckStatusAccepted {
+		t.Fatalf("signed registration ack=%+v", regAck)
+	}
+	if _, _, replayAck := register(signedBeta); replayAck.Status != session.AckStatusRejected {
+		t.Fatalf("expected replayed registration rejected, got %+v", replayAck)
+	}
+	payload, err := session.EncodeEventFrame(7, session.Event{
+		EventID:   "evt.unsigned",
+		CommandID: "cmd.unsigned",
+		IntentID:  "intent.unsigned",
+		GhostID:   "ghost.beta",
+		SeedID:    "seed.flow",
+		Outcome:   ghost.OutcomeSuccess,
+	})
 	if err != nil {
-		t.Fatalf("send event replay (B): %v", err)
+		t.Fatalf("encode event: %v", err)
 	}
-	if ackA.AckStatus != ackB.AckStatus {
-		t.Fatalf("ack status mismatch across reconnect: a=%q b=%q", ackA.AckStatus, ackB.AckStatus)
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("write event: %v", err)
 	}
-	if ackA.AckCode != ackB.AckCode {
-		t.Fatalf("ack code mismatch across reconnect: a=%d b=%d", ackA.AckCode, ackB.AckCode)
+	fr, err := session.ReadFrame(reader, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read error frame: %v", err)
 	}
-	if ackA.TimestampMS != ackB.TimestampMS {
-		t.Fatalf("ack timestamp mismatch across reconnect: a=%d b=%d", ackA.TimestampMS, ackB.TimestampMS)
+	env, err := session.DecodeErrorFrame(fr)
+	if err != nil {
+		t.Fatalf("decode error frame: %v", err)
 	}
-
-	if !waitForGhostState(2*time.Second, 20*time.Millisecond, svc, "ghost.alpha", func(g RegisteredGhost) bool {
-		return g.Connected && g.EventCount == 1
-	}) {
-		t.Fatalf("ghost state did not preserve idempotent event count after replay")
+	if env.Code != session.ErrorCodeTransportFailure || env.MessageID != 7 {
+		t.Fatalf("unexpected error envelope: %+v", env)
+	}
+	if _, err := session.ReadFrame(reader, frame.DefaultLimits()); err == nil {
+		t.Fatalf("expected session closed after auth failure")
 	}
 
 	cancel()
@@ -243,7 +1625,7 @@ func TestServiceEventAckReplayAcrossReconnect(t *testing.T) {
 	}
 }
 
-func TestServiceIngestEventEmitsReportHistory(t *testing.T) {
+func TestServiceKeepsExecutorForGhostWithoutPushCommandCapability(t *testing.T) {
 	testlog.Start(t)
 
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
@@ -251,19 +1633,20 @@ func TestServiceIngestEventEmitsReportHistory(t *testing.T) {
 		t.Fatalf("listen: %v", err)
 	}
 	cfg := DefaultServiceConfig()
-	cfg.RequireIdentityBinding = true
 	cfg.Session.ReadTimeout = 2 * time.Second
-	cfg.Session.WriteTimeout = 2 * time.Second
 	cfg.Session.HandshakeTimeout = 2 * time.Second
 	svc := NewServiceWithConfig(cfg)
-
+	exec := &fakeExecutor{}
+	if err := svc.Server().RegisterExecutor("ghost.legacy", exec); err != nil {
+		t.Fatalf("register executor: %v", err)
+	}
 	if err := svc.Server().SubmitIssue(IssueEnv{
-		IntentID:    "intent.1",
+		IntentID:    "intent.legacy.1",
 		Actor:       "user:dan",
-		TargetScope: "ghost:ghost.alpha",
+		TargetScope: "ghost:ghost.legacy",
 		Objective:   "status",
 		CommandPlan: []IssueCommand{
-			{GhostID: "ghost.alpha", SeedSelector: "seed.flow", Operation: "status"},
+			{GhostID: "ghost.legacy", SeedSelector: "seed.flow", Operation: "status"},
 		},
 	}); err != nil {
 		t.Fatalf("submit issue: %v", err)
@@ -276,48 +1659,33 @@ func TestServiceIngestEventEmitsReportHistory(t *testing.T) {
 		done <- svc.Serve(ctx, ln)
 	}()
 
-	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
-		Address:      ln.Addr().String(),
-		GhostID:      "ghost.alpha",
-		PeerIdentity: "ghost.alpha",
-		SeedList: []session.SeedInfo{
-			{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"},
-		},
-		Session: session.DefaultConfig(),
-	})
-	if err != nil {
-		t.Fatalf("new client: %v", err)
-	}
-	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
-	defer connectCancel()
-	gs, err := client.ConnectAndRegister(connectCtx)
+	conn, err := net.Dial("tcp", ln.Addr().String())
 	if err != nil {
-		t.Fatalf("connect and register: %v", err)
+		t.Fatalf("dial: %v", err)
 	}
-	defer gs.Close()
-
-	event := ghost.EventEnv{
-		EventID:     "evt.cmd.intent.1.1",
-		CommandID:   "cmd.intent.1.1",
-		IntentID:    "intent.1",
-		GhostID:     "ghost.alpha",
-		SeedID:      "seed.flow",
-		Outcome:     ghost.OutcomeSuccess,
-		TimestampMS: uint64(time.Now().UnixMilli()),
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	if err := session.WriteRegistration(conn, session.Registration{
+		GhostID:            "ghost.legacy",
+		PeerIdentity:       "ghost.legacy",
+		SeedList:           []session.SeedInfo{},
+		MinProtocolVersion: frame.MinProtocolVersion,
+		MaxProtocolVersion: frame.MaxProtocolVersion,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
 	}
-	if _, err := gs.SendEventWithAck(connectCtx, event); err != nil {
-		t.Fatalf("send event: %v", err)
+	if ack, err := session.ReadRegistrationAck(bufio.NewReader(conn)); err != nil || ack.Supports(session.CapabilityPushCommand) {
+		t.Fatalf("registration ack=%+v err=%v", ack, err)
 	}
 
-	if !waitForReportCount(2*time.Second, 25*time.Millisecond, svc, 1) {
-		t.Fatalf("expected report history entry after observed event")
-	}
-	reports := svc.RecentReports(10)
-	if len(reports) != 1 {
-		t.Fatalf("expected one report, got %d", len(reports))
+	reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer reconcileCancel()
+	report, err := svc.Server().ReconcileIntent(reconcileCtx, "intent.legacy.1")
+	if err != nil {
+		t.Fatalf("reconcile intent: %v", err)
 	}
-	if reports[0].IntentID != "intent.1" || reports[0].Phase != ReportPhaseComplete {
-		t.Fatalf("unexpected report payload: %+v", reports[0])
+	if report.Phase != ReportPhaseComplete || exec.count != 1 {
+		t.Fatalf("expected previous executor to run the command, report=%+v count=%d", report, exec.count)
 	}
 
 	cancel()
@@ -434,6 +1802,202 @@ func TestServiceRegistrationTLSMTLSIdentityBound(t *testing.T) {
 	}
 }
 
+func TestServiceTLSRotationAppliesToNewSessions(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	ca := tlstest.NewAuthority(t, dir, "edgectl-test-ca")
+	serverCert, serverKey := ca.IssueServerCert(t, dir, "mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")})
+	clientCert, clientKey := ca.IssueClientCert(t, dir, "ghost.alpha")
+
+	cfg := DefaultServiceConfig()
+	cfg.Session.SecurityMode = session.SecurityModeProduction
+	cfg.Session.TLS = session.TLSConfig{
+		Enabled:  true,
+		Mutual:   true,
+		CertFile: serverCert,
+		KeyFile:  serverKey,
+		CAFile:   ca.CAFile(),
+	}
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	svc := NewServiceWithConfig(cfg)
+	tlsCfg, err := svc.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("server tls config: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatalf("listen tls: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	clientSessionCfg := session.DefaultConfig()
+	clientSessionCfg.SecurityMode = session.SecurityModeProduction
+	clientSessionCfg.TLS = session.TLSConfig{
+		Enabled:    true,
+		Mutual:     true,
+		CertFile:   clientCert,
+		KeyFile:    clientKey,
+		CAFile:     ca.CAFile(),
+		ServerName: "mirage.local",
+	}
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		Session:            clientSessionCfg,
+		MaxConnectAttempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	_ = gs.Close()
+
+	// Rotate every file on both sides to material from a new CA; neither side restarts.
+	next := t.TempDir()
+	nextCA := tlstest.NewAuthority(t, next, "edgectl-test-ca-2")
+	nextServerCert, nextServerKey := nextCA.IssueServerCert(t, next, "mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")})
+	nextClientCert, nextClientKey := nextCA.IssueClientCert(t, next, "ghost.alpha")
+	for dst, src := range map[string]string{
+		serverCert:  nextServerCert,
+		serverKey:   nextServerKey,
+		clientCert:  nextClientCert,
+		clientKey:   nextClientKey,
+		ca.CAFile(): nextCA.CAFile(),
+	} {
+		raw, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("read rotated %s: %v", src, err)
+		}
+		if err := os.WriteFile(dst, raw, 0o600); err != nil {
+			t.Fatalf("rotate %s: %v", dst, err)
+		}
+	}
+
+	// A certificate from the replaced CA no longer passes the reloaded client CA pool.
+	staleCert, staleKey := ca.IssueClientCert(t, dir, "ghost.stale")
+	stalePair, err := tls.LoadX509KeyPair(staleCert, staleKey)
+	if err != nil {
+		t.Fatalf("load stale pair: %v", err)
+	}
+	staleConn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{stalePair},
+	})
+	if err == nil {
+		_ = staleConn.SetDeadline(time.Now().Add(2 * time.Second))
+		_, err = staleConn.Read(make([]byte, 1))
+		_ = staleConn.Close()
+	}
+	if err == nil {
+		t.Fatalf("expected client from replaced CA rejected")
+	}
+
+	gs, err = client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("reconnect with rotated material: %v", err)
+	}
+	_ = gs.Close()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
+func TestServiceClosesSessionWhenClientCertExpires(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	ca := tlstest.NewAuthority(t, dir, "edgectl-test-ca")
+	serverCert, serverKey := ca.IssueServerCert(t, dir, "mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")})
+	// x509 validity has second precision and key generation is slow; leave room to handshake.
+	expiry := time.Now().Add(3 * time.Second)
+	clientCert, clientKey := ca.IssueClientCertUntil(t, dir, "ghost.alpha", expiry)
+
+	cfg := DefaultServiceConfig()
+	cfg.Session.TLS = session.TLSConfig{
+		Enabled:             true,
+		Mutual:              true,
+		CertFile:            serverCert,
+		KeyFile:             serverKey,
+		CAFile:              ca.CAFile(),
+		RehandshakeOnExpiry: true,
+	}
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Session.ReadTimeout = 10 * time.Second
+	cfg.Session.SessionDeadAfter = 10 * time.Second
+	svc := NewServiceWithConfig(cfg)
+	tlsCfg, err := svc.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("server tls config: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatalf("listen tls: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	clientSessionCfg := session.DefaultConfig()
+	clientSessionCfg.TLS = session.TLSConfig{
+		Enabled:    true,
+		Mutual:     true,
+		CertFile:   clientCert,
+		KeyFile:    clientKey,
+		CAFile:     ca.CAFile(),
+		ServerName: "mirage.local",
+	}
+	client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+		Address:            ln.Addr().String(),
+		GhostID:            "ghost.alpha",
+		PeerIdentity:       "ghost.alpha",
+		SeedList:           []session.SeedInfo{},
+		Session:            clientSessionCfg,
+		MaxConnectAttempts: 1,
+	})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer connectCancel()
+	gs, err := client.ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("connect and register: %v", err)
+	}
+	defer gs.Close()
+
+	select {
+	case <-gs.Done():
+	case <-time.After(6 * time.Second):
+		t.Fatalf("session outlived its client certificate")
+	}
+	if time.Now().Before(expiry.Truncate(time.Second)) {
+		t.Fatalf("session closed before certificate expiry %s", expiry)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
+
 func TestServiceRegistrationTLSIdentityMismatchRejected(t *testing.T) {
 	testlog.Start(t)
 
@@ -503,3 +2067,137 @@ func TestServiceRegistrationTLSIdentityMismatchRejected(t *testing.T) {
 		t.Fatalf("serve exit err: %v", err)
 	}
 }
+
+func TestServiceEnrollsGhostWithJoinTokenThenAcceptsMTLS(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	caDir := filepath.Join(dir, "pki")
+	authority, err := pki.Init(caDir, "edgectl-test-ca", 0)
+	if err != nil {
+		t.Fatalf("init ca: %v", err)
+	}
+	server, err := authority.IssueMirage("mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")}, 0)
+	if err != nil {
+		t.Fatalf("issue mirage: %v", err)
+	}
+
+	cfg := DefaultServiceConfig()
+	cfg.RequireIdentityBinding = true
+	cfg.Session.SecurityMode = session.SecurityModeProduction
+	cfg.Session.TLS.Enabled = true
+	cfg.Session.TLS.Mutual = true
+	cfg.Session.TLS.CertFile = server.CertFile
+	cfg.Session.TLS.KeyFile = server.KeyFile
+	cfg.Session.TLS.CAFile = authority.CAFile()
+	cfg.Session.TLS.CRLFile = authority.CRLFile()
+	cfg.Session.HandshakeTimeout = 2 * time.Second
+	cfg.Enrollment.CADir = caDir
+
+	svc := NewServiceWithConfig(cfg)
+	if _, _, err := svc.MintJoinToken("", 0); !errors.Is(err, ErrEnrollmentDisabled) {
+		t.Fatalf("expected enrollment disabled before tls setup, got %v", err)
+	}
+	tlsCfg, err := svc.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("server tls config: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatalf("listen tls: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Serve(ctx, ln)
+	}()
+
+	// Ghost starts with nothing but the token; its TLS paths do not exist yet.
+	var clients int
+	enrollingClient := func(ghostID string, token session.JoinToken) *ghost.MirageClient {
+		clients++
+		ghostDir := filepath.Join(dir, fmt.Sprintf("%s-%d", ghostID, clients))
+		sessionCfg := session.DefaultConfig()
+		sessionCfg.SecurityMode = session.SecurityModeProduction
+		sessionCfg.TLS.Enabled = true
+		sessionCfg.TLS.Mutual = true
+		sessionCfg.TLS.CertFile = filepath.Join(ghostDir, "client.crt")
+		sessionCfg.TLS.KeyFile = filepath.Join(ghostDir, "client.key")
+		sessionCfg.TLS.CAFile = filepath.Join(ghostDir, "ca.crt")
+		sessionCfg.TLS.ServerName = "mirage.local"
+		sessionCfg.TLS.JoinToken = token.String()
+		client, err := ghost.NewMirageClient(ghost.MirageClientConfig{
+			Address: ln.Addr().String(),
+			GhostID: ghostID,
+			SeedList: []session.SeedInfo{
+				{ID: "seed.flow", Name: "Flow", Description: "Deterministic control-flow seed"},
+			},
+			Session:            sessionCfg,
+			MaxConnectAttempts: 1,
+		})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		return client
+	}
+	connectCtx, connectCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer connectCancel()
+
+	token, _, err := svc.MintJoinToken("ghost.alpha", time.Minute)
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+	if _, err := enrollingClient("ghost.beta", token).ConnectAndRegister(connectCtx); !errors.Is(err, ghost.ErrEnrollmentRejected) {
+		t.Fatalf("expected token bound to ghost.alpha rejected for ghost.beta, got %v", err)
+	}
+	gs, err := enrollingClient("ghost.alpha", token).ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("enroll and register: %v", err)
+	}
+	gs.Close()
+
+	// Tokens are one-time.
+	if _, err := enrollingClient("ghost.alpha", token).ConnectAndRegister(connectCtx); !errors.Is(err, ghost.ErrEnrollmentRejected) {
+		t.Fatalf("expected reused token rejected, got %v", err)
+	}
+	// An unbound token cannot claim an identity that already holds a live cert.
+	open, _, err := svc.MintJoinToken("", time.Minute)
+	if err != nil {
+		t.Fatalf("mint unbound token: %v", err)
+	}
+	if _, err := enrollingClient("ghost.alpha", open).ConnectAndRegister(connectCtx); !errors.Is(err, ghost.ErrEnrollmentRejected) {
+		t.Fatalf("expected takeover of enrolled ghost.alpha rejected, got %v", err)
+	}
+	// A signing failure hands the token back instead of consuming it.
+	gs, err = enrollingClient("ghost.delta", open).ConnectAndRegister(connectCtx)
+	if err != nil {
+		t.Fatalf("expected token restored after rejected signing: %v", err)
+	}
+	gs.Close()
+	entries, err := authority.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var enrolled int
+	for _, entry := range entries {
+		if entry.Kind == pki.KindGhost && entry.Name == "ghost.alpha" {
+			enrolled++
+		}
+	}
+	if enrolled != 1 {
+		t.Fatalf("expected one enrollment recorded, got %+v", entries)
+	}
+
+	// A token pinned to another CA never reaches Mirage with its secret.
+	forged := token
+	forged.CAHash = session.CACertHash([]byte("other-ca"))
+	if _, err := enrollingClient("ghost.gamma", forged).ConnectAndRegister(connectCtx); !errors.Is(err, ghost.ErrEnrollmentRejected) {
+		t.Fatalf("expected pin mismatch rejected, got %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve exit err: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+// Package pki owns the built-in certificate authority behind `edgectl pki`.
+//
+// Ownership boundary:
+// - Mirage CA bootstrap and on-disk layout
+//
+// - per-Ghost client and per-Mirage server certificate issuance
+//
+// - revocation index and CRL publication
+//
+// Session TLS enforcement stays in internal/protocol/session; pki only produces files.
+//
+// Canonical references (consult before changes):
+// - docs/architecture/transport.md
+//
+// - docs/glossary/definitions.md
+package pki
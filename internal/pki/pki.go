@@ -0,0 +1,543 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PKI on-disk layout under the authority directory.
+// Ghost material lands in ghosts/<ghost_id>/ and Mirage material in mirage/<mirage_id>/,
+// so each directory maps directly onto the ghostctl mirage_tls_* and miragectl
+// session_tls_* config keys.
+const (
+	CAFile        = "ca.crt"
+	CAKeyFile     = "ca.key"
+	CRLFile       = "ca.crl"
+	IndexFile     = "index.json"
+	GhostsDir     = "ghosts"
+	MirageDir     = "mirage"
+	ClientCrtFile = "client.crt"
+	ClientKeyFile = "client.key"
+	ServerCrtFile = "server.crt"
+	ServerKeyFile = "server.key"
+)
+
+// PKI certificate kinds recorded in the index.
+const (
+	KindGhost  = "ghost"
+	KindMirage = "mirage"
+)
+
+// PKI default validity windows.
+const (
+	DefaultCAValidity   = 10 * 365 * 24 * time.Hour
+	DefaultCertValidity = 365 * 24 * time.Hour
+	// CRL refresh horizon; revoke rewrites the CRL long before this elapses.
+	crlValidity = 30 * 24 * time.Hour
+)
+
+var (
+	ErrCAExists     = errors.New("pki: certificate authority already exists")
+	ErrCANotFound   = errors.New("pki: certificate authority not found")
+	ErrInvalidName  = errors.New("pki: invalid certificate name")
+	ErrCertNotFound = errors.New("pki: certificate not found")
+	ErrCertActive   = errors.New("pki: unrevoked certificate exists")
+)
+
+// PKI names double as CN, DNS SAN, and directory name, so they stay DNS- and path-safe.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*$`)
+
+// PKI index row for one issued certificate.
+// CertFile and KeyFile are empty for enrolled Ghosts: the cert is returned to the
+// node and the key never leaves it.
+type Entry struct {
+	Serial    string     `json:"serial"`
+	Kind      string     `json:"kind"`
+	Name      string     `json:"name"`
+	NotAfter  time.Time  `json:"not_after"`
+	CertFile  string     `json:"cert_file"`
+	KeyFile   string     `json:"key_file,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// PKI revocation state for an entry.
+func (e Entry) Revoked() bool {
+	return e.RevokedAt != nil
+}
+
+// PKI entry still accepted at now: neither revoked nor expired.
+func (e Entry) Active(now time.Time) bool {
+	return !e.Revoked() && now.Before(e.NotAfter)
+}
+
+// PKI persisted index of issued certificates and the CRL sequence.
+type index struct {
+	CRLNumber int64   `json:"crl_number"`
+	Entries   []Entry `json:"entries"`
+}
+
+// PKI certificate authority rooted at one directory.
+type Authority struct {
+	dir  string
+	cert *x509.Certificate
+	key  crypto.Signer
+
+	mu sync.Mutex
+}
+
+// PKI authority bootstrap: writes a new self-signed CA, an empty index, and an empty CRL.
+func Init(dir string, commonName string, validFor time.Duration) (*Authority, error) {
+	commonName = strings.TrimSpace(commonName)
+	if commonName == "" {
+		return nil, fmt.Errorf("%w: empty ca common name", ErrInvalidName)
+	}
+	if validFor <= 0 {
+		validFor = DefaultCAValidity
+	}
+	if _, err := os.Stat(filepath.Join(dir, CAKeyFile)); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCAExists, dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("pki: generate ca key: %w", err)
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("pki: create ca cert: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("pki: parse ca cert: %w", err)
+	}
+	if err := writeKey(filepath.Join(dir, CAKeyFile), key); err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, CAFile), "CERTIFICATE", der, 0o644); err != nil {
+		return nil, err
+	}
+
+	a := &Authority{dir: dir, cert: cert, key: key}
+	idx := &index{}
+	if err := a.writeCRL(idx); err != nil {
+		return nil, err
+	}
+	if err := a.saveIndex(idx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// PKI authority loader for a directory created by Init.
+func Open(dir string) (*Authority, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CAFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrCANotFound, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("pki: parse ca cert: no certificate block in %s", CAFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("pki: parse ca cert: %w", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("pki: parse ca key: no pem block in %s", CAKeyFile)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("pki: parse ca key: %w", err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("pki: parse ca key: unsupported key type %T", parsed)
+	}
+	return &Authority{dir: dir, cert: cert, key: key}, nil
+}
+
+// PKI CA certificate path for session_tls_ca_file / mirage_tls_ca_file.
+func (a *Authority) CAFile() string {
+	return filepath.Join(a.dir, CAFile)
+}
+
+// PKI CRL path for session_tls_crl_file.
+func (a *Authority) CRLFile() string {
+	return filepath.Join(a.dir, CRLFile)
+}
+
+// PKI CA certificate used to sign every leaf.
+func (a *Authority) Certificate() *x509.Certificate {
+	return a.cert
+}
+
+// PKI Ghost client certificate with the Ghost ID as CN and DNS SAN.
+// The CN is what Mirage binds to the registering ghost_id when identity binding is on.
+// A copy of the CA lands beside the pair so the directory is self-contained.
+func (a *Authority) IssueGhost(ghostID string, validFor time.Duration) (Entry, error) {
+	ghostID = strings.TrimSpace(ghostID)
+	if err := validateName(ghostID); err != nil {
+		return Entry{}, err
+	}
+	entry, _, err := a.issue(a.ghostLeaf(ghostID, nil), validFor)
+	if err != nil {
+		return Entry{}, err
+	}
+	return entry, a.writeGhostCA(ghostID)
+}
+
+// PKI Ghost client certificate for a key the Ghost generated itself (enrollment).
+// The CSR must be self-signed by its key; its subject is ignored and replaced by
+// ghostID, so the token holder cannot pick another identity. Returns the DER leaf.
+// Fails with ErrCertActive while ghostID holds an unrevoked, unexpired certificate,
+// so a join token cannot take over an enrolled Ghost; revoke it first to re-enroll.
+func (a *Authority) SignGhostCSR(ghostID string, csrDER []byte, validFor time.Duration) (Entry, []byte, error) {
+	ghostID = strings.TrimSpace(ghostID)
+	if err := validateName(ghostID); err != nil {
+		return Entry{}, nil, err
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return Entry{}, nil, fmt.Errorf("pki: parse csr: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return Entry{}, nil, fmt.Errorf("pki: csr signature: %w", err)
+	}
+	spec := a.ghostLeaf(ghostID, csr.PublicKey)
+	spec.exclusive = true
+	entry, der, err := a.issue(spec, validFor)
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	return entry, der, nil
+}
+
+// PKI Mirage server certificate; the Mirage ID is the CN and dnsNames/ips are the SANs
+// Ghosts dial. With no SANs the Mirage ID itself becomes the DNS SAN. The cert file
+// carries the CA after the leaf so enrolling Ghosts can check their join-token pin.
+func (a *Authority) IssueMirage(mirageID string, dnsNames []string, ips []net.IP, validFor time.Duration) (Entry, error) {
+	mirageID = strings.TrimSpace(mirageID)
+	if err := validateName(mirageID); err != nil {
+		return Entry{}, err
+	}
+	if len(dnsNames) == 0 && len(ips) == 0 {
+		dnsNames = []string{mirageID}
+	}
+	entry, _, err := a.issue(leafSpec{
+		kind:      KindMirage,
+		name:      mirageID,
+		dir:       filepath.Join(a.dir, MirageDir, mirageID),
+		certName:  ServerCrtFile,
+		keyName:   ServerKeyFile,
+		fullChain: true,
+		shape: func(t *x509.Certificate) {
+			t.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+			t.DNSNames = dnsNames
+			t.IPAddresses = ips
+		},
+	}, validFor)
+	return entry, err
+}
+
+// PKI leaf request shared by every issuer.
+// A nil pub generates a key and writes the pair under dir; otherwise nothing is written
+// locally, so a CSR-signed cert never sits beside a stale key from an earlier issue.
+// exclusive refuses to issue while name already holds an active certificate.
+type leafSpec struct {
+	kind      string
+	name      string
+	dir       string
+	certName  string
+	keyName   string
+	pub       crypto.PublicKey
+	fullChain bool
+	exclusive bool
+	shape     func(*x509.Certificate)
+}
+
+// PKI Ghost client leaf request.
+func (a *Authority) ghostLeaf(ghostID string, pub crypto.PublicKey) leafSpec {
+	return leafSpec{
+		kind:     KindGhost,
+		name:     ghostID,
+		dir:      filepath.Join(a.dir, GhostsDir, ghostID),
+		certName: ClientCrtFile,
+		keyName:  ClientKeyFile,
+		pub:      pub,
+		shape: func(t *x509.Certificate) {
+			t.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+			t.DNSNames = []string{ghostID}
+		},
+	}
+}
+
+// PKI CA copy beside a Ghost's client pair.
+func (a *Authority) writeGhostCA(ghostID string) error {
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.cert.Raw})
+	return os.WriteFile(filepath.Join(a.dir, GhostsDir, ghostID, CAFile), caPEM, 0o644)
+}
+
+// PKI revocation by serial (hex) or by name; a name revokes every active cert issued to it.
+// The CRL is rewritten so Mirage rejects the serials on the next handshake.
+func (a *Authority) Revoke(ref string) ([]Entry, error) {
+	ref = strings.TrimSpace(ref)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	var revoked []Entry
+	for i := range idx.Entries {
+		e := &idx.Entries[i]
+		if e.Revoked() || (!strings.EqualFold(e.Serial, ref) && e.Name != ref) {
+			continue
+		}
+		e.RevokedAt = &now
+		revoked = append(revoked, *e)
+	}
+	if len(revoked) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrCertNotFound, ref)
+	}
+	if err := a.writeCRL(idx); err != nil {
+		return nil, err
+	}
+	if err := a.saveIndex(idx); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// PKI issued certificates ordered by kind, name, then expiry.
+func (a *Authority) List() ([]Entry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	out := append([]Entry(nil), idx.Entries...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].NotAfter.Before(out[j].NotAfter)
+	})
+	return out, nil
+}
+
+// PKI shared leaf issuer; spec.shape fills in usage and SANs for the kind.
+// Reissuing a name overwrites its files; the old serial stays valid until revoked.
+func (a *Authority) issue(spec leafSpec, validFor time.Duration) (Entry, []byte, error) {
+	if validFor <= 0 {
+		validFor = DefaultCertValidity
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	idx, err := a.loadIndex()
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	now := time.Now()
+	if spec.exclusive {
+		for _, e := range idx.Entries {
+			if e.Kind == spec.kind && e.Name == spec.name && e.Active(now) {
+				return Entry{}, nil, fmt.Errorf("%w: %s %q serial=%s", ErrCertActive, spec.kind, spec.name, e.Serial)
+			}
+		}
+	}
+
+	var key *ecdsa.PrivateKey
+	pub := spec.pub
+	if pub == nil {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return Entry{}, nil, fmt.Errorf("pki: generate key: %w", err)
+		}
+		pub = &key.PublicKey
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	notAfter := now.Add(validFor)
+	if notAfter.After(a.cert.NotAfter) {
+		notAfter = a.cert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: spec.name},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	spec.shape(template)
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, pub, a.key)
+	if err != nil {
+		return Entry{}, nil, fmt.Errorf("pki: create %s cert: %w", spec.kind, err)
+	}
+
+	entry := Entry{
+		Serial:   serial.Text(16),
+		Kind:     spec.kind,
+		Name:     spec.name,
+		NotAfter: notAfter.UTC().Truncate(time.Second),
+	}
+	if key != nil {
+		if err := os.MkdirAll(spec.dir, 0o700); err != nil {
+			return Entry{}, nil, err
+		}
+		entry.CertFile = filepath.Join(spec.dir, spec.certName)
+		entry.KeyFile = filepath.Join(spec.dir, spec.keyName)
+		if err := writeKey(entry.KeyFile, key); err != nil {
+			return Entry{}, nil, err
+		}
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		if spec.fullChain {
+			certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.cert.Raw})...)
+		}
+		if err := os.WriteFile(entry.CertFile, certPEM, 0o644); err != nil {
+			return Entry{}, nil, err
+		}
+	}
+
+	idx.Entries = append(idx.Entries, entry)
+	if err := a.saveIndex(idx); err != nil {
+		return Entry{}, nil, err
+	}
+	return entry, der, nil
+}
+
+// PKI CRL signed by the CA listing every revoked serial in idx; bumps the CRL number.
+func (a *Authority) writeCRL(idx *index) error {
+	idx.CRLNumber++
+	var entries []x509.RevocationListEntry
+	for _, e := range idx.Entries {
+		if !e.Revoked() {
+			continue
+		}
+		serial, ok := new(big.Int).SetString(e.Serial, 16)
+		if !ok {
+			return fmt.Errorf("pki: malformed serial in index: %q", e.Serial)
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: *e.RevokedAt,
+		})
+	}
+	now := time.Now()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(idx.CRLNumber),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(crlValidity),
+		RevokedCertificateEntries: entries,
+	}, a.cert, a.key)
+	if err != nil {
+		return fmt.Errorf("pki: create crl: %w", err)
+	}
+	return writePEM(a.CRLFile(), "X509 CRL", der, 0o644)
+}
+
+// PKI index reader; a missing index is an empty authority.
+func (a *Authority) loadIndex() (*index, error) {
+	raw, err := os.ReadFile(filepath.Join(a.dir, IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &index{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var idx index
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, fmt.Errorf("pki: parse index: %w", err)
+	}
+	return &idx, nil
+}
+
+// PKI index writer.
+func (a *Authority) saveIndex(idx *index) error {
+	raw, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.dir, IndexFile), append(raw, '\n'), 0o600)
+}
+
+// PKI name validation for Ghost and Mirage IDs.
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// PKI random 128-bit positive serial.
+func newSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("pki: generate serial: %w", err)
+	}
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// PKI private key writer in PKCS#8 PEM with owner-only permissions.
+func writeKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("pki: marshal key: %w", err)
+	}
+	return writePEM(path, "PRIVATE KEY", der, 0o600)
+}
+
+// PKI helper that writes one PEM block to disk.
+func writePEM(path string, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	return os.WriteFile(path, data, perm)
+}
@@ -0,0 +1,212 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/session"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestIssueGhostBindsIDAndChainsToCA(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	a, err := Init(dir, "edgectl-test-ca", 0)
+	if err != nil {
+		t.Fatalf("init ca: %v", err)
+	}
+	if _, err := Init(dir, "edgectl-test-ca", 0); !errors.Is(err, ErrCAExists) {
+		t.Fatalf("expected ErrCAExists on second init, got %v", err)
+	}
+	entry, err := a.IssueGhost("ghost.alpha", 48*time.Hour)
+	if err != nil {
+		t.Fatalf("issue ghost: %v", err)
+	}
+	if entry.CertFile != filepath.Join(dir, GhostsDir, "ghost.alpha", ClientCrtFile) {
+		t.Fatalf("unexpected ghost cert path: %q", entry.CertFile)
+	}
+	if _, err := tls.LoadX509KeyPair(entry.CertFile, entry.KeyFile); err != nil {
+		t.Fatalf("load issued pair: %v", err)
+	}
+	cert := readCert(t, entry.CertFile)
+	if cert.Subject.CommonName != "ghost.alpha" || len(cert.DNSNames) != 1 || cert.DNSNames[0] != "ghost.alpha" {
+		t.Fatalf("expected ghost id in CN and SAN, got cn=%q dns=%v", cert.Subject.CommonName, cert.DNSNames)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(readCert(t, filepath.Join(dir, GhostsDir, "ghost.alpha", CAFile)))
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatalf("verify ghost cert against bundled ca: %v", err)
+	}
+
+	if _, err := a.IssueGhost("../escape", 0); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName for path-like id, got %v", err)
+	}
+}
+
+func TestIssueMirageUsesRequestedSANs(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	if _, err := Init(dir, "edgectl-test-ca", 0); err != nil {
+		t.Fatalf("init ca: %v", err)
+	}
+	a, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open ca: %v", err)
+	}
+	entry, err := a.IssueMirage("mirage.main", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")}, 0)
+	if err != nil {
+		t.Fatalf("issue mirage: %v", err)
+	}
+	cert := readCert(t, entry.CertFile)
+	if err := cert.VerifyHostname("127.0.0.1"); err != nil {
+		t.Fatalf("expected ip SAN: %v", err)
+	}
+	if err := cert.VerifyHostname("mirage.local"); err != nil {
+		t.Fatalf("expected dns SAN: %v", err)
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Fatalf("expected server auth usage, got %v", cert.ExtKeyUsage)
+	}
+}
+
+func TestSignGhostCSRBindsRequestedGhostID(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	a, err := Init(dir, "edgectl-test-ca", 0)
+	if err != nil {
+		t.Fatalf("init ca: %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	// The CSR subject is the requester's claim; the signed identity comes from ghostID.
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "ghost.other"},
+	}, key)
+	if err != nil {
+		t.Fatalf("create csr: %v", err)
+	}
+	entry, der, err := a.SignGhostCSR("ghost.alpha", csr, 0)
+	if err != nil {
+		t.Fatalf("sign csr: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse signed cert: %v", err)
+	}
+	if cert.Subject.CommonName != "ghost.alpha" || !key.PublicKey.Equal(cert.PublicKey) {
+		t.Fatalf("expected ghost.alpha cert for csr key, got cn=%q", cert.Subject.CommonName)
+	}
+	if entry.KeyFile != "" || entry.CertFile != "" {
+		t.Fatalf("expected no local files for enrolled ghost, got %+v", entry)
+	}
+	if _, err := os.Stat(filepath.Join(dir, GhostsDir, "ghost.alpha")); !os.IsNotExist(err) {
+		t.Fatalf("expected no ghost directory written for csr signing, got %v", err)
+	}
+	entries, err := a.List()
+	if err != nil || len(entries) != 1 || entries[0].Serial != entry.Serial {
+		t.Fatalf("expected enrollment recorded in index, got %+v err=%v", entries, err)
+	}
+
+	if _, _, err := a.SignGhostCSR("ghost.alpha", csr, 0); !errors.Is(err, ErrCertActive) {
+		t.Fatalf("expected enrollment over an active cert rejected, got %v", err)
+	}
+	if _, err := a.Revoke(entry.Serial); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := a.SignGhostCSR("ghost.alpha", csr, 0); err != nil {
+		t.Fatalf("expected re-enrollment after revoke: %v", err)
+	}
+
+	csr[len(csr)-1] ^= 0xff
+	if _, _, err := a.SignGhostCSR("ghost.beta", csr, 0); err == nil {
+		t.Fatalf("expected tampered csr rejected")
+	}
+}
+
+func TestRevokeRejectsGhostThroughSessionCRL(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	a, err := Init(dir, "edgectl-test-ca", 0)
+	if err != nil {
+		t.Fatalf("init ca: %v", err)
+	}
+	keep, err := a.IssueGhost("ghost.keep", 0)
+	if err != nil {
+		t.Fatalf("issue ghost.keep: %v", err)
+	}
+	drop, err := a.IssueGhost("ghost.drop", 0)
+	if err != nil {
+		t.Fatalf("issue ghost.drop: %v", err)
+	}
+
+	files, err := session.NewTLSReloader(session.TLSFiles{CAFile: a.CAFile(), CRLFile: a.CRLFile()})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	if err := files.VerifyClient([][]byte{readCert(t, drop.CertFile).Raw}, nil); err != nil {
+		t.Fatalf("expected ghost.drop valid before revoke: %v", err)
+	}
+
+	revoked, err := a.Revoke("ghost.drop")
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if len(revoked) != 1 || revoked[0].Serial != drop.Serial {
+		t.Fatalf("unexpected revoked entries: %+v", revoked)
+	}
+	if err := files.VerifyClient([][]byte{readCert(t, drop.CertFile).Raw}, nil); !errors.Is(err, session.ErrTLSCertificateRevoked) {
+		t.Fatalf("expected revoked ghost rejected, got %v", err)
+	}
+	if err := files.VerifyClient([][]byte{readCert(t, keep.CertFile).Raw}, nil); err != nil {
+		t.Fatalf("expected unrevoked ghost still valid: %v", err)
+	}
+	if _, err := a.Revoke(drop.Serial); !errors.Is(err, ErrCertNotFound) {
+		t.Fatalf("expected second revoke to find nothing active, got %v", err)
+	}
+
+	entries, err := a.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "ghost.drop" || !entries[0].Revoked() || entries[1].Revoked() {
+		t.Fatalf("unexpected list: %+v", entries)
+	}
+}
+
+// First certificate in a PEM file.
+func readCert(t *testing.T, path string) *x509.Certificate {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		t.Fatalf("no pem block in %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	return cert
+}
@@ -0,0 +1,335 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	logs "github.com/danmuck/smplog"
+)
+
+const (
+	// Frame initial capacity of pooled codec buffers.
+	codecBufferSize = 4 * 1024
+	// Frame largest buffer returned to the pool; bigger buffers are left to the GC
+	// so one oversized message does not pin memory for every idle session.
+	maxPooledBuffer = 1024 * 1024
+)
+
+var codecBuffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, codecBufferSize)
+		return &b
+	},
+}
+
+// Frame pooled buffer checkout.
+func getBuffer() *[]byte {
+	return codecBuffers.Get().(*[]byte)
+}
+
+// Frame pooled buffer return; oversized buffers are dropped.
+func putBuffer(b *[]byte) {
+	if b == nil || cap(*b) > maxPooledBuffer {
+		return
+	}
+	*b = (*b)[:0]
+	codecBuffers.Put(b)
+}
+
+// Frame streaming decoder bound to one reader with a reusable buffer.
+// Auth and Payload of a decoded frame alias the decoder buffer and are only valid
+// until the next Decode or Release; callers that keep them must copy.
+// A Decoder is not safe for concurrent use.
+type Decoder struct {
+	r      io.Reader
+	limits Limits
+	fixed  [FixedHeaderLen]byte
+	buf    *[]byte
+}
+
+// Frame decoder constructor reading frames from r within limits.
+func NewDecoder(r io.Reader, limits Limits) *Decoder {
+	return &Decoder{r: r, limits: limits}
+}
+
+// Decode reads one full frame with the same structural validation as ReadFrame.
+func (d *Decoder) Decode() (Frame, error) {
+	if _, err := io.ReadFull(d.r, d.fixed[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return Frame{}, ErrShortHeader
+		}
+		return Frame{}, err
+	}
+	h, err := parseHeader(d.fixed[:])
+	if err != nil {
+		logs.Errf("frame.Decoder.Decode decode header err=%v", err)
+		return Frame{}, err
+	}
+	authLen, err := checkBodyLengths(h, d.limits)
+	if err != nil {
+		logs.Errf("frame.Decoder.Decode message_id=%d err=%v", h.MessageID, err)
+		return Frame{}, err
+	}
+
+	n := int(authLen + h.PayloadLen)
+	if d.buf == nil {
+		d.buf = getBuffer()
+	}
+	if cap(*d.buf) < n {
+		*d.buf = make([]byte, n)
+	}
+	body := (*d.buf)[:n]
+	if _, err := io.ReadFull(d.r, body); err != nil {
+		logs.Errf("frame.Decoder.Decode body read message_id=%d err=%v", h.MessageID, err)
+		return Frame{}, err
+	}
+	return Frame{
+		Header:  h,
+		Auth:    body[:authLen:authLen],
+		Payload: body[authLen:],
+	}, nil
+}
+
+// Release returns the decoder buffer to the shared pool.
+// Frames returned by earlier Decode calls must no longer be used.
+func (d *Decoder) Release() {
+	putBuffer(d.buf)
+	d.buf = nil
+}
+
+// Frame streaming encoder bound to one writer with a reusable buffer.
+// Each frame, or each message with EncodeMessage, is written with a single Write call.
+// An Encoder is not safe for concurrent use.
+type Encoder struct {
+	w      io.Writer
+	limits Limits
+	opts   EncoderOptions
+	buf    *[]byte
+}
+
+// Frame per-stream settings applied by Encoder.EncodeMessage.
+// Version 0 keeps the message header version; CompressThreshold 0 disables
+// compression; a nil Auth leaves frames unsigned.
+type EncoderOptions struct {
+	Version           uint16
+	CompressThreshold int
+	Auth              Authenticator
+}
+
+// Frame encoder constructor writing frames to w within limits.
+func NewEncoder(w io.Writer, limits Limits) *Encoder {
+	return &Encoder{w: w, limits: limits}
+}
+
+// Frame encoder constructor applying opts to every EncodeMessage call.
+func NewEncoderWithOptions(w io.Writer, limits Limits, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, limits: limits, opts: opts}
+}
+
+// Encode writes one full frame with the same structural validation as WriteFrame.
+func (e *Encoder) Encode(f Frame) error {
+	h, err := prepareHeader(f, e.limits)
+	if err != nil {
+		logs.Errf("frame.Encoder.Encode message_id=%d err=%v", f.Header.MessageID, err)
+		return err
+	}
+	if e.buf == nil {
+		e.buf = getBuffer()
+	}
+	out := AppendHeader((*e.buf)[:0], h)
+	out = append(out, f.Auth...)
+	out = append(out, f.Payload...)
+	*e.buf = out
+	if _, err := e.w.Write(out); err != nil {
+		logs.Errf("frame.Encoder.Encode write message_id=%d err=%v", h.MessageID, err)
+		return err
+	}
+	return nil
+}
+
+// EncodeMessage writes one logical message: the payload is versioned, compressed,
+// fragmented, and each frame signed per the encoder options, building every frame once.
+func (e *Encoder) EncodeMessage(f Frame) error {
+	if e.opts.Version != 0 {
+		f.Header.Version = e.opts.Version
+	}
+	f, err := CompressFrame(f, e.opts.CompressThreshold)
+	if err != nil {
+		return err
+	}
+	frames, err := Fragment(f, e.limits, f.Header.MessageID)
+	if err != nil {
+		return err
+	}
+	if e.buf == nil {
+		e.buf = getBuffer()
+	}
+	out := (*e.buf)[:0]
+	for _, fr := range frames {
+		if fr, err = SignFrame(fr, e.opts.Auth); err != nil {
+			return err
+		}
+		if out, err = AppendFrame(out, fr, e.limits); err != nil {
+			logs.Errf("frame.Encoder.EncodeMessage message_id=%d err=%v", f.Header.MessageID, err)
+			return err
+		}
+	}
+	*e.buf = out
+	if _, err := e.w.Write(out); err != nil {
+		logs.Errf("frame.Encoder.EncodeMessage write message_id=%d err=%v", f.Header.MessageID, err)
+		return err
+	}
+	return nil
+}
+
+// Release returns the encoder buffer to the shared pool.
+func (e *Encoder) Release() {
+	putBuffer(e.buf)
+	e.buf = nil
+}
+
+// Frame encoder appending one full frame to dst with WriteFrame validation.
+func AppendFrame(dst []byte, f Frame, limits Limits) ([]byte, error) {
+	h, err := prepareHeader(f, limits)
+	if err != nil {
+		return dst, err
+	}
+	dst = AppendHeader(dst, h)
+	dst = append(dst, f.Auth...)
+	return append(dst, f.Payload...), nil
+}
+
+// Frame encoder appending one logical message to dst, fragmenting like WriteMessage.
+func AppendMessage(dst []byte, f Frame, limits Limits) ([]byte, error) {
+	if uint64(len(f.Payload)) <= limits.MaxPayloadBytes {
+		return AppendFrame(dst, f, limits)
+	}
+	frames, err := Fragment(f, limits, f.Header.MessageID)
+	if err != nil {
+		return dst, err
+	}
+	for _, fr := range frames {
+		if dst, err = AppendFrame(dst, fr, limits); err != nil {
+			return dst, err
+		}
+	}
+	return dst, nil
+}
+
+// Frame zero-copy parser for one frame at the start of raw.
+// Auth and Payload alias raw; n is the number of bytes the frame occupies.
+func ParseFrame(raw []byte, limits Limits) (f Frame, n int, err error) {
+	if len(raw) < int(FixedHeaderLen) {
+		return Frame{}, 0, ErrShortHeader
+	}
+	h, err := parseHeader(raw[:FixedHeaderLen])
+	if err != nil {
+		return Frame{}, 0, err
+	}
+	authLen, err := checkBodyLengths(h, limits)
+	if err != nil {
+		return Frame{}, 0, err
+	}
+	start := uint64(FixedHeaderLen)
+	end := start + authLen + h.PayloadLen
+	if end > uint64(len(raw)) {
+		return Frame{}, 0, io.ErrUnexpectedEOF
+	}
+	return Frame{
+		Header:  h,
+		Auth:    raw[start : start+authLen : start+authLen],
+		Payload: raw[start+authLen : end : end],
+	}, int(end), nil
+}
+
+// Frame header serializer appending fixed-width header bytes to dst.
+func AppendHeader(dst []byte, h Header) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, h.Magic)
+	dst = binary.BigEndian.AppendUint16(dst, h.Version)
+	dst = binary.BigEndian.AppendUint16(dst, h.HeaderLen)
+	dst = binary.BigEndian.AppendUint64(dst, h.MessageID)
+	dst = binary.BigEndian.AppendUint32(dst, h.MessageType)
+	dst = binary.BigEndian.AppendUint32(dst, h.Flags)
+	dst = binary.BigEndian.AppendUint64(dst, h.PayloadLen)
+	return dst
+}
+
+// Frame fixed header parse and validation without logging on success.
+func parseHeader(b []byte) (Header, error) {
+	if len(b) != int(FixedHeaderLen) {
+		return Header{}, fmt.Errorf("frame: invalid fixed header length: %d", len(b))
+	}
+	h := Header{
+		Magic:       binary.BigEndian.Uint32(b[0:4]),
+		Version:     binary.BigEndian.Uint16(b[4:6]),
+		HeaderLen:   binary.BigEndian.Uint16(b[6:8]),
+		MessageID:   binary.BigEndian.Uint64(b[8:16]),
+		MessageType: binary.BigEndian.Uint32(b[16:20]),
+		Flags:       binary.BigEndian.Uint32(b[20:24]),
+		PayloadLen:  binary.BigEndian.Uint64(b[24:32]),
+	}
+	if h.Magic != ProtocolMagic {
+		return Header{}, fmt.Errorf("%w: magic=0x%08X", ErrUnsupportedMagic, h.Magic)
+	}
+	if !SupportsVersion(h.Version) {
+		return Header{}, fmt.Errorf("%w: version=%d", ErrUnsupportedVersion, h.Version)
+	}
+	if h.Flags&^SupportedFlags != 0 {
+		return Header{}, fmt.Errorf("%w: flags=0x%08X", ErrUnsupportedFlags, h.Flags)
+	}
+	return h, nil
+}
+
+// Frame auth and payload length checks for a decoded header; returns the auth length.
+func checkBodyLengths(h Header, limits Limits) (uint64, error) {
+	if h.HeaderLen < FixedHeaderLen {
+		return 0, ErrHeaderLenTooSmall
+	}
+	authLen := uint64(h.HeaderLen - FixedHeaderLen)
+	if h.Flags&FlagHasAuth != 0 && authLen == 0 {
+		return 0, ErrHeaderLenMismatch
+	}
+	if authLen > limits.MaxAuthBytes {
+		return 0, ErrAuthTooLarge
+	}
+	if h.PayloadLen > limits.MaxPayloadBytes {
+		return 0, ErrPayloadTooLarge
+	}
+	return authLen, nil
+}
+
+// Frame header normalization and validation shared by WriteFrame and Encoder.
+func prepareHeader(f Frame, limits Limits) (Header, error) {
+	authLen := uint64(len(f.Auth))
+	payloadLen := uint64(len(f.Payload))
+	if authLen > limits.MaxAuthBytes {
+		return Header{}, ErrAuthTooLarge
+	}
+	if payloadLen > limits.MaxPayloadBytes {
+		return Header{}, ErrPayloadTooLarge
+	}
+	h := f.Header
+	if h.Magic == 0 {
+		h.Magic = ProtocolMagic
+	}
+	if h.Version == 0 {
+		h.Version = ProtocolVersion
+	}
+	if !SupportsVersion(h.Version) {
+		return Header{}, fmt.Errorf("%w: version=%d", ErrUnsupportedVersion, h.Version)
+	}
+	if h.Flags&^SupportedFlags != 0 {
+		return Header{}, fmt.Errorf("%w: flags=0x%08X", ErrUnsupportedFlags, h.Flags)
+	}
+	h.HeaderLen = FixedHeaderLen + uint16(authLen)
+	h.PayloadLen = payloadLen
+	if authLen > 0 {
+		h.Flags |= FlagHasAuth
+	} else {
+		h.Flags &^= FlagHasAuth
+	}
+	return h, nil
+}
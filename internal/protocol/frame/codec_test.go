@@ -0,0 +1,204 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+// loopReader replays one encoded stream forever so benchmarks never hit EOF.
+type loopReader struct {
+	raw []byte
+	off int
+}
+
+func (l *loopReader) Read(p []byte) (int, error) {
+	n := copy(p, l.raw[l.off:])
+	l.off = (l.off + n) % len(l.raw)
+	return n, nil
+}
+
+func codecTestFrame(messageID uint64) Frame {
+	return Frame{
+		Header: Header{MessageID: messageID, MessageType: 2},
+		Payload: tlv.EncodeFields([]tlv.Field{
+			{ID: 1, Type: tlv.TypeString, Value: []byte("intent-1")},
+			{ID: 2, Type: tlv.TypeString, Value: []byte("ghost.alpha")},
+			{ID: 3, Type: tlv.TypeBytes, Value: bytes.Repeat([]byte{0x5A}, 256)},
+		}),
+	}
+}
+
+func TestEncoderDecoderRoundTripReusesBuffer(t *testing.T) {
+	testlog.Start(t)
+	var wire bytes.Buffer
+	enc := NewEncoder(&wire, DefaultLimits())
+	defer enc.Release()
+	first := codecTestFrame(1)
+	first.Auth = []byte("auth")
+	if err := enc.Encode(first); err != nil {
+		t.Fatalf("encode first: %v", err)
+	}
+	if err := enc.Encode(codecTestFrame(2)); err != nil {
+		t.Fatalf("encode second: %v", err)
+	}
+
+	var legacy bytes.Buffer
+	if err := WriteFrame(&legacy, first, DefaultLimits()); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	if !bytes.HasPrefix(wire.Bytes(), legacy.Bytes()) {
+		t.Fatalf("encoder output differs from WriteFrame")
+	}
+
+	dec := NewDecoder(&wire, DefaultLimits())
+	defer dec.Release()
+	a, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("decode first: %v", err)
+	}
+	if a.Header.MessageID != 1 || string(a.Auth) != "auth" || a.Header.Flags&FlagHasAuth == 0 {
+		t.Fatalf("unexpected first frame header=%+v auth=%q", a.Header, a.Auth)
+	}
+	firstBody := &a.Auth[0]
+	b, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("decode second: %v", err)
+	}
+	if b.Header.MessageID != 2 || len(b.Auth) != 0 || !bytes.Equal(b.Payload, codecTestFrame(2).Payload) {
+		t.Fatalf("unexpected second frame header=%+v", b.Header)
+	}
+	if &b.Payload[0] != firstBody {
+		t.Fatalf("expected second frame to reuse the decoder buffer")
+	}
+	if _, err := dec.Decode(); !errors.Is(err, ErrShortHeader) {
+		t.Fatalf("expected ErrShortHeader at end of stream, got %v", err)
+	}
+}
+
+func TestDecoderAppliesReadFrameValidation(t *testing.T) {
+	testlog.Start(t)
+	limits := Limits{MaxAuthBytes: 16, MaxPayloadBytes: 8}
+	var raw bytes.Buffer
+	if err := WriteFrame(&raw, Frame{Header: Header{MessageID: 1, MessageType: 1}, Payload: make([]byte, 9)}, DefaultLimits()); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	if _, err := NewDecoder(bytes.NewReader(raw.Bytes()), limits).Decode(); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+	}
+	if _, _, err := ParseFrame(raw.Bytes(), limits); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge from ParseFrame, got %v", err)
+	}
+
+	bad := append([]byte(nil), raw.Bytes()...)
+	bad[0] = 0
+	if _, err := NewDecoder(bytes.NewReader(bad), DefaultLimits()).Decode(); !errors.Is(err, ErrUnsupportedMagic) {
+		t.Fatalf("expected ErrUnsupportedMagic, got %v", err)
+	}
+	truncated := raw.Bytes()[:raw.Len()-1]
+	if _, err := NewDecoder(bytes.NewReader(truncated), DefaultLimits()).Decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, _, err := ParseFrame(truncated, DefaultLimits()); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF from ParseFrame, got %v", err)
+	}
+}
+
+func TestParseFrameAliasesInput(t *testing.T) {
+	testlog.Start(t)
+	raw, err := AppendFrame(nil, codecTestFrame(9), DefaultLimits())
+	if err != nil {
+		t.Fatalf("append frame: %v", err)
+	}
+	fr, n, err := ParseFrame(raw, DefaultLimits())
+	if err != nil {
+		t.Fatalf("parse frame: %v", err)
+	}
+	if n != len(raw) || fr.Header.MessageID != 9 {
+		t.Fatalf("unexpected parse n=%d header=%+v", n, fr.Header)
+	}
+	raw[len(raw)-1] ^= 0xFF
+	if fr.Payload[len(fr.Payload)-1] != raw[len(raw)-1] {
+		t.Fatalf("expected payload to alias input bytes")
+	}
+	if cap(fr.Payload) != len(fr.Payload) {
+		t.Fatalf("expected payload capacity clipped to prevent appends into the input")
+	}
+}
+
+func TestDecoderSteadyStateDoesNotAllocate(t *testing.T) {
+	testlog.Start(t)
+	raw, err := AppendFrame(nil, codecTestFrame(1), DefaultLimits())
+	if err != nil {
+		t.Fatalf("append frame: %v", err)
+	}
+	dec := NewDecoder(&loopReader{raw: raw}, DefaultLimits())
+	defer dec.Release()
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := dec.Decode(); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected zero allocs per decoded frame, got %.1f", allocs)
+	}
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	raw, err := AppendFrame(nil, codecTestFrame(1), DefaultLimits())
+	if err != nil {
+		b.Fatalf("append frame: %v", err)
+	}
+	r := &loopReader{raw: raw}
+	limits := DefaultLimits()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		if _, err := ReadFrame(r, limits); err != nil {
+			b.Fatalf("read frame: %v", err)
+		}
+	}
+}
+
+func BenchmarkDecoderDecode(b *testing.B) {
+	raw, err := AppendFrame(nil, codecTestFrame(1), DefaultLimits())
+	if err != nil {
+		b.Fatalf("append frame: %v", err)
+	}
+	dec := NewDecoder(&loopReader{raw: raw}, DefaultLimits())
+	defer dec.Release()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		if _, err := dec.Decode(); err != nil {
+			b.Fatalf("decode: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteFrame(b *testing.B) {
+	f := codecTestFrame(1)
+	limits := DefaultLimits()
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := WriteFrame(io.Discard, f, limits); err != nil {
+			b.Fatalf("write frame: %v", err)
+		}
+	}
+}
+
+func BenchmarkEncoderEncode(b *testing.B) {
+	f := codecTestFrame(1)
+	enc := NewEncoder(io.Discard, DefaultLimits())
+	defer enc.Release()
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := enc.Encode(f); err != nil {
+			b.Fatalf("encode: %v", err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package frame
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+
+	logs "github.com/danmuck/smplog"
+)
+
+// Frame default payload size at or above which compression is attempted.
+const DefaultCompressThreshold = 1024
+
+var (
+	ErrCompressionInvalid   = errors.New("frame: invalid compressed payload")
+	ErrDecompressedTooLarge = errors.New("frame: decompressed payload too large")
+)
+
+// Frame DEFLATE compressor for one logical message payload.
+// Payloads below threshold, already compressed, or not shrinking are returned unchanged.
+func CompressFrame(f Frame, threshold int) (Frame, error) {
+	if threshold <= 0 || len(f.Payload) < threshold || f.Header.Flags&FlagIsCompressed != 0 {
+		return f, nil
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return Frame{}, err
+	}
+	if _, err := w.Write(f.Payload); err != nil {
+		return Frame{}, err
+	}
+	if err := w.Close(); err != nil {
+		return Frame{}, err
+	}
+	if buf.Len() >= len(f.Payload) {
+		return f, nil
+	}
+	logs.Debugf(
+		"frame.CompressFrame message_id=%d raw_len=%d compressed_len=%d",
+		f.Header.MessageID,
+		len(f.Payload),
+		buf.Len(),
+	)
+	f.Header.Flags |= FlagIsCompressed
+	f.Payload = buf.Bytes()
+	f.Header.PayloadLen = uint64(len(f.Payload))
+	return f, nil
+}
+
+// Frame DEFLATE decompressor for one logical message; uncompressed frames pass through.
+// Output is bounded by MaxMessageBytes, or MaxPayloadBytes when fragmentation is disabled.
+func DecompressFrame(f Frame, limits Limits) (Frame, error) {
+	if f.Header.Flags&FlagIsCompressed == 0 {
+		return f, nil
+	}
+	maxLen := limits.MaxMessageBytes
+	if maxLen == 0 {
+		maxLen = limits.MaxPayloadBytes
+	}
+	r := flate.NewReader(bytes.NewReader(f.Payload))
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxLen)+1))
+	if err != nil {
+		return Frame{}, fmt.Errorf("%w: message_id=%d: %v", ErrCompressionInvalid, f.Header.MessageID, err)
+	}
+	if uint64(len(out)) > maxLen {
+		return Frame{}, fmt.Errorf("%w: message_id=%d limit=%d", ErrDecompressedTooLarge, f.Header.MessageID, maxLen)
+	}
+	f.Header.Flags &^= FlagIsCompressed
+	f.Payload = out
+	f.Header.PayloadLen = uint64(len(out))
+	return f, nil
+}
@@ -0,0 +1,98 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestCompressFrameRoundTripAboveThreshold(t *testing.T) {
+	testlog.Start(t)
+	payload := bytes.Repeat([]byte("seed stdout line\n"), 200)
+	in := Frame{Header: Header{MessageID: 3, MessageType: 5}, Payload: payload}
+
+	out, err := CompressFrame(in, DefaultCompressThreshold)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if out.Header.Flags&FlagIsCompressed == 0 || len(out.Payload) >= len(payload) {
+		t.Fatalf("expected compressed payload flags=0x%X len=%d", out.Header.Flags, len(out.Payload))
+	}
+	back, err := DecompressFrame(out, DefaultLimits())
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if back.Header.Flags&FlagIsCompressed != 0 || !bytes.Equal(back.Payload, payload) {
+		t.Fatalf("round trip mismatch flags=0x%X len=%d", back.Header.Flags, len(back.Payload))
+	}
+}
+
+func TestCompressFrameSkipsSmallAndIncompressible(t *testing.T) {
+	testlog.Start(t)
+	small := Frame{Payload: []byte("tiny")}
+	if out, _ := CompressFrame(small, DefaultCompressThreshold); out.Header.Flags&FlagIsCompressed != 0 {
+		t.Fatalf("expected payload below threshold left raw")
+	}
+	noise := make([]byte, 2048)
+	rand.New(rand.NewSource(1)).Read(noise)
+	if out, _ := CompressFrame(Frame{Payload: noise}, 16); out.Header.Flags&FlagIsCompressed != 0 || !bytes.Equal(out.Payload, noise) {
+		t.Fatalf("expected non-shrinking payload left raw")
+	}
+	if out, _ := CompressFrame(Frame{Payload: bytes.Repeat([]byte("a"), 4096)}, 0); out.Header.Flags&FlagIsCompressed != 0 {
+		t.Fatalf("expected threshold 0 to disable compression")
+	}
+}
+
+func TestDecompressFrameBoundsAndRejectsGarbage(t *testing.T) {
+	testlog.Start(t)
+	bomb, err := CompressFrame(Frame{Payload: make([]byte, 64*1024)}, 1)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	limits := Limits{MaxAuthBytes: 1024, MaxPayloadBytes: 1024, MaxMessageBytes: 4096}
+	if _, err := DecompressFrame(bomb, limits); !errors.Is(err, ErrDecompressedTooLarge) {
+		t.Fatalf("expected ErrDecompressedTooLarge, got %v", err)
+	}
+	garbage := Frame{Header: Header{Flags: FlagIsCompressed}, Payload: []byte{0xFF, 0x00, 0x13}}
+	if _, err := DecompressFrame(garbage, DefaultLimits()); !errors.Is(err, ErrCompressionInvalid) {
+		t.Fatalf("expected ErrCompressionInvalid, got %v", err)
+	}
+}
+
+func TestEncodeMessageCompressesBeforeFragmenting(t *testing.T) {
+	testlog.Start(t)
+	limits := Limits{MaxAuthBytes: 1024, MaxPayloadBytes: 64, MaxMessageBytes: 64 * 1024}
+	payload := bytes.Repeat([]byte("buildlog "), 400)
+
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, Frame{Header: Header{MessageID: 11, MessageType: 6}, Payload: payload}, limits); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	var compressed bytes.Buffer
+	enc := NewEncoderWithOptions(&compressed, limits, EncoderOptions{CompressThreshold: 128})
+	defer enc.Release()
+	if err := enc.EncodeMessage(Frame{Header: Header{MessageID: 11, MessageType: 6}, Payload: payload}); err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	if compressed.Len() >= buf.Len() {
+		t.Fatalf("expected smaller wire bytes: raw=%d compressed=%d", buf.Len(), compressed.Len())
+	}
+
+	msg, err := DecodeMessage(compressed.Bytes(), limits)
+	if err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	if msg.Header.Flags&FlagIsCompressed == 0 {
+		t.Fatalf("expected compressed logical message flags=0x%X", msg.Header.Flags)
+	}
+	out, err := DecompressFrame(msg, limits)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if !bytes.Equal(out.Payload, payload) || out.Header.MessageID != 11 {
+		t.Fatalf("unexpected message after decompress: id=%d len=%d", out.Header.MessageID, len(out.Payload))
+	}
+}
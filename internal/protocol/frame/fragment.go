@@ -0,0 +1,264 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	logs "github.com/danmuck/smplog"
+)
+
+const (
+	// Fragment header layout: stream_id(u64) | index(u32) | fragment_flags(u8).
+	FragmentHeaderLen = 13
+	// Fragment flag marking the last fragment of a logical message.
+	FragmentFinal uint8 = 0x01
+
+	// Fragment cap on concurrently open reassembly streams per reader.
+	maxReassemblyStreams = 64
+)
+
+var (
+	ErrFragmentInvalid    = errors.New("frame: invalid fragment")
+	ErrFragmentOutOfOrder = errors.New("frame: fragment out of order")
+	ErrReassemblyBudget   = errors.New("frame: reassembly budget exceeded")
+)
+
+// Frame fragment header carried at the start of every fragment payload.
+type FragmentHeader struct {
+	StreamID uint64
+	Index    uint32
+	Final    bool
+}
+
+// Frame fragment header serializer.
+func EncodeFragmentHeader(h FragmentHeader) []byte {
+	buf := make([]byte, FragmentHeaderLen)
+	binary.BigEndian.PutUint64(buf[0:8], h.StreamID)
+	binary.BigEndian.PutUint32(buf[8:12], h.Index)
+	if h.Final {
+		buf[12] = FragmentFinal
+	}
+	return buf
+}
+
+// Frame fragment header parser returning the header and the fragment chunk.
+func DecodeFragmentHeader(payload []byte) (FragmentHeader, []byte, error) {
+	if len(payload) < FragmentHeaderLen {
+		return FragmentHeader{}, nil, fmt.Errorf("%w: short fragment header len=%d", ErrFragmentInvalid, len(payload))
+	}
+	flags := payload[12]
+	if flags&^FragmentFinal != 0 {
+		return FragmentHeader{}, nil, fmt.Errorf("%w: unsupported fragment flags=0x%02X", ErrFragmentInvalid, flags)
+	}
+	h := FragmentHeader{
+		StreamID: binary.BigEndian.Uint64(payload[0:8]),
+		Index:    binary.BigEndian.Uint32(payload[8:12]),
+		Final:    flags&FragmentFinal != 0,
+	}
+	return h, payload[FragmentHeaderLen:], nil
+}
+
+// Frame splitter for one logical message whose payload exceeds MaxPayloadBytes.
+// Payloads that fit are returned unchanged as a single frame. Fragments share the
+// logical message_id and message_type; auth blocks are dropped so each fragment
+// can be signed individually.
+func Fragment(f Frame, limits Limits, streamID uint64) ([]Frame, error) {
+	payloadLen := uint64(len(f.Payload))
+	if payloadLen <= limits.MaxPayloadBytes {
+		return []Frame{f}, nil
+	}
+	if limits.MaxMessageBytes == 0 || payloadLen > limits.MaxMessageBytes {
+		logs.Errf("frame.Fragment message too large payload_len=%d max_message=%d", payloadLen, limits.MaxMessageBytes)
+		return nil, fmt.Errorf("%w: payload_len=%d", ErrPayloadTooLarge, payloadLen)
+	}
+	if limits.MaxPayloadBytes <= FragmentHeaderLen {
+		return nil, fmt.Errorf("%w: max_payload=%d leaves no fragment capacity", ErrFragmentInvalid, limits.MaxPayloadBytes)
+	}
+	chunk := limits.MaxPayloadBytes - FragmentHeaderLen
+
+	count := (payloadLen + chunk - 1) / chunk
+	out := make([]Frame, 0, count)
+	for i := uint64(0); i < count; i++ {
+		start := i * chunk
+		end := min(start+chunk, payloadLen)
+		fh := EncodeFragmentHeader(FragmentHeader{
+			StreamID: streamID,
+			Index:    uint32(i),
+			Final:    i == count-1,
+		})
+		body := make([]byte, 0, FragmentHeaderLen+int(end-start))
+		body = append(body, fh...)
+		body = append(body, f.Payload[start:end]...)
+
+		h := f.Header
+		h.Flags = (h.Flags | FlagIsFragment) &^ FlagHasAuth
+		out = append(out, Frame{Header: h, Payload: body})
+	}
+	logs.Debugf(
+		"frame.Fragment message_id=%d stream_id=%d payload_len=%d fragments=%d",
+		f.Header.MessageID,
+		streamID,
+		payloadLen,
+		len(out),
+	)
+	return out, nil
+}
+
+// Frame writer for one logical message, fragmenting when the payload is too large
+// for one frame. The message_id doubles as the fragment stream id.
+func WriteMessage(w io.Writer, f Frame, limits Limits) error {
+	frames, err := Fragment(f, limits, f.Header.MessageID)
+	if err != nil {
+		return err
+	}
+	for _, fr := range frames {
+		if err := WriteFrame(w, fr, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frame reader for one logical message, reassembling fragments through reasm.
+func ReadMessage(r io.Reader, reasm *Reassembler) (Frame, error) {
+	for {
+		fr, err := ReadFrame(r, reasm.limits)
+		if err != nil {
+			return Frame{}, err
+		}
+		msg, complete, err := reasm.Accept(fr)
+		if err != nil {
+			return Frame{}, err
+		}
+		if complete {
+			return msg, nil
+		}
+	}
+}
+
+// Frame decoder for one logical message held in memory, fragmented or not.
+// Unfragmented messages are parsed without copying, so the payload aliases raw.
+func DecodeMessage(raw []byte, limits Limits) (Frame, error) {
+	fr, n, err := ParseFrame(raw, limits)
+	if err != nil {
+		return Frame{}, err
+	}
+	if fr.Header.Flags&FlagIsFragment == 0 {
+		return fr, nil
+	}
+	reasm := NewReassembler(limits)
+	for {
+		msg, complete, err := reasm.Accept(fr)
+		if err != nil {
+			return Frame{}, err
+		}
+		if complete {
+			return msg, nil
+		}
+		raw = raw[n:]
+		if fr, n, err = ParseFrame(raw, limits); err != nil {
+			return Frame{}, err
+		}
+	}
+}
+
+// Frame reassembly state for one in-flight fragment stream.
+type partialMessage struct {
+	header Header
+	next   uint32
+	buf    []byte
+}
+
+// Frame fragment reassembler bounded by Limits.MaxMessageBytes across all open streams.
+// A Reassembler belongs to one reader and is not safe for concurrent use.
+type Reassembler struct {
+	limits   Limits
+	streams  map[uint64]*partialMessage
+	buffered uint64
+}
+
+// Frame reassembler constructor using limits for frame reads and the memory budget.
+func NewReassembler(limits Limits) *Reassembler {
+	return &Reassembler{
+		limits:  limits,
+		streams: make(map[uint64]*partialMessage),
+	}
+}
+
+// Accept consumes one frame and returns the logical message once it is complete.
+// Unfragmented frames are returned as-is. A failing stream is discarded.
+func (r *Reassembler) Accept(f Frame) (Frame, bool, error) {
+	if f.Header.Flags&FlagIsFragment == 0 {
+		return f, true, nil
+	}
+	fh, chunk, err := DecodeFragmentHeader(f.Payload)
+	if err != nil {
+		return Frame{}, false, err
+	}
+
+	p, ok := r.streams[fh.StreamID]
+	if !ok {
+		if fh.Index != 0 {
+			return Frame{}, false, fmt.Errorf("%w: stream_id=%d first index=%d", ErrFragmentOutOfOrder, fh.StreamID, fh.Index)
+		}
+		if len(r.streams) >= maxReassemblyStreams {
+			return Frame{}, false, fmt.Errorf("%w: open streams=%d", ErrReassemblyBudget, len(r.streams))
+		}
+		h := f.Header
+		h.Flags &^= FlagIsFragment | FlagHasAuth
+		h.HeaderLen = FixedHeaderLen
+		p = &partialMessage{header: h}
+		r.streams[fh.StreamID] = p
+	} else if fh.Index != p.next || f.Header.MessageType != p.header.MessageType {
+		r.drop(fh.StreamID)
+		return Frame{}, false, fmt.Errorf(
+			"%w: stream_id=%d index=%d want=%d",
+			ErrFragmentOutOfOrder,
+			fh.StreamID,
+			fh.Index,
+			p.next,
+		)
+	}
+
+	size := uint64(len(chunk))
+	if r.buffered+size > r.limits.MaxMessageBytes {
+		r.drop(fh.StreamID)
+		logs.Errf("frame.Reassembler budget exceeded stream_id=%d buffered=%d", fh.StreamID, r.buffered)
+		return Frame{}, false, fmt.Errorf("%w: stream_id=%d buffered=%d", ErrReassemblyBudget, fh.StreamID, r.buffered)
+	}
+	p.buf = append(p.buf, chunk...)
+	p.next++
+	r.buffered += size
+	if !fh.Final {
+		return Frame{}, false, nil
+	}
+
+	r.drop(fh.StreamID)
+	msg := Frame{Header: p.header, Payload: p.buf}
+	msg.Header.PayloadLen = uint64(len(p.buf))
+	logs.Debugf(
+		"frame.Reassembler complete message_id=%d stream_id=%d payload_len=%d fragments=%d",
+		msg.Header.MessageID,
+		fh.StreamID,
+		len(p.buf),
+		p.next,
+	)
+	return msg, true, nil
+}
+
+// Frame count of fragment streams still awaiting their final fragment.
+func (r *Reassembler) Pending() int {
+	return len(r.streams)
+}
+
+// Frame stream removal that releases its buffered bytes from the budget.
+func (r *Reassembler) drop(streamID uint64) {
+	p, ok := r.streams[streamID]
+	if !ok {
+		return
+	}
+	r.buffered -= uint64(len(p.buf))
+	delete(r.streams, streamID)
+}
@@ -0,0 +1,186 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func smallFragmentLimits() Limits {
+	return Limits{MaxAuthBytes: 1024, MaxPayloadBytes: 32, MaxMessageBytes: 256}
+}
+
+func TestWriteReadMessageFragmentsAndReassembles(t *testing.T) {
+	testlog.Start(t)
+	limits := smallFragmentLimits()
+	payload := bytes.Repeat([]byte("0123456789"), 20)
+
+	var buf bytes.Buffer
+	in := Frame{Header: Header{MessageID: 9, MessageType: 5, Flags: FlagIsResponse}, Payload: payload}
+	if err := WriteMessage(&buf, in, limits); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	// Every wire frame must respect the per-frame payload limit.
+	wire := bytes.NewReader(buf.Bytes())
+	frames := 0
+	for wire.Len() > 0 {
+		fr, err := ReadFrame(wire, limits)
+		if err != nil {
+			t.Fatalf("read fragment %d: %v", frames, err)
+		}
+		if fr.Header.Flags&FlagIsFragment == 0 || fr.Header.MessageID != 9 {
+			t.Fatalf("unexpected fragment header: %+v", fr.Header)
+		}
+		frames++
+	}
+	if frames < 2 {
+		t.Fatalf("expected several fragments, got %d", frames)
+	}
+
+	reasm := NewReassembler(limits)
+	out, err := ReadMessage(bytes.NewReader(buf.Bytes()), reasm)
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	if !bytes.Equal(out.Payload, payload) {
+		t.Fatalf("payload mismatch: got=%d bytes want=%d", len(out.Payload), len(payload))
+	}
+	if out.Header.MessageID != 9 || out.Header.MessageType != 5 || out.Header.Flags != FlagIsResponse {
+		t.Fatalf("unexpected reassembled header: %+v", out.Header)
+	}
+	if out.Header.PayloadLen != uint64(len(payload)) || reasm.Pending() != 0 {
+		t.Fatalf("unexpected reassembly state payload_len=%d pending=%d", out.Header.PayloadLen, reasm.Pending())
+	}
+}
+
+func TestWriteMessageSmallPayloadIsSingleFrame(t *testing.T) {
+	testlog.Start(t)
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, Frame{Header: Header{MessageID: 1, MessageType: 1}, Payload: []byte("small")}, smallFragmentLimits()); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	fr, err := ReadFrame(bytes.NewReader(buf.Bytes()), smallFragmentLimits())
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if fr.Header.Flags&FlagIsFragment != 0 || string(fr.Payload) != "small" {
+		t.Fatalf("expected unfragmented frame: %+v", fr.Header)
+	}
+}
+
+func TestFragmentRejectsMessagesOverBudget(t *testing.T) {
+	testlog.Start(t)
+	limits := smallFragmentLimits()
+	big := Frame{Header: Header{MessageID: 1, MessageType: 1}, Payload: make([]byte, limits.MaxMessageBytes+1)}
+	if _, err := Fragment(big, limits, 1); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+	}
+	limits.MaxMessageBytes = 0
+	if _, err := Fragment(Frame{Payload: make([]byte, 64)}, limits, 1); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected fragmentation disabled, got %v", err)
+	}
+}
+
+func TestReassemblerInterleavedStreamsAndBudget(t *testing.T) {
+	testlog.Start(t)
+	limits := smallFragmentLimits()
+	a, err := Fragment(Frame{Header: Header{MessageID: 1, MessageType: 5}, Payload: bytes.Repeat([]byte("a"), 50)}, limits, 1)
+	if err != nil {
+		t.Fatalf("fragment a: %v", err)
+	}
+	b, err := Fragment(Frame{Header: Header{MessageID: 2, MessageType: 5}, Payload: bytes.Repeat([]byte("b"), 50)}, limits, 2)
+	if err != nil {
+		t.Fatalf("fragment b: %v", err)
+	}
+
+	reasm := NewReassembler(limits)
+	var done []Frame
+	for i := 0; i < max(len(a), len(b)); i++ {
+		for _, frames := range [][]Frame{a, b} {
+			if i >= len(frames) {
+				continue
+			}
+			msg, complete, err := reasm.Accept(frames[i])
+			if err != nil {
+				t.Fatalf("accept: %v", err)
+			}
+			if complete {
+				done = append(done, msg)
+			}
+		}
+	}
+	if len(done) != 2 || len(done[0].Payload) != 50 || len(done[1].Payload) != 50 {
+		t.Fatalf("unexpected interleaved reassembly: %d messages", len(done))
+	}
+
+	// Skipping an index discards the stream.
+	if _, _, err := reasm.Accept(a[0]); err != nil {
+		t.Fatalf("accept first: %v", err)
+	}
+	if _, _, err := reasm.Accept(a[2]); !errors.Is(err, ErrFragmentOutOfOrder) {
+		t.Fatalf("expected ErrFragmentOutOfOrder, got %v", err)
+	}
+	if reasm.Pending() != 0 {
+		t.Fatalf("expected failed stream discarded, pending=%d", reasm.Pending())
+	}
+
+	// Buffered bytes across open streams are bounded by MaxMessageBytes.
+	tight := NewReassembler(Limits{MaxAuthBytes: 1024, MaxPayloadBytes: 32, MaxMessageBytes: 30})
+	if _, _, err := tight.Accept(a[0]); err != nil {
+		t.Fatalf("accept a[0]: %v", err)
+	}
+	if _, _, err := tight.Accept(b[0]); !errors.Is(err, ErrReassemblyBudget) {
+		t.Fatalf("expected ErrReassemblyBudget, got %v", err)
+	}
+}
+
+func TestEncodeMessageSignsEveryFragment(t *testing.T) {
+	testlog.Start(t)
+	limits := smallFragmentLimits()
+	limits.MaxPayloadBytes = 64
+	sender, err := NewHMACAuthenticator("k1", []byte("secret"), time.Minute)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	receiver, err := NewHMACAuthenticator("k1", []byte("secret"), time.Minute)
+	if err != nil {
+		t.Fatalf("new receiver: %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, limits, EncoderOptions{Version: MinProtocolVersion, Auth: sender})
+	defer enc.Release()
+	if err := enc.EncodeMessage(Frame{Header: Header{MessageID: 4, MessageType: 5}, Payload: bytes.Repeat([]byte("z"), 150)}); err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+
+	wire := bytes.NewReader(buf.Bytes())
+	reasm := NewReassembler(limits)
+	fragments := 0
+	for wire.Len() > 0 {
+		fr, err := ReadFrame(wire, limits)
+		if err != nil {
+			t.Fatalf("read fragment: %v", err)
+		}
+		fragments++
+		if fr.Header.Version != MinProtocolVersion || fr.Header.Flags&FlagIsFragment == 0 {
+			t.Fatalf("unexpected fragment header: %+v", fr.Header)
+		}
+		if err := VerifyFrame(fr, receiver, true); err != nil {
+			t.Fatalf("verify fragment: %v", err)
+		}
+		msg, complete, err := reasm.Accept(fr)
+		if err != nil {
+			t.Fatalf("accept: %v", err)
+		}
+		if complete && (len(msg.Payload) != 150 || msg.Header.Flags&FlagHasAuth != 0) {
+			t.Fatalf("unexpected reassembled message: %+v", msg.Header)
+		}
+	}
+	if fragments < 2 {
+		t.Fatalf("expected a fragmented message, fragments=%d", fragments)
+	}
+}
@@ -1,22 +1,29 @@
 package frame
 
 import (
-	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 
 	logs "github.com/danmuck/smplog"
 )
 
 const (
-	FixedHeaderLen  uint16 = 32
-	ProtocolMagic   uint32 = 0xEDCE1001
-	ProtocolVersion uint16 = 1
-	FlagHasAuth     uint32 = 0x01
-	FlagIsResponse  uint32 = 0x02
-	FlagIsError     uint32 = 0x04
-	SupportedFlags  uint32 = FlagHasAuth | FlagIsResponse | FlagIsError
+	FixedHeaderLen   uint16 = 32
+	ProtocolMagic    uint32 = 0xEDCE1001
+	ProtocolVersion  uint16 = 2
+	FlagHasAuth      uint32 = 0x01
+	FlagIsResponse   uint32 = 0x02
+	FlagIsError      uint32 = 0x04
+	FlagIsFragment   uint32 = 0x08
+	FlagIsCompressed uint32 = 0x10
+	SupportedFlags   uint32 = FlagHasAuth | FlagIsResponse | FlagIsError | FlagIsFragment | FlagIsCompressed
+)
+
+// Frame protocol versions this codec can decode and encode.
+// ProtocolVersion is the default for frames written without an explicit version.
+const (
+	MinProtocolVersion uint16 = 1
+	MaxProtocolVersion uint16 = ProtocolVersion
 )
 
 var (
@@ -49,17 +56,21 @@ type Frame struct {
 }
 
 // Frame decode/encode memory limits.
+// MaxMessageBytes bounds one reassembled logical message and the reassembly buffer;
+// zero disables fragmentation.
 type Limits struct {
 	MaxAuthBytes    uint64
 	MaxPayloadBytes uint64
+	MaxMessageBytes uint64
 }
 
 // Frame package conservative default size limits for runtime decode/encode.
+// Called on per-message paths, so it stays allocation- and log-free.
 func DefaultLimits() Limits {
-	logs.Debug("frame.DefaultLimits")
 	return Limits{
 		MaxAuthBytes:    64 * 1024,
 		MaxPayloadBytes: 8 * 1024 * 1024,
+		MaxMessageBytes: 64 * 1024 * 1024,
 	}
 }
 
@@ -86,23 +97,15 @@ func ReadFrame(r io.Reader, limits Limits) (Frame, error) {
 		return Frame{}, err
 	}
 
-	if h.HeaderLen < FixedHeaderLen {
-		logs.Errf("frame.ReadFrame invalid header_len=%d", h.HeaderLen)
-		return Frame{}, ErrHeaderLenTooSmall
-	}
-
-	authLen := uint64(h.HeaderLen - FixedHeaderLen)
-	if h.Flags&FlagHasAuth != 0 && authLen == 0 {
-		logs.Err("frame.ReadFrame auth flag set without auth bytes")
-		return Frame{}, ErrHeaderLenMismatch
-	}
-	if authLen > limits.MaxAuthBytes {
-		logs.Errf("frame.ReadFrame auth too large auth_len=%d", authLen)
-		return Frame{}, ErrAuthTooLarge
-	}
-	if h.PayloadLen > limits.MaxPayloadBytes {
-		logs.Errf("frame.ReadFrame payload too large payload_len=%d", h.PayloadLen)
-		return Frame{}, ErrPayloadTooLarge
+	authLen, err := checkBodyLengths(h, limits)
+	if err != nil {
+		logs.Errf(
+			"frame.ReadFrame invalid lengths header_len=%d payload_len=%d err=%v",
+			h.HeaderLen,
+			h.PayloadLen,
+			err,
+		)
+		return Frame{}, err
 	}
 
 	auth := make([]byte, authLen)
@@ -142,32 +145,10 @@ func WriteFrame(w io.Writer, f Frame, limits Limits) error {
 		authLen,
 		payloadLen,
 	)
-	if authLen > limits.MaxAuthBytes {
-		logs.Errf("frame.WriteFrame auth too large auth_len=%d", authLen)
-		return ErrAuthTooLarge
-	}
-	if payloadLen > limits.MaxPayloadBytes {
-		logs.Errf("frame.WriteFrame payload too large payload_len=%d", payloadLen)
-		return ErrPayloadTooLarge
-	}
-
-	h := f.Header
-	if h.Magic == 0 {
-		h.Magic = ProtocolMagic
-	}
-	if h.Version == 0 {
-		h.Version = ProtocolVersion
-	}
-	if h.Flags&^SupportedFlags != 0 {
-		logs.Errf("frame.WriteFrame unsupported flags=0x%08X", h.Flags)
-		return fmt.Errorf("%w: flags=0x%08X", ErrUnsupportedFlags, h.Flags)
-	}
-	h.HeaderLen = FixedHeaderLen + uint16(authLen)
-	h.PayloadLen = payloadLen
-	if authLen > 0 {
-		h.Flags |= FlagHasAuth
-	} else {
-		h.Flags &^= FlagHasAuth
+	h, err := prepareHeader(f, limits)
+	if err != nil {
+		logs.Errf("frame.WriteFrame invalid frame message_id=%d err=%v", f.Header.MessageID, err)
+		return err
 	}
 
 	hb := EncodeHeader(h)
@@ -200,44 +181,21 @@ func WriteFrame(w io.Writer, f Frame, limits Limits) error {
 // Frame header serializer for fixed-width protocol header bytes.
 func EncodeHeader(h Header) []byte {
 	logs.Debugf("frame.EncodeHeader message_id=%d message_type=%d", h.MessageID, h.MessageType)
-	buf := make([]byte, FixedHeaderLen)
-	binary.BigEndian.PutUint32(buf[0:4], h.Magic)
-	binary.BigEndian.PutUint16(buf[4:6], h.Version)
-	binary.BigEndian.PutUint16(buf[6:8], h.HeaderLen)
-	binary.BigEndian.PutUint64(buf[8:16], h.MessageID)
-	binary.BigEndian.PutUint32(buf[16:20], h.MessageType)
-	binary.BigEndian.PutUint32(buf[20:24], h.Flags)
-	binary.BigEndian.PutUint64(buf[24:32], h.PayloadLen)
-	return buf
+	return AppendHeader(make([]byte, 0, FixedHeaderLen), h)
 }
 
 // Frame header parser/validator for fixed-width protocol header bytes.
 func DecodeHeader(b []byte) (Header, error) {
-	if len(b) != int(FixedHeaderLen) {
-		logs.Errf("frame.DecodeHeader invalid length=%d", len(b))
-		return Header{}, fmt.Errorf("frame: invalid fixed header length: %d", len(b))
-	}
-	h := Header{
-		Magic:       binary.BigEndian.Uint32(b[0:4]),
-		Version:     binary.BigEndian.Uint16(b[4:6]),
-		HeaderLen:   binary.BigEndian.Uint16(b[6:8]),
-		MessageID:   binary.BigEndian.Uint64(b[8:16]),
-		MessageType: binary.BigEndian.Uint32(b[16:20]),
-		Flags:       binary.BigEndian.Uint32(b[20:24]),
-		PayloadLen:  binary.BigEndian.Uint64(b[24:32]),
-	}
-	if h.Magic != ProtocolMagic {
-		logs.Errf("frame.DecodeHeader unsupported magic=0x%08X", h.Magic)
-		return Header{}, fmt.Errorf("%w: magic=0x%08X", ErrUnsupportedMagic, h.Magic)
-	}
-	if h.Version != ProtocolVersion {
-		logs.Errf("frame.DecodeHeader unsupported version=%d", h.Version)
-		return Header{}, fmt.Errorf("%w: version=%d", ErrUnsupportedVersion, h.Version)
-	}
-	if h.Flags&^SupportedFlags != 0 {
-		logs.Errf("frame.DecodeHeader unsupported flags=0x%08X", h.Flags)
-		return Header{}, fmt.Errorf("%w: flags=0x%08X", ErrUnsupportedFlags, h.Flags)
+	h, err := parseHeader(b)
+	if err != nil {
+		logs.Errf("frame.DecodeHeader err=%v", err)
+		return Header{}, err
 	}
 	logs.Debugf("frame.DecodeHeader ok message_id=%d message_type=%d", h.MessageID, h.MessageType)
 	return h, nil
 }
+
+// Frame version check against the codec supported range.
+func SupportsVersion(v uint16) bool {
+	return v >= MinProtocolVersion && v <= MaxProtocolVersion
+}
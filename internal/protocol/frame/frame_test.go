@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/danmuck/edgectl/internal/protocol/tlv"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
@@ -93,7 +94,7 @@ func TestDecodeHeaderRejectsUnsupportedVersion(t *testing.T) {
 	testlog.Start(t)
 	h := Header{
 		Magic:       ProtocolMagic,
-		Version:     ProtocolVersion + 1,
+		Version:     MaxProtocolVersion + 1,
 		HeaderLen:   FixedHeaderLen,
 		MessageID:   1,
 		MessageType: 1,
@@ -106,6 +107,34 @@ func TestDecodeHeaderRejectsUnsupportedVersion(t *testing.T) {
 	}
 }
 
+func TestCodecAcceptsEverySupportedVersion(t *testing.T) {
+	testlog.Start(t)
+	for v := MinProtocolVersion; v <= MaxProtocolVersion; v++ {
+		var buf bytes.Buffer
+		in := Frame{Header: Header{Version: v, MessageID: uint64(v), MessageType: 1}, Payload: []byte{0x01}}
+		if err := WriteFrame(&buf, in, DefaultLimits()); err != nil {
+			t.Fatalf("write version=%d: %v", v, err)
+		}
+		out, err := ReadFrame(&buf, DefaultLimits())
+		if err != nil {
+			t.Fatalf("read version=%d: %v", v, err)
+		}
+		if out.Header.Version != v {
+			t.Fatalf("version mismatch: got=%d want=%d", out.Header.Version, v)
+		}
+	}
+	var buf bytes.Buffer
+	enc := NewEncoderWithOptions(&buf, DefaultLimits(), EncoderOptions{Version: MaxProtocolVersion + 1})
+	defer enc.Release()
+	if err := enc.EncodeMessage(Frame{Header: Header{MessageType: 1}}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion on encode, got %v", err)
+	}
+	err := WriteFrame(&buf, Frame{Header: Header{Version: MaxProtocolVersion + 1, MessageType: 1}}, DefaultLimits())
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion on write, got %v", err)
+	}
+}
+
 func TestDecodeHeaderRejectsUnsupportedFlags(t *testing.T) {
 	testlog.Start(t)
 	h := Header{
@@ -114,7 +143,7 @@ func TestDecodeHeaderRejectsUnsupportedFlags(t *testing.T) {
 		HeaderLen:   FixedHeaderLen,
 		MessageID:   1,
 		MessageType: 1,
-		Flags:       FlagIsResponse | 0x08,
+		Flags:       FlagIsResponse | 0x80000000,
 		PayloadLen:  0,
 	}
 	_, err := DecodeHeader(EncodeHeader(h))
@@ -122,3 +151,79 @@ func TestDecodeHeaderRejectsUnsupportedFlags(t *testing.T) {
 		t.Fatalf("expected ErrUnsupportedFlags, got %v", err)
 	}
 }
+
+func TestHMACAuthenticatorSignVerifyAndReplay(t *testing.T) {
+	testlog.Start(t)
+	signer, err := NewHMACAuthenticator("site-a", []byte("shared-secret"), time.Minute)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+	verifier, err := NewHMACAuthenticator("site-a", []byte("shared-secret"), time.Minute)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	in, err := SignFrame(Frame{
+		Header:  Header{MessageID: 7, MessageType: 5},
+		Payload: []byte("payload"),
+	}, signer)
+	if err != nil {
+		t.Fatalf("sign frame: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteFrame(&buf, in, DefaultLimits()); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	signed := buf.Bytes()
+	fr, err := ReadFrame(bytes.NewReader(signed), DefaultLimits())
+	if err != nil {
+		t.Fatalf("read signed frame: %v", err)
+	}
+	if fr.Header.Flags&FlagHasAuth == 0 {
+		t.Fatalf("expected has_auth flag")
+	}
+	if err := VerifyFrame(fr, verifier, true); err != nil {
+		t.Fatalf("verify signed frame: %v", err)
+	}
+	if err := VerifyFrame(fr, verifier, true); !errors.Is(err, ErrAuthReplay) {
+		t.Fatalf("expected ErrAuthReplay, got %v", err)
+	}
+
+	tampered, err := ReadFrame(bytes.NewReader(signed), DefaultLimits())
+	if err != nil {
+		t.Fatalf("read signed frame: %v", err)
+	}
+	tampered.Payload[0] ^= 0xFF
+	other, _ := NewHMACAuthenticator("site-a", []byte("shared-secret"), time.Minute)
+	if err := VerifyFrame(tampered, other, true); !errors.Is(err, ErrAuthInvalid) {
+		t.Fatalf("expected ErrAuthInvalid for tampered payload, got %v", err)
+	}
+
+	wrongKey, _ := NewHMACAuthenticator("site-a", []byte("other-secret"), time.Minute)
+	if err := VerifyFrame(fr, wrongKey, true); !errors.Is(err, ErrAuthInvalid) {
+		t.Fatalf("expected ErrAuthInvalid for wrong key, got %v", err)
+	}
+}
+
+func TestHMACAuthenticatorRejectsStaleAndUnsigned(t *testing.T) {
+	testlog.Start(t)
+	signer, _ := NewHMACAuthenticator("site-a", []byte("shared-secret"), time.Second)
+	signer.now = func() time.Time { return time.Now().Add(-time.Minute) }
+	verifier, _ := NewHMACAuthenticator("site-a", []byte("shared-secret"), time.Second)
+
+	fr, err := SignFrame(Frame{Header: Header{MessageID: 8, MessageType: 5}, Payload: []byte("x")}, signer)
+	if err != nil {
+		t.Fatalf("sign frame: %v", err)
+	}
+	if err := VerifyFrame(fr, verifier, true); !errors.Is(err, ErrAuthExpired) {
+		t.Fatalf("expected ErrAuthExpired, got %v", err)
+	}
+
+	unsigned := Frame{Header: Header{MessageID: 9, MessageType: 5}, Payload: []byte("x")}
+	if err := VerifyFrame(unsigned, verifier, true); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired, got %v", err)
+	}
+	if err := VerifyFrame(unsigned, verifier, false); err != nil {
+		t.Fatalf("expected optional auth to accept unsigned frame, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package frame
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	logs "github.com/danmuck/smplog"
+)
+
+const (
+	// HMAC auth block layout version.
+	HMACAuthVersion uint8 = 1
+	// HMAC auth nonce size in bytes.
+	HMACAuthNonceLen = 16
+	// HMAC auth default timestamp skew tolerance.
+	DefaultHMACMaxSkew = 30 * time.Second
+
+	hmacAuthDomain = "edgectl.frame.auth.v1"
+)
+
+var ErrAuthKeyRequired = errors.New("frame: auth key required")
+
+// Frame HMAC-SHA256 pre-shared-key authenticator.
+// Auth block: version(u8) | key_id_len(u8) | key_id | timestamp_ms(u64) | nonce(16) | mac(32).
+// Frames outside MaxSkew or reusing a seen nonce are rejected.
+type HMACAuthenticator struct {
+	keyID   string
+	key     []byte
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	nextPrune time.Time
+}
+
+// Frame HMAC authenticator constructor for one key id and shared secret.
+func NewHMACAuthenticator(keyID string, key []byte, maxSkew time.Duration) (*HMACAuthenticator, error) {
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" || len(keyID) > 255 {
+		return nil, fmt.Errorf("%w: key_id must be 1..255 bytes", ErrAuthKeyRequired)
+	}
+	if len(key) == 0 {
+		return nil, ErrAuthKeyRequired
+	}
+	if maxSkew <= 0 {
+		maxSkew = DefaultHMACMaxSkew
+	}
+	return &HMACAuthenticator{
+		keyID:   keyID,
+		key:     append([]byte(nil), key...),
+		maxSkew: maxSkew,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}, nil
+}
+
+// Frame HMAC key id used for outbound frames.
+func (a *HMACAuthenticator) KeyID() string {
+	return a.keyID
+}
+
+// Sign builds the HMAC auth block for one frame header and payload.
+func (a *HMACAuthenticator) Sign(h Header, payload []byte) ([]byte, error) {
+	nonce := make([]byte, HMACAuthNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("frame: auth nonce: %w", err)
+	}
+	now := a.now()
+	ts := uint64(now.UnixMilli())
+
+	block := make([]byte, 0, 2+len(a.keyID)+8+HMACAuthNonceLen+sha256.Size)
+	block = append(block, HMACAuthVersion, uint8(len(a.keyID)))
+	block = append(block, a.keyID...)
+	block = binary.BigEndian.AppendUint64(block, ts)
+	block = append(block, nonce...)
+	block = append(block, a.mac(block, h, payload)...)
+
+	// Record own nonces so a reflected frame is rejected as a replay.
+	a.remember(string(nonce), now.Add(a.maxSkew))
+	return block, nil
+}
+
+// Verify checks key id, timestamp skew, MAC, and nonce uniqueness.
+func (a *HMACAuthenticator) Verify(h Header, auth []byte, payload []byte) error {
+	if len(auth) < 2 || auth[0] != HMACAuthVersion {
+		return fmt.Errorf("%w: unsupported auth block", ErrAuthInvalid)
+	}
+	keyLen := int(auth[1])
+	want := 2 + keyLen + 8 + HMACAuthNonceLen + sha256.Size
+	if len(auth) != want {
+		return fmt.Errorf("%w: auth block length=%d want=%d", ErrAuthInvalid, len(auth), want)
+	}
+	keyID := string(auth[2 : 2+keyLen])
+	if keyID != a.keyID {
+		return fmt.Errorf("%w: unknown key_id=%q", ErrAuthInvalid, keyID)
+	}
+	tsOff := 2 + keyLen
+	ts := binary.BigEndian.Uint64(auth[tsOff : tsOff+8])
+	nonce := auth[tsOff+8 : tsOff+8+HMACAuthNonceLen]
+	signed := auth[:tsOff+8+HMACAuthNonceLen]
+	mac := auth[tsOff+8+HMACAuthNonceLen:]
+
+	if !hmac.Equal(mac, a.mac(signed, h, payload)) {
+		return fmt.Errorf("%w: mac mismatch message_id=%d", ErrAuthInvalid, h.MessageID)
+	}
+
+	now := a.now()
+	sentAt := time.UnixMilli(int64(ts))
+	skew := now.Sub(sentAt)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > a.maxSkew {
+		return fmt.Errorf("%w: skew=%s message_id=%d", ErrAuthExpired, skew, h.MessageID)
+	}
+	if !a.remember(string(nonce), sentAt.Add(a.maxSkew)) {
+		logs.Warnf("frame.HMACAuthenticator.Verify replay key_id=%q message_id=%d", keyID, h.MessageID)
+		return fmt.Errorf("%w: message_id=%d", ErrAuthReplay, h.MessageID)
+	}
+	return nil
+}
+
+// Frame HMAC over domain tag, auth prefix, and canonical frame bytes.
+func (a *HMACAuthenticator) mac(prefix []byte, h Header, payload []byte) []byte {
+	m := hmac.New(sha256.New, a.key)
+	m.Write([]byte(hmacAuthDomain))
+	m.Write(prefix)
+	m.Write(AuthSigningBytes(h, payload))
+	return m.Sum(nil)
+}
+
+// Frame nonce cache insert; returns false when nonce was already seen.
+// Entries expire once their timestamp falls outside the skew window.
+func (a *HMACAuthenticator) remember(nonce string, expiresAt time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	if now.After(a.nextPrune) {
+		for k, exp := range a.seen {
+			if now.After(exp) {
+				delete(a.seen, k)
+			}
+		}
+		a.nextPrune = now.Add(a.maxSkew)
+	}
+	if _, exists := a.seen[nonce]; exists {
+		return false
+	}
+	a.seen[nonce] = expiresAt
+	return true
+}
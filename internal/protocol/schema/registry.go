@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	logs "github.com/danmuck/smplog"
+)
+
+// Schema reserved id ranges: core ids belong to the tlv contract,
+// extension ids are free for seeds and plugins to register per runtime.
+const (
+	CoreMessageTypeMax      uint32 = 0x7FFF
+	ExtensionMessageTypeMin uint32 = 0x8000
+	ExtensionMessageTypeMax uint32 = 0xFFFF
+
+	CoreFieldIDMax      uint16 = 0x7FFF
+	ExtensionFieldIDMin uint16 = 0x8000
+)
+
+var (
+	ErrReservedMessageType = errors.New("schema: message type outside extension range")
+	ErrMessageTypeConflict = errors.New("schema: message type already registered")
+	ErrInvalidRequirement  = errors.New("schema: invalid requirement")
+	ErrReservedFieldID     = errors.New("schema: field id reserved for core contract")
+)
+
+// Schema registry layering runtime-registered extension message types over the
+// core contract. Each runtime owns its own instance; the zero value is not usable.
+// Extension messages are valid at every supported protocol version.
+type Registry struct {
+	mu         sync.RWMutex
+	extensions map[uint32][]Requirement
+}
+
+// Schema registry constructor holding only the core contract.
+func NewRegistry() *Registry {
+	return &Registry{extensions: make(map[uint32][]Requirement)}
+}
+
+// Register adds one extension message type with its field requirements.
+// Requirements may reuse core field ids (their core value rules still apply)
+// or use ids in the extension field range.
+func (r *Registry) Register(messageType uint32, reqs []Requirement) error {
+	if messageType < ExtensionMessageTypeMin || messageType > ExtensionMessageTypeMax {
+		return fmt.Errorf("%w: message_type=%d range=[%d,%d]", ErrReservedMessageType, messageType, ExtensionMessageTypeMin, ExtensionMessageTypeMax)
+	}
+	for i, req := range reqs {
+		if req.ID == 0 || req.Type == 0 || req.Type > tlv.TypeMap {
+			return fmt.Errorf("%w: message_type=%d field=%d type=%d", ErrInvalidRequirement, messageType, req.ID, req.Type)
+		}
+		if req.ID <= CoreFieldIDMax && !isCoreField(req.ID) {
+			return fmt.Errorf("%w: message_type=%d field=%d", ErrReservedFieldID, messageType, req.ID)
+		}
+		for _, prev := range reqs[:i] {
+			if prev.ID == req.ID {
+				return fmt.Errorf("%w: message_type=%d duplicate field=%d", ErrInvalidRequirement, messageType, req.ID)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.extensions[messageType]; exists {
+		return fmt.Errorf("%w: message_type=%d", ErrMessageTypeConflict, messageType)
+	}
+	r.extensions[messageType] = append([]Requirement(nil), reqs...)
+	logs.Infof("schema.Registry.Register message_type=%d fields=%d", messageType, len(reqs))
+	return nil
+}
+
+// Registered reports whether messageType is a core or registered extension message.
+func (r *Registry) Registered(messageType uint32) bool {
+	if _, ok := requirementsFor(frame.MaxProtocolVersion, messageType); ok {
+		return true
+	}
+	_, ok := r.extension(messageType)
+	return ok
+}
+
+// Extension reports whether messageType was registered on this registry.
+func (r *Registry) Extension(messageType uint32) bool {
+	_, ok := r.extension(messageType)
+	return ok
+}
+
+// Validate checks fields against core or extension requirements at the default protocol version.
+func (r *Registry) Validate(messageType uint32, fields []tlv.Field) error {
+	return validate(r, frame.ProtocolVersion, messageType, fields)
+}
+
+// ValidateVersion checks fields against core or extension requirements at one protocol version.
+func (r *Registry) ValidateVersion(version uint16, messageType uint32, fields []tlv.Field) error {
+	return validate(r, version, messageType, fields)
+}
+
+// Schema extension requirement lookup under the read lock.
+func (r *Registry) extension(messageType uint32) ([]Requirement, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reqs, ok := r.extensions[messageType]
+	return reqs, ok
+}
+
+// Schema check that a core-range field id is defined by the tlv contract.
+func isCoreField(id uint16) bool {
+	for _, byType := range requirementsByVersion {
+		for _, reqs := range byType {
+			for _, req := range reqs {
+				if req.ID == id {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+const (
+	testMsgProgress   = ExtensionMessageTypeMin + 1
+	testFieldPercent  = ExtensionFieldIDMin + 1
+	testFieldProgress = ExtensionFieldIDMin + 2
+)
+
+func progressRequirements() []Requirement {
+	return []Requirement{
+		{ID: FieldCommandID, Type: tlv.TypeString},
+		{ID: testFieldPercent, Type: tlv.TypeU8},
+		{ID: testFieldProgress, Type: tlv.TypeString, Optional: true},
+	}
+}
+
+func TestRegistryRegistersAndValidatesExtensionMessages(t *testing.T) {
+	testlog.Start(t)
+	reg := NewRegistry()
+	if err := reg.Register(testMsgProgress, progressRequirements()); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if !reg.Registered(testMsgProgress) || !reg.Extension(testMsgProgress) || !reg.Registered(MsgEvent) || reg.Extension(MsgEvent) {
+		t.Fatalf("unexpected registry membership")
+	}
+
+	fields := []tlv.Field{
+		{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("cmd.1")},
+		{ID: testFieldPercent, Type: tlv.TypeU8, Value: []byte{42}},
+	}
+	if err := reg.Validate(testMsgProgress, fields); err != nil {
+		t.Fatalf("validate extension: %v", err)
+	}
+	if err := reg.Validate(MsgEvent, fields); err == nil {
+		t.Fatalf("expected core requirements to still apply through the registry")
+	}
+
+	var ve ValidationError
+	if err := reg.Validate(testMsgProgress, fields[1:]); !errors.As(err, &ve) || ve.Rule != RuleRequired || ve.FieldID != FieldCommandID {
+		t.Fatalf("expected missing command_id, got %v", err)
+	}
+	badID := append([]tlv.Field{{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("cmd 1")}}, fields[1:]...)
+	if err := reg.Validate(testMsgProgress, badID); !errors.As(err, &ve) || ve.Rule != RulePattern {
+		t.Fatalf("expected core field rule applied to extension message, got %v", err)
+	}
+
+	if err := Validate(testMsgProgress, fields); !errors.As(err, &ve) || ve.Rule != RuleMessageType {
+		t.Fatalf("expected package-level Validate to know only the core contract, got %v", err)
+	}
+	if err := NewRegistry().Validate(testMsgProgress, fields); !errors.As(err, &ve) || ve.Rule != RuleMessageType {
+		t.Fatalf("expected registries to be independent, got %v", err)
+	}
+}
+
+func TestRegistryRejectsReservedAndConflictingRegistrations(t *testing.T) {
+	testlog.Start(t)
+	reg := NewRegistry()
+	if err := reg.Register(MsgEvent, progressRequirements()); !errors.Is(err, ErrReservedMessageType) {
+		t.Fatalf("expected core message type reserved, got %v", err)
+	}
+	if err := reg.Register(ExtensionMessageTypeMax+1, progressRequirements()); !errors.Is(err, ErrReservedMessageType) {
+		t.Fatalf("expected type above extension range reserved, got %v", err)
+	}
+	if err := reg.Register(testMsgProgress, []Requirement{{ID: 9000, Type: tlv.TypeString}}); !errors.Is(err, ErrReservedFieldID) {
+		t.Fatalf("expected undefined core-range field rejected, got %v", err)
+	}
+	if err := reg.Register(testMsgProgress, []Requirement{{ID: testFieldPercent, Type: 0}}); !errors.Is(err, ErrInvalidRequirement) {
+		t.Fatalf("expected invalid type rejected, got %v", err)
+	}
+	dup := []Requirement{{ID: testFieldPercent, Type: tlv.TypeU8}, {ID: testFieldPercent, Type: tlv.TypeU16}}
+	if err := reg.Register(testMsgProgress, dup); !errors.Is(err, ErrInvalidRequirement) {
+		t.Fatalf("expected duplicate requirement rejected, got %v", err)
+	}
+	if err := reg.Register(testMsgProgress, progressRequirements()); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := reg.Register(testMsgProgress, progressRequirements()); !errors.Is(err, ErrMessageTypeConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func TestCoreContractStaysInsideReservedRanges(t *testing.T) {
+	testlog.Start(t)
+	for version, byType := range requirementsByVersion {
+		for messageType, reqs := range byType {
+			if messageType > CoreMessageTypeMax {
+				t.Fatalf("version %d: core message_type=%d outside core range", version, messageType)
+			}
+			for _, req := range reqs {
+				if req.ID > CoreFieldIDMax {
+					t.Fatalf("version %d: core field=%d outside core range", version, req.ID)
+				}
+			}
+		}
+	}
+}
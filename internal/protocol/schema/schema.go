@@ -1,151 +1,107 @@
 package schema
 
+//go:generate go run ../../../cmd/schemagen -contract ../../../docs/architecture/definitions/tlv.toml -out schema_gen.go
+
 import (
 	"fmt"
+	"regexp"
+	"sort"
 
+	"github.com/danmuck/edgectl/internal/protocol/frame"
 	"github.com/danmuck/edgectl/internal/protocol/tlv"
 	logs "github.com/danmuck/smplog"
 )
 
-// Message type IDs from tlv contract.
+// Schema rule names reported in ValidationError.Rule.
 const (
-	MsgIssue       uint32 = 1
-	MsgCommand     uint32 = 2
-	MsgSeedExecute uint32 = 3
-	MsgSeedResult  uint32 = 4
-	MsgEvent       uint32 = 5
-	MsgReport      uint32 = 6
-	MsgError       uint32 = 7
-	MsgEventAck    uint32 = 8
+	RuleVersion     = "version"
+	RuleMessageType = "message_type"
+	RuleDuplicate   = "duplicate"
+	RuleRequired    = "required"
+	RuleType        = "type"
+	RuleContainer   = "container"
+	RuleMaxLen      = "max_len"
+	RuleEnum        = "enum"
+	RulePattern     = "pattern"
 )
 
-// Field IDs from tlv contract.
-const (
-	FieldIntentID    uint16 = 1
-	FieldCommandID   uint16 = 2
-	FieldExecutionID uint16 = 3
-	FieldEventID     uint16 = 4
-	FieldPhase       uint16 = 5
-	FieldTimestampMS uint16 = 6
-
-	FieldActor       uint16 = 100
-	FieldTargetScope uint16 = 101
-	FieldObjective   uint16 = 102
-
-	FieldGhostID      uint16 = 200
-	FieldSeedSelector uint16 = 201
-	FieldOperation    uint16 = 202
-	FieldArgs         uint16 = 203
-
-	FieldSeedID               uint16 = 300
-	FieldSeedExecuteOperation uint16 = 301
-	FieldSeedExecuteArgs      uint16 = 302
-
-	FieldStatus   uint16 = 400
-	FieldStdout   uint16 = 401
-	FieldStderr   uint16 = 402
-	FieldExitCode uint16 = 403
-
-	FieldOutcome uint16 = 500
-
-	FieldSummary         uint16 = 600
-	FieldCompletionState uint16 = 601
-
-	FieldAckStatus uint16 = 700
-	FieldAckCode   uint16 = 701
-)
-
-// Schema required field id/type pair for a message type.
+// Schema field rule for a message type.
+// Elem constrains list element or map value types for container fields (0 = any).
+// Optional fields may be absent but must match Type when present.
 type Requirement struct {
-	ID   uint16
-	Type uint8
+	ID       uint16
+	Type     uint8
+	Elem     uint8
+	Optional bool
+}
+
+// Schema value constraints for one field id, checked whenever the field is present.
+// Zero values disable a check; MaxLen counts encoded value bytes.
+type FieldRule struct {
+	MaxLen  int
+	Enum    []string
+	Pattern *regexp.Regexp
 }
 
-// Schema validation failure details.
+// Schema validation failure details; Rule names the check that failed.
 type ValidationError struct {
 	MessageType uint32
 	FieldID     uint16
+	Rule        string
 	Reason      string
 }
 
 // Schema validation error string formatter.
 func (e ValidationError) Error() string {
 	if e.FieldID == 0 {
-		return fmt.Sprintf("schema: message_type=%d: %s", e.MessageType, e.Reason)
+		return fmt.Sprintf("schema: message_type=%d rule=%s: %s", e.MessageType, e.Rule, e.Reason)
 	}
-	return fmt.Sprintf("schema: message_type=%d field=%d: %s", e.MessageType, e.FieldID, e.Reason)
+	return fmt.Sprintf("schema: message_type=%d field=%d rule=%s: %s", e.MessageType, e.FieldID, e.Rule, e.Reason)
 }
 
-var requirements = map[uint32][]Requirement{
-	MsgIssue: {
-		{FieldIntentID, tlv.TypeString},
-		{FieldActor, tlv.TypeString},
-		{FieldTargetScope, tlv.TypeString},
-		{FieldObjective, tlv.TypeString},
-	},
-	MsgCommand: {
-		{FieldCommandID, tlv.TypeString},
-		{FieldIntentID, tlv.TypeString},
-		{FieldGhostID, tlv.TypeString},
-		{FieldSeedSelector, tlv.TypeString},
-		{FieldOperation, tlv.TypeString},
-	},
-	MsgSeedExecute: {
-		{FieldExecutionID, tlv.TypeString},
-		{FieldCommandID, tlv.TypeString},
-		{FieldSeedID, tlv.TypeString},
-		{FieldSeedExecuteOperation, tlv.TypeString},
-		{FieldSeedExecuteArgs, tlv.TypeBytes},
-	},
-	MsgSeedResult: {
-		{FieldExecutionID, tlv.TypeString},
-		{FieldSeedID, tlv.TypeString},
-		{FieldStatus, tlv.TypeString},
-		{FieldStdout, tlv.TypeBytes},
-		{FieldStderr, tlv.TypeBytes},
-		{FieldExitCode, tlv.TypeU32},
-	},
-	MsgEvent: {
-		{FieldEventID, tlv.TypeString},
-		{FieldCommandID, tlv.TypeString},
-		{FieldIntentID, tlv.TypeString},
-		{FieldGhostID, tlv.TypeString},
-		{FieldSeedID, tlv.TypeString},
-		{FieldOutcome, tlv.TypeString},
-	},
-	MsgReport: {
-		{FieldIntentID, tlv.TypeString},
-		{FieldPhase, tlv.TypeString},
-		{FieldSummary, tlv.TypeString},
-		{FieldCompletionState, tlv.TypeString},
-	},
-	MsgEventAck: {
-		{FieldEventID, tlv.TypeString},
-		{FieldCommandID, tlv.TypeString},
-		{FieldGhostID, tlv.TypeString},
-		{FieldAckStatus, tlv.TypeString},
-		{FieldTimestampMS, tlv.TypeU64},
-	},
+// Schema validator for required fields, field types, and field value rules by message type.
+// Validates against the default protocol version; unknown fields are ignored by design.
+func Validate(messageType uint32, fields []tlv.Field) error {
+	return ValidateVersion(frame.ProtocolVersion, messageType, fields)
 }
 
-// Schema validator for required fields and required field types by message type.
-// Unknown fields are ignored by design.
-func Validate(messageType uint32, fields []tlv.Field) error {
-	logs.Debugf("schema.Validate message_type=%d fields=%d", messageType, len(fields))
-	reqs, ok := requirements[messageType]
+// Schema validator for one message decoded from a frame with the given protocol version.
+// Duplicate field ids are rejected; other unknown fields are ignored by design.
+func ValidateVersion(version uint16, messageType uint32, fields []tlv.Field) error {
+	return validate(nil, version, messageType, fields)
+}
+
+// Schema validation shared by the core tables and Registry; reg may be nil.
+// Runs on every message, so only failures are logged.
+func validate(reg *Registry, version uint16, messageType uint32, fields []tlv.Field) error {
+	if !frame.SupportsVersion(version) {
+		logs.Errf("schema.Validate unsupported version=%d message_type=%d", version, messageType)
+		return ValidationError{MessageType: messageType, Rule: RuleVersion, Reason: fmt.Sprintf("unsupported protocol version %d", version)}
+	}
+	reqs, ok := requirementsFor(version, messageType)
+	if !ok && reg != nil {
+		reqs, ok = reg.extension(messageType)
+	}
 	if !ok {
-		logs.Errf("schema.Validate unknown message_type=%d", messageType)
-		return ValidationError{MessageType: messageType, Reason: "unknown message_type"}
+		logs.Errf("schema.Validate unknown message_type=%d version=%d", messageType, version)
+		return ValidationError{MessageType: messageType, Rule: RuleMessageType, Reason: "unknown message_type"}
+	}
+	if id, dup := duplicateFieldID(fields); dup {
+		logs.Errf("schema.Validate duplicate field message_type=%d field_id=%d", messageType, id)
+		return ValidationError{MessageType: messageType, FieldID: id, Rule: RuleDuplicate, Reason: "duplicate field id"}
 	}
 	for _, req := range reqs {
 		f, found := tlv.GetField(fields, req.ID)
+		if !found && req.Optional {
+			continue
+		}
 		if !found {
 			logs.Errf(
 				"schema.Validate missing field message_type=%d field_id=%d",
 				messageType,
 				req.ID,
 			)
-			return ValidationError{MessageType: messageType, FieldID: req.ID, Reason: "missing required field"}
+			return ValidationError{MessageType: messageType, FieldID: req.ID, Rule: RuleRequired, Reason: "missing required field"}
 		}
 		if f.Type != req.Type {
 			logs.Errf(
@@ -155,9 +111,121 @@ func Validate(messageType uint32, fields []tlv.Field) error {
 				f.Type,
 				req.Type,
 			)
-			return ValidationError{MessageType: messageType, FieldID: req.ID, Reason: "type mismatch"}
+			return ValidationError{MessageType: messageType, FieldID: req.ID, Rule: RuleType, Reason: "type mismatch"}
+		}
+		if req.Type == tlv.TypeList || req.Type == tlv.TypeMap {
+			if err := tlv.ValidateContainer(f, req.Elem, tlv.DefaultContainerLimits()); err != nil {
+				logs.Errf(
+					"schema.Validate invalid container message_type=%d field_id=%d err=%v",
+					messageType,
+					req.ID,
+					err,
+				)
+				return ValidationError{MessageType: messageType, FieldID: req.ID, Rule: RuleContainer, Reason: fmt.Sprintf("invalid container: %v", err)}
+			}
+		}
+		if rule, ok := fieldRules[req.ID]; ok {
+			if ruleName, reason := rule.check(f.Value); ruleName != "" {
+				logs.Errf(
+					"schema.Validate rule failed message_type=%d field_id=%d rule=%s",
+					messageType,
+					req.ID,
+					ruleName,
+				)
+				return ValidationError{MessageType: messageType, FieldID: req.ID, Rule: ruleName, Reason: reason}
+			}
 		}
 	}
-	logs.Infof("schema.Validate ok message_type=%d", messageType)
 	return nil
 }
+
+// Schema value rule evaluation; returns the failed rule name and reason, or "" when valid.
+func (r FieldRule) check(value []byte) (string, string) {
+	if r.MaxLen > 0 && len(value) > r.MaxLen {
+		return RuleMaxLen, fmt.Sprintf("value length %d exceeds %d", len(value), r.MaxLen)
+	}
+	if len(r.Enum) > 0 {
+		allowed := false
+		for _, v := range r.Enum {
+			if string(value) == v {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return RuleEnum, fmt.Sprintf("value %q not in %v", truncateValue(value), r.Enum)
+		}
+	}
+	if r.Pattern != nil && !r.Pattern.Match(value) {
+		return RulePattern, fmt.Sprintf("value %q does not match %s", truncateValue(value), r.Pattern)
+	}
+	return "", ""
+}
+
+// Schema duplicate field id scan; small payloads avoid the map allocation.
+func duplicateFieldID(fields []tlv.Field) (uint16, bool) {
+	const linearScanMax = 16
+	if len(fields) <= linearScanMax {
+		for i := 1; i < len(fields); i++ {
+			for j := 0; j < i; j++ {
+				if fields[i].ID == fields[j].ID {
+					return fields[i].ID, true
+				}
+			}
+		}
+		return 0, false
+	}
+	seen := make(map[uint16]struct{}, len(fields))
+	for _, f := range fields {
+		if _, ok := seen[f.ID]; ok {
+			return f.ID, true
+		}
+		seen[f.ID] = struct{}{}
+	}
+	return 0, false
+}
+
+// Schema helper bounding rejected values quoted in error reasons.
+func truncateValue(value []byte) string {
+	const maxQuoted = 64
+	if len(value) > maxQuoted {
+		return string(value[:maxQuoted]) + "..."
+	}
+	return string(value)
+}
+
+// Schema requirement resolution for one message type at one protocol version.
+// Returns false when the message type is not defined at or before version.
+func requirementsFor(version uint16, messageType uint32) ([]Requirement, bool) {
+	versions := make([]uint16, 0, len(requirementsByVersion))
+	for v := range requirementsByVersion {
+		if v <= version {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	var out []Requirement
+	known := false
+	for _, v := range versions {
+		reqs, ok := requirementsByVersion[v][messageType]
+		if !ok {
+			continue
+		}
+		known = true
+		for _, req := range reqs {
+			replaced := false
+			for i := range out {
+				if out[i].ID == req.ID {
+					out[i] = req
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				out = append(out, req)
+			}
+		}
+	}
+	return out, known
+}
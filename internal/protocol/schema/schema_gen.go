@@ -0,0 +1,964 @@
+// Code generated by cmd/schemagen from docs/architecture/definitions/tlv.toml. DO NOT EDIT.
+
+package schema
+
+import (
+	"regexp"
+
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+// Schema newest protocol version described by the tlv contract.
+const contractVersion uint16 = 2
+
+// Message type IDs from tlv contract.
+const (
+	MsgIssue       uint32 = 1
+	MsgCommand     uint32 = 2
+	MsgSeedExecute uint32 = 3
+	MsgSeedResult  uint32 = 4
+	MsgEvent       uint32 = 5
+	MsgReport      uint32 = 6
+	MsgError       uint32 = 7
+	MsgEventAck    uint32 = 8
+	MsgPing        uint32 = 9
+	MsgPong        uint32 = 10
+	MsgProgress    uint32 = 11
+)
+
+// Field IDs from tlv contract.
+const (
+	FieldIntentID    uint16 = 1
+	FieldCommandID   uint16 = 2
+	FieldExecutionID uint16 = 3
+	FieldEventID     uint16 = 4
+	FieldPhase       uint16 = 5
+	FieldTimestampMS uint16 = 6
+
+	FieldActor       uint16 = 100
+	FieldTargetScope uint16 = 101
+	FieldObjective   uint16 = 102
+
+	FieldGhostID      uint16 = 200
+	FieldSeedSelector uint16 = 201
+	FieldOperation    uint16 = 202
+	FieldArgs         uint16 = 203
+
+	FieldSeedID               uint16 = 300
+	FieldSeedExecuteOperation uint16 = 301
+	FieldSeedExecuteArgs      uint16 = 302
+
+	FieldStatus   uint16 = 400
+	FieldStdout   uint16 = 401
+	FieldStderr   uint16 = 402
+	FieldExitCode uint16 = 403
+
+	FieldOutcome  uint16 = 500
+	FieldEventSeq uint16 = 501
+
+	FieldSummary         uint16 = 600
+	FieldCompletionState uint16 = 601
+
+	FieldAckStatus    uint16 = 700
+	FieldAckCode      uint16 = 701
+	FieldRetryAfterMS uint16 = 702
+
+	FieldErrorCode        uint16 = 800
+	FieldErrorClass       uint16 = 801
+	FieldErrorMessage     uint16 = 802
+	FieldErrorMessageID   uint16 = 803
+	FieldErrorMessageType uint16 = 804
+
+	FieldRttMS uint16 = 900
+
+	FieldPingTimestampMS uint16 = 1000
+
+	FieldProgressSeq     uint16 = 1100
+	FieldStream          uint16 = 1101
+	FieldChunk           uint16 = 1102
+	FieldPercent         uint16 = 1103
+	FieldProgressMessage uint16 = 1104
+)
+
+// Schema requirements keyed by the protocol version that introduced them.
+// A version inherits every earlier version's message types and requirements;
+// a later entry for the same field id replaces the earlier type.
+var requirementsByVersion = map[uint16]map[uint32][]Requirement{
+	1: {
+		MsgIssue: {
+			{ID: FieldIntentID, Type: tlv.TypeString},
+			{ID: FieldActor, Type: tlv.TypeString},
+			{ID: FieldTargetScope, Type: tlv.TypeString},
+			{ID: FieldObjective, Type: tlv.TypeString},
+		},
+		MsgCommand: {
+			{ID: FieldCommandID, Type: tlv.TypeString},
+			{ID: FieldIntentID, Type: tlv.TypeString},
+			{ID: FieldGhostID, Type: tlv.TypeString},
+			{ID: FieldSeedSelector, Type: tlv.TypeString},
+			{ID: FieldOperation, Type: tlv.TypeString},
+			{ID: FieldArgs, Type: tlv.TypeBytes, Optional: true},
+		},
+		MsgSeedExecute: {
+			{ID: FieldExecutionID, Type: tlv.TypeString},
+			{ID: FieldCommandID, Type: tlv.TypeString},
+			{ID: FieldSeedID, Type: tlv.TypeString},
+			{ID: FieldSeedExecuteOperation, Type: tlv.TypeString},
+			{ID: FieldSeedExecuteArgs, Type: tlv.TypeBytes},
+		},
+		MsgSeedResult: {
+			{ID: FieldExecutionID, Type: tlv.TypeString},
+			{ID: FieldSeedID, Type: tlv.TypeString},
+			{ID: FieldStatus, Type: tlv.TypeString},
+			{ID: FieldStdout, Type: tlv.TypeBytes},
+			{ID: FieldStderr, Type: tlv.TypeBytes},
+			{ID: FieldExitCode, Type: tlv.TypeU32},
+		},
+		MsgEvent: {
+			{ID: FieldEventID, Type: tlv.TypeString},
+			{ID: FieldCommandID, Type: tlv.TypeString},
+			{ID: FieldIntentID, Type: tlv.TypeString},
+			{ID: FieldGhostID, Type: tlv.TypeString},
+			{ID: FieldSeedID, Type: tlv.TypeString},
+			{ID: FieldOutcome, Type: tlv.TypeString},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64, Optional: true},
+			{ID: FieldEventSeq, Type: tlv.TypeU64, Optional: true},
+		},
+		MsgReport: {
+			{ID: FieldIntentID, Type: tlv.TypeString},
+			{ID: FieldPhase, Type: tlv.TypeString},
+			{ID: FieldSummary, Type: tlv.TypeString},
+			{ID: FieldCompletionState, Type: tlv.TypeString},
+			{ID: FieldCommandID, Type: tlv.TypeString, Optional: true},
+			{ID: FieldExecutionID, Type: tlv.TypeString, Optional: true},
+			{ID: FieldEventID, Type: tlv.TypeString, Optional: true},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64, Optional: true},
+			{ID: FieldOutcome, Type: tlv.TypeString, Optional: true},
+		},
+		MsgError: {
+			{ID: FieldErrorCode, Type: tlv.TypeU32},
+			{ID: FieldErrorClass, Type: tlv.TypeString},
+			{ID: FieldErrorMessage, Type: tlv.TypeString},
+			{ID: FieldErrorMessageID, Type: tlv.TypeU64},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64},
+			{ID: FieldErrorMessageType, Type: tlv.TypeU32, Optional: true},
+		},
+		MsgEventAck: {
+			{ID: FieldEventID, Type: tlv.TypeString},
+			{ID: FieldCommandID, Type: tlv.TypeString},
+			{ID: FieldGhostID, Type: tlv.TypeString},
+			{ID: FieldAckStatus, Type: tlv.TypeString},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64},
+			{ID: FieldAckCode, Type: tlv.TypeU32, Optional: true},
+			{ID: FieldRetryAfterMS, Type: tlv.TypeU64, Optional: true},
+		},
+		MsgPing: {
+			{ID: FieldTimestampMS, Type: tlv.TypeU64},
+			{ID: FieldRttMS, Type: tlv.TypeU64, Optional: true},
+		},
+		MsgPong: {
+			{ID: FieldPingTimestampMS, Type: tlv.TypeU64},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64},
+		},
+		MsgProgress: {
+			{ID: FieldExecutionID, Type: tlv.TypeString},
+			{ID: FieldCommandID, Type: tlv.TypeString},
+			{ID: FieldIntentID, Type: tlv.TypeString},
+			{ID: FieldGhostID, Type: tlv.TypeString},
+			{ID: FieldProgressSeq, Type: tlv.TypeU64},
+			{ID: FieldPercent, Type: tlv.TypeU32},
+			{ID: FieldTimestampMS, Type: tlv.TypeU64},
+			{ID: FieldStream, Type: tlv.TypeString, Optional: true},
+			{ID: FieldChunk, Type: tlv.TypeBytes, Optional: true},
+			{ID: FieldProgressMessage, Type: tlv.TypeString, Optional: true},
+		},
+	},
+	2: {
+		MsgCommand: {
+			{ID: FieldArgs, Type: tlv.TypeMap, Elem: tlv.TypeString, Optional: true},
+		},
+		MsgSeedExecute: {
+			{ID: FieldSeedExecuteArgs, Type: tlv.TypeMap, Elem: tlv.TypeString},
+		},
+	},
+}
+
+// Schema value patterns from tlv contract.
+var (
+	patternID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/@-]*$`)
+)
+
+// Schema value rules by field id, applied wherever the field is present.
+var fieldRules = map[uint16]FieldRule{
+	FieldIntentID:             {MaxLen: 128, Pattern: patternID},
+	FieldCommandID:            {MaxLen: 128, Pattern: patternID},
+	FieldExecutionID:          {MaxLen: 128, Pattern: patternID},
+	FieldEventID:              {MaxLen: 128, Pattern: patternID},
+	FieldPhase:                {MaxLen: 32},
+	FieldActor:                {MaxLen: 128},
+	FieldTargetScope:          {MaxLen: 256},
+	FieldObjective:            {MaxLen: 1024},
+	FieldGhostID:              {MaxLen: 128, Pattern: patternID},
+	FieldSeedSelector:         {MaxLen: 128, Pattern: patternID},
+	FieldOperation:            {MaxLen: 64},
+	FieldSeedID:               {MaxLen: 128, Pattern: patternID},
+	FieldSeedExecuteOperation: {MaxLen: 64},
+	FieldStatus:               {MaxLen: 32},
+	FieldOutcome:              {Enum: []string{"success", "error", "timeout", "cancelled"}},
+	FieldSummary:              {MaxLen: 4096},
+	FieldCompletionState:      {Enum: []string{"in_progress", "satisfied", "failed"}},
+	FieldAckStatus:            {Enum: []string{"accepted", "rejected", "throttled"}},
+	FieldErrorClass:           {MaxLen: 64},
+	FieldErrorMessage:         {MaxLen: 1024},
+	FieldStream:               {Enum: []string{"stdout", "stderr"}},
+	FieldChunk:                {MaxLen: 32768},
+	FieldProgressMessage:      {MaxLen: 1024},
+}
+
+// Schema typed issue payload at the newest contract version.
+type IssueEnvelope struct {
+	IntentID    string
+	Actor       string
+	TargetScope string
+	Objective   string
+}
+
+// MessageType returns MsgIssue.
+func (IssueEnvelope) MessageType() uint32 {
+	return MsgIssue
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e IssueEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(4)
+	b.String(FieldIntentID, e.IntentID)
+	b.String(FieldActor, e.Actor)
+	b.String(FieldTargetScope, e.TargetScope)
+	b.String(FieldObjective, e.Objective)
+	if err := ValidateVersion(contractVersion, MsgIssue, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *IssueEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgIssue, r.Fields()); err != nil {
+		return err
+	}
+	var out IssueEnvelope
+	if out.IntentID, err = r.String(FieldIntentID); err != nil {
+		return err
+	}
+	if out.Actor, err = r.String(FieldActor); err != nil {
+		return err
+	}
+	if out.TargetScope, err = r.String(FieldTargetScope); err != nil {
+		return err
+	}
+	if out.Objective, err = r.String(FieldObjective); err != nil {
+		return err
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed command payload at the newest contract version.
+type CommandEnvelope struct {
+	CommandID    string
+	IntentID     string
+	GhostID      string
+	SeedSelector string
+	Operation    string
+	Args         map[string]string
+}
+
+// MessageType returns MsgCommand.
+func (CommandEnvelope) MessageType() uint32 {
+	return MsgCommand
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e CommandEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(6)
+	b.String(FieldCommandID, e.CommandID)
+	b.String(FieldIntentID, e.IntentID)
+	b.String(FieldGhostID, e.GhostID)
+	b.String(FieldSeedSelector, e.SeedSelector)
+	b.String(FieldOperation, e.Operation)
+	if len(e.Args) > 0 {
+		b.StringMap(FieldArgs, e.Args)
+	}
+	if err := ValidateVersion(contractVersion, MsgCommand, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *CommandEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgCommand, r.Fields()); err != nil {
+		return err
+	}
+	var out CommandEnvelope
+	if out.CommandID, err = r.String(FieldCommandID); err != nil {
+		return err
+	}
+	if out.IntentID, err = r.String(FieldIntentID); err != nil {
+		return err
+	}
+	if out.GhostID, err = r.String(FieldGhostID); err != nil {
+		return err
+	}
+	if out.SeedSelector, err = r.String(FieldSeedSelector); err != nil {
+		return err
+	}
+	if out.Operation, err = r.String(FieldOperation); err != nil {
+		return err
+	}
+	if r.Has(FieldArgs) {
+		if out.Args, err = r.StringMap(FieldArgs); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed seed.execute payload at the newest contract version.
+type SeedExecuteEnvelope struct {
+	ExecutionID string
+	CommandID   string
+	SeedID      string
+	Operation   string
+	Args        map[string]string
+}
+
+// MessageType returns MsgSeedExecute.
+func (SeedExecuteEnvelope) MessageType() uint32 {
+	return MsgSeedExecute
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e SeedExecuteEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(5)
+	b.String(FieldExecutionID, e.ExecutionID)
+	b.String(FieldCommandID, e.CommandID)
+	b.String(FieldSeedID, e.SeedID)
+	b.String(FieldSeedExecuteOperation, e.Operation)
+	b.StringMap(FieldSeedExecuteArgs, e.Args)
+	if err := ValidateVersion(contractVersion, MsgSeedExecute, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *SeedExecuteEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgSeedExecute, r.Fields()); err != nil {
+		return err
+	}
+	var out SeedExecuteEnvelope
+	if out.ExecutionID, err = r.String(FieldExecutionID); err != nil {
+		return err
+	}
+	if out.CommandID, err = r.String(FieldCommandID); err != nil {
+		return err
+	}
+	if out.SeedID, err = r.String(FieldSeedID); err != nil {
+		return err
+	}
+	if out.Operation, err = r.String(FieldSeedExecuteOperation); err != nil {
+		return err
+	}
+	if out.Args, err = r.StringMap(FieldSeedExecuteArgs); err != nil {
+		return err
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed seed.result payload at the newest contract version.
+type SeedResultEnvelope struct {
+	ExecutionID string
+	SeedID      string
+	Status      string
+	Stdout      []byte
+	Stderr      []byte
+	ExitCode    uint32
+}
+
+// MessageType returns MsgSeedResult.
+func (SeedResultEnvelope) MessageType() uint32 {
+	return MsgSeedResult
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e SeedResultEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(6)
+	b.String(FieldExecutionID, e.ExecutionID)
+	b.String(FieldSeedID, e.SeedID)
+	b.String(FieldStatus, e.Status)
+	b.Bytes(FieldStdout, e.Stdout)
+	b.Bytes(FieldStderr, e.Stderr)
+	b.U32(FieldExitCode, e.ExitCode)
+	if err := ValidateVersion(contractVersion, MsgSeedResult, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *SeedResultEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgSeedResult, r.Fields()); err != nil {
+		return err
+	}
+	var out SeedResultEnvelope
+	if out.ExecutionID, err = r.String(FieldExecutionID); err != nil {
+		return err
+	}
+	if out.SeedID, err = r.String(FieldSeedID); err != nil {
+		return err
+	}
+	if out.Status, err = r.String(FieldStatus); err != nil {
+		return err
+	}
+	if out.Stdout, err = r.Bytes(FieldStdout); err != nil {
+		return err
+	}
+	if out.Stderr, err = r.Bytes(FieldStderr); err != nil {
+		return err
+	}
+	if out.ExitCode, err = r.U32(FieldExitCode); err != nil {
+		return err
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed event payload at the newest contract version.
+type EventEnvelope struct {
+	EventID     string
+	CommandID   string
+	IntentID    string
+	GhostID     string
+	SeedID      string
+	Outcome     string
+	TimestampMS uint64
+	EventSeq    uint64
+}
+
+// MessageType returns MsgEvent.
+func (EventEnvelope) MessageType() uint32 {
+	return MsgEvent
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e EventEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(8)
+	b.String(FieldEventID, e.EventID)
+	b.String(FieldCommandID, e.CommandID)
+	b.String(FieldIntentID, e.IntentID)
+	b.String(FieldGhostID, e.GhostID)
+	b.String(FieldSeedID, e.SeedID)
+	b.String(FieldOutcome, e.Outcome)
+	if e.TimestampMS != 0 {
+		b.U64(FieldTimestampMS, e.TimestampMS)
+	}
+	if e.EventSeq != 0 {
+		b.U64(FieldEventSeq, e.EventSeq)
+	}
+	if err := ValidateVersion(contractVersion, MsgEvent, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *EventEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgEvent, r.Fields()); err != nil {
+		return err
+	}
+	var out EventEnvelope
+	if out.EventID, err = r.String(FieldEventID); err != nil {
+		return err
+	}
+	if out.CommandID, err = r.String(FieldCommandID); err != nil {
+		return err
+	}
+	if out.IntentID, err = r.String(FieldIntentID); err != nil {
+		return err
+	}
+	if out.GhostID, err = r.String(FieldGhostID); err != nil {
+		return err
+	}
+	if out.SeedID, err = r.String(FieldSeedID); err != nil {
+		return err
+	}
+	if out.Outcome, err = r.String(FieldOutcome); err != nil {
+		return err
+	}
+	if r.Has(FieldTimestampMS) {
+		if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldEventSeq) {
+		if out.EventSeq, err = r.U64(FieldEventSeq); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed report payload at the newest contract version.
+type ReportEnvelope struct {
+	IntentID        string
+	Phase           string
+	Summary         string
+	CompletionState string
+	CommandID       string
+	ExecutionID     string
+	EventID         string
+	TimestampMS     uint64
+	Outcome         string
+}
+
+// MessageType returns MsgReport.
+func (ReportEnvelope) MessageType() uint32 {
+	return MsgReport
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e ReportEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(9)
+	b.String(FieldIntentID, e.IntentID)
+	b.String(FieldPhase, e.Phase)
+	b.String(FieldSummary, e.Summary)
+	b.String(FieldCompletionState, e.CompletionState)
+	if e.CommandID != "" {
+		b.String(FieldCommandID, e.CommandID)
+	}
+	if e.ExecutionID != "" {
+		b.String(FieldExecutionID, e.ExecutionID)
+	}
+	if e.EventID != "" {
+		b.String(FieldEventID, e.EventID)
+	}
+	if e.TimestampMS != 0 {
+		b.U64(FieldTimestampMS, e.TimestampMS)
+	}
+	if e.Outcome != "" {
+		b.String(FieldOutcome, e.Outcome)
+	}
+	if err := ValidateVersion(contractVersion, MsgReport, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *ReportEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgReport, r.Fields()); err != nil {
+		return err
+	}
+	var out ReportEnvelope
+	if out.IntentID, err = r.String(FieldIntentID); err != nil {
+		return err
+	}
+	if out.Phase, err = r.String(FieldPhase); err != nil {
+		return err
+	}
+	if out.Summary, err = r.String(FieldSummary); err != nil {
+		return err
+	}
+	if out.CompletionState, err = r.String(FieldCompletionState); err != nil {
+		return err
+	}
+	if r.Has(FieldCommandID) {
+		if out.CommandID, err = r.String(FieldCommandID); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldExecutionID) {
+		if out.ExecutionID, err = r.String(FieldExecutionID); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldEventID) {
+		if out.EventID, err = r.String(FieldEventID); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldTimestampMS) {
+		if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldOutcome) {
+		if out.Outcome, err = r.String(FieldOutcome); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed error payload at the newest contract version.
+type ErrorEnvelope struct {
+	ErrorCode        uint32
+	ErrorClass       string
+	ErrorMessage     string
+	ErrorMessageID   uint64
+	TimestampMS      uint64
+	ErrorMessageType uint32
+}
+
+// MessageType returns MsgError.
+func (ErrorEnvelope) MessageType() uint32 {
+	return MsgError
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e ErrorEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(6)
+	b.U32(FieldErrorCode, e.ErrorCode)
+	b.String(FieldErrorClass, e.ErrorClass)
+	b.String(FieldErrorMessage, e.ErrorMessage)
+	b.U64(FieldErrorMessageID, e.ErrorMessageID)
+	b.U64(FieldTimestampMS, e.TimestampMS)
+	if e.ErrorMessageType != 0 {
+		b.U32(FieldErrorMessageType, e.ErrorMessageType)
+	}
+	if err := ValidateVersion(contractVersion, MsgError, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *ErrorEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgError, r.Fields()); err != nil {
+		return err
+	}
+	var out ErrorEnvelope
+	if out.ErrorCode, err = r.U32(FieldErrorCode); err != nil {
+		return err
+	}
+	if out.ErrorClass, err = r.String(FieldErrorClass); err != nil {
+		return err
+	}
+	if out.ErrorMessage, err = r.String(FieldErrorMessage); err != nil {
+		return err
+	}
+	if out.ErrorMessageID, err = r.U64(FieldErrorMessageID); err != nil {
+		return err
+	}
+	if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+		return err
+	}
+	if r.Has(FieldErrorMessageType) {
+		if out.ErrorMessageType, err = r.U32(FieldErrorMessageType); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed event.ack payload at the newest contract version.
+type EventAckEnvelope struct {
+	EventID      string
+	CommandID    string
+	GhostID      string
+	AckStatus    string
+	TimestampMS  uint64
+	AckCode      uint32
+	RetryAfterMS uint64
+}
+
+// MessageType returns MsgEventAck.
+func (EventAckEnvelope) MessageType() uint32 {
+	return MsgEventAck
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e EventAckEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(7)
+	b.String(FieldEventID, e.EventID)
+	b.String(FieldCommandID, e.CommandID)
+	b.String(FieldGhostID, e.GhostID)
+	b.String(FieldAckStatus, e.AckStatus)
+	b.U64(FieldTimestampMS, e.TimestampMS)
+	if e.AckCode != 0 {
+		b.U32(FieldAckCode, e.AckCode)
+	}
+	if e.RetryAfterMS != 0 {
+		b.U64(FieldRetryAfterMS, e.RetryAfterMS)
+	}
+	if err := ValidateVersion(contractVersion, MsgEventAck, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *EventAckEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgEventAck, r.Fields()); err != nil {
+		return err
+	}
+	var out EventAckEnvelope
+	if out.EventID, err = r.String(FieldEventID); err != nil {
+		return err
+	}
+	if out.CommandID, err = r.String(FieldCommandID); err != nil {
+		return err
+	}
+	if out.GhostID, err = r.String(FieldGhostID); err != nil {
+		return err
+	}
+	if out.AckStatus, err = r.String(FieldAckStatus); err != nil {
+		return err
+	}
+	if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+		return err
+	}
+	if r.Has(FieldAckCode) {
+		if out.AckCode, err = r.U32(FieldAckCode); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldRetryAfterMS) {
+		if out.RetryAfterMS, err = r.U64(FieldRetryAfterMS); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed ping payload at the newest contract version.
+type PingEnvelope struct {
+	TimestampMS uint64
+	RttMS       uint64
+}
+
+// MessageType returns MsgPing.
+func (PingEnvelope) MessageType() uint32 {
+	return MsgPing
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e PingEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(2)
+	b.U64(FieldTimestampMS, e.TimestampMS)
+	if e.RttMS != 0 {
+		b.U64(FieldRttMS, e.RttMS)
+	}
+	if err := ValidateVersion(contractVersion, MsgPing, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *PingEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgPing, r.Fields()); err != nil {
+		return err
+	}
+	var out PingEnvelope
+	if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+		return err
+	}
+	if r.Has(FieldRttMS) {
+		if out.RttMS, err = r.U64(FieldRttMS); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed pong payload at the newest contract version.
+type PongEnvelope struct {
+	PingTimestampMS uint64
+	TimestampMS     uint64
+}
+
+// MessageType returns MsgPong.
+func (PongEnvelope) MessageType() uint32 {
+	return MsgPong
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e PongEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(2)
+	b.U64(FieldPingTimestampMS, e.PingTimestampMS)
+	b.U64(FieldTimestampMS, e.TimestampMS)
+	if err := ValidateVersion(contractVersion, MsgPong, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *PongEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgPong, r.Fields()); err != nil {
+		return err
+	}
+	var out PongEnvelope
+	if out.PingTimestampMS, err = r.U64(FieldPingTimestampMS); err != nil {
+		return err
+	}
+	if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+		return err
+	}
+	*e = out
+	return nil
+}
+
+// Schema typed progress payload at the newest contract version.
+type ProgressEnvelope struct {
+	ExecutionID     string
+	CommandID       string
+	IntentID        string
+	GhostID         string
+	ProgressSeq     uint64
+	Percent         uint32
+	TimestampMS     uint64
+	Stream          string
+	Chunk           []byte
+	ProgressMessage string
+}
+
+// MessageType returns MsgProgress.
+func (ProgressEnvelope) MessageType() uint32 {
+	return MsgProgress
+}
+
+// Encode validates e and serializes it into one TLV payload.
+// Optional fields holding their zero value are omitted.
+func (e ProgressEnvelope) Encode() ([]byte, error) {
+	b := tlv.NewBuilder(10)
+	b.String(FieldExecutionID, e.ExecutionID)
+	b.String(FieldCommandID, e.CommandID)
+	b.String(FieldIntentID, e.IntentID)
+	b.String(FieldGhostID, e.GhostID)
+	b.U64(FieldProgressSeq, e.ProgressSeq)
+	b.U32(FieldPercent, e.Percent)
+	b.U64(FieldTimestampMS, e.TimestampMS)
+	if e.Stream != "" {
+		b.String(FieldStream, e.Stream)
+	}
+	if len(e.Chunk) > 0 {
+		b.Bytes(FieldChunk, e.Chunk)
+	}
+	if e.ProgressMessage != "" {
+		b.String(FieldProgressMessage, e.ProgressMessage)
+	}
+	if err := ValidateVersion(contractVersion, MsgProgress, b.Fields()); err != nil {
+		return nil, err
+	}
+	return b.Encode(), nil
+}
+
+// Decode validates payload and replaces e with its fields.
+func (e *ProgressEnvelope) Decode(payload []byte) error {
+	r, err := tlv.ReadPayload(payload)
+	if err != nil {
+		return err
+	}
+	if err := ValidateVersion(contractVersion, MsgProgress, r.Fields()); err != nil {
+		return err
+	}
+	var out ProgressEnvelope
+	if out.ExecutionID, err = r.String(FieldExecutionID); err != nil {
+		return err
+	}
+	if out.CommandID, err = r.String(FieldCommandID); err != nil {
+		return err
+	}
+	if out.IntentID, err = r.String(FieldIntentID); err != nil {
+		return err
+	}
+	if out.GhostID, err = r.String(FieldGhostID); err != nil {
+		return err
+	}
+	if out.ProgressSeq, err = r.U64(FieldProgressSeq); err != nil {
+		return err
+	}
+	if out.Percent, err = r.U32(FieldPercent); err != nil {
+		return err
+	}
+	if out.TimestampMS, err = r.U64(FieldTimestampMS); err != nil {
+		return err
+	}
+	if r.Has(FieldStream) {
+		if out.Stream, err = r.String(FieldStream); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldChunk) {
+		if out.Chunk, err = r.Bytes(FieldChunk); err != nil {
+			return err
+		}
+	}
+	if r.Has(FieldProgressMessage) {
+		if out.ProgressMessage, err = r.String(FieldProgressMessage); err != nil {
+			return err
+		}
+	}
+	*e = out
+	return nil
+}
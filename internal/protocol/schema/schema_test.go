@@ -1,8 +1,10 @@
 package schema
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/danmuck/edgectl/internal/protocol/frame"
 	"github.com/danmuck/edgectl/internal/protocol/tlv"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
 )
@@ -113,11 +115,15 @@ func TestValidateSeedExecuteUsesCanonicalFieldIDs(t *testing.T) {
 		{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("cmd.1")},
 		{ID: FieldSeedID, Type: tlv.TypeString, Value: []byte("seed.flow")},
 		{ID: FieldSeedExecuteOperation, Type: tlv.TypeString, Value: []byte("status")},
-		{ID: FieldSeedExecuteArgs, Type: tlv.TypeBytes, Value: []byte("{}")},
+		{ID: FieldSeedExecuteArgs, Type: tlv.TypeMap, Value: tlv.EncodeMap(nil)},
 	}
 	if err := Validate(MsgSeedExecute, fields); err != nil {
 		t.Fatalf("validate seed.execute: %v", err)
 	}
+	fields[4] = tlv.Field{ID: FieldSeedExecuteArgs, Type: tlv.TypeBytes, Value: []byte("{}")}
+	if err := ValidateVersion(1, MsgSeedExecute, fields); err != nil {
+		t.Fatalf("validate v1 seed.execute: %v", err)
+	}
 }
 
 func TestValidateSeedExecuteLegacyFieldIDsRejected(t *testing.T) {
@@ -141,3 +147,209 @@ func TestValidateSeedExecuteLegacyFieldIDsRejected(t *testing.T) {
 		t.Fatalf("unexpected validation error: %+v", ve)
 	}
 }
+
+func TestValidateVersionAppliesRequirementsIntroducedByVersion(t *testing.T) {
+	testlog.Start(t)
+
+	const (
+		nextVersion         = frame.MaxProtocolVersion + 1
+		msgNextOnly  uint32 = 999
+		fieldNextReq uint16 = 9001
+	)
+	requirementsByVersion[nextVersion] = map[uint32][]Requirement{
+		MsgEventAck: {{ID: fieldNextReq, Type: tlv.TypeU32}},
+		msgNextOnly: {{ID: FieldIntentID, Type: tlv.TypeString}},
+	}
+	defer delete(requirementsByVersion, nextVersion)
+
+	fields := []tlv.Field{
+		{ID: FieldEventID, Type: tlv.TypeString, Value: []byte("evt-1")},
+		{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("cmd-1")},
+		{ID: FieldGhostID, Type: tlv.TypeString, Value: []byte("ghost-1")},
+		{ID: FieldAckStatus, Type: tlv.TypeString, Value: []byte("accepted")},
+		{ID: FieldTimestampMS, Type: tlv.TypeU64, Value: make([]byte, 8)},
+	}
+	if err := ValidateVersion(1, MsgEventAck, fields); err != nil {
+		t.Fatalf("validate v1 event ack: %v", err)
+	}
+
+	reqs, ok := requirementsFor(nextVersion, MsgEventAck)
+	if !ok || len(reqs) != len(requirementsByVersion[1][MsgEventAck])+1 || reqs[len(reqs)-1].ID != fieldNextReq {
+		t.Fatalf("expected later requirements to extend earlier ones: %+v", reqs)
+	}
+	if _, ok := requirementsFor(frame.MaxProtocolVersion, msgNextOnly); ok {
+		t.Fatalf("expected message type introduced later to be unknown at current version")
+	}
+	if err := ValidateVersion(0, MsgEventAck, fields); err == nil {
+		t.Fatalf("expected version 0 rejected")
+	}
+}
+
+func TestValidateVersionMigratesArgsToStringMap(t *testing.T) {
+	testlog.Start(t)
+
+	base := []tlv.Field{
+		{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("cmd-1")},
+		{ID: FieldIntentID, Type: tlv.TypeString, Value: []byte("intent-1")},
+		{ID: FieldGhostID, Type: tlv.TypeString, Value: []byte("ghost-1")},
+		{ID: FieldSeedSelector, Type: tlv.TypeString, Value: []byte("seed.flow")},
+		{ID: FieldOperation, Type: tlv.TypeString, Value: []byte("status")},
+	}
+	jsonArgs := append(append([]tlv.Field(nil), base...), tlv.Field{ID: FieldArgs, Type: tlv.TypeBytes, Value: []byte(`{"a":"b"}`)})
+	mapArgs := append(append([]tlv.Field(nil), base...), tlv.Field{
+		ID:    FieldArgs,
+		Type:  tlv.TypeMap,
+		Value: tlv.EncodeMap(tlv.StringEntries(map[string]string{"a": "b"})),
+	})
+	badElem := append(append([]tlv.Field(nil), base...), tlv.Field{
+		ID:   FieldArgs,
+		Type: tlv.TypeMap,
+		Value: tlv.EncodeMap([]tlv.MapEntry{
+			{Key: "n", Value: tlv.Field{Type: tlv.TypeU32, Value: []byte{0, 0, 0, 1}}},
+		}),
+	})
+
+	for version := frame.MinProtocolVersion; version <= frame.MaxProtocolVersion; version++ {
+		if err := ValidateVersion(version, MsgCommand, base); err != nil {
+			t.Fatalf("v%d command without args: %v", version, err)
+		}
+	}
+	if err := ValidateVersion(1, MsgCommand, jsonArgs); err != nil {
+		t.Fatalf("v1 json args: %v", err)
+	}
+	if err := ValidateVersion(1, MsgCommand, mapArgs); err == nil {
+		t.Fatalf("expected v1 to reject map args")
+	}
+	if err := ValidateVersion(2, MsgCommand, mapArgs); err != nil {
+		t.Fatalf("v2 map args: %v", err)
+	}
+	var ve ValidationError
+	if err := ValidateVersion(2, MsgCommand, jsonArgs); !errors.As(err, &ve) || ve.FieldID != FieldArgs || ve.Reason != "type mismatch" {
+		t.Fatalf("expected v2 json args type mismatch, got %v", err)
+	}
+	if err := ValidateVersion(2, MsgCommand, badElem); !errors.As(err, &ve) || ve.FieldID != FieldArgs {
+		t.Fatalf("expected v2 non-string map value rejected, got %v", err)
+	}
+}
+
+func TestContractVersionMatchesFrameProtocol(t *testing.T) {
+	testlog.Start(t)
+	if contractVersion != frame.MaxProtocolVersion {
+		t.Fatalf("tlv contract describes version %d but frame supports up to %d", contractVersion, frame.MaxProtocolVersion)
+	}
+}
+
+func TestGeneratedEnvelopeRoundTripAndValidation(t *testing.T) {
+	testlog.Start(t)
+	in := CommandEnvelope{
+		CommandID:    "cmd-1",
+		IntentID:     "intent-1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+		Args:         map[string]string{"mode": "full"},
+	}
+	payload, err := in.Encode()
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var out CommandEnvelope
+	if err := out.Decode(payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.CommandID != in.CommandID || out.Operation != in.Operation || out.Args["mode"] != "full" {
+		t.Fatalf("round trip mismatch: %+v", out)
+	}
+
+	report, err := ReportEnvelope{IntentID: "intent-1", Phase: "complete", Summary: "ok", CompletionState: "satisfied"}.Encode()
+	if err != nil {
+		t.Fatalf("encode report: %v", err)
+	}
+	fields, err := tlv.DecodeFields(report)
+	if err != nil {
+		t.Fatalf("decode report fields: %v", err)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("expected zero optional fields omitted, got %d fields", len(fields))
+	}
+	var ack EventAckEnvelope
+	var validation ValidationError
+	if err := ack.Decode(report); !errors.As(err, &validation) || validation.FieldID != FieldEventID {
+		t.Fatalf("expected missing event_id validation error, got %v", err)
+	}
+}
+
+func TestValidateRejectsDuplicateFieldIDs(t *testing.T) {
+	testlog.Start(t)
+	fields := []tlv.Field{
+		{ID: FieldIntentID, Type: tlv.TypeString, Value: []byte("intent-1")},
+		{ID: FieldActor, Type: tlv.TypeString, Value: []byte("user:dan")},
+		{ID: FieldTargetScope, Type: tlv.TypeString, Value: []byte("ghost:*")},
+		{ID: FieldObjective, Type: tlv.TypeString, Value: []byte("restart mongodb")},
+		{ID: FieldActor, Type: tlv.TypeString, Value: []byte("user:mallory")},
+	}
+	var ve ValidationError
+	if err := Validate(MsgIssue, fields); !errors.As(err, &ve) || ve.Rule != RuleDuplicate || ve.FieldID != FieldActor {
+		t.Fatalf("expected duplicate actor rejected, got %v", err)
+	}
+
+	many := make([]tlv.Field, 0, 40)
+	many = append(many, fields[:4]...)
+	for i := range 32 {
+		many = append(many, tlv.Field{ID: uint16(9000 + i), Type: tlv.TypeU8, Value: []byte{1}})
+	}
+	if err := Validate(MsgIssue, many); err != nil {
+		t.Fatalf("expected distinct unknown fields accepted: %v", err)
+	}
+	many = append(many, tlv.Field{ID: 9000, Type: tlv.TypeU8, Value: []byte{2}})
+	if err := Validate(MsgIssue, many); !errors.As(err, &ve) || ve.Rule != RuleDuplicate || ve.FieldID != 9000 {
+		t.Fatalf("expected duplicate unknown field rejected, got %v", err)
+	}
+}
+
+func TestValidateFieldRulesReportFailedRule(t *testing.T) {
+	testlog.Start(t)
+	report := func(overrides ...tlv.Field) []tlv.Field {
+		fields := []tlv.Field{
+			{ID: FieldIntentID, Type: tlv.TypeString, Value: []byte("intent.1")},
+			{ID: FieldPhase, Type: tlv.TypeString, Value: []byte("complete")},
+			{ID: FieldSummary, Type: tlv.TypeString, Value: []byte("ok")},
+			{ID: FieldCompletionState, Type: tlv.TypeString, Value: []byte("satisfied")},
+		}
+		for _, o := range overrides {
+			replaced := false
+			for i := range fields {
+				if fields[i].ID == o.ID {
+					fields[i] = o
+					replaced = true
+				}
+			}
+			if !replaced {
+				fields = append(fields, o)
+			}
+		}
+		return fields
+	}
+	if err := Validate(MsgReport, report()); err != nil {
+		t.Fatalf("validate report: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		field tlv.Field
+		rule  string
+	}{
+		{"enum", tlv.Field{ID: FieldCompletionState, Type: tlv.TypeString, Value: []byte("done")}, RuleEnum},
+		{"max_len", tlv.Field{ID: FieldSummary, Type: tlv.TypeString, Value: make([]byte, 4097)}, RuleMaxLen},
+		{"pattern", tlv.Field{ID: FieldIntentID, Type: tlv.TypeString, Value: []byte("intent 1")}, RulePattern},
+		{"optional enum", tlv.Field{ID: FieldOutcome, Type: tlv.TypeString, Value: []byte("maybe")}, RuleEnum},
+		{"optional pattern", tlv.Field{ID: FieldCommandID, Type: tlv.TypeString, Value: []byte("")}, RulePattern},
+	}
+	for _, tc := range cases {
+		var ve ValidationError
+		err := Validate(MsgReport, report(tc.field))
+		if !errors.As(err, &ve) || ve.Rule != tc.rule || ve.FieldID != tc.field.ID {
+			t.Fatalf("%s: expected rule %q on field %d, got %v", tc.name, tc.rule, tc.field.ID, err)
+		}
+	}
+}
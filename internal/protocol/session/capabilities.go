@@ -0,0 +1,44 @@
+package session
+
+import (
+	"slices"
+	"strings"
+)
+
+// Session feature flags advertised in seed.register and echoed in the ack.
+// A feature is used only when both peers list it; peers predating a flag send none,
+// so the other side falls back to the behavior that peer understands.
+const (
+	// Ping/pong heartbeat frames and ping-based session liveness.
+	CapabilityHeartbeat = "heartbeat"
+	// Non-terminal progress frames for in-flight commands.
+	CapabilityProgress = "progress"
+	// Throttled event.ack with a retry_after_ms hint; without it Mirage stops reading instead.
+	CapabilityThrottle = "throttle"
+	// Mirage-pushed command frames on the registered session.
+	CapabilityPushCommand = "push_command"
+)
+
+// Session capabilities the local peer implements, in advertisement order.
+func SupportedCapabilities() []string {
+	return []string{CapabilityHeartbeat, CapabilityProgress, CapabilityThrottle, CapabilityPushCommand}
+}
+
+// Session capability selection: local capabilities the peer also offered, in local order.
+func NegotiateCapabilities(offered []string) []string {
+	var out []string
+	for _, capability := range SupportedCapabilities() {
+		for _, peer := range offered {
+			if strings.EqualFold(strings.TrimSpace(peer), capability) {
+				out = append(out, capability)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Session check that the registration ack enabled one capability for the stream.
+func (a RegistrationAck) Supports(capability string) bool {
+	return slices.Contains(a.Capabilities, capability)
+}
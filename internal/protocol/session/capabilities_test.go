@@ -0,0 +1,23 @@
+package session
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestNegotiateCapabilitiesKeepsCommonLocalOrder(t *testing.T) {
+	testlog.Start(t)
+
+	if got := NegotiateCapabilities(nil); len(got) != 0 {
+		t.Fatalf("expected no capabilities for legacy peer, got %v", got)
+	}
+	if got := NegotiateCapabilities([]string{"unknown", " Heartbeat "}); !slices.Equal(got, []string{CapabilityHeartbeat}) {
+		t.Fatalf("unexpected negotiated capabilities: %v", got)
+	}
+	ack := RegistrationAck{Capabilities: NegotiateCapabilities(SupportedCapabilities())}
+	if !ack.Supports(CapabilityHeartbeat) || !ack.Supports(CapabilityThrottle) || !ack.Supports(CapabilityPushCommand) || ack.Supports("unknown") {
+		t.Fatalf("unexpected ack support: %+v", ack)
+	}
+}
@@ -1,7 +1,6 @@
 package session
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -73,63 +72,69 @@ func (r Report) Validate() error {
 
 // Session encoder for command envelope into framed protocol message bytes.
 func EncodeCommandFrame(messageID uint64, command Command) ([]byte, error) {
+	return EncodeCommandFrameVersion(frame.ProtocolVersion, messageID, command)
+}
+
+// Session frame builder for one command envelope using the args encoding of version.
+// Versions before ArgsMapProtocolVersion carry args as a JSON object in a bytes field.
+func NewCommandFrame(version uint16, messageID uint64, command Command) (frame.Frame, error) {
 	if err := command.Validate(); err != nil {
-		return nil, err
-	}
-	fields := []tlv.Field{
-		{ID: schema.FieldCommandID, Type: tlv.TypeString, Value: []byte(command.CommandID)},
-		{ID: schema.FieldIntentID, Type: tlv.TypeString, Value: []byte(command.IntentID)},
-		{ID: schema.FieldGhostID, Type: tlv.TypeString, Value: []byte(command.GhostID)},
-		{ID: schema.FieldSeedSelector, Type: tlv.TypeString, Value: []byte(command.SeedSelector)},
-		{ID: schema.FieldOperation, Type: tlv.TypeString, Value: []byte(command.Operation)},
-	}
+		return frame.Frame{}, err
+	}
+	fields := tlv.NewBuilder(6).
+		String(schema.FieldCommandID, command.CommandID).
+		String(schema.FieldIntentID, command.IntentID).
+		String(schema.FieldGhostID, command.GhostID).
+		String(schema.FieldSeedSelector, command.SeedSelector).
+		String(schema.FieldOperation, command.Operation)
 	if len(command.Args) > 0 {
-		argsPayload, err := json.Marshal(command.Args)
-		if err != nil {
-			return nil, err
+		if version >= ArgsMapProtocolVersion {
+			fields.StringMap(schema.FieldArgs, command.Args)
+		} else {
+			argsPayload, err := json.Marshal(command.Args)
+			if err != nil {
+				return frame.Frame{}, err
+			}
+			fields.Bytes(schema.FieldArgs, argsPayload)
 		}
-		fields = append(fields, tlv.Field{ID: schema.FieldArgs, Type: tlv.TypeBytes, Value: argsPayload})
 	}
-	if err := schema.Validate(schema.MsgCommand, fields); err != nil {
-		return nil, err
+	if err := schema.ValidateVersion(version, schema.MsgCommand, fields.Fields()); err != nil {
+		return frame.Frame{}, err
 	}
-	payload := tlv.EncodeFields(fields)
-	var buf bytes.Buffer
-	err := frame.WriteFrame(&buf, frame.Frame{
-		Header: frame.Header{
-			MessageID:   messageID,
-			MessageType: schema.MsgCommand,
-		},
-		Payload: payload,
-	}, frame.DefaultLimits())
-	if err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return newMessage(frame.Header{
+		Version:     version,
+		MessageID:   messageID,
+		MessageType: schema.MsgCommand,
+	}, fields.Fields()), nil
+}
+
+// Session encoder for command envelope using the args encoding of one protocol version.
+func EncodeCommandFrameVersion(version uint16, messageID uint64, command Command) ([]byte, error) {
+	return encodeFrame(NewCommandFrame(version, messageID, command))
 }
 
 // Session decoder for one command frame payload with schema validation.
 func DecodeCommandFrame(f frame.Frame) (Command, error) {
-	fields, err := tlv.DecodeFields(f.Payload)
+	r, err := readFrameFields(f, schema.MsgCommand)
 	if err != nil {
 		return Command{}, err
 	}
-	if err := schema.Validate(schema.MsgCommand, fields); err != nil {
-		return Command{}, err
-	}
 	command := Command{
-		CommandID:    getRequiredString(fields, schema.FieldCommandID),
-		IntentID:     getRequiredString(fields, schema.FieldIntentID),
-		GhostID:      getRequiredString(fields, schema.FieldGhostID),
-		SeedSelector: getRequiredString(fields, schema.FieldSeedSelector),
-		Operation:    getRequiredString(fields, schema.FieldOperation),
+		CommandID:    r.str(schema.FieldCommandID),
+		IntentID:     r.str(schema.FieldIntentID),
+		GhostID:      r.str(schema.FieldGhostID),
+		SeedSelector: r.str(schema.FieldSeedSelector),
+		Operation:    r.str(schema.FieldOperation),
 		Args:         map[string]string{},
 	}
-	if argsField, ok := tlv.GetField(fields, schema.FieldArgs); ok {
-		var args map[string]string
-		if err := json.Unmarshal(argsField.Value, &args); err != nil {
-			return Command{}, err
-		}
+	if r.err != nil {
+		return Command{}, r.err
+	}
+	args, err := decodeArgs(r.Reader, schema.FieldArgs)
+	if err != nil {
+		return Command{}, err
+	}
+	if args != nil {
 		command.Args = args
 	}
 	return command, nil
@@ -140,79 +145,66 @@ func EncodeReportFrame(messageID uint64, report Report) ([]byte, error) {
 	if err := report.Validate(); err != nil {
 		return nil, err
 	}
-	fields := []tlv.Field{
-		{ID: schema.FieldIntentID, Type: tlv.TypeString, Value: []byte(report.IntentID)},
-		{ID: schema.FieldPhase, Type: tlv.TypeString, Value: []byte(report.Phase)},
-		{ID: schema.FieldSummary, Type: tlv.TypeString, Value: []byte(report.Summary)},
-		{ID: schema.FieldCompletionState, Type: tlv.TypeString, Value: []byte(report.CompletionState)},
-	}
-	if v := strings.TrimSpace(report.CommandID); v != "" {
-		fields = append(fields, tlv.Field{ID: schema.FieldCommandID, Type: tlv.TypeString, Value: []byte(v)})
-	}
-	if v := strings.TrimSpace(report.ExecutionID); v != "" {
-		fields = append(fields, tlv.Field{ID: schema.FieldExecutionID, Type: tlv.TypeString, Value: []byte(v)})
-	}
-	if v := strings.TrimSpace(report.EventID); v != "" {
-		fields = append(fields, tlv.Field{ID: schema.FieldEventID, Type: tlv.TypeString, Value: []byte(v)})
-	}
-	if v := strings.TrimSpace(report.Outcome); v != "" {
-		fields = append(fields, tlv.Field{ID: schema.FieldOutcome, Type: tlv.TypeString, Value: []byte(v)})
-	}
-	if report.TimestampMS != 0 {
-		fields = append(fields, tlv.Field{ID: schema.FieldTimestampMS, Type: tlv.TypeU64, Value: putU64(report.TimestampMS)})
-	}
+	fields := tlv.NewBuilder(9).
+		String(schema.FieldIntentID, report.IntentID).
+		String(schema.FieldPhase, report.Phase).
+		String(schema.FieldSummary, report.Summary).
+		String(schema.FieldCompletionState, report.CompletionState).
+		OptString(schema.FieldCommandID, strings.TrimSpace(report.CommandID)).
+		OptString(schema.FieldExecutionID, strings.TrimSpace(report.ExecutionID)).
+		OptString(schema.FieldEventID, strings.TrimSpace(report.EventID)).
+		OptString(schema.FieldOutcome, strings.TrimSpace(report.Outcome)).
+		OptU64(schema.FieldTimestampMS, report.TimestampMS).
+		Fields()
 	if err := schema.Validate(schema.MsgReport, fields); err != nil {
 		return nil, err
 	}
-	payload := tlv.EncodeFields(fields)
-	var buf bytes.Buffer
-	err := frame.WriteFrame(&buf, frame.Frame{
-		Header: frame.Header{
-			MessageID:   messageID,
-			MessageType: schema.MsgReport,
-		},
-		Payload: payload,
-	}, frame.DefaultLimits())
-	if err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return encodeMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgReport,
+	}, fields)
 }
 
 // Session decoder for one report frame payload with schema validation.
 func DecodeReportFrame(f frame.Frame) (Report, error) {
-	fields, err := tlv.DecodeFields(f.Payload)
+	r, err := readFrameFields(f, schema.MsgReport)
 	if err != nil {
 		return Report{}, err
 	}
-	if err := schema.Validate(schema.MsgReport, fields); err != nil {
-		return Report{}, err
-	}
 	report := Report{
-		IntentID:        getRequiredString(fields, schema.FieldIntentID),
-		Phase:           getRequiredString(fields, schema.FieldPhase),
-		Summary:         getRequiredString(fields, schema.FieldSummary),
-		CompletionState: getRequiredString(fields, schema.FieldCompletionState),
-		CommandID:       getOptionalString(fields, schema.FieldCommandID),
-		ExecutionID:     getOptionalString(fields, schema.FieldExecutionID),
-		EventID:         getOptionalString(fields, schema.FieldEventID),
-		Outcome:         getOptionalString(fields, schema.FieldOutcome),
-	}
-	if tsField, ok := tlv.GetField(fields, schema.FieldTimestampMS); ok {
-		ts, err := u64FromBytes(tsField.Value)
-		if err != nil {
-			return Report{}, err
-		}
-		report.TimestampMS = ts
+		IntentID:        r.str(schema.FieldIntentID),
+		Phase:           r.str(schema.FieldPhase),
+		Summary:         r.str(schema.FieldSummary),
+		CompletionState: r.str(schema.FieldCompletionState),
+		CommandID:       r.optStr(schema.FieldCommandID),
+		ExecutionID:     r.optStr(schema.FieldExecutionID),
+		EventID:         r.optStr(schema.FieldEventID),
+		Outcome:         r.optStr(schema.FieldOutcome),
+		TimestampMS:     r.optU64(schema.FieldTimestampMS),
+	}
+	if r.err != nil {
+		return Report{}, r.err
 	}
 	return report, nil
 }
 
-// Session helper returning optional string field value if present.
-func getOptionalString(fields []tlv.Field, id uint16) string {
-	f, ok := tlv.GetField(fields, id)
+// Session args decoder accepting the string map encoding and the legacy JSON bytes;
+// schema validation has already pinned which one the frame version allows.
+func decodeArgs(r tlv.Reader, id uint16) (map[string]string, error) {
+	typ, ok := r.Type(id)
 	if !ok {
-		return ""
+		return nil, nil
+	}
+	if typ == tlv.TypeMap {
+		return r.StringMap(id)
+	}
+	raw, err := r.Bytes(id)
+	if err != nil {
+		return nil, err
+	}
+	var args map[string]string
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
 	}
-	return string(f.Value)
+	return args, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/danmuck/edgectl/internal/protocol/frame"
 	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
 )
 
@@ -47,6 +48,53 @@ func TestCommandFrameRoundTrip(t *testing.T) {
 	}
 }
 
+func TestCommandFrameArgsEncodingFollowsVersion(t *testing.T) {
+	testlog.Start(t)
+
+	in := Command{
+		CommandID:    "cmd.1",
+		IntentID:     "intent.1",
+		GhostID:      "ghost.alpha",
+		SeedSelector: "seed.flow",
+		Operation:    "status",
+		Args:         map[string]string{"mode": "full", "path": "/tmp"},
+	}
+	cases := []struct {
+		version  uint16
+		argsType uint8
+	}{
+		{LegacyProtocolVersion, tlv.TypeBytes},
+		{ArgsMapProtocolVersion, tlv.TypeMap},
+	}
+	for _, tc := range cases {
+		payload, err := EncodeCommandFrameVersion(tc.version, 7, in)
+		if err != nil {
+			t.Fatalf("v%d encode: %v", tc.version, err)
+		}
+		fr, err := frame.DecodeMessage(payload, frame.DefaultLimits())
+		if err != nil {
+			t.Fatalf("v%d decode message: %v", tc.version, err)
+		}
+		if fr.Header.Version != tc.version {
+			t.Fatalf("expected frame version %d, got %d", tc.version, fr.Header.Version)
+		}
+		fields, err := tlv.DecodeFields(fr.Payload)
+		if err != nil {
+			t.Fatalf("v%d decode fields: %v", tc.version, err)
+		}
+		if args, ok := tlv.GetField(fields, schema.FieldArgs); !ok || args.Type != tc.argsType {
+			t.Fatalf("v%d expected args type %d, got %+v", tc.version, tc.argsType, args)
+		}
+		out, err := DecodeCommandFrame(fr)
+		if err != nil {
+			t.Fatalf("v%d decode command: %v", tc.version, err)
+		}
+		if len(out.Args) != 2 || out.Args["mode"] != "full" || out.Args["path"] != "/tmp" {
+			t.Fatalf("v%d args mismatch: %+v", tc.version, out.Args)
+		}
+	}
+}
+
 func TestReportFrameRoundTrip(t *testing.T) {
 	testlog.Start(t)
 
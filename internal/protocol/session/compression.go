@@ -0,0 +1,72 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+)
+
+// Session payload compression codec identifiers advertised during registration.
+const (
+	CompressionNone    = ""
+	CompressionDeflate = "deflate"
+)
+
+var (
+	ErrUnsupportedCompression   = errors.New("session: unsupported compression codec")
+	ErrCompressionNotNegotiated = errors.New("session: compressed frame without negotiated compression")
+)
+
+// Session codecs the local peer can encode and decode, in preference order.
+func SupportedCompression() []string {
+	return []string{CompressionDeflate}
+}
+
+// Session codecs advertised in seed.register; empty when compression is disabled.
+func (c CompressionConfig) Offer() []string {
+	if !c.Enabled {
+		return nil
+	}
+	return SupportedCompression()
+}
+
+// Session codec selection from a peer offer; returns CompressionNone when nothing matches.
+func NegotiateCompression(local CompressionConfig, offered []string) string {
+	if !local.Enabled {
+		return CompressionNone
+	}
+	for _, codec := range SupportedCompression() {
+		for _, peer := range offered {
+			if strings.EqualFold(strings.TrimSpace(peer), codec) {
+				return codec
+			}
+		}
+	}
+	return CompressionNone
+}
+
+// Session check that a codec selected by the peer is one the local side offered.
+func CheckCompression(local CompressionConfig, selected string) error {
+	selected = strings.TrimSpace(selected)
+	if selected == CompressionNone {
+		return nil
+	}
+	if !slices.Contains(local.Offer(), selected) {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCompression, selected)
+	}
+	return nil
+}
+
+// Session inbound decompression for one reassembled frame using the negotiated codec.
+func DecompressFrame(f frame.Frame, codec string, limits frame.Limits) (frame.Frame, error) {
+	if f.Header.Flags&frame.FlagIsCompressed == 0 {
+		return f, nil
+	}
+	if codec == CompressionNone {
+		return frame.Frame{}, fmt.Errorf("%w: message_id=%d", ErrCompressionNotNegotiated, f.Header.MessageID)
+	}
+	return frame.DecompressFrame(f, limits)
+}
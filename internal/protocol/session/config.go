@@ -1,6 +1,10 @@
 package session
 
-import "time"
+import (
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+)
 
 // Session transport enforcement level selector.
 type SecurityMode string
@@ -11,14 +15,39 @@ const (
 )
 
 // Session TLS/mTLS transport settings.
+// Cert, key, and CA files are reloaded for new handshakes when they change on disk.
 type TLSConfig struct {
-	Enabled            bool
-	Mutual             bool
-	CertFile           string
-	KeyFile            string
-	CAFile             string
+	Enabled  bool
+	Mutual   bool
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// Server-side revocation list for client certificates; empty disables revocation checks.
+	CRLFile            string
 	ServerName         string
 	InsecureSkipVerify bool
+	// Ghost-side one-time join token; while CertFile is missing the Ghost enrolls
+	// with Mirage and writes the issued cert, key, and CA to the configured paths.
+	JoinToken string
+	// Close a session once a certificate it was opened with expires; the Ghost
+	// reconnects with rotated material and resumes.
+	RehandshakeOnExpiry bool
+}
+
+// Session frame auth-block settings for HMAC pre-shared-key signing.
+// With a key file configured, outbound frames are signed; Required rejects unsigned inbound frames.
+type AuthConfig struct {
+	Required bool
+	KeyID    string
+	KeyFile  string
+	MaxSkew  time.Duration
+}
+
+// Session payload compression settings negotiated during registration.
+// Threshold is the minimum payload size compressed; unset uses the frame default.
+type CompressionConfig struct {
+	Enabled   bool
+	Threshold int
 }
 
 // Session retry backoff behavior settings.
@@ -30,6 +59,7 @@ type BackoffConfig struct {
 }
 
 // Session transport/reliability configuration.
+// EventWindow bounds how many events may await event.ack at once on one session.
 type Config struct {
 	ConnectTimeout    time.Duration
 	HandshakeTimeout  time.Duration
@@ -38,8 +68,12 @@ type Config struct {
 	HeartbeatInterval time.Duration
 	SessionDeadAfter  time.Duration
 	AckTimeout        time.Duration
+	EventWindow       int
 	SecurityMode      SecurityMode
 	TLS               TLSConfig
+	Auth              AuthConfig
+	Versions          VersionRange
+	Compression       CompressionConfig
 	Backoff           BackoffConfig
 }
 
@@ -53,7 +87,13 @@ func DefaultConfig() Config {
 		HeartbeatInterval: 5 * time.Second,
 		SessionDeadAfter:  15 * time.Second,
 		AckTimeout:        20 * time.Second,
+		EventWindow:       32,
 		SecurityMode:      SecurityModeDevelopment,
+		Versions:          SupportedVersionRange(),
+		Compression: CompressionConfig{
+			Enabled:   true,
+			Threshold: frame.DefaultCompressThreshold,
+		},
 		Backoff: BackoffConfig{
 			InitialDelay: 250 * time.Millisecond,
 			Multiplier:   2.0,
@@ -88,6 +128,9 @@ func (c Config) WithDefaults() Config {
 	if c.AckTimeout <= 0 {
 		c.AckTimeout = d.AckTimeout
 	}
+	if c.EventWindow <= 0 {
+		c.EventWindow = d.EventWindow
+	}
 	if c.Backoff.InitialDelay <= 0 {
 		c.Backoff.InitialDelay = d.Backoff.InitialDelay
 	}
@@ -97,6 +140,10 @@ func (c Config) WithDefaults() Config {
 	if c.Backoff.MaxDelay <= 0 {
 		c.Backoff.MaxDelay = d.Backoff.MaxDelay
 	}
+	c.Versions = c.Versions.WithDefaults()
+	if c.Compression.Threshold <= 0 {
+		c.Compression.Threshold = d.Compression.Threshold
+	}
 	c.SecurityMode = NormalizeSecurityMode(c.SecurityMode)
 	return c
 }
@@ -31,10 +31,21 @@ type SeedInfo struct {
 }
 
 // Session seed.register payload from Ghost to Mirage.
+// Min/MaxProtocolVersion advertise the frame versions Ghost can speak;
+// Compression lists the payload codecs Ghost accepts.
+// SessionEpoch names the Ghost outbox whose event sequence the session resumes.
+// Capabilities lists the optional session features Ghost implements.
+// Auth is the frame auth block over the rest of the registration (see SignRegistration).
 type Registration struct {
-	GhostID      string     `json:"ghost_id"`
-	PeerIdentity string     `json:"peer_identity"`
-	SeedList     []SeedInfo `json:"seed_list"`
+	GhostID            string     `json:"ghost_id"`
+	PeerIdentity       string     `json:"peer_identity"`
+	SeedList           []SeedInfo `json:"seed_list"`
+	MinProtocolVersion uint16     `json:"min_protocol_version,omitempty"`
+	MaxProtocolVersion uint16     `json:"max_protocol_version,omitempty"`
+	Compression        []string   `json:"compression,omitempty"`
+	SessionEpoch       uint64     `json:"session_epoch,omitempty"`
+	Capabilities       []string   `json:"capabilities,omitempty"`
+	Auth               []byte     `json:"auth,omitempty"`
 }
 
 // Session seed.register validator for required payload fields.
@@ -45,6 +56,9 @@ func (r Registration) Validate() error {
 	if r.SeedList == nil {
 		return fmt.Errorf("%w: missing seed_list", ErrInvalidRegistration)
 	}
+	if versions := r.ProtocolVersions(); versions.Min > versions.Max {
+		return fmt.Errorf("%w: protocol version range %d..%d", ErrInvalidRegistration, versions.Min, versions.Max)
+	}
 	for i, seed := range r.SeedList {
 		if strings.TrimSpace(seed.ID) == "" {
 			return fmt.Errorf("%w: seed_list[%d] missing id", ErrInvalidRegistration, i)
@@ -60,12 +74,20 @@ func (r Registration) Validate() error {
 }
 
 // Session seed.register.ack payload from Mirage to Ghost.
+// ProtocolVersion and Compression carry the negotiated stream settings on accepted registrations.
+// LastSeq is the event sequence Mirage has observed without gaps for SessionEpoch since it started.
+// Capabilities lists the optional features enabled for the session (see NegotiateCapabilities).
 type RegistrationAck struct {
-	Status      string `json:"status"`
-	Code        uint32 `json:"code"`
-	Message     string `json:"message"`
-	GhostID     string `json:"ghost_id"`
-	TimestampMS uint64 `json:"timestamp_ms"`
+	Status          string   `json:"status"`
+	Code            uint32   `json:"code"`
+	Message         string   `json:"message"`
+	GhostID         string   `json:"ghost_id"`
+	TimestampMS     uint64   `json:"timestamp_ms"`
+	ProtocolVersion uint16   `json:"protocol_version,omitempty"`
+	Compression     string   `json:"compression,omitempty"`
+	SessionEpoch    uint64   `json:"session_epoch,omitempty"`
+	LastSeq         uint64   `json:"last_seq,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
 }
 
 // Session seed.register.ack validator for required payload fields.
@@ -85,9 +107,11 @@ func (a RegistrationAck) Validate() error {
 
 // Session control-plane envelope for handshake payload variants.
 type controlEnvelope struct {
-	Type string           `json:"type"`
-	Reg  *Registration    `json:"registration,omitempty"`
-	Ack  *RegistrationAck `json:"registration_ack,omitempty"`
+	Type      string           `json:"type"`
+	Reg       *Registration    `json:"registration,omitempty"`
+	Ack       *RegistrationAck `json:"registration_ack,omitempty"`
+	Enroll    *EnrollRequest   `json:"enroll,omitempty"`
+	EnrollAck *EnrollAck       `json:"enroll_ack,omitempty"`
 }
 
 // Session writer for one newline-delimited seed.register envelope.
@@ -0,0 +1,220 @@
+package session
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	controlTypeEnroll    = "ghost.enroll"
+	controlTypeEnrollAck = "ghost.enroll.ack"
+
+	joinTokenIDLen     = 12
+	joinTokenSecretLen = 32
+	joinTokenCAHashLen = 64
+)
+
+var (
+	ErrInvalidJoinToken     = errors.New("session: invalid join token")
+	ErrInvalidEnrollment    = errors.New("session: invalid enrollment")
+	ErrInvalidEnrollmentAck = errors.New("session: invalid enrollment ack")
+	ErrEnrollCAPinMismatch  = errors.New("session: mirage ca does not match join token pin")
+)
+
+// Session one-time join token minted by Mirage for Ghost enrollment.
+// The string form is "<id>.<secret>.<ca_sha256>"; CAHash pins the Mirage CA so a
+// Ghost holding only the token can authenticate Mirage before sending the secret.
+type JoinToken struct {
+	ID     string
+	Secret string
+	CAHash string
+}
+
+// Session join token in its operator-facing string form.
+func (t JoinToken) String() string {
+	return t.ID + "." + t.Secret + "." + t.CAHash
+}
+
+// Session join token parser for the "<id>.<secret>.<ca_sha256>" form.
+func ParseJoinToken(raw string) (JoinToken, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ".")
+	if len(parts) != 3 {
+		return JoinToken{}, fmt.Errorf("%w: expected <id>.<secret>.<ca_sha256>", ErrInvalidJoinToken)
+	}
+	tok := JoinToken{ID: parts[0], Secret: parts[1], CAHash: strings.ToLower(parts[2])}
+	for _, part := range []struct {
+		name  string
+		value string
+		size  int
+	}{
+		{"id", tok.ID, joinTokenIDLen},
+		{"secret", tok.Secret, joinTokenSecretLen},
+		{"ca_sha256", tok.CAHash, joinTokenCAHashLen},
+	} {
+		if len(part.value) != part.size {
+			return JoinToken{}, fmt.Errorf("%w: %s must be %d hex chars", ErrInvalidJoinToken, part.name, part.size)
+		}
+		if _, err := hex.DecodeString(part.value); err != nil {
+			return JoinToken{}, fmt.Errorf("%w: %s is not hex", ErrInvalidJoinToken, part.name)
+		}
+	}
+	return tok, nil
+}
+
+// Session CA pin: hex SHA-256 of a DER certificate.
+func CACertHash(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// Session server-chain verifier for enrollment dials that have no CA file yet.
+// Mirage must present its CA in the chain; the CA matching pin becomes the only root
+// and the leaf must be valid for serverName. Used with InsecureSkipVerify so the
+// default verifier does not reject the unknown root first.
+func PinnedCAVerifier(pin string, serverName string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return ErrEnrollCAPinMismatch
+		}
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return fmt.Errorf("session: parse mirage certificate: %w", err)
+		}
+		roots := x509.NewCertPool()
+		intermediates := x509.NewCertPool()
+		pinned := false
+		for _, raw := range rawCerts[1:] {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("session: parse mirage certificate: %w", err)
+			}
+			if CACertHash(raw) == pin {
+				roots.AddCert(cert)
+				pinned = true
+				continue
+			}
+			intermediates.AddCert(cert)
+		}
+		if !pinned {
+			return ErrEnrollCAPinMismatch
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+		return err
+	}
+}
+
+// Session ghost.enroll payload from a Ghost that has a join token but no certificate.
+// CSR is a DER PKCS#10 request; Mirage signs it bound to GhostID.
+type EnrollRequest struct {
+	TokenID     string `json:"token_id"`
+	TokenSecret string `json:"token_secret"`
+	GhostID     string `json:"ghost_id"`
+	CSR         []byte `json:"csr"`
+}
+
+// Session ghost.enroll validator for required payload fields.
+func (r EnrollRequest) Validate() error {
+	if strings.TrimSpace(r.TokenID) == "" || strings.TrimSpace(r.TokenSecret) == "" {
+		return fmt.Errorf("%w: missing token", ErrInvalidEnrollment)
+	}
+	if strings.TrimSpace(r.GhostID) == "" {
+		return fmt.Errorf("%w: missing ghost_id", ErrInvalidEnrollment)
+	}
+	if len(r.CSR) == 0 {
+		return fmt.Errorf("%w: missing csr", ErrInvalidEnrollment)
+	}
+	return nil
+}
+
+// Session ghost.enroll.ack payload from Mirage to Ghost.
+// Accepted acks carry the signed DER leaf and the DER CA the Ghost should trust.
+type EnrollAck struct {
+	Status        string `json:"status"`
+	Code          uint32 `json:"code"`
+	Message       string `json:"message"`
+	GhostID       string `json:"ghost_id"`
+	TimestampMS   uint64 `json:"timestamp_ms"`
+	Certificate   []byte `json:"certificate,omitempty"`
+	CACertificate []byte `json:"ca_certificate,omitempty"`
+}
+
+// Session ghost.enroll.ack validator for required payload fields.
+func (a EnrollAck) Validate() error {
+	status := strings.TrimSpace(a.Status)
+	if status != AckStatusAccepted && status != AckStatusRejected {
+		return fmt.Errorf("%w: invalid status", ErrInvalidEnrollmentAck)
+	}
+	if strings.TrimSpace(a.GhostID) == "" {
+		return fmt.Errorf("%w: missing ghost_id", ErrInvalidEnrollmentAck)
+	}
+	if a.TimestampMS == 0 {
+		return fmt.Errorf("%w: missing timestamp_ms", ErrInvalidEnrollmentAck)
+	}
+	if status == AckStatusAccepted && (len(a.Certificate) == 0 || len(a.CACertificate) == 0) {
+		return fmt.Errorf("%w: accepted ack missing certificates", ErrInvalidEnrollmentAck)
+	}
+	return nil
+}
+
+// Session writer for one newline-delimited ghost.enroll envelope.
+func WriteEnrollRequest(w io.Writer, req EnrollRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	return writeControlEnvelope(w, controlEnvelope{
+		Type:   controlTypeEnroll,
+		Enroll: &req,
+	})
+}
+
+// Session reader for one validated ghost.enroll envelope.
+func ReadEnrollRequest(r *bufio.Reader) (EnrollRequest, error) {
+	env, err := readControlEnvelope(r)
+	if err != nil {
+		return EnrollRequest{}, err
+	}
+	if env.Type != controlTypeEnroll || env.Enroll == nil {
+		return EnrollRequest{}, fmt.Errorf("%w: unexpected control type", ErrInvalidEnrollment)
+	}
+	if err := env.Enroll.Validate(); err != nil {
+		return EnrollRequest{}, err
+	}
+	return *env.Enroll, nil
+}
+
+// Session writer for one newline-delimited ghost.enroll.ack envelope.
+func WriteEnrollAck(w io.Writer, ack EnrollAck) error {
+	if err := ack.Validate(); err != nil {
+		return err
+	}
+	return writeControlEnvelope(w, controlEnvelope{
+		Type:      controlTypeEnrollAck,
+		EnrollAck: &ack,
+	})
+}
+
+// Session reader for one validated ghost.enroll.ack envelope.
+func ReadEnrollAck(r *bufio.Reader) (EnrollAck, error) {
+	env, err := readControlEnvelope(r)
+	if err != nil {
+		return EnrollAck{}, err
+	}
+	if env.Type != controlTypeEnrollAck || env.EnrollAck == nil {
+		return EnrollAck{}, fmt.Errorf("%w: unexpected control type", ErrInvalidEnrollmentAck)
+	}
+	if err := env.EnrollAck.Validate(); err != nil {
+		return EnrollAck{}, err
+	}
+	return *env.EnrollAck, nil
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestJoinTokenRoundTripAndRejectsMalformed(t *testing.T) {
+	testlog.Start(t)
+
+	tok := JoinToken{
+		ID:     "0a1b2c3d4e5f",
+		Secret: strings.Repeat("ab", 16),
+		CAHash: CACertHash([]byte("ca")),
+	}
+	parsed, err := ParseJoinToken(tok.String())
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if parsed != tok {
+		t.Fatalf("expected %+v, got %+v", tok, parsed)
+	}
+	for _, raw := range []string{
+		"",
+		tok.ID + "." + tok.Secret,
+		tok.ID + "." + tok.Secret[:8] + "." + tok.CAHash,
+		tok.ID + "." + strings.Repeat("zz", 16) + "." + tok.CAHash,
+	} {
+		if _, err := ParseJoinToken(raw); !errors.Is(err, ErrInvalidJoinToken) {
+			t.Fatalf("expected ErrInvalidJoinToken for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestEnrollEnvelopesRoundTrip(t *testing.T) {
+	testlog.Start(t)
+
+	var buf bytes.Buffer
+	req := EnrollRequest{TokenID: "0a1b2c3d4e5f", TokenSecret: "secret", GhostID: "ghost.alpha", CSR: []byte{0x30, 0x01}}
+	if err := WriteEnrollRequest(&buf, req); err != nil {
+		t.Fatalf("write enroll: %v", err)
+	}
+	ack := EnrollAck{
+		Status:        AckStatusAccepted,
+		GhostID:       "ghost.alpha",
+		TimestampMS:   1,
+		Certificate:   []byte{0x30, 0x02},
+		CACertificate: []byte{0x30, 0x03},
+	}
+	if err := WriteEnrollAck(&buf, ack); err != nil {
+		t.Fatalf("write enroll ack: %v", err)
+	}
+	reader := bufio.NewReader(&buf)
+	gotReq, err := ReadEnrollRequest(reader)
+	if err != nil {
+		t.Fatalf("read enroll: %v", err)
+	}
+	if gotReq.GhostID != req.GhostID || !bytes.Equal(gotReq.CSR, req.CSR) {
+		t.Fatalf("unexpected enroll request: %+v", gotReq)
+	}
+	gotAck, err := ReadEnrollAck(reader)
+	if err != nil {
+		t.Fatalf("read enroll ack: %v", err)
+	}
+	if !bytes.Equal(gotAck.Certificate, ack.Certificate) || !bytes.Equal(gotAck.CACertificate, ack.CACertificate) {
+		t.Fatalf("unexpected enroll ack: %+v", gotAck)
+	}
+
+	// Accepted acks must carry the issued material.
+	ack.Certificate = nil
+	if err := WriteEnrollAck(&buf, ack); !errors.Is(err, ErrInvalidEnrollmentAck) {
+		t.Fatalf("expected ErrInvalidEnrollmentAck, got %v", err)
+	}
+	// A registration cannot stand in for an enrollment.
+	buf.Reset()
+	if err := WriteRegistration(&buf, Registration{GhostID: "ghost.alpha", SeedList: []SeedInfo{}}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	if _, err := ReadEnrollRequest(bufio.NewReader(&buf)); !errors.Is(err, ErrInvalidEnrollment) {
+		t.Fatalf("expected ErrInvalidEnrollment, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+// Session wire error codes from the errors contract.
+const (
+	ErrorCodeTransportFailure          uint32 = 1000
+	ErrorCodeTransportThrottled        uint32 = 1001
+	ErrorCodeFramingInvalidHeader      uint32 = 1100
+	ErrorCodeFramingOversize           uint32 = 1101
+	ErrorCodeTLVDecodeFailure          uint32 = 1200
+	ErrorCodeSemanticValidationFailure uint32 = 1300
+	ErrorCodeCommandInFlight           uint32 = 1301
+	ErrorCodeRuntimeExecutionFailure   uint32 = 1400
+	ErrorCodeInternalError             uint32 = 1500
+)
+
+// Session wire error classes from the errors contract.
+const (
+	ErrorClassTransport = "transport"
+	ErrorClassFraming   = "framing"
+	ErrorClassTLVDecode = "tlv_decode"
+	ErrorClassSemantic  = "semantic"
+	ErrorClassRuntime   = "runtime"
+)
+
+// Session maximum error message bytes carried on the wire.
+const maxErrorMessageBytes = 1024
+
+// Session wire error envelope emitted by a peer for a failed inbound message.
+// ErrorEnvelope implements error so peers can surface it with errors.As.
+type ErrorEnvelope struct {
+	Code        uint32
+	Class       string
+	Message     string
+	MessageID   uint64
+	MessageType uint32
+	TimestampMS uint64
+}
+
+// Session error-envelope constructor deriving class from code.
+func NewErrorEnvelope(code uint32, messageID uint64, messageType uint32, message string) ErrorEnvelope {
+	return ErrorEnvelope{
+		Code:        code,
+		Class:       ErrorClassForCode(code),
+		Message:     truncateErrorMessage(message),
+		MessageID:   messageID,
+		MessageType: messageType,
+		TimestampMS: uint64(time.Now().UnixMilli()),
+	}
+}
+
+// Session error string for a remote error envelope.
+func (e ErrorEnvelope) Error() string {
+	return fmt.Sprintf(
+		"session: remote error code=%d class=%s message_id=%d message_type=%d: %s",
+		e.Code,
+		e.Class,
+		e.MessageID,
+		e.MessageType,
+		e.Message,
+	)
+}
+
+// Session error-envelope validator for required payload fields.
+func (e ErrorEnvelope) Validate() error {
+	if ErrorClassForCode(e.Code) == "" {
+		return fmt.Errorf("error missing known error_code: %d", e.Code)
+	}
+	if strings.TrimSpace(e.Class) == "" {
+		return fmt.Errorf("error missing error_class")
+	}
+	if e.Class != ErrorClassForCode(e.Code) {
+		return fmt.Errorf("error class mismatch code=%d class=%q", e.Code, e.Class)
+	}
+	if strings.TrimSpace(e.Message) == "" {
+		return fmt.Errorf("error missing error_message")
+	}
+	if e.TimestampMS == 0 {
+		return fmt.Errorf("error missing timestamp_ms")
+	}
+	return nil
+}
+
+// Session wire code to error class mapping; unknown codes map to "".
+func ErrorClassForCode(code uint32) string {
+	switch code {
+	case ErrorCodeTransportFailure, ErrorCodeTransportThrottled:
+		return ErrorClassTransport
+	case ErrorCodeFramingInvalidHeader, ErrorCodeFramingOversize:
+		return ErrorClassFraming
+	case ErrorCodeTLVDecodeFailure:
+		return ErrorClassTLVDecode
+	case ErrorCodeSemanticValidationFailure, ErrorCodeCommandInFlight:
+		return ErrorClassSemantic
+	case ErrorCodeRuntimeExecutionFailure, ErrorCodeInternalError:
+		return ErrorClassRuntime
+	default:
+		return ""
+	}
+}
+
+// Session classifier mapping local decode/validation errors to wire error codes.
+func ErrorCodeFor(err error) uint32 {
+	var remote ErrorEnvelope
+	var validation schema.ValidationError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &remote):
+		return remote.Code
+	case errors.Is(err, frame.ErrAuthRequired),
+		errors.Is(err, frame.ErrAuthInvalid),
+		errors.Is(err, frame.ErrAuthReplay),
+		errors.Is(err, frame.ErrAuthExpired):
+		return ErrorCodeTransportFailure
+	case errors.Is(err, frame.ErrPayloadTooLarge),
+		errors.Is(err, frame.ErrAuthTooLarge),
+		errors.Is(err, frame.ErrReassemblyBudget),
+		errors.Is(err, frame.ErrDecompressedTooLarge):
+		return ErrorCodeFramingOversize
+	case errors.Is(err, frame.ErrShortHeader),
+		errors.Is(err, frame.ErrHeaderLenTooSmall),
+		errors.Is(err, frame.ErrHeaderLenMismatch),
+		errors.Is(err, frame.ErrUnsupportedMagic),
+		errors.Is(err, frame.ErrUnsupportedVersion),
+		errors.Is(err, frame.ErrUnsupportedFlags),
+		errors.Is(err, frame.ErrFragmentInvalid),
+		errors.Is(err, frame.ErrFragmentOutOfOrder),
+		errors.Is(err, ErrProtocolVersionMismatch),
+		errors.Is(err, ErrCompressionNotNegotiated):
+		return ErrorCodeFramingInvalidHeader
+	case errors.Is(err, frame.ErrCompressionInvalid):
+		return ErrorCodeTLVDecodeFailure
+	case errors.Is(err, tlv.ErrShortFieldHeader),
+		errors.Is(err, tlv.ErrShortFieldValue),
+		errors.Is(err, tlv.ErrFieldLength),
+		errors.Is(err, tlv.ErrContainerInvalid),
+		errors.Is(err, tlv.ErrContainerTooDeep),
+		errors.Is(err, tlv.ErrContainerTooLarge):
+		return ErrorCodeTLVDecodeFailure
+	case errors.Is(err, tlv.ErrFieldMissing), errors.Is(err, tlv.ErrFieldType):
+		return ErrorCodeSemanticValidationFailure
+	case errors.As(err, &validation):
+		return ErrorCodeSemanticValidationFailure
+	default:
+		return ErrorCodeInternalError
+	}
+}
+
+// Session frame builder for error envelope, written directly by session encoders.
+// The frame reuses the offending message_id so the peer can correlate it.
+func NewErrorFrame(messageID uint64, env ErrorEnvelope) (frame.Frame, error) {
+	env.Message = truncateErrorMessage(env.Message)
+	if err := env.Validate(); err != nil {
+		return frame.Frame{}, err
+	}
+	fields := tlv.NewBuilder(6).
+		U32(schema.FieldErrorCode, env.Code).
+		String(schema.FieldErrorClass, env.Class).
+		String(schema.FieldErrorMessage, env.Message).
+		U64(schema.FieldErrorMessageID, env.MessageID).
+		U32(schema.FieldErrorMessageType, env.MessageType).
+		U64(schema.FieldTimestampMS, env.TimestampMS).
+		Fields()
+	if err := schema.Validate(schema.MsgError, fields); err != nil {
+		return frame.Frame{}, err
+	}
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgError,
+		Flags:       frame.FlagIsResponse | frame.FlagIsError,
+	}, fields), nil
+}
+
+// Session encoder for error envelope into framed protocol message bytes.
+// The frame reuses the offending message_id so the peer can correlate it.
+func EncodeErrorFrame(messageID uint64, env ErrorEnvelope) ([]byte, error) {
+	return encodeFrame(NewErrorFrame(messageID, env))
+}
+
+// Session decoder for one error frame payload with schema validation.
+func DecodeErrorFrame(f frame.Frame) (ErrorEnvelope, error) {
+	r, err := readFrameFields(f, schema.MsgError)
+	if err != nil {
+		return ErrorEnvelope{}, err
+	}
+	env := ErrorEnvelope{
+		Code:        r.u32(schema.FieldErrorCode),
+		Class:       r.str(schema.FieldErrorClass),
+		Message:     r.str(schema.FieldErrorMessage),
+		MessageID:   r.u64(schema.FieldErrorMessageID),
+		MessageType: r.optU32(schema.FieldErrorMessageType),
+		TimestampMS: r.u64(schema.FieldTimestampMS),
+	}
+	if r.err != nil {
+		return ErrorEnvelope{}, r.err
+	}
+	if err := env.Validate(); err != nil {
+		return ErrorEnvelope{}, err
+	}
+	return env, nil
+}
+
+// Session helper bounding error message length on the wire.
+func truncateErrorMessage(message string) string {
+	message = strings.TrimSpace(message)
+	if len(message) <= maxErrorMessageBytes {
+		return message
+	}
+	return message[:maxErrorMessageBytes]
+}
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestErrorFrameRoundTrip(t *testing.T) {
+	testlog.Start(t)
+
+	in := NewErrorEnvelope(ErrorCodeSemanticValidationFailure, 77, schema.MsgCommand, "command missing ghost_id")
+	payload, err := EncodeErrorFrame(77, in)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	fr, err := frame.ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgError {
+		t.Fatalf("unexpected message type: %d", fr.Header.MessageType)
+	}
+	if fr.Header.Flags&frame.FlagIsError == 0 {
+		t.Fatalf("expected is_error flag, got flags=%d", fr.Header.Flags)
+	}
+
+	out, err := DecodeErrorFrame(fr)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if out != in {
+		t.Fatalf("error envelope mismatch: in=%+v out=%+v", in, out)
+	}
+	if out.Class != ErrorClassSemantic {
+		t.Fatalf("unexpected class: %q", out.Class)
+	}
+
+	var surfaced ErrorEnvelope
+	if !errors.As(fmt.Errorf("wrapped: %w", out), &surfaced) || surfaced.Code != ErrorCodeSemanticValidationFailure {
+		t.Fatalf("expected typed error envelope, got %+v", surfaced)
+	}
+}
+
+func TestErrorEnvelopeRejectsUnknownCodeAndClassMismatch(t *testing.T) {
+	testlog.Start(t)
+
+	if _, err := EncodeErrorFrame(1, NewErrorEnvelope(999, 1, schema.MsgEvent, "bad")); err == nil {
+		t.Fatalf("expected unknown code rejected")
+	}
+	env := NewErrorEnvelope(ErrorCodeFramingOversize, 1, schema.MsgEvent, "too big")
+	env.Class = ErrorClassRuntime
+	if _, err := EncodeErrorFrame(1, env); err == nil {
+		t.Fatalf("expected class mismatch rejected")
+	}
+}
+
+func TestErrorCodeForClassifiesDecodeFailures(t *testing.T) {
+	testlog.Start(t)
+
+	cases := []struct {
+		name string
+		err  error
+		want uint32
+	}{
+		{"oversize", fmt.Errorf("%w: 9", frame.ErrPayloadTooLarge), ErrorCodeFramingOversize},
+		{"bad magic", fmt.Errorf("%w: 1", frame.ErrUnsupportedMagic), ErrorCodeFramingInvalidHeader},
+		{"auth replay", fmt.Errorf("%w: 3", frame.ErrAuthReplay), ErrorCodeTransportFailure},
+		{"tlv", tlv.ErrShortFieldValue, ErrorCodeTLVDecodeFailure},
+		{"semantic", schema.ValidationError{MessageType: schema.MsgEvent, FieldID: schema.FieldEventID, Reason: "missing required field"}, ErrorCodeSemanticValidationFailure},
+		{"other", errors.New("boom"), ErrorCodeInternalError},
+	}
+	for _, tc := range cases {
+		if got := ErrorCodeFor(tc.err); got != tc.want {
+			t.Fatalf("%s: got=%d want=%d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package session
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"io"
 	"strings"
@@ -13,6 +11,7 @@ import (
 )
 
 // Session wire event payload sent from Ghost to Mirage.
+// Seq is the Ghost outbox sequence within the registered session epoch; 0 when unsequenced.
 type Event struct {
 	EventID     string
 	CommandID   string
@@ -21,6 +20,7 @@ type Event struct {
 	SeedID      string
 	Outcome     string
 	TimestampMS uint64
+	Seq         uint64
 }
 
 // Session event validator for required payload fields.
@@ -46,14 +46,20 @@ func (e Event) Validate() error {
 	return nil
 }
 
+// Session event.ack status asking the Ghost to back off and resend the event later.
+// Throttled acks are never cached; the event has not been ingested.
+const AckStatusThrottled = "throttled"
+
 // Session wire event.ack payload sent from Mirage to Ghost.
+// RetryAfterMS is a back-off hint carried only by throttled acks.
 type EventAck struct {
-	EventID     string
-	CommandID   string
-	GhostID     string
-	AckStatus   string
-	AckCode     uint32
-	TimestampMS uint64
+	EventID      string
+	CommandID    string
+	GhostID      string
+	AckStatus    string
+	AckCode      uint32
+	RetryAfterMS uint64
+	TimestampMS  uint64
 }
 
 // Session event.ack validator for required payload fields.
@@ -76,121 +82,103 @@ func (a EventAck) Validate() error {
 	return nil
 }
 
-// Session encoder for event envelope into framed protocol message bytes.
-func EncodeEventFrame(messageID uint64, event Event) ([]byte, error) {
+// Session frame builder for event envelope, written directly by session encoders.
+func NewEventFrame(messageID uint64, event Event) (frame.Frame, error) {
 	if err := event.Validate(); err != nil {
-		return nil, err
-	}
-	fields := []tlv.Field{
-		{ID: schema.FieldEventID, Type: tlv.TypeString, Value: []byte(event.EventID)},
-		{ID: schema.FieldCommandID, Type: tlv.TypeString, Value: []byte(event.CommandID)},
-		{ID: schema.FieldIntentID, Type: tlv.TypeString, Value: []byte(event.IntentID)},
-		{ID: schema.FieldGhostID, Type: tlv.TypeString, Value: []byte(event.GhostID)},
-		{ID: schema.FieldSeedID, Type: tlv.TypeString, Value: []byte(event.SeedID)},
-		{ID: schema.FieldOutcome, Type: tlv.TypeString, Value: []byte(event.Outcome)},
-	}
-	if event.TimestampMS != 0 {
-		fields = append(fields, tlv.Field{ID: schema.FieldTimestampMS, Type: tlv.TypeU64, Value: putU64(event.TimestampMS)})
+		return frame.Frame{}, err
 	}
+	fields := tlv.NewBuilder(8).
+		String(schema.FieldEventID, event.EventID).
+		String(schema.FieldCommandID, event.CommandID).
+		String(schema.FieldIntentID, event.IntentID).
+		String(schema.FieldGhostID, event.GhostID).
+		String(schema.FieldSeedID, event.SeedID).
+		String(schema.FieldOutcome, event.Outcome).
+		OptU64(schema.FieldTimestampMS, event.TimestampMS).
+		OptU64(schema.FieldEventSeq, event.Seq).
+		Fields()
 	if err := schema.Validate(schema.MsgEvent, fields); err != nil {
-		return nil, err
-	}
-	payload := tlv.EncodeFields(fields)
-	var buf bytes.Buffer
-	err := frame.WriteFrame(&buf, frame.Frame{
-		Header: frame.Header{
-			MessageID:   messageID,
-			MessageType: schema.MsgEvent,
-		},
-		Payload: payload,
-	}, frame.DefaultLimits())
-	if err != nil {
-		return nil, err
+		return frame.Frame{}, err
 	}
-	return buf.Bytes(), nil
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgEvent,
+	}, fields), nil
+}
+
+// Session encoder for event envelope into framed protocol message bytes.
+func EncodeEventFrame(messageID uint64, event Event) ([]byte, error) {
+	return encodeFrame(NewEventFrame(messageID, event))
 }
 
 // Session decoder for one event frame payload with schema validation.
 func DecodeEventFrame(f frame.Frame) (Event, error) {
-	fields, err := tlv.DecodeFields(f.Payload)
+	r, err := readFrameFields(f, schema.MsgEvent)
 	if err != nil {
 		return Event{}, err
 	}
-	if err := schema.Validate(schema.MsgEvent, fields); err != nil {
-		return Event{}, err
-	}
 	event := Event{
-		EventID:   getRequiredString(fields, schema.FieldEventID),
-		CommandID: getRequiredString(fields, schema.FieldCommandID),
-		IntentID:  getRequiredString(fields, schema.FieldIntentID),
-		GhostID:   getRequiredString(fields, schema.FieldGhostID),
-		SeedID:    getRequiredString(fields, schema.FieldSeedID),
-		Outcome:   getRequiredString(fields, schema.FieldOutcome),
-	}
-	if tsField, ok := tlv.GetField(fields, schema.FieldTimestampMS); ok {
-		ts, err := u64FromBytes(tsField.Value)
-		if err != nil {
-			return Event{}, err
-		}
-		event.TimestampMS = ts
+		EventID:     r.str(schema.FieldEventID),
+		CommandID:   r.str(schema.FieldCommandID),
+		IntentID:    r.str(schema.FieldIntentID),
+		GhostID:     r.str(schema.FieldGhostID),
+		SeedID:      r.str(schema.FieldSeedID),
+		Outcome:     r.str(schema.FieldOutcome),
+		TimestampMS: r.optU64(schema.FieldTimestampMS),
+		Seq:         r.optU64(schema.FieldEventSeq),
+	}
+	if r.err != nil {
+		return Event{}, r.err
 	}
 	return event, nil
 }
 
-// Session encoder for event.ack envelope into framed protocol message bytes.
-func EncodeEventAckFrame(messageID uint64, ack EventAck) ([]byte, error) {
+// Session frame builder for event.ack envelope, written directly by session encoders.
+func NewEventAckFrame(messageID uint64, ack EventAck) (frame.Frame, error) {
 	if err := ack.Validate(); err != nil {
-		return nil, err
-	}
-	fields := []tlv.Field{
-		{ID: schema.FieldEventID, Type: tlv.TypeString, Value: []byte(ack.EventID)},
-		{ID: schema.FieldCommandID, Type: tlv.TypeString, Value: []byte(ack.CommandID)},
-		{ID: schema.FieldGhostID, Type: tlv.TypeString, Value: []byte(ack.GhostID)},
-		{ID: schema.FieldAckStatus, Type: tlv.TypeString, Value: []byte(ack.AckStatus)},
-		{ID: schema.FieldAckCode, Type: tlv.TypeU32, Value: putU32(ack.AckCode)},
-		{ID: schema.FieldTimestampMS, Type: tlv.TypeU64, Value: putU64(ack.TimestampMS)},
+		return frame.Frame{}, err
 	}
+	fields := tlv.NewBuilder(7).
+		String(schema.FieldEventID, ack.EventID).
+		String(schema.FieldCommandID, ack.CommandID).
+		String(schema.FieldGhostID, ack.GhostID).
+		String(schema.FieldAckStatus, ack.AckStatus).
+		U32(schema.FieldAckCode, ack.AckCode).
+		OptU64(schema.FieldRetryAfterMS, ack.RetryAfterMS).
+		U64(schema.FieldTimestampMS, ack.TimestampMS).
+		Fields()
 	if err := schema.Validate(schema.MsgEventAck, fields); err != nil {
-		return nil, err
-	}
-	payload := tlv.EncodeFields(fields)
-	var buf bytes.Buffer
-	err := frame.WriteFrame(&buf, frame.Frame{
-		Header: frame.Header{
-			MessageID:   messageID,
-			MessageType: schema.MsgEventAck,
-			Flags:       frame.FlagIsResponse,
-		},
-		Payload: payload,
-	}, frame.DefaultLimits())
-	if err != nil {
-		return nil, err
+		return frame.Frame{}, err
 	}
-	return buf.Bytes(), nil
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgEventAck,
+		Flags:       frame.FlagIsResponse,
+	}, fields), nil
+}
+
+// Session encoder for event.ack envelope into framed protocol message bytes.
+func EncodeEventAckFrame(messageID uint64, ack EventAck) ([]byte, error) {
+	return encodeFrame(NewEventAckFrame(messageID, ack))
 }
 
 // Session decoder for one event.ack frame payload with schema validation.
 func DecodeEventAckFrame(f frame.Frame) (EventAck, error) {
-	fields, err := tlv.DecodeFields(f.Payload)
+	r, err := readFrameFields(f, schema.MsgEventAck)
 	if err != nil {
 		return EventAck{}, err
 	}
-	if err := schema.Validate(schema.MsgEventAck, fields); err != nil {
-		return EventAck{}, err
-	}
 	ack := EventAck{
-		EventID:     getRequiredString(fields, schema.FieldEventID),
-		CommandID:   getRequiredString(fields, schema.FieldCommandID),
-		GhostID:     getRequiredString(fields, schema.FieldGhostID),
-		AckStatus:   getRequiredString(fields, schema.FieldAckStatus),
-		TimestampMS: getRequiredU64(fields, schema.FieldTimestampMS),
-	}
-	if ackField, ok := tlv.GetField(fields, schema.FieldAckCode); ok {
-		v, err := tlv.U32FromBytes(ackField.Value)
-		if err != nil {
-			return EventAck{}, err
-		}
-		ack.AckCode = v
+		EventID:      r.str(schema.FieldEventID),
+		CommandID:    r.str(schema.FieldCommandID),
+		GhostID:      r.str(schema.FieldGhostID),
+		AckStatus:    r.str(schema.FieldAckStatus),
+		AckCode:      r.optU32(schema.FieldAckCode),
+		RetryAfterMS: r.optU64(schema.FieldRetryAfterMS),
+		TimestampMS:  r.u64(schema.FieldTimestampMS),
+	}
+	if r.err != nil {
+		return EventAck{}, r.err
 	}
 	return ack, nil
 }
@@ -200,37 +188,86 @@ func ReadFrame(r io.Reader, limits frame.Limits) (frame.Frame, error) {
 	return frame.ReadFrame(r, limits)
 }
 
-// Session helper returning required string field value after schema validation.
-func getRequiredString(fields []tlv.Field, id uint16) string {
-	f, _ := tlv.GetField(fields, id)
-	return string(f.Value)
+// Session wire limits for frames built here, resolved once instead of per message.
+var wireLimits = frame.DefaultLimits()
+
+// Session frame carrying fields as its TLV payload.
+func newMessage(h frame.Header, fields []tlv.Field) frame.Frame {
+	return frame.Frame{Header: h, Payload: tlv.AppendFields(make([]byte, 0, tlv.EncodedLen(fields)), fields)}
 }
 
-// Session helper returning required u64 field value after schema validation.
-func getRequiredU64(fields []tlv.Field, id uint16) uint64 {
-	f, _ := tlv.GetField(fields, id)
-	v, _ := u64FromBytes(f.Value)
-	return v
+// Session framed-message encoder appending header and payload of a built frame into one buffer.
+func encodeFrame(f frame.Frame, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, int(frame.FixedHeaderLen)+len(f.Payload))
+	return frame.AppendMessage(out, f, wireLimits)
 }
 
-// Session helper encoding uint32 in network byte order.
-func putU32(v uint32) []byte {
-	out := make([]byte, 4)
-	binary.BigEndian.PutUint32(out, v)
-	return out
+// Session framed-message encoder appending header and TLV payload into one buffer.
+func encodeMessage(h frame.Header, fields []tlv.Field) ([]byte, error) {
+	return encodeFrame(newMessage(h, fields), nil)
 }
 
-// Session helper encoding uint64 in network byte order.
-func putU64(v uint64) []byte {
-	out := make([]byte, 8)
-	binary.BigEndian.PutUint64(out, v)
-	return out
+// Session field reader over one validated payload that keeps the first getter error,
+// so decoders can read every field and check once.
+type fieldReader struct {
+	tlv.Reader
+	err error
 }
 
-// Session helper decoding network-order uint64 from fixed-length bytes.
-func u64FromBytes(b []byte) (uint64, error) {
-	if len(b) != 8 {
-		return 0, fmt.Errorf("session: invalid u64 length: %d", len(b))
+// Session payload decode plus version-aware schema validation for one message type.
+func readFrameFields(f frame.Frame, messageType uint32) (*fieldReader, error) {
+	r, err := tlv.ReadPayload(f.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFrameFields(f, messageType, r.Fields()); err != nil {
+		return nil, err
 	}
-	return binary.BigEndian.Uint64(b), nil
+	return &fieldReader{Reader: r}, nil
+}
+
+// Session sticky-error recorder for field getters.
+func (r *fieldReader) keep(err error) {
+	if r.err == nil && err != nil {
+		r.err = err
+	}
+}
+
+func (r *fieldReader) str(id uint16) string {
+	v, err := r.String(id)
+	r.keep(err)
+	return v
+}
+
+func (r *fieldReader) optStr(id uint16) string {
+	v, err := r.OptString(id)
+	r.keep(err)
+	return v
+}
+
+func (r *fieldReader) u32(id uint16) uint32 {
+	v, err := r.U32(id)
+	r.keep(err)
+	return v
+}
+
+func (r *fieldReader) optU32(id uint16) uint32 {
+	v, err := r.OptU32(id)
+	r.keep(err)
+	return v
+}
+
+func (r *fieldReader) u64(id uint16) uint64 {
+	v, err := r.U64(id)
+	r.keep(err)
+	return v
+}
+
+func (r *fieldReader) optU64(id uint16) uint64 {
+	v, err := r.OptU64(id)
+	r.keep(err)
+	return v
 }
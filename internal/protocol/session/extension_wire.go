@@ -0,0 +1,41 @@
+package session
+
+import (
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+// Session frame builder for one registry-defined extension message, written directly by session encoders.
+func NewExtensionFrame(reg *schema.Registry, version uint16, messageID uint64, messageType uint32, fields []tlv.Field) (frame.Frame, error) {
+	if err := reg.ValidateVersion(version, messageType, fields); err != nil {
+		return frame.Frame{}, err
+	}
+	return newMessage(frame.Header{
+		Version:     version,
+		MessageID:   messageID,
+		MessageType: messageType,
+	}, fields), nil
+}
+
+// Session encoder for one registry-defined extension message at a protocol version.
+func EncodeExtensionFrame(reg *schema.Registry, version uint16, messageID uint64, messageType uint32, fields []tlv.Field) ([]byte, error) {
+	return encodeFrame(NewExtensionFrame(reg, version, messageID, messageType, fields))
+}
+
+// Session decoder for one extension frame validated against reg.
+// Returned fields are copies and stay valid after the frame buffer is reused.
+func DecodeExtensionFrame(reg *schema.Registry, f frame.Frame) ([]tlv.Field, error) {
+	fields, err := tlv.DecodeFields(f.Payload)
+	if err != nil {
+		return nil, err
+	}
+	version := f.Header.Version
+	if version == 0 {
+		version = frame.ProtocolVersion
+	}
+	if err := reg.ValidateVersion(version, f.Header.MessageType, fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
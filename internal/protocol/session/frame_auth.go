@@ -0,0 +1,86 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+)
+
+var (
+	ErrAuthKeyFileRequired = errors.New("session: auth key file required")
+	ErrAuthKeyIDRequired   = errors.New("session: auth key id required")
+)
+
+// Session frame authenticator builder from auth config.
+// Returns nil when no key is configured and auth is not required.
+func NewFrameAuthenticator(c AuthConfig) (frame.Authenticator, error) {
+	keyFile := strings.TrimSpace(c.KeyFile)
+	if keyFile == "" {
+		if c.Required {
+			return nil, ErrAuthKeyFileRequired
+		}
+		return nil, nil
+	}
+	keyID := strings.TrimSpace(c.KeyID)
+	if keyID == "" {
+		return nil, ErrAuthKeyIDRequired
+	}
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("session: read auth key file: %w", err)
+	}
+	key := []byte(strings.TrimSpace(string(raw)))
+	return frame.NewHMACAuthenticator(keyID, key, c.MaxSkew)
+}
+
+// Session seed.register signing header; message type 0 is never a post-registration
+// frame, so a registration auth block cannot be replayed as a frame.
+var registrationAuthHeader = frame.Header{
+	Magic:       frame.ProtocolMagic,
+	Version:     frame.ProtocolVersion,
+	MessageType: 0,
+}
+
+// Session seed.register signer: fills reg.Auth with an auth block over the canonical
+// registration (ghost_id, peer identity, seeds, versions, codecs, epoch) using the
+// frame key. A nil authenticator leaves the registration unsigned.
+func SignRegistration(reg Registration, auth frame.Authenticator) (Registration, error) {
+	if auth == nil {
+		return reg, nil
+	}
+	payload, err := registrationSigningBytes(reg)
+	if err != nil {
+		return Registration{}, err
+	}
+	signed, err := frame.SignFrame(frame.Frame{Header: registrationAuthHeader, Payload: payload}, auth)
+	if err != nil {
+		return Registration{}, err
+	}
+	reg.Auth = signed.Auth
+	return reg, nil
+}
+
+// Session seed.register verifier with frame auth semantics: a present auth block must
+// verify (key, skew, MAC, nonce); required rejects unsigned registrations.
+func VerifyRegistration(reg Registration, auth frame.Authenticator, required bool) error {
+	payload, err := registrationSigningBytes(reg)
+	if err != nil {
+		return err
+	}
+	h := registrationAuthHeader
+	h.PayloadLen = uint64(len(payload))
+	if len(reg.Auth) > 0 {
+		h.Flags |= frame.FlagHasAuth
+	}
+	return frame.VerifyFrame(frame.Frame{Header: h, Auth: reg.Auth, Payload: payload}, auth, required)
+}
+
+// Session canonical registration bytes: the JSON encoding without the auth block.
+func registrationSigningBytes(reg Registration) ([]byte, error) {
+	reg.Auth = nil
+	return json.Marshal(reg)
+}
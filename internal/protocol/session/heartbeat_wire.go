@@ -0,0 +1,120 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+// Session wire heartbeat ping sent from Ghost to Mirage.
+// RTTMS carries the sender's last measured round trip so Mirage can surface it.
+type Ping struct {
+	TimestampMS uint64
+	RTTMS       uint64
+}
+
+// Session ping validator for required payload fields.
+func (p Ping) Validate() error {
+	if p.TimestampMS == 0 {
+		return fmt.Errorf("ping missing timestamp_ms")
+	}
+	return nil
+}
+
+// Session wire heartbeat pong echoing the ping timestamp back to the sender.
+type Pong struct {
+	PingTimestampMS uint64
+	TimestampMS     uint64
+}
+
+// Session pong validator for required payload fields.
+func (p Pong) Validate() error {
+	if p.PingTimestampMS == 0 {
+		return fmt.Errorf("pong missing ping_timestamp_ms")
+	}
+	if p.TimestampMS == 0 {
+		return fmt.Errorf("pong missing timestamp_ms")
+	}
+	return nil
+}
+
+// Session frame builder for ping envelope, written directly by session encoders.
+func NewPingFrame(messageID uint64, ping Ping) (frame.Frame, error) {
+	if err := ping.Validate(); err != nil {
+		return frame.Frame{}, err
+	}
+	fields := tlv.NewBuilder(2).
+		U64(schema.FieldTimestampMS, ping.TimestampMS).
+		OptU64(schema.FieldRttMS, ping.RTTMS).
+		Fields()
+	if err := schema.Validate(schema.MsgPing, fields); err != nil {
+		return frame.Frame{}, err
+	}
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgPing,
+	}, fields), nil
+}
+
+// Session encoder for ping envelope into framed protocol message bytes.
+func EncodePingFrame(messageID uint64, ping Ping) ([]byte, error) {
+	return encodeFrame(NewPingFrame(messageID, ping))
+}
+
+// Session decoder for one ping frame payload with schema validation.
+func DecodePingFrame(f frame.Frame) (Ping, error) {
+	r, err := readFrameFields(f, schema.MsgPing)
+	if err != nil {
+		return Ping{}, err
+	}
+	ping := Ping{
+		TimestampMS: r.u64(schema.FieldTimestampMS),
+		RTTMS:       r.optU64(schema.FieldRttMS),
+	}
+	if r.err != nil {
+		return Ping{}, r.err
+	}
+	return ping, nil
+}
+
+// Session frame builder for pong envelope, written directly by session encoders.
+func NewPongFrame(messageID uint64, pong Pong) (frame.Frame, error) {
+	if err := pong.Validate(); err != nil {
+		return frame.Frame{}, err
+	}
+	fields := tlv.NewBuilder(2).
+		U64(schema.FieldPingTimestampMS, pong.PingTimestampMS).
+		U64(schema.FieldTimestampMS, pong.TimestampMS).
+		Fields()
+	if err := schema.Validate(schema.MsgPong, fields); err != nil {
+		return frame.Frame{}, err
+	}
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgPong,
+		Flags:       frame.FlagIsResponse,
+	}, fields), nil
+}
+
+// Session encoder for pong envelope; the frame reuses the ping message_id.
+func EncodePongFrame(messageID uint64, pong Pong) ([]byte, error) {
+	return encodeFrame(NewPongFrame(messageID, pong))
+}
+
+// Session decoder for one pong frame payload with schema validation.
+func DecodePongFrame(f frame.Frame) (Pong, error) {
+	r, err := readFrameFields(f, schema.MsgPong)
+	if err != nil {
+		return Pong{}, err
+	}
+	pong := Pong{
+		PingTimestampMS: r.u64(schema.FieldPingTimestampMS),
+		TimestampMS:     r.u64(schema.FieldTimestampMS),
+	}
+	if r.err != nil {
+		return Pong{}, r.err
+	}
+	return pong, nil
+}
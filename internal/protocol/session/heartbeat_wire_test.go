@@ -0,0 +1,55 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestPingPongFrameRoundTrip(t *testing.T) {
+	testlog.Start(t)
+
+	ping := Ping{TimestampMS: 1700000000000, RTTMS: 12}
+	payload, err := EncodePingFrame(41, ping)
+	if err != nil {
+		t.Fatalf("encode ping: %v", err)
+	}
+	fr, err := frame.ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read ping frame: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgPing || fr.Header.MessageID != 41 {
+		t.Fatalf("unexpected ping header: %+v", fr.Header)
+	}
+	gotPing, err := DecodePingFrame(fr)
+	if err != nil || gotPing != ping {
+		t.Fatalf("ping mismatch: in=%+v out=%+v err=%v", ping, gotPing, err)
+	}
+
+	pong := Pong{PingTimestampMS: ping.TimestampMS, TimestampMS: 1700000000005}
+	payload, err = EncodePongFrame(41, pong)
+	if err != nil {
+		t.Fatalf("encode pong: %v", err)
+	}
+	fr, err = frame.ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read pong frame: %v", err)
+	}
+	if fr.Header.MessageType != schema.MsgPong || fr.Header.MessageID != 41 || fr.Header.Flags&frame.FlagIsResponse == 0 {
+		t.Fatalf("unexpected pong header: %+v", fr.Header)
+	}
+	gotPong, err := DecodePongFrame(fr)
+	if err != nil || gotPong != pong {
+		t.Fatalf("pong mismatch: in=%+v out=%+v err=%v", pong, gotPong, err)
+	}
+
+	if _, err := EncodePingFrame(42, Ping{}); err == nil {
+		t.Fatalf("expected ping without timestamp rejected")
+	}
+	if _, err := EncodePongFrame(42, Pong{TimestampMS: 1}); err == nil {
+		t.Fatalf("expected pong without ping timestamp rejected")
+	}
+}
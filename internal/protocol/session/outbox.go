@@ -5,13 +5,18 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/danmuck/edgectl/internal/journal"
 )
 
 // Session outbox record for one event awaiting event.ack.
+// Seq preserves first-enqueue order; Event retains the wire payload for replay.
 type PendingEvent struct {
 	EventID       string
 	CommandID     string
 	GhostID       string
+	Seq           uint64
+	Event         Event
 	Attempts      int
 	QueuedAt      time.Time
 	LastAttemptAt time.Time
@@ -20,27 +25,54 @@ type PendingEvent struct {
 }
 
 // Session outbox store keyed by stable event_id.
+// When opened with OpenEventOutbox, mutations are journaled to disk.
+// Seq values are monotonic within one epoch; a new epoch starts whenever
+// the sequence cannot be continued (a fresh in-memory outbox or journal).
 type EventOutbox struct {
-	mu    sync.RWMutex
-	items map[string]PendingEvent
+	mu      sync.RWMutex
+	items   map[string]PendingEvent
+	epoch   uint64
+	nextSeq uint64
+	journal *journal.Journal
 }
 
-// Session outbox constructor for an empty event_id-keyed store.
+// Session outbox constructor for an empty event_id-keyed store in a new epoch.
 func NewEventOutbox() *EventOutbox {
 	return &EventOutbox{
 		items: make(map[string]PendingEvent),
+		epoch: uint64(time.Now().UnixNano()),
 	}
 }
 
-// Session outbox insert-or-replace by event_id.
-func (o *EventOutbox) Upsert(item PendingEvent) {
+// Session outbox epoch identifying the sequence space of its Seq values.
+func (o *EventOutbox) Epoch() uint64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.epoch
+}
+
+// Session outbox insert-or-replace by event_id; returns the stored item with its Seq.
+// Replacing an existing event keeps its original queue position.
+func (o *EventOutbox) Upsert(item PendingEvent) PendingEvent {
 	key := strings.TrimSpace(item.EventID)
 	if key == "" {
-		return
+		return item
 	}
+	item.EventID = key
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	if prev, ok := o.items[key]; ok && prev.Seq != 0 {
+		item.Seq = prev.Seq
+	}
+	if item.Seq == 0 {
+		o.nextSeq++
+		item.Seq = o.nextSeq
+	} else if item.Seq > o.nextSeq {
+		o.nextSeq = item.Seq
+	}
 	o.items[key] = item
+	o.persistLocked(outboxRecord{Op: outboxOpUpsert, Item: &item}, true)
+	return item
 }
 
 // Session outbox attempt tracker for retry counters and latest error details.
@@ -56,6 +88,7 @@ func (o *EventOutbox) MarkAttempt(eventID string, at time.Time, lastErr string)
 	item.LastAttemptAt = at
 	item.LastError = strings.TrimSpace(lastErr)
 	o.items[key] = item
+	o.persistLocked(outboxRecord{Op: outboxOpUpsert, Item: &item}, false)
 	return item, true
 }
 
@@ -64,7 +97,25 @@ func (o *EventOutbox) Remove(eventID string) {
 	key := strings.TrimSpace(eventID)
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	if _, ok := o.items[key]; !ok {
+		return
+	}
 	delete(o.items, key)
+	o.persistLocked(outboxRecord{Op: outboxOpRemove, EventID: key}, true)
+}
+
+// Session outbox removal of every item with Seq <= seq, i.e. already observed by the peer.
+// Returns the removed event ids in queue order.
+func (o *EventOutbox) RemoveThrough(seq uint64) []string {
+	var removed []string
+	for _, item := range o.Pending() {
+		if item.Seq == 0 || item.Seq > seq {
+			continue
+		}
+		o.Remove(item.EventID)
+		removed = append(removed, item.EventID)
+	}
+	return removed
 }
 
 // Session outbox lookup by event_id.
@@ -78,14 +129,51 @@ func (o *EventOutbox) Get(eventID string) (PendingEvent, bool) {
 
 // Session outbox snapshot in deterministic event_id order.
 func (o *EventOutbox) List() []PendingEvent {
+	out := o.snapshot()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].EventID < out[j].EventID
+	})
+	return out
+}
+
+// Session outbox snapshot in queue (first-enqueue) order for replay.
+func (o *EventOutbox) Pending() []PendingEvent {
+	out := o.snapshot()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Seq != out[j].Seq {
+			return out[i].Seq < out[j].Seq
+		}
+		return out[i].EventID < out[j].EventID
+	})
+	return out
+}
+
+// Session outbox pending-event count.
+func (o *EventOutbox) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.items)
+}
+
+// Session outbox close that flushes and releases the backing journal file.
+func (o *EventOutbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.journal == nil {
+		return nil
+	}
+	err := o.journal.Close()
+	o.journal = nil
+	return err
+}
+
+// Session outbox unordered copy of current pending items.
+func (o *EventOutbox) snapshot() []PendingEvent {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 	out := make([]PendingEvent, 0, len(o.items))
 	for _, item := range o.items {
 		out = append(out, item)
 	}
-	sort.Slice(out, func(i, j int) bool {
-		return out[i].EventID < out[j].EventID
-	})
 	return out
 }
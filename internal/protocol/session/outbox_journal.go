@@ -0,0 +1,127 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danmuck/edgectl/internal/journal"
+	logs "github.com/danmuck/smplog"
+)
+
+const (
+	outboxOpUpsert = "upsert"
+	outboxOpRemove = "remove"
+	outboxOpEpoch  = "epoch"
+)
+
+var ErrOutboxPathRequired = errors.New("session: outbox path required")
+
+// Session outbox journal record appended for each outbox mutation.
+// Epoch records carry the sequence epoch and high-water mark across compaction.
+type outboxRecord struct {
+	Op      string        `json:"op"`
+	EventID string        `json:"event_id,omitempty"`
+	Item    *PendingEvent `json:"item,omitempty"`
+	Epoch   uint64        `json:"epoch,omitempty"`
+	Seq     uint64        `json:"seq,omitempty"`
+}
+
+// Session durable outbox constructor backed by an append-only journal at path.
+// Existing journal contents are replayed, then compacted to live pending items;
+// the sequence epoch and high-water mark survive reopen.
+func OpenEventOutbox(path string) (*EventOutbox, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, ErrOutboxPathRequired
+	}
+
+	o := NewEventOutbox()
+	if err := o.load(path); err != nil {
+		return nil, err
+	}
+	file, err := journal.Open(path, o.snapshotRecordsLocked())
+	if err != nil {
+		return nil, fmt.Errorf("session: outbox: %w", err)
+	}
+	o.journal = file
+	logs.Debugf("session.OpenEventOutbox path=%q pending=%d", path, len(o.items))
+	return o, nil
+}
+
+// Session outbox journal replay into in-memory state.
+// A torn trailing record from an interrupted write is ignored.
+func (o *EventOutbox) load(path string) error {
+	err := journal.Replay(path, func(raw []byte) error {
+		var rec outboxRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+		o.applyRecord(rec)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("session: outbox: %w", err)
+	}
+	return nil
+}
+
+// Session outbox journal record application during replay.
+func (o *EventOutbox) applyRecord(rec outboxRecord) {
+	switch rec.Op {
+	case outboxOpUpsert:
+		if rec.Item == nil || strings.TrimSpace(rec.Item.EventID) == "" {
+			return
+		}
+		item := *rec.Item
+		o.items[item.EventID] = item
+		if item.Seq > o.nextSeq {
+			o.nextSeq = item.Seq
+		}
+	case outboxOpRemove:
+		delete(o.items, strings.TrimSpace(rec.EventID))
+	case outboxOpEpoch:
+		if rec.Epoch != 0 {
+			o.epoch = rec.Epoch
+		}
+		if rec.Seq > o.nextSeq {
+			o.nextSeq = rec.Seq
+		}
+	}
+}
+
+// Session outbox journal append; caller must hold o.mu.
+// Journal failures are logged; in-memory state stays authoritative for this process.
+// Compaction runs in the background on a snapshot, so mutations never wait on the rewrite.
+func (o *EventOutbox) persistLocked(rec outboxRecord, sync bool) {
+	if o.journal == nil {
+		return
+	}
+	if err := o.journal.Append(rec, sync); err != nil {
+		logs.Warnf("session.EventOutbox journal append path=%q op=%s err=%v", o.journal.Path(), rec.Op, err)
+		return
+	}
+	if o.journal.NeedsCompaction(len(o.items) + 1) {
+		o.journal.Compact(o.snapshotRecordsLocked())
+	}
+}
+
+// Session outbox journal records rebuilding the live items in queue order,
+// led by the epoch record. Caller must hold o.mu (or own o exclusively during open).
+func (o *EventOutbox) snapshotRecordsLocked() []any {
+	items := make([]PendingEvent, 0, len(o.items))
+	for _, item := range o.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].Seq < items[b].Seq
+	})
+	recs := make([]any, 0, len(items)+1)
+	recs = append(recs, outboxRecord{Op: outboxOpEpoch, Epoch: o.epoch, Seq: o.nextSeq})
+	for i := range items {
+		recs = append(recs, outboxRecord{Op: outboxOpUpsert, Item: &items[i]})
+	}
+	return recs
+}
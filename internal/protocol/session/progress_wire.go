@@ -0,0 +1,121 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+// Session progress stream names for output chunks.
+const (
+	ProgressStreamStdout = "stdout"
+	ProgressStreamStderr = "stderr"
+)
+
+// Session wire progress payload sent from Ghost to Mirage while a command runs.
+// Seq orders records within one execution starting at 1; progress is never acknowledged.
+// Chunk carries Stream output; a record without Chunk is a percent/message update.
+type Progress struct {
+	ExecutionID string
+	CommandID   string
+	IntentID    string
+	GhostID     string
+	Seq         uint64
+	Stream      string
+	Chunk       []byte
+	Percent     uint32
+	Message     string
+	TimestampMS uint64
+}
+
+// Session progress validator for required payload fields.
+func (p Progress) Validate() error {
+	if strings.TrimSpace(p.ExecutionID) == "" {
+		return fmt.Errorf("progress missing execution_id")
+	}
+	if strings.TrimSpace(p.CommandID) == "" {
+		return fmt.Errorf("progress missing command_id")
+	}
+	if strings.TrimSpace(p.IntentID) == "" {
+		return fmt.Errorf("progress missing intent_id")
+	}
+	if strings.TrimSpace(p.GhostID) == "" {
+		return fmt.Errorf("progress missing ghost_id")
+	}
+	if p.Seq == 0 {
+		return fmt.Errorf("progress missing progress_seq")
+	}
+	if p.Percent > 100 {
+		return fmt.Errorf("progress percent out of range: %d", p.Percent)
+	}
+	if len(p.Chunk) > 0 && p.Stream == "" {
+		return fmt.Errorf("progress chunk missing stream")
+	}
+	if p.TimestampMS == 0 {
+		return fmt.Errorf("progress missing timestamp_ms")
+	}
+	return nil
+}
+
+// Session frame builder for progress envelope, written directly by session encoders.
+func NewProgressFrame(messageID uint64, p Progress) (frame.Frame, error) {
+	if err := p.Validate(); err != nil {
+		return frame.Frame{}, err
+	}
+	fields := tlv.NewBuilder(10).
+		String(schema.FieldExecutionID, p.ExecutionID).
+		String(schema.FieldCommandID, p.CommandID).
+		String(schema.FieldIntentID, p.IntentID).
+		String(schema.FieldGhostID, p.GhostID).
+		U64(schema.FieldProgressSeq, p.Seq).
+		U32(schema.FieldPercent, p.Percent).
+		U64(schema.FieldTimestampMS, p.TimestampMS).
+		OptString(schema.FieldStream, p.Stream).
+		OptString(schema.FieldProgressMessage, p.Message)
+	if len(p.Chunk) > 0 {
+		fields.Bytes(schema.FieldChunk, p.Chunk)
+	}
+	if err := schema.Validate(schema.MsgProgress, fields.Fields()); err != nil {
+		return frame.Frame{}, err
+	}
+	return newMessage(frame.Header{
+		MessageID:   messageID,
+		MessageType: schema.MsgProgress,
+	}, fields.Fields()), nil
+}
+
+// Session encoder for progress envelope into framed protocol message bytes.
+func EncodeProgressFrame(messageID uint64, p Progress) ([]byte, error) {
+	return encodeFrame(NewProgressFrame(messageID, p))
+}
+
+// Session decoder for one progress frame payload with schema validation.
+func DecodeProgressFrame(f frame.Frame) (Progress, error) {
+	r, err := readFrameFields(f, schema.MsgProgress)
+	if err != nil {
+		return Progress{}, err
+	}
+	p := Progress{
+		ExecutionID: r.str(schema.FieldExecutionID),
+		CommandID:   r.str(schema.FieldCommandID),
+		IntentID:    r.str(schema.FieldIntentID),
+		GhostID:     r.str(schema.FieldGhostID),
+		Seq:         r.u64(schema.FieldProgressSeq),
+		Stream:      r.optStr(schema.FieldStream),
+		Percent:     r.u32(schema.FieldPercent),
+		Message:     r.optStr(schema.FieldProgressMessage),
+		TimestampMS: r.u64(schema.FieldTimestampMS),
+	}
+	if r.Has(schema.FieldChunk) {
+		chunk, err := r.Bytes(schema.FieldChunk)
+		r.keep(err)
+		p.Chunk = chunk
+	}
+	if r.err != nil {
+		return Progress{}, r.err
+	}
+	return p, nil
+}
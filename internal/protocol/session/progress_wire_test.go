@@ -0,0 +1,76 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestProgressFrameRoundTrip(t *testing.T) {
+	testlog.Start(t)
+
+	cases := []Progress{
+		{
+			ExecutionID: "exec.1",
+			CommandID:   "cmd.1",
+			IntentID:    "intent.1",
+			GhostID:     "ghost.alpha",
+			Seq:         1,
+			Stream:      ProgressStreamStdout,
+			Chunk:       []byte("installing...\n"),
+			Percent:     10,
+			TimestampMS: 1700000000000,
+		},
+		{
+			ExecutionID: "exec.1",
+			CommandID:   "cmd.1",
+			IntentID:    "intent.1",
+			GhostID:     "ghost.alpha",
+			Seq:         2,
+			Percent:     55,
+			Message:     "copied 550/1000 files",
+			TimestampMS: 1700000000100,
+		},
+	}
+	for i, in := range cases {
+		payload, err := EncodeProgressFrame(uint64(70+i), in)
+		if err != nil {
+			t.Fatalf("encode progress %d: %v", i, err)
+		}
+		fr, err := frame.ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+		if err != nil {
+			t.Fatalf("read progress frame %d: %v", i, err)
+		}
+		if fr.Header.MessageType != schema.MsgProgress {
+			t.Fatalf("unexpected progress header: %+v", fr.Header)
+		}
+		out, err := DecodeProgressFrame(fr)
+		if err != nil {
+			t.Fatalf("decode progress %d: %v", i, err)
+		}
+		if out.Seq != in.Seq || out.Stream != in.Stream || !bytes.Equal(out.Chunk, in.Chunk) ||
+			out.Percent != in.Percent || out.Message != in.Message || out.ExecutionID != in.ExecutionID ||
+			out.TimestampMS != in.TimestampMS {
+			t.Fatalf("progress mismatch: in=%+v out=%+v", in, out)
+		}
+	}
+
+	bad := cases[0]
+	bad.Seq = 0
+	if _, err := EncodeProgressFrame(80, bad); err == nil {
+		t.Fatalf("expected progress without seq rejected")
+	}
+	bad = cases[0]
+	bad.Stream = "stdin"
+	if _, err := EncodeProgressFrame(81, bad); err == nil {
+		t.Fatalf("expected unknown progress stream rejected")
+	}
+	bad = cases[1]
+	bad.Percent = 101
+	if _, err := EncodeProgressFrame(82, bad); err == nil {
+		t.Fatalf("expected percent above 100 rejected")
+	}
+}
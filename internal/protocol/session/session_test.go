@@ -4,10 +4,15 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/danmuck/edgectl/internal/journal"
 	"github.com/danmuck/edgectl/internal/protocol/frame"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
 )
@@ -64,6 +69,146 @@ func TestEventOutboxLifecycle(t *testing.T) {
 	}
 }
 
+func TestDurableEventOutboxSurvivesReopen(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "local", "outbox", "events.log")
+	o, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	for _, id := range []string{"evt.c", "evt.a", "evt.b"} {
+		o.Upsert(PendingEvent{
+			EventID:   id,
+			CommandID: "cmd." + id,
+			GhostID:   "ghost.a",
+			Event: Event{
+				EventID:     id,
+				CommandID:   "cmd." + id,
+				IntentID:    "intent.1",
+				GhostID:     "ghost.a",
+				SeedID:      "seed.flow",
+				Outcome:     "success",
+				TimestampMS: 1,
+			},
+			QueuedAt: now,
+		})
+	}
+	o.MarkAttempt("evt.a", now.Add(time.Second), "timeout")
+	o.Remove("evt.b")
+	if err := o.Close(); err != nil {
+		t.Fatalf("close outbox: %v", err)
+	}
+
+	// Simulate a torn trailing write from a crash.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"upsert","item":{"EventID":"evt.torn"`); err != nil {
+		t.Fatalf("write torn record: %v", err)
+	}
+	_ = f.Close()
+
+	reopened, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	defer reopened.Close()
+	pending := reopened.Pending()
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending events, got %+v", pending)
+	}
+	if pending[0].EventID != "evt.c" || pending[1].EventID != "evt.a" {
+		t.Fatalf("expected queue order evt.c, evt.a got %q, %q", pending[0].EventID, pending[1].EventID)
+	}
+	if pending[1].Attempts != 1 || pending[1].LastError != "timeout" {
+		t.Fatalf("attempt metadata not persisted: %+v", pending[1])
+	}
+	if pending[0].Event.SeedID != "seed.flow" {
+		t.Fatalf("event payload not persisted: %+v", pending[0].Event)
+	}
+
+	reopened.Upsert(PendingEvent{EventID: "evt.d", CommandID: "cmd.evt.d", GhostID: "ghost.a"})
+	pending = reopened.Pending()
+	if pending[len(pending)-1].EventID != "evt.d" {
+		t.Fatalf("expected new event queued last, got %+v", pending)
+	}
+}
+
+func TestDurableEventOutboxKeepsEpochAndSequence(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "events.log")
+	o, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	epoch := o.Epoch()
+	if epoch == 0 || NewEventOutbox().Epoch() == epoch {
+		t.Fatalf("expected a fresh non-zero epoch, got %d", epoch)
+	}
+	for _, id := range []string{"evt.1", "evt.2", "evt.3"} {
+		if item := o.Upsert(PendingEvent{EventID: id, CommandID: "cmd." + id, GhostID: "ghost.a"}); item.Seq == 0 {
+			t.Fatalf("expected seq assigned to %s", id)
+		}
+	}
+	if removed := o.RemoveThrough(2); len(removed) != 2 || removed[0] != "evt.1" || removed[1] != "evt.2" {
+		t.Fatalf("unexpected removed through seq 2: %v", removed)
+	}
+	o.Remove("evt.3")
+	if err := o.Close(); err != nil {
+		t.Fatalf("close outbox: %v", err)
+	}
+
+	reopened, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Epoch() != epoch {
+		t.Fatalf("epoch not persisted: got %d want %d", reopened.Epoch(), epoch)
+	}
+	if item := reopened.Upsert(PendingEvent{EventID: "evt.4", CommandID: "cmd.evt.4", GhostID: "ghost.a"}); item.Seq != 4 {
+		t.Fatalf("expected sequence to continue at 4 after an empty reopen, got %d", item.Seq)
+	}
+}
+
+func TestDurableEventOutboxCompacts(t *testing.T) {
+	testlog.Start(t)
+	path := filepath.Join(t.TempDir(), "events.log")
+	o, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	for i := 0; i < 2*journal.MinCompactRecords; i++ {
+		id := fmt.Sprintf("evt.%d", i)
+		o.Upsert(PendingEvent{EventID: id, CommandID: "cmd." + id, GhostID: "ghost.a"})
+		o.Remove(id)
+	}
+	o.Upsert(PendingEvent{EventID: "evt.keep", CommandID: "cmd.keep", GhostID: "ghost.a"})
+	// Close waits for a background compaction still swapping files.
+	if err := o.Close(); err != nil {
+		t.Fatalf("close outbox: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	lines := strings.Count(string(raw), "\n")
+	if lines >= journal.MinCompactRecords {
+		t.Fatalf("expected compacted journal, got %d records", lines)
+	}
+	reopened, err := OpenEventOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 1 || len(reopened.Pending()) != 1 || reopened.Pending()[0].EventID != "evt.keep" {
+		t.Fatalf("expected only evt.keep pending after compaction, got %d", reopened.Len())
+	}
+}
+
 func TestRegistrationRoundTrip(t *testing.T) {
 	testlog.Start(t)
 	reg := Registration{
@@ -108,6 +253,155 @@ func TestRegistrationAckRoundTrip(t *testing.T) {
 	}
 }
 
+func TestNegotiateProtocolVersionSelectsHighestCommon(t *testing.T) {
+	testlog.Start(t)
+
+	cases := []struct {
+		name  string
+		local VersionRange
+		peer  VersionRange
+		want  uint16
+	}{
+		{"identical", VersionRange{Min: 1, Max: 1}, VersionRange{Min: 1, Max: 1}, 1},
+		{"peer newer", VersionRange{Min: 1, Max: 2}, VersionRange{Min: 1, Max: 4}, 2},
+		{"local newer", VersionRange{Min: 1, Max: 4}, VersionRange{Min: 2, Max: 3}, 3},
+	}
+	for _, tc := range cases {
+		got, err := NegotiateProtocolVersion(tc.local, tc.peer)
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: got=%d err=%v want=%d", tc.name, got, err, tc.want)
+		}
+	}
+	if _, err := NegotiateProtocolVersion(VersionRange{Min: 1, Max: 1}, VersionRange{Min: 2, Max: 3}); !errors.Is(err, ErrNoCommonProtocolVersion) {
+		t.Fatalf("expected ErrNoCommonProtocolVersion, got %v", err)
+	}
+}
+
+func TestRegistrationProtocolVersionsLegacyAndAdvertised(t *testing.T) {
+	testlog.Start(t)
+
+	// A pre-negotiation Ghost omits the range and implies the legacy version.
+	legacy := `{"type":"seed.register","registration":{"ghost_id":"ghost.old","peer_identity":"ghost.old","seed_list":[]}}` + "\n"
+	reg, err := ReadRegistration(bufio.NewReader(strings.NewReader(legacy)))
+	if err != nil {
+		t.Fatalf("read legacy registration: %v", err)
+	}
+	if got := reg.ProtocolVersions(); got != (VersionRange{Min: LegacyProtocolVersion, Max: LegacyProtocolVersion}) {
+		t.Fatalf("unexpected legacy range: %+v", got)
+	}
+	if got := (RegistrationAck{}).NegotiatedVersion(); got != LegacyProtocolVersion {
+		t.Fatalf("unexpected legacy ack version: %d", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRegistration(&buf, Registration{
+		GhostID:            "ghost.new",
+		SeedList:           []SeedInfo{},
+		MinProtocolVersion: 1,
+		MaxProtocolVersion: 3,
+	}); err != nil {
+		t.Fatalf("write registration: %v", err)
+	}
+	reg, err = ReadRegistration(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatalf("read registration: %v", err)
+	}
+	if got := reg.ProtocolVersions(); got != (VersionRange{Min: 1, Max: 3}) {
+		t.Fatalf("unexpected advertised range: %+v", got)
+	}
+	if err := WriteRegistration(&buf, Registration{
+		GhostID:            "ghost.bad",
+		SeedList:           []SeedInfo{},
+		MinProtocolVersion: 3,
+		MaxProtocolVersion: 1,
+	}); !errors.Is(err, ErrInvalidRegistration) {
+		t.Fatalf("expected inverted range rejected, got %v", err)
+	}
+}
+
+func TestDecodeFrameRejectsVersionMismatch(t *testing.T) {
+	testlog.Start(t)
+
+	payload, err := EncodeEventAckFrame(3, EventAck{
+		EventID:     "evt.1",
+		CommandID:   "cmd.1",
+		GhostID:     "ghost.alpha",
+		AckStatus:   AckStatusAccepted,
+		TimestampMS: 1700000000000,
+	})
+	if err != nil {
+		t.Fatalf("encode ack: %v", err)
+	}
+	fr, err := ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if err := CheckFrameVersion(fr, frame.ProtocolVersion); err != nil {
+		t.Fatalf("unexpected version mismatch: %v", err)
+	}
+	if err := CheckFrameVersion(fr, frame.ProtocolVersion+1); !errors.Is(err, ErrProtocolVersionMismatch) {
+		t.Fatalf("expected ErrProtocolVersionMismatch, got %v", err)
+	}
+}
+
+func TestNegotiateCompressionHonorsOfferAndLocalPolicy(t *testing.T) {
+	testlog.Start(t)
+
+	enabled := CompressionConfig{Enabled: true, Threshold: 64}
+	if got := NegotiateCompression(enabled, []string{"zstd", " DEFLATE "}); got != CompressionDeflate {
+		t.Fatalf("expected deflate, got %q", got)
+	}
+	if got := NegotiateCompression(enabled, nil); got != CompressionNone {
+		t.Fatalf("expected legacy peer to get no compression, got %q", got)
+	}
+	if got := NegotiateCompression(CompressionConfig{}, []string{CompressionDeflate}); got != CompressionNone {
+		t.Fatalf("expected disabled local policy to win, got %q", got)
+	}
+	if err := CheckCompression(CompressionConfig{}, CompressionDeflate); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("expected unoffered codec rejected, got %v", err)
+	}
+
+	payload, err := EncodeEventFrame(5, Event{
+		EventID:   "evt.1",
+		CommandID: "cmd.1",
+		IntentID:  "intent.1",
+		GhostID:   "ghost.alpha",
+		SeedID:    strings.Repeat("seed.flow.", 12),
+		Outcome:   "success",
+	})
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+	if opts := WireEncoderOptions(2, CompressionNone, 64, nil); opts.CompressThreshold != 0 || opts.Version != 2 {
+		t.Fatalf("expected no compression without a negotiated codec, opts=%+v", opts)
+	}
+	msg, err := frame.DecodeMessage(payload, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	var compressed bytes.Buffer
+	enc := frame.NewEncoderWithOptions(&compressed, frame.DefaultLimits(), WireEncoderOptions(0, CompressionDeflate, 64, nil))
+	defer enc.Release()
+	if err := enc.EncodeMessage(msg); err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	fr, err := ReadFrame(&compressed, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if _, err := DecompressFrame(fr, CompressionNone, frame.DefaultLimits()); !errors.Is(err, ErrCompressionNotNegotiated) {
+		t.Fatalf("expected ErrCompressionNotNegotiated, got %v", err)
+	}
+	inflated, err := DecompressFrame(fr, CompressionDeflate, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	event, err := DecodeEventFrame(inflated)
+	if err != nil || event.EventID != "evt.1" {
+		t.Fatalf("decode inflated event=%+v err=%v", event, err)
+	}
+}
+
 func TestEncodeDecodeEventFrame(t *testing.T) {
 	testlog.Start(t)
 	payload, err := EncodeEventFrame(42, Event{
@@ -229,3 +523,96 @@ func TestValidateServerTransportProductionRequiresTLSMTLS(t *testing.T) {
 		t.Fatalf("expected ErrMTLSRequired, got %v", err)
 	}
 }
+
+func TestNewEventFrameMatchesEncodedMessage(t *testing.T) {
+	testlog.Start(t)
+
+	event := Event{
+		EventID:     "evt.1",
+		CommandID:   "cmd.1",
+		IntentID:    "intent.1",
+		GhostID:     "ghost.alpha",
+		SeedID:      "seed.flow",
+		Outcome:     "success",
+		TimestampMS: 1700000000000,
+		Seq:         7,
+	}
+	built, err := NewEventFrame(3, event)
+	if err != nil {
+		t.Fatalf("build event frame: %v", err)
+	}
+	got, err := DecodeEventFrame(built)
+	if err != nil || got != event {
+		t.Fatalf("built frame decode=%+v err=%v", got, err)
+	}
+	raw, err := EncodeEventFrame(3, event)
+	if err != nil {
+		t.Fatalf("encode event frame: %v", err)
+	}
+	decoded, err := frame.DecodeMessage(raw, frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("decode message: %v", err)
+	}
+	if decoded.Header.MessageID != built.Header.MessageID || !bytes.Equal(decoded.Payload, built.Payload) {
+		t.Fatalf("encoded message differs from built frame: header=%+v", decoded.Header)
+	}
+	if _, err := NewEventFrame(3, Event{}); err == nil {
+		t.Fatalf("expected invalid event rejected by builder")
+	}
+}
+
+func BenchmarkEventFrameRoundTrip(b *testing.B) {
+	event := Event{
+		EventID:     "evt.alpha.0001",
+		CommandID:   "cmd.alpha.0001",
+		IntentID:    "intent.alpha",
+		GhostID:     "ghost.alpha",
+		SeedID:      "seed.flow",
+		Outcome:     "success",
+		TimestampMS: 1700000000000,
+	}
+	limits := frame.DefaultLimits()
+	b.ReportAllocs()
+	for b.Loop() {
+		raw, err := EncodeEventFrame(1, event)
+		if err != nil {
+			b.Fatalf("encode: %v", err)
+		}
+		fr, err := frame.DecodeMessage(raw, limits)
+		if err != nil {
+			b.Fatalf("decode message: %v", err)
+		}
+		if _, err := DecodeEventFrame(fr); err != nil {
+			b.Fatalf("decode event: %v", err)
+		}
+	}
+}
+
+func TestEventAckFrameCarriesThrottleHint(t *testing.T) {
+	testlog.Start(t)
+
+	ack := EventAck{
+		EventID:      "evt.1",
+		CommandID:    "cmd.1",
+		GhostID:      "ghost.alpha",
+		AckStatus:    AckStatusThrottled,
+		AckCode:      ErrorCodeTransportThrottled,
+		RetryAfterMS: 250,
+		TimestampMS:  1700000000000,
+	}
+	payload, err := EncodeEventAckFrame(5, ack)
+	if err != nil {
+		t.Fatalf("encode throttled ack: %v", err)
+	}
+	fr, err := ReadFrame(bytes.NewReader(payload), frame.DefaultLimits())
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	got, err := DecodeEventAckFrame(fr)
+	if err != nil || got != ack {
+		t.Fatalf("throttled ack mismatch: in=%+v out=%+v err=%v", ack, got, err)
+	}
+	if ErrorClassForCode(got.AckCode) != ErrorClassTransport {
+		t.Fatalf("expected throttle code in transport class, got %q", ErrorClassForCode(got.AckCode))
+	}
+}
@@ -0,0 +1,274 @@
+package session
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	logs "github.com/danmuck/smplog"
+)
+
+var (
+	ErrTLSCertificateUnavailable = errors.New("session: tls certificate unavailable")
+	ErrTLSCertificateRevoked     = errors.New("session: tls certificate revoked")
+)
+
+// Session TLS file paths watched by a TLSReloader; empty paths are skipped.
+// CRLFile lists revoked client serials and must be signed by a cert in CAFile.
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	CRLFile  string
+}
+
+// Session TLS material backed by cert, key, CA, and CRL files that may rotate on disk.
+// Files are stat-checked on every access and reparsed only when one changed, so each
+// new handshake sees rotated material; a failed reload keeps the previous material.
+type TLSReloader struct {
+	files TLSFiles
+
+	mu      sync.Mutex
+	stamps  map[string]fileStamp
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	revoked map[string]struct{}
+}
+
+// Session file identity used to detect rotation without rereading contents.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Session TLS reloader constructor; empty paths disable that piece of material.
+// The initial load must succeed so misconfiguration still fails at startup.
+func NewTLSReloader(files TLSFiles) (*TLSReloader, error) {
+	r := &TLSReloader{
+		files: TLSFiles{
+			CertFile: strings.TrimSpace(files.CertFile),
+			KeyFile:  strings.TrimSpace(files.KeyFile),
+			CAFile:   strings.TrimSpace(files.CAFile),
+			CRLFile:  strings.TrimSpace(files.CRLFile),
+		},
+	}
+	stamps, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(stamps); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Certificate returns the current leaf certificate after a rotation check.
+func (r *TLSReloader) Certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshLocked()
+	if r.cert == nil {
+		return nil, ErrTLSCertificateUnavailable
+	}
+	return r.cert, nil
+}
+
+// CAPool returns the current CA pool after a rotation check; nil when no CA file is set.
+func (r *TLSReloader) CAPool() *x509.CertPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshLocked()
+	return r.pool
+}
+
+// VerifyClient checks a peer client chain against the current CA pool and CRL.
+// Used as tls.Config.VerifyPeerCertificate so CA rotation and revocations apply
+// without a new listener.
+func (r *TLSReloader) VerifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return ErrMTLSRequired
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("session: parse peer certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	r.mu.Lock()
+	r.refreshLocked()
+	pool, revoked := r.pool, r.revoked
+	r.mu.Unlock()
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return err
+	}
+	if _, ok := revoked[certs[0].SerialNumber.String()]; ok {
+		return fmt.Errorf("%w: serial=%x", ErrTLSCertificateRevoked, certs[0].SerialNumber)
+	}
+	return nil
+}
+
+// Session rotation check; reload errors are logged and retried on the next access.
+func (r *TLSReloader) refreshLocked() {
+	stamps, err := r.stat()
+	if err != nil {
+		logs.Warnf("session.TLSReloader stat err=%v", err)
+		return
+	}
+	if sameStamps(stamps, r.stamps) {
+		return
+	}
+	if err := r.load(stamps); err != nil {
+		logs.Warnf("session.TLSReloader reload kept previous material err=%v", err)
+		return
+	}
+	logs.Infof("session.TLSReloader reloaded cert=%q ca=%q crl=%q", r.files.CertFile, r.files.CAFile, r.files.CRLFile)
+}
+
+// Session stat of every configured file.
+func (r *TLSReloader) stat() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp, 4)
+	for _, path := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile, r.files.CRLFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// Session parse of every configured file; material is replaced only when all of it parses.
+func (r *TLSReloader) load(stamps map[string]fileStamp) error {
+	var cert *tls.Certificate
+	if r.files.CertFile != "" || r.files.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return err
+		}
+		cert = &pair
+	}
+	var pool *x509.CertPool
+	var cas []*x509.Certificate
+	if r.files.CAFile != "" {
+		caPEM, err := os.ReadFile(r.files.CAFile)
+		if err != nil {
+			return err
+		}
+		cas = parsePEMCertificates(caPEM)
+		if len(cas) == 0 {
+			return fmt.Errorf("session: parse tls ca bundle: %s", r.files.CAFile)
+		}
+		pool = x509.NewCertPool()
+		for _, ca := range cas {
+			pool.AddCert(ca)
+		}
+	}
+	var revoked map[string]struct{}
+	if r.files.CRLFile != "" {
+		var err error
+		if revoked, err = loadRevoked(r.files.CRLFile, cas); err != nil {
+			return err
+		}
+	}
+	r.cert = cert
+	r.pool = pool
+	r.revoked = revoked
+	r.stamps = stamps
+	return nil
+}
+
+// Session revoked-serial set from a PEM or DER CRL signed by one of cas.
+func loadRevoked(path string, cas []*x509.Certificate) (map[string]struct{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return nil, fmt.Errorf("session: parse tls crl %s: %w", path, err)
+	}
+	signed := false
+	for _, ca := range cas {
+		if crl.CheckSignatureFrom(ca) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, fmt.Errorf("session: tls crl %s not signed by configured ca", path)
+	}
+	revoked := make(map[string]struct{}, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked[entry.SerialNumber.String()] = struct{}{}
+	}
+	return revoked, nil
+}
+
+// Session CERTIFICATE blocks parsed from a PEM bundle; other blocks are skipped.
+func parsePEMCertificates(raw []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// Session stamp comparison across the configured file set.
+func sameStamps(a map[string]fileStamp, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		prev, ok := b[path]
+		if !ok || !prev.modTime.Equal(stamp.modTime) || prev.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+// Session TLS expiry: the earliest NotAfter of the peer leaf and the local leaf.
+// Zero when neither side presented a certificate.
+func CertificateExpiry(state tls.ConnectionState, local *tls.Certificate) time.Time {
+	var expiry time.Time
+	if len(state.PeerCertificates) > 0 {
+		expiry = state.PeerCertificates[0].NotAfter
+	}
+	if local != nil && local.Leaf != nil {
+		if expiry.IsZero() || local.Leaf.NotAfter.Before(expiry) {
+			expiry = local.Leaf.NotAfter
+		}
+	}
+	return expiry
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+	"github.com/danmuck/edgectl/internal/testutil/tlstest"
+)
+
+func TestTLSReloaderPicksUpRotatedMaterial(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	ca := tlstest.NewAuthority(t, dir, "edgectl-test-ca")
+	certFile, keyFile := ca.IssueServerCert(t, dir, "mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")})
+
+	files, err := NewTLSReloader(TLSFiles{CertFile: certFile, KeyFile: keyFile, CAFile: ca.CAFile()})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	before, err := files.Certificate()
+	if err != nil {
+		t.Fatalf("initial certificate: %v", err)
+	}
+
+	// Rotation: a new CA issues a new server cert, written over the same paths.
+	next := t.TempDir()
+	nextCA := tlstest.NewAuthority(t, next, "edgectl-test-ca-2")
+	nextCert, nextKey := nextCA.IssueServerCert(t, next, "mirage.local", []string{"mirage.local"}, []net.IP{net.ParseIP("127.0.0.1")})
+	rotateFile(t, certFile, nextCert)
+	rotateFile(t, keyFile, nextKey)
+	rotateFile(t, ca.CAFile(), nextCA.CAFile())
+
+	after, err := files.Certificate()
+	if err != nil {
+		t.Fatalf("rotated certificate: %v", err)
+	}
+	if after.Leaf.SerialNumber.Cmp(before.Leaf.SerialNumber) == 0 {
+		t.Fatalf("expected rotated certificate, serial unchanged")
+	}
+	clientCert, _ := nextCA.IssueClientCert(t, next, "ghost.alpha")
+	if err := files.VerifyClient([][]byte{readCertDER(t, clientCert)}, nil); err != nil {
+		t.Fatalf("expected client from rotated CA verified: %v", err)
+	}
+	staleClient, _ := ca.IssueClientCert(t, dir, "ghost.stale")
+	if err := files.VerifyClient([][]byte{readCertDER(t, staleClient)}, nil); err == nil {
+		t.Fatalf("expected client from replaced CA rejected")
+	}
+
+	// A torn write keeps the last good material instead of failing handshakes.
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("corrupt cert: %v", err)
+	}
+	kept, err := files.Certificate()
+	if err != nil || kept != after {
+		t.Fatalf("expected previous certificate kept, got %v err=%v", kept, err)
+	}
+}
+
+func TestCertificateExpiryPicksEarliestLeaf(t *testing.T) {
+	testlog.Start(t)
+
+	dir := t.TempDir()
+	ca := tlstest.NewAuthority(t, dir, "edgectl-test-ca")
+	soon := time.Now().Add(time.Hour).Truncate(time.Second)
+	certFile, keyFile := ca.IssueClientCertUntil(t, dir, "ghost.alpha", soon)
+	files, err := NewTLSReloader(TLSFiles{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	local, err := files.Certificate()
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	if files.CAPool() != nil {
+		t.Fatalf("expected nil CA pool without a CA file")
+	}
+	if got := CertificateExpiry(tlsStateWithPeer(t, ca, dir), local); !got.Equal(soon) {
+		t.Fatalf("expected local leaf expiry %s, got %s", soon, got)
+	}
+}
+
+// Connection state carrying one day-long peer certificate from ca.
+func tlsStateWithPeer(t *testing.T, ca *tlstest.Authority, dir string) tls.ConnectionState {
+	t.Helper()
+	peerFile, _ := ca.IssueServerCert(t, dir, "mirage.peer", nil, nil)
+	peer, err := x509.ParseCertificate(readCertDER(t, peerFile))
+	if err != nil {
+		t.Fatalf("parse peer cert: %v", err)
+	}
+	return tls.ConnectionState{PeerCertificates: []*x509.Certificate{peer}}
+}
+
+// Overwrites dst in place with src contents, as a cert rotation job would.
+func rotateFile(t *testing.T, dst string, src string) {
+	t.Helper()
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read %s: %v", src, err)
+	}
+	if err := os.WriteFile(dst, raw, 0o600); err != nil {
+		t.Fatalf("rotate %s: %v", dst, err)
+	}
+}
+
+// First PEM certificate block in path as DER.
+func readCertDER(t *testing.T, path string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		t.Fatalf("no pem block in %s", path)
+	}
+	return block.Bytes
+}
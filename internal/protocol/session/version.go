@@ -0,0 +1,115 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+	"github.com/danmuck/edgectl/internal/protocol/schema"
+	"github.com/danmuck/edgectl/internal/protocol/tlv"
+)
+
+const (
+	// Session protocol version assumed for peers that do not advertise a range.
+	LegacyProtocolVersion uint16 = 1
+	// Session protocol version that carries args as native TLV string maps.
+	ArgsMapProtocolVersion uint16 = 2
+)
+
+var (
+	ErrNoCommonProtocolVersion = errors.New("session: no common protocol version")
+	ErrProtocolVersionMismatch = errors.New("session: frame version differs from negotiated version")
+)
+
+// Session inclusive protocol version range advertised during registration.
+type VersionRange struct {
+	Min uint16
+	Max uint16
+}
+
+// Session version range covering every version the local frame codec supports.
+func SupportedVersionRange() VersionRange {
+	return VersionRange{Min: frame.MinProtocolVersion, Max: frame.MaxProtocolVersion}
+}
+
+// Session range normalizer that fills unset bounds and clamps to codec support.
+func (r VersionRange) WithDefaults() VersionRange {
+	local := SupportedVersionRange()
+	if r.Min == 0 || r.Min < local.Min {
+		r.Min = local.Min
+	}
+	if r.Max == 0 || r.Max > local.Max {
+		r.Max = local.Max
+	}
+	return r
+}
+
+// Session range membership check.
+func (r VersionRange) Contains(v uint16) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// Session version range declared by a registering Ghost.
+// Registrations without a range come from peers that only speak the legacy version.
+func (r Registration) ProtocolVersions() VersionRange {
+	if r.MinProtocolVersion == 0 && r.MaxProtocolVersion == 0 {
+		return VersionRange{Min: LegacyProtocolVersion, Max: LegacyProtocolVersion}
+	}
+	out := VersionRange{Min: r.MinProtocolVersion, Max: r.MaxProtocolVersion}
+	if out.Min == 0 {
+		out.Min = LegacyProtocolVersion
+	}
+	if out.Max == 0 {
+		out.Max = out.Min
+	}
+	return out
+}
+
+// Session version selected by Mirage; acks from older Mirages imply the legacy version.
+func (a RegistrationAck) NegotiatedVersion() uint16 {
+	if a.ProtocolVersion == 0 {
+		return LegacyProtocolVersion
+	}
+	return a.ProtocolVersion
+}
+
+// Session negotiation selecting the highest version inside both ranges.
+func NegotiateProtocolVersion(local VersionRange, peer VersionRange) (uint16, error) {
+	lo := max(local.Min, peer.Min)
+	hi := min(local.Max, peer.Max)
+	if lo == 0 || lo > hi {
+		return 0, fmt.Errorf(
+			"%w: local=%d..%d peer=%d..%d",
+			ErrNoCommonProtocolVersion,
+			local.Min,
+			local.Max,
+			peer.Min,
+			peer.Max,
+		)
+	}
+	return hi, nil
+}
+
+// Session check that an inbound frame uses the version negotiated for the stream.
+func CheckFrameVersion(f frame.Frame, negotiated uint16) error {
+	if f.Header.Version != negotiated {
+		return fmt.Errorf(
+			"%w: got=%d want=%d message_id=%d",
+			ErrProtocolVersionMismatch,
+			f.Header.Version,
+			negotiated,
+			f.Header.MessageID,
+		)
+	}
+	return nil
+}
+
+// Session schema validation using the version carried in the frame header.
+// Frames built in memory without a version validate against the default version.
+func validateFrameFields(f frame.Frame, messageType uint32, fields []tlv.Field) error {
+	version := f.Header.Version
+	if version == 0 {
+		version = frame.ProtocolVersion
+	}
+	return schema.ValidateVersion(version, messageType, fields)
+}
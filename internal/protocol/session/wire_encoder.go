@@ -0,0 +1,15 @@
+package session
+
+import (
+	"github.com/danmuck/edgectl/internal/protocol/frame"
+)
+
+// Session outbound encoder settings for one registered stream: the negotiated
+// version and codec plus the frame authenticator (nil leaves frames unsigned).
+func WireEncoderOptions(version uint16, codec string, threshold int, auth frame.Authenticator) frame.EncoderOptions {
+	opts := frame.EncoderOptions{Version: version, Auth: auth}
+	if codec != CompressionNone {
+		opts.CompressThreshold = threshold
+	}
+	return opts
+}
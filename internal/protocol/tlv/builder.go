@@ -0,0 +1,322 @@
+package tlv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFieldMissing = errors.New("tlv: field missing")
+	ErrFieldType    = errors.New("tlv: field type mismatch")
+	ErrFieldLength  = errors.New("tlv: invalid field length")
+)
+
+// TLV ordered field builder with typed setters encoding values in network byte order.
+// The zero value is ready to use.
+type Builder struct {
+	fields []Field
+}
+
+// TLV builder constructor with capacity for n fields.
+func NewBuilder(n int) *Builder {
+	return &Builder{fields: make([]Field, 0, n)}
+}
+
+// Field appends one pre-built field as-is.
+func (b *Builder) Field(f Field) *Builder {
+	b.fields = append(b.fields, f)
+	return b
+}
+
+// U8 appends a TypeU8 field.
+func (b *Builder) U8(id uint16, v uint8) *Builder {
+	return b.Field(Field{ID: id, Type: TypeU8, Value: []byte{v}})
+}
+
+// U16 appends a TypeU16 field.
+func (b *Builder) U16(id uint16, v uint16) *Builder {
+	return b.Field(Field{ID: id, Type: TypeU16, Value: binary.BigEndian.AppendUint16(nil, v)})
+}
+
+// U32 appends a TypeU32 field.
+func (b *Builder) U32(id uint16, v uint32) *Builder {
+	return b.Field(Field{ID: id, Type: TypeU32, Value: binary.BigEndian.AppendUint32(nil, v)})
+}
+
+// U64 appends a TypeU64 field.
+func (b *Builder) U64(id uint16, v uint64) *Builder {
+	return b.Field(Field{ID: id, Type: TypeU64, Value: binary.BigEndian.AppendUint64(nil, v)})
+}
+
+// Bool appends a TypeBool field encoded as one 0x00/0x01 byte.
+func (b *Builder) Bool(id uint16, v bool) *Builder {
+	var raw byte
+	if v {
+		raw = 1
+	}
+	return b.Field(Field{ID: id, Type: TypeBool, Value: []byte{raw}})
+}
+
+// String appends a TypeString field.
+func (b *Builder) String(id uint16, v string) *Builder {
+	return b.Field(Field{ID: id, Type: TypeString, Value: []byte(v)})
+}
+
+// Bytes appends a TypeBytes field; v is referenced, not copied.
+func (b *Builder) Bytes(id uint16, v []byte) *Builder {
+	return b.Field(Field{ID: id, Type: TypeBytes, Value: v})
+}
+
+// List appends a TypeList field holding elems.
+func (b *Builder) List(id uint16, elems []Field) *Builder {
+	return b.Field(Field{ID: id, Type: TypeList, Value: EncodeList(elems)})
+}
+
+// Map appends a TypeMap field holding entries sorted by key.
+func (b *Builder) Map(id uint16, entries []MapEntry) *Builder {
+	return b.Field(Field{ID: id, Type: TypeMap, Value: EncodeMap(entries)})
+}
+
+// StringMap appends a TypeMap field of string values.
+func (b *Builder) StringMap(id uint16, m map[string]string) *Builder {
+	return b.Map(id, StringEntries(m))
+}
+
+// OptString appends a TypeString field only when v is non-empty.
+func (b *Builder) OptString(id uint16, v string) *Builder {
+	if v == "" {
+		return b
+	}
+	return b.String(id, v)
+}
+
+// OptU64 appends a TypeU64 field only when v is non-zero.
+func (b *Builder) OptU64(id uint16, v uint64) *Builder {
+	if v == 0 {
+		return b
+	}
+	return b.U64(id, v)
+}
+
+// Fields returns the ordered fields built so far.
+func (b *Builder) Fields() []Field {
+	return b.fields
+}
+
+// Encode serializes the built fields into one TLV payload.
+func (b *Builder) Encode() []byte {
+	return AppendFields(make([]byte, 0, EncodedLen(b.fields)), b.fields)
+}
+
+// TLV typed accessor over decoded fields; getters return the first field with an id.
+type Reader struct {
+	fields []Field
+}
+
+// TLV reader constructor over already-decoded fields.
+func NewReader(fields []Field) Reader {
+	return Reader{fields: fields}
+}
+
+// TLV reader constructor decoding payload without copying values.
+// Getters copy out, so results stay valid after payload is reused.
+func ReadPayload(payload []byte) (Reader, error) {
+	fields, err := ViewFields(nil, payload)
+	if err != nil {
+		return Reader{}, err
+	}
+	return Reader{fields: fields}, nil
+}
+
+// Fields returns the underlying decoded fields.
+func (r Reader) Fields() []Field {
+	return r.fields
+}
+
+// Has reports whether a field with id is present.
+func (r Reader) Has(id uint16) bool {
+	for _, f := range r.fields {
+		if f.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// U8 returns a required TypeU8 field.
+func (r Reader) U8(id uint16) (uint8, error) {
+	f, err := r.typed(id, TypeU8)
+	if err != nil {
+		return 0, err
+	}
+	return U8FromBytes(f.Value)
+}
+
+// U16 returns a required TypeU16 field.
+func (r Reader) U16(id uint16) (uint16, error) {
+	f, err := r.typed(id, TypeU16)
+	if err != nil {
+		return 0, err
+	}
+	return U16FromBytes(f.Value)
+}
+
+// U32 returns a required TypeU32 field.
+func (r Reader) U32(id uint16) (uint32, error) {
+	f, err := r.typed(id, TypeU32)
+	if err != nil {
+		return 0, err
+	}
+	return U32FromBytes(f.Value)
+}
+
+// U64 returns a required TypeU64 field.
+func (r Reader) U64(id uint16) (uint64, error) {
+	f, err := r.typed(id, TypeU64)
+	if err != nil {
+		return 0, err
+	}
+	return U64FromBytes(f.Value)
+}
+
+// Bool returns a required TypeBool field.
+func (r Reader) Bool(id uint16) (bool, error) {
+	f, err := r.typed(id, TypeBool)
+	if err != nil {
+		return false, err
+	}
+	return BoolFromBytes(f.Value)
+}
+
+// String returns a required TypeString field.
+func (r Reader) String(id uint16) (string, error) {
+	f, err := r.typed(id, TypeString)
+	if err != nil {
+		return "", err
+	}
+	return string(f.Value), nil
+}
+
+// Bytes returns a copy of a required TypeBytes field.
+func (r Reader) Bytes(id uint16) ([]byte, error) {
+	f, err := r.typed(id, TypeBytes)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), f.Value...), nil
+}
+
+// List returns the elements of a required TypeList field within default container limits.
+func (r Reader) List(id uint16) ([]Field, error) {
+	f, err := r.typed(id, TypeList)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeList(f.Value, DefaultContainerLimits())
+}
+
+// Map returns the entries of a required TypeMap field within default container limits.
+func (r Reader) Map(id uint16) ([]MapEntry, error) {
+	f, err := r.typed(id, TypeMap)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeMap(f.Value, DefaultContainerLimits())
+}
+
+// StringMap returns a required TypeMap field whose values are all strings.
+func (r Reader) StringMap(id uint16) (map[string]string, error) {
+	f, err := r.typed(id, TypeMap)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeStringMap(f.Value, DefaultContainerLimits())
+}
+
+// Type returns the type id of the field with id, or false when absent.
+func (r Reader) Type(id uint16) (uint8, bool) {
+	for _, f := range r.fields {
+		if f.ID == id {
+			return f.Type, true
+		}
+	}
+	return 0, false
+}
+
+// OptString returns a TypeString field, or "" when absent.
+func (r Reader) OptString(id uint16) (string, error) {
+	if !r.Has(id) {
+		return "", nil
+	}
+	return r.String(id)
+}
+
+// OptU32 returns a TypeU32 field, or 0 when absent.
+func (r Reader) OptU32(id uint16) (uint32, error) {
+	if !r.Has(id) {
+		return 0, nil
+	}
+	return r.U32(id)
+}
+
+// OptU64 returns a TypeU64 field, or 0 when absent.
+func (r Reader) OptU64(id uint16) (uint64, error) {
+	if !r.Has(id) {
+		return 0, nil
+	}
+	return r.U64(id)
+}
+
+// TLV reader lookup enforcing presence and type id.
+func (r Reader) typed(id uint16, want uint8) (Field, error) {
+	for _, f := range r.fields {
+		if f.ID != id {
+			continue
+		}
+		if f.Type != want {
+			return Field{}, fmt.Errorf("%w: id=%d got=%d want=%d", ErrFieldType, id, f.Type, want)
+		}
+		return f, nil
+	}
+	return Field{}, fmt.Errorf("%w: id=%d", ErrFieldMissing, id)
+}
+
+// TLV helper decoding a uint8 from fixed-length bytes.
+func U8FromBytes(b []byte) (uint8, error) {
+	if len(b) != 1 {
+		return 0, fmt.Errorf("%w: u8 length=%d", ErrFieldLength, len(b))
+	}
+	return b[0], nil
+}
+
+// TLV helper decoding a big-endian uint16 from fixed-length bytes.
+func U16FromBytes(b []byte) (uint16, error) {
+	if len(b) != 2 {
+		return 0, fmt.Errorf("%w: u16 length=%d", ErrFieldLength, len(b))
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// TLV helper decoding a big-endian uint64 from fixed-length bytes.
+func U64FromBytes(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("%w: u64 length=%d", ErrFieldLength, len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// TLV helper decoding a one-byte boolean; values other than 0x00/0x01 are rejected.
+func BoolFromBytes(b []byte) (bool, error) {
+	if len(b) != 1 {
+		return false, fmt.Errorf("%w: bool length=%d", ErrFieldLength, len(b))
+	}
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: bool value=0x%02X", ErrFieldLength, b[0])
+	}
+}
@@ -0,0 +1,92 @@
+package tlv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestBuilderReaderRoundTripAllTypes(t *testing.T) {
+	testlog.Start(t)
+	raw := []byte{0xDE, 0xAD}
+	payload := NewBuilder(7).
+		U8(1, 0xAB).
+		U16(2, 0xBEEF).
+		U32(3, 0xCAFEBABE).
+		U64(4, 1<<40+7).
+		Bool(5, true).
+		String(6, "ghost.alpha").
+		Bytes(7, raw).
+		OptString(8, "").
+		OptU64(9, 0).
+		Encode()
+
+	r, err := ReadPayload(payload)
+	if err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	if len(r.Fields()) != 7 || r.Has(8) || r.Has(9) {
+		t.Fatalf("expected optional zero values to be omitted, fields=%d", len(r.Fields()))
+	}
+	if v, err := r.U8(1); err != nil || v != 0xAB {
+		t.Fatalf("u8=%d err=%v", v, err)
+	}
+	if v, err := r.U16(2); err != nil || v != 0xBEEF {
+		t.Fatalf("u16=%d err=%v", v, err)
+	}
+	if v, err := r.U32(3); err != nil || v != 0xCAFEBABE {
+		t.Fatalf("u32=%d err=%v", v, err)
+	}
+	if v, err := r.U64(4); err != nil || v != 1<<40+7 {
+		t.Fatalf("u64=%d err=%v", v, err)
+	}
+	if v, err := r.Bool(5); err != nil || !v {
+		t.Fatalf("bool=%v err=%v", v, err)
+	}
+	if v, err := r.String(6); err != nil || v != "ghost.alpha" {
+		t.Fatalf("string=%q err=%v", v, err)
+	}
+	got, err := r.Bytes(7)
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("bytes=%x err=%v", got, err)
+	}
+	payload[len(payload)-1] = 0x00
+	if got[1] != 0xAD {
+		t.Fatalf("expected Bytes to return a copy of the payload value")
+	}
+	if v, err := r.OptString(8); err != nil || v != "" {
+		t.Fatalf("opt string=%q err=%v", v, err)
+	}
+	if v, err := r.OptU64(9); err != nil || v != 0 {
+		t.Fatalf("opt u64=%d err=%v", v, err)
+	}
+}
+
+func TestReaderReportsMissingMismatchedAndMalformedFields(t *testing.T) {
+	testlog.Start(t)
+	r := NewReader([]Field{
+		{ID: 1, Type: TypeString, Value: []byte("x")},
+		{ID: 2, Type: TypeU64, Value: []byte{1, 2, 3}},
+		{ID: 3, Type: TypeBool, Value: []byte{2}},
+	})
+	if _, err := r.String(9); !errors.Is(err, ErrFieldMissing) {
+		t.Fatalf("expected ErrFieldMissing, got %v", err)
+	}
+	if _, err := r.U32(1); !errors.Is(err, ErrFieldType) {
+		t.Fatalf("expected ErrFieldType, got %v", err)
+	}
+	if _, err := r.OptU64(2); !errors.Is(err, ErrFieldLength) {
+		t.Fatalf("expected ErrFieldLength for short u64, got %v", err)
+	}
+	if _, err := r.Bool(3); !errors.Is(err, ErrFieldLength) {
+		t.Fatalf("expected ErrFieldLength for non-canonical bool, got %v", err)
+	}
+	if _, err := U16FromBytes([]byte{1}); !errors.Is(err, ErrFieldLength) {
+		t.Fatalf("expected ErrFieldLength for short u16, got %v", err)
+	}
+	if _, err := ReadPayload([]byte{0, 1}); !errors.Is(err, ErrShortFieldHeader) {
+		t.Fatalf("expected ErrShortFieldHeader, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package tlv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Container element id; list elements and map keys/values carry no field id of their own.
+const ElementID uint16 = 0
+
+var (
+	ErrContainerInvalid  = errors.New("tlv: invalid container")
+	ErrContainerTooDeep  = errors.New("tlv: container nesting too deep")
+	ErrContainerTooLarge = errors.New("tlv: container has too many elements")
+)
+
+// TLV bounds applied while decoding list and map values.
+// Depth counts the outermost container as 1; elements are counted per container.
+type ContainerLimits struct {
+	MaxDepth    int
+	MaxElements int
+}
+
+// TLV conservative container bounds for control-plane payloads.
+func DefaultContainerLimits() ContainerLimits {
+	return ContainerLimits{
+		MaxDepth:    4,
+		MaxElements: 1024,
+	}
+}
+
+// TLV map entry pairing a string key with a typed value field.
+type MapEntry struct {
+	Key   string
+	Value Field
+}
+
+// TLV list serializer: a sequence of element fields with ElementID.
+func EncodeList(elems []Field) []byte {
+	out := make([]byte, 0, EncodedLen(elems))
+	for _, e := range elems {
+		e.ID = ElementID
+		out = AppendField(out, e)
+	}
+	return out
+}
+
+// TLV map serializer: key string field followed by value field per entry.
+// Entries are written sorted by key so equal maps encode to equal bytes;
+// duplicate keys are encoded as given and rejected by DecodeMap.
+func EncodeMap(entries []MapEntry) []byte {
+	sorted := append([]MapEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	var out []byte
+	for _, e := range sorted {
+		out = AppendField(out, Field{ID: ElementID, Type: TypeString, Value: []byte(e.Key)})
+		v := e.Value
+		v.ID = ElementID
+		out = AppendField(out, v)
+	}
+	return out
+}
+
+// TLV map entries for a string-to-string map, sorted by key.
+func StringEntries(m map[string]string) []MapEntry {
+	out := make([]MapEntry, 0, len(m))
+	for k, v := range m {
+		out = append(out, MapEntry{Key: k, Value: Field{ID: ElementID, Type: TypeString, Value: []byte(v)}})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// TLV list parser; element values alias value and nested containers are checked.
+func DecodeList(value []byte, limits ContainerLimits) ([]Field, error) {
+	return decodeList(value, limits, 1)
+}
+
+// TLV map parser; keys must be unique strings and nested containers are checked.
+func DecodeMap(value []byte, limits ContainerLimits) ([]MapEntry, error) {
+	return decodeMap(value, limits, 1)
+}
+
+// TLV string map parser requiring every map value to be TypeString.
+func DecodeStringMap(value []byte, limits ContainerLimits) (map[string]string, error) {
+	entries, err := DecodeMap(value, limits)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if e.Value.Type != TypeString {
+			return nil, fmt.Errorf("%w: key=%q got=%d want=%d", ErrFieldType, e.Key, e.Value.Type, TypeString)
+		}
+		out[e.Key] = string(e.Value.Value)
+	}
+	return out, nil
+}
+
+// TLV container validator for one list or map field.
+// When elem is non-zero every list element or map value must have that type.
+func ValidateContainer(f Field, elem uint8, limits ContainerLimits) error {
+	var types []uint8
+	switch f.Type {
+	case TypeList:
+		elems, err := DecodeList(f.Value, limits)
+		if err != nil {
+			return err
+		}
+		for _, e := range elems {
+			types = append(types, e.Type)
+		}
+	case TypeMap:
+		entries, err := DecodeMap(f.Value, limits)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			types = append(types, e.Value.Type)
+		}
+	default:
+		return fmt.Errorf("%w: field %d type=%d is not a container", ErrContainerInvalid, f.ID, f.Type)
+	}
+	if elem == 0 {
+		return nil
+	}
+	for i, t := range types {
+		if t != elem {
+			return fmt.Errorf("%w: field %d element=%d got=%d want=%d", ErrFieldType, f.ID, i, t, elem)
+		}
+	}
+	return nil
+}
+
+// TLV list decode at one nesting depth.
+func decodeList(value []byte, limits ContainerLimits, depth int) ([]Field, error) {
+	if depth > limits.MaxDepth {
+		return nil, fmt.Errorf("%w: depth=%d max=%d", ErrContainerTooDeep, depth, limits.MaxDepth)
+	}
+	var out []Field
+	it := NewIterator(value)
+	for {
+		f, ok := it.Next()
+		if !ok {
+			break
+		}
+		if len(out) >= limits.MaxElements {
+			return nil, fmt.Errorf("%w: max=%d", ErrContainerTooLarge, limits.MaxElements)
+		}
+		if err := checkNested(f, limits, depth); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrContainerInvalid, err)
+	}
+	return out, nil
+}
+
+// TLV map decode at one nesting depth.
+func decodeMap(value []byte, limits ContainerLimits, depth int) ([]MapEntry, error) {
+	if depth > limits.MaxDepth {
+		return nil, fmt.Errorf("%w: depth=%d max=%d", ErrContainerTooDeep, depth, limits.MaxDepth)
+	}
+	var out []MapEntry
+	seen := make(map[string]struct{})
+	it := NewIterator(value)
+	for {
+		key, ok := it.Next()
+		if !ok {
+			break
+		}
+		if key.Type != TypeString {
+			return nil, fmt.Errorf("%w: map key type=%d", ErrContainerInvalid, key.Type)
+		}
+		val, ok := it.Next()
+		if !ok {
+			if it.Err() != nil {
+				break
+			}
+			return nil, fmt.Errorf("%w: map key %q has no value", ErrContainerInvalid, key.Value)
+		}
+		if len(out) >= limits.MaxElements {
+			return nil, fmt.Errorf("%w: max=%d", ErrContainerTooLarge, limits.MaxElements)
+		}
+		k := string(key.Value)
+		if _, dup := seen[k]; dup {
+			return nil, fmt.Errorf("%w: duplicate map key %q", ErrContainerInvalid, k)
+		}
+		seen[k] = struct{}{}
+		if err := checkNested(val, limits, depth); err != nil {
+			return nil, err
+		}
+		out = append(out, MapEntry{Key: k, Value: val})
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrContainerInvalid, err)
+	}
+	return out, nil
+}
+
+// TLV recursive check for a container nested inside another container.
+func checkNested(f Field, limits ContainerLimits, depth int) error {
+	switch f.Type {
+	case TypeList:
+		_, err := decodeList(f.Value, limits, depth+1)
+		return err
+	case TypeMap:
+		_, err := decodeMap(f.Value, limits, depth+1)
+		return err
+	default:
+		return nil
+	}
+}
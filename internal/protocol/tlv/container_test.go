@@ -0,0 +1,86 @@
+package tlv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestMapAndListRoundTripNested(t *testing.T) {
+	testlog.Start(t)
+	inner := EncodeList([]Field{
+		{Type: TypeU32, Value: []byte{0, 0, 0, 7}},
+		{Type: TypeString, Value: []byte("x")},
+	})
+	value := EncodeMap([]MapEntry{
+		{Key: "zeta", Value: Field{Type: TypeString, Value: []byte("last")}},
+		{Key: "alpha", Value: Field{Type: TypeList, Value: inner}},
+	})
+	entries, err := DecodeMap(value, DefaultContainerLimits())
+	if err != nil {
+		t.Fatalf("decode map: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Key != "alpha" || entries[1].Key != "zeta" {
+		t.Fatalf("expected entries sorted by key, got %+v", entries)
+	}
+	elems, err := DecodeList(entries[0].Value.Value, DefaultContainerLimits())
+	if err != nil || len(elems) != 2 || elems[1].Type != TypeString {
+		t.Fatalf("nested list=%+v err=%v", elems, err)
+	}
+
+	a := EncodeMap(StringEntries(map[string]string{"b": "2", "a": "1"}))
+	b := EncodeMap(StringEntries(map[string]string{"a": "1", "b": "2"}))
+	if !bytes.Equal(a, b) {
+		t.Fatalf("expected deterministic map encoding")
+	}
+	m, err := DecodeStringMap(a, DefaultContainerLimits())
+	if err != nil || len(m) != 2 || m["a"] != "1" || m["b"] != "2" {
+		t.Fatalf("string map=%v err=%v", m, err)
+	}
+	if _, err := DecodeStringMap(value, DefaultContainerLimits()); !errors.Is(err, ErrFieldType) {
+		t.Fatalf("expected ErrFieldType for non-string map value, got %v", err)
+	}
+}
+
+func TestContainerDecodeEnforcesLimitsAndShape(t *testing.T) {
+	testlog.Start(t)
+	limits := ContainerLimits{MaxDepth: 2, MaxElements: 2}
+
+	deep := EncodeList([]Field{{Type: TypeList, Value: EncodeList([]Field{{Type: TypeList, Value: nil}})}})
+	if _, err := DecodeList(deep, limits); !errors.Is(err, ErrContainerTooDeep) {
+		t.Fatalf("expected ErrContainerTooDeep, got %v", err)
+	}
+	wide := EncodeList([]Field{{Type: TypeU8, Value: []byte{1}}, {Type: TypeU8, Value: []byte{2}}, {Type: TypeU8, Value: []byte{3}}})
+	if _, err := DecodeList(wide, limits); !errors.Is(err, ErrContainerTooLarge) {
+		t.Fatalf("expected ErrContainerTooLarge, got %v", err)
+	}
+
+	dup := EncodeMap([]MapEntry{
+		{Key: "k", Value: Field{Type: TypeString, Value: []byte("1")}},
+		{Key: "k", Value: Field{Type: TypeString, Value: []byte("2")}},
+	})
+	if _, err := DecodeMap(dup, limits); !errors.Is(err, ErrContainerInvalid) {
+		t.Fatalf("expected duplicate key rejected, got %v", err)
+	}
+	dangling := EncodeField(Field{Type: TypeString, Value: []byte("k")})
+	if _, err := DecodeMap(dangling, limits); !errors.Is(err, ErrContainerInvalid) {
+		t.Fatalf("expected key without value rejected, got %v", err)
+	}
+	badKey := EncodeList([]Field{{Type: TypeU8, Value: []byte{1}}, {Type: TypeString, Value: []byte("v")}})
+	if _, err := DecodeMap(badKey, limits); !errors.Is(err, ErrContainerInvalid) {
+		t.Fatalf("expected non-string key rejected, got %v", err)
+	}
+	if _, err := DecodeList([]byte{0, 0, TypeString}, limits); !errors.Is(err, ErrContainerInvalid) || !errors.Is(err, ErrShortFieldHeader) {
+		t.Fatalf("expected malformed element wrapped as ErrContainerInvalid, got %v", err)
+	}
+
+	field := Field{ID: 9, Type: TypeMap, Value: EncodeMap(StringEntries(map[string]string{"a": "1"}))}
+	if err := ValidateContainer(field, TypeString, limits); err != nil {
+		t.Fatalf("validate string map: %v", err)
+	}
+	if err := ValidateContainer(field, TypeU32, limits); !errors.Is(err, ErrFieldType) {
+		t.Fatalf("expected element type mismatch, got %v", err)
+	}
+}
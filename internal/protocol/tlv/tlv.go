@@ -24,6 +24,8 @@ const (
 	TypeBool   uint8 = 5
 	TypeString uint8 = 6
 	TypeBytes  uint8 = 7
+	TypeList   uint8 = 8
+	TypeMap    uint8 = 9
 )
 
 // TLV decoded field tuple.
@@ -36,12 +38,15 @@ type Field struct {
 // TLV serializer for one field header and value bytes.
 func EncodeField(f Field) []byte {
 	logs.Debugf("tlv.EncodeField id=%d type=%d len=%d", f.ID, f.Type, len(f.Value))
-	buf := make([]byte, HeaderLen+len(f.Value))
-	binary.BigEndian.PutUint16(buf[0:2], f.ID)
-	buf[2] = f.Type
-	binary.BigEndian.PutUint32(buf[3:7], uint32(len(f.Value)))
-	copy(buf[7:], f.Value)
-	return buf
+	return AppendField(make([]byte, 0, HeaderLen+len(f.Value)), f)
+}
+
+// TLV serializer appending one field header and value bytes to dst.
+func AppendField(dst []byte, f Field) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, f.ID)
+	dst = append(dst, f.Type)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(f.Value)))
+	return append(dst, f.Value...)
 }
 
 // TLV parser for payload bytes into ordered fields.
@@ -74,24 +79,94 @@ func DecodeFields(payload []byte) ([]Field, error) {
 // TLV serializer for an ordered set of fields.
 func EncodeFields(fields []Field) []byte {
 	logs.Debugf("tlv.EncodeFields count=%d", len(fields))
-	out := make([]byte, 0)
-	for _, f := range fields {
-		out = append(out, EncodeField(f)...)
-	}
+	out := AppendFields(make([]byte, 0, EncodedLen(fields)), fields)
 	logs.Debugf("tlv.EncodeFields bytes=%d", len(out))
 	return out
 }
 
+// TLV serializer appending an ordered set of fields to dst.
+func AppendFields(dst []byte, fields []Field) []byte {
+	for _, f := range fields {
+		dst = AppendField(dst, f)
+	}
+	return dst
+}
+
+// TLV encoded size of an ordered set of fields.
+func EncodedLen(fields []Field) int {
+	n := 0
+	for _, f := range fields {
+		n += HeaderLen + len(f.Value)
+	}
+	return n
+}
+
+// TLV parser appending fields to dst whose values alias payload instead of copying.
+// The returned values are only valid while payload is unchanged.
+func ViewFields(dst []Field, payload []byte) ([]Field, error) {
+	it := NewIterator(payload)
+	for {
+		f, ok := it.Next()
+		if !ok {
+			break
+		}
+		dst = append(dst, f)
+	}
+	if err := it.Err(); err != nil {
+		logs.Errf("tlv.ViewFields err=%v", err)
+		return nil, err
+	}
+	return dst, nil
+}
+
+// TLV zero-copy field iterator over one payload.
+// Field values are sub-slices of the payload.
+type Iterator struct {
+	payload []byte
+	off     int
+	err     error
+}
+
+// TLV iterator constructor over payload bytes.
+func NewIterator(payload []byte) Iterator {
+	return Iterator{payload: payload}
+}
+
+// Next returns the next field, or false at the end of payload or on a malformed field.
+func (it *Iterator) Next() (Field, bool) {
+	if it.err != nil || it.off >= len(it.payload) {
+		return Field{}, false
+	}
+	rest := it.payload[it.off:]
+	if len(rest) < HeaderLen {
+		it.err = fmt.Errorf("%w: offset=%d", ErrShortFieldHeader, it.off)
+		return Field{}, false
+	}
+	id := binary.BigEndian.Uint16(rest[0:2])
+	typeID := rest[2]
+	l := binary.BigEndian.Uint32(rest[3:7])
+	if uint64(len(rest)-HeaderLen) < uint64(l) {
+		it.err = fmt.Errorf("%w: id=%d expected=%d remaining=%d", ErrShortFieldValue, id, l, len(rest)-HeaderLen)
+		return Field{}, false
+	}
+	end := HeaderLen + int(l)
+	it.off += end
+	return Field{ID: id, Type: typeID, Value: rest[HeaderLen:end:end]}, true
+}
+
+// Err returns the decode error that stopped iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
 // TLV field lookup returning the first field with matching id.
+// Runs once per schema requirement on every message, so it does not log.
 func GetField(fields []Field, id uint16) (Field, bool) {
-	logs.Debugf("tlv.GetField id=%d count=%d", id, len(fields))
 	for _, f := range fields {
 		if f.ID == id {
-			logs.Debugf("tlv.GetField found id=%d type=%d", f.ID, f.Type)
 			return f, true
 		}
 	}
-	logs.Debugf("tlv.GetField missing id=%d", id)
 	return Field{}, false
 }
 
@@ -109,7 +184,7 @@ func MustType(f Field, expected uint8) error {
 func U32FromBytes(b []byte) (uint32, error) {
 	if len(b) != 4 {
 		logs.Errf("tlv.U32FromBytes invalid len=%d", len(b))
-		return 0, fmt.Errorf("tlv: invalid u32 length: %d", len(b))
+		return 0, fmt.Errorf("%w: u32 length=%d", ErrFieldLength, len(b))
 	}
 	v := binary.BigEndian.Uint32(b)
 	logs.Debugf("tlv.U32FromBytes value=%d", v)
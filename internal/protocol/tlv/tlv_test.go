@@ -44,3 +44,108 @@ func TestDecodeFieldsMalformedLengthIsDeterministic(t *testing.T) {
 		t.Fatalf("expected ErrShortFieldValue, got %v", err)
 	}
 }
+
+func TestIteratorYieldsFieldsAliasingPayload(t *testing.T) {
+	testlog.Start(t)
+	in := []Field{
+		{ID: 1, Type: TypeString, Value: []byte("intent-1")},
+		{ID: 2, Type: TypeBytes, Value: nil},
+		{ID: 9999, Type: TypeBytes, Value: []byte{0xAA, 0xBB}},
+	}
+	payload := AppendFields(nil, in)
+	if !bytes.Equal(payload, EncodeFields(in)) || len(payload) != EncodedLen(in) {
+		t.Fatalf("AppendFields/EncodedLen disagree with EncodeFields")
+	}
+
+	it := NewIterator(payload)
+	var got []Field
+	for {
+		f, ok := it.Next()
+		if !ok {
+			break
+		}
+		got = append(got, f)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterate: %v", err)
+	}
+	if len(got) != 3 || got[0].ID != 1 || len(got[1].Value) != 0 || got[2].ID != 9999 {
+		t.Fatalf("unexpected fields: %+v", got)
+	}
+	payload[len(payload)-1] = 0xCC
+	if got[2].Value[1] != 0xCC {
+		t.Fatalf("expected iterator values to alias the payload")
+	}
+
+	views, err := ViewFields(nil, payload)
+	if err != nil || len(views) != 3 {
+		t.Fatalf("view fields len=%d err=%v", len(views), err)
+	}
+}
+
+func TestIteratorStopsOnMalformedField(t *testing.T) {
+	testlog.Start(t)
+	payload := append(EncodeField(Field{ID: 1, Type: TypeString, Value: []byte("ok")}), 0, 2, TypeString, 0, 0, 0, 5, 'a')
+	it := NewIterator(payload)
+	if _, ok := it.Next(); !ok {
+		t.Fatalf("expected first field")
+	}
+	if _, ok := it.Next(); ok {
+		t.Fatalf("expected malformed field to stop iteration")
+	}
+	if !errors.Is(it.Err(), ErrShortFieldValue) {
+		t.Fatalf("expected ErrShortFieldValue, got %v", it.Err())
+	}
+	if _, err := ViewFields(nil, payload[:len(payload)-6]); !errors.Is(err, ErrShortFieldHeader) {
+		t.Fatalf("expected ErrShortFieldHeader, got %v", err)
+	}
+}
+
+func benchmarkPayload() []byte {
+	return EncodeFields([]Field{
+		{ID: 1, Type: TypeString, Value: []byte("evt.alpha.0001")},
+		{ID: 2, Type: TypeString, Value: []byte("cmd.alpha.0001")},
+		{ID: 3, Type: TypeString, Value: []byte("intent.alpha")},
+		{ID: 4, Type: TypeString, Value: []byte("ghost.alpha")},
+		{ID: 5, Type: TypeString, Value: []byte("seed.flow")},
+		{ID: 6, Type: TypeString, Value: []byte("success")},
+	})
+}
+
+func BenchmarkDecodeFields(b *testing.B) {
+	payload := benchmarkPayload()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := DecodeFields(payload); err != nil {
+			b.Fatalf("decode: %v", err)
+		}
+	}
+}
+
+func BenchmarkIterator(b *testing.B) {
+	payload := benchmarkPayload()
+	b.ReportAllocs()
+	for b.Loop() {
+		it := NewIterator(payload)
+		for {
+			if _, ok := it.Next(); !ok {
+				break
+			}
+		}
+		if err := it.Err(); err != nil {
+			b.Fatalf("iterate: %v", err)
+		}
+	}
+}
+
+func BenchmarkAppendFields(b *testing.B) {
+	fields, err := DecodeFields(benchmarkPayload())
+	if err != nil {
+		b.Fatalf("decode: %v", err)
+	}
+	buf := make([]byte, 0, EncodedLen(fields))
+	b.ReportAllocs()
+	for b.Loop() {
+		buf = AppendFields(buf[:0], fields)
+	}
+}
@@ -0,0 +1,74 @@
+package seeds
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Seeds package adapter exposing any seed through the context-aware boundary.
+// Seeds that implement ContextSeed are returned as-is; legacy seeds run Execute on a
+// separate goroutine and the call returns an *AbandonedError once ctx is done. The legacy
+// call cannot be interrupted and finishes in the background with its result dropped.
+func WithContext(seed Seed) ContextSeed {
+	if cs, ok := seed.(ContextSeed); ok {
+		return cs
+	}
+	return legacySeed{Seed: seed}
+}
+
+// AbandonedError reports a legacy Execute call that outlived its context.
+// It unwraps to the context error; Done closes once the abandoned call returns, so
+// callers can keep the seed's concurrency slots held until then.
+type AbandonedError struct {
+	Err  error
+	Done <-chan struct{}
+}
+
+func (e *AbandonedError) Error() string {
+	return "seeds: legacy execute abandoned: " + e.Err.Error()
+}
+
+func (e *AbandonedError) Unwrap() error {
+	return e.Err
+}
+
+// Seeds package wrapper for seeds that predate ContextSeed.
+type legacySeed struct {
+	Seed
+}
+
+// Seeds package legacy execution bounded by ctx.
+func (s legacySeed) ExecuteContext(ctx context.Context, action string, args map[string]string) (SeedResult, error) {
+	if err := ctx.Err(); err != nil {
+		return SeedResult{}, err
+	}
+	type outcome struct {
+		result SeedResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		result, err := s.Execute(action, args)
+		done <- outcome{result: result, err: err}
+	}()
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-ctx.Done():
+		return SeedResult{}, &AbandonedError{Err: ctx.Err(), Done: returned}
+	}
+}
+
+// Seeds package lookup of the declared default timeout for one operation.
+func OperationTimeout(seed Seed, action string) time.Duration {
+	act := strings.TrimSpace(action)
+	for _, spec := range seed.Operations() {
+		if spec.Name == act {
+			return spec.Timeout
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package seeds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+type slowSeed struct {
+	fakeSeed
+	release chan struct{}
+}
+
+func (s slowSeed) Execute(action string, args map[string]string) (SeedResult, error) {
+	<-s.release
+	return SeedResult{Status: "ok"}, nil
+}
+
+func TestWithContextLegacySeedReturnsOnDeadline(t *testing.T) {
+	testlog.Start(t)
+	release := make(chan struct{})
+	defer close(release)
+	seed := WithContext(slowSeed{release: release})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := seed.ExecuteContext(ctx, "status", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	var abandoned *AbandonedError
+	if !errors.As(err, &abandoned) {
+		t.Fatalf("expected AbandonedError, got %T", err)
+	}
+	select {
+	case <-abandoned.Done:
+		t.Fatalf("abandoned call reported done while still running")
+	default:
+	}
+	release <- struct{}{}
+	select {
+	case <-abandoned.Done:
+	case <-time.After(time.Second):
+		t.Fatalf("abandoned call did not report completion")
+	}
+
+	res, err := WithContext(fakeSeed{}).ExecuteContext(context.Background(), "status", nil)
+	if err != nil || res.Status != "ok" {
+		t.Fatalf("unexpected legacy result: %+v err=%v", res, err)
+	}
+}
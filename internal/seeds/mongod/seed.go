@@ -1,9 +1,11 @@
 package mongod
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/danmuck/edgectl/internal/seeds"
 	"github.com/danmuck/edgectl/internal/tools"
@@ -12,6 +14,9 @@ import (
 
 const (
 	DefaultUnit = "mongod"
+
+	queryTimeout   = 15 * time.Second
+	controlTimeout = 2 * time.Minute
 )
 
 var (
@@ -55,16 +60,26 @@ func (s Seed) Metadata() seeds.SeedMetadata {
 // Operations returns mongod control behavior catalog.
 func (s Seed) Operations() []seeds.OperationSpec {
 	return []seeds.OperationSpec{
-		{Name: "status", Description: "read mongod service status", Idempotent: true},
-		{Name: "start", Description: "start mongod service", Idempotent: true},
-		{Name: "stop", Description: "stop mongod service", Idempotent: true},
-		{Name: "restart", Description: "restart mongod service", Idempotent: false},
-		{Name: "version", Description: "read mongod binary version", Idempotent: true},
+		{Name: "status", Description: "read mongod service status", Idempotent: true, Timeout: queryTimeout},
+		{Name: "start", Description: "start mongod service", Idempotent: true, Timeout: controlTimeout},
+		{Name: "stop", Description: "stop mongod service", Idempotent: true, Timeout: controlTimeout},
+		{Name: "restart", Description: "restart mongod service", Idempotent: false, Timeout: controlTimeout},
+		{Name: "version", Description: "read mongod binary version", Idempotent: true, Timeout: queryTimeout},
 	}
 }
 
-// Execute dispatches mongod operations to system commands.
+// Execute dispatches mongod operations to system commands without a deadline.
 func (s Seed) Execute(action string, args map[string]string) (seeds.SeedResult, error) {
+	return s.ExecuteContext(context.Background(), action, args)
+}
+
+// ExecuteContext dispatches mongod operations; the system command is killed when ctx is done.
+func (s Seed) ExecuteContext(ctx context.Context, action string, args map[string]string) (seeds.SeedResult, error) {
+	return s.ExecuteStream(ctx, action, args, nil)
+}
+
+// ExecuteStream dispatches mongod operations and streams system command output to sink.
+func (s Seed) ExecuteStream(ctx context.Context, action string, args map[string]string, sink seeds.OutputSink) (seeds.SeedResult, error) {
 	act := strings.TrimSpace(action)
 	unit := s.unit
 	if args != nil {
@@ -74,23 +89,31 @@ func (s Seed) Execute(action string, args map[string]string) (seeds.SeedResult,
 	}
 	switch act {
 	case "status":
-		return s.exec("systemctl", "is-active", unit)
+		return s.exec(ctx, sink, "systemctl", "is-active", unit)
 	case "start":
-		return s.exec("systemctl", "start", unit)
+		return s.exec(ctx, sink, "systemctl", "start", unit)
 	case "stop":
-		return s.exec("systemctl", "stop", unit)
+		return s.exec(ctx, sink, "systemctl", "stop", unit)
 	case "restart":
-		return s.exec("systemctl", "restart", unit)
+		return s.exec(ctx, sink, "systemctl", "restart", unit)
 	case "version":
-		return s.exec("mongod", "--version")
+		return s.exec(ctx, sink, "mongod", "--version")
 	default:
 		errMsg := fmt.Sprintf("unknown action: %s", act)
 		return seeds.SeedResult{Status: "error", Stderr: []byte(errMsg + "\n"), ExitCode: 64}, ErrUnknownAction
 	}
 }
 
-func (s Seed) exec(name string, args ...string) (seeds.SeedResult, error) {
-	stdout, stderr, exitCode, err := s.runner.Run(name, args...)
+func (s Seed) exec(ctx context.Context, sink seeds.OutputSink, name string, args ...string) (seeds.SeedResult, error) {
+	seeds.ReportProgress(sink, 0, name+" "+strings.Join(args, " "))
+	stdout, stderr, exitCode, err := tools.RunStream(
+		ctx,
+		s.runner,
+		seeds.SinkWriter(sink, seeds.StreamStdout),
+		seeds.SinkWriter(sink, seeds.StreamStderr),
+		name,
+		args...,
+	)
 	if err != nil {
 		if len(stderr) == 0 {
 			stderr = []byte(err.Error() + "\n")
@@ -103,7 +126,8 @@ func (s Seed) exec(name string, args ...string) (seeds.SeedResult, error) {
 			Stdout:   stdout,
 			Stderr:   stderr,
 			ExitCode: exitCode,
-		}, fmt.Errorf("%w: %v", ErrCommandFailed, err)
+		}, fmt.Errorf("%w: %w", ErrCommandFailed, err)
 	}
+	seeds.ReportProgress(sink, 100, "")
 	return seeds.SeedResult{Status: "ok", Stdout: stdout, Stderr: stderr, ExitCode: 0}, nil
 }
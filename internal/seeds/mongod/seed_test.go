@@ -1,11 +1,14 @@
 package mongod
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/danmuck/edgectl/internal/seeds"
 	"github.com/danmuck/edgectl/internal/testutil/testlog"
+	"github.com/danmuck/edgectl/internal/tools"
 )
 
 type fakeRunner struct {
@@ -63,3 +66,67 @@ func TestSeedCommandFailure(t *testing.T) {
 		t.Fatalf("unexpected failure result: %+v", res)
 	}
 }
+
+type blockingRunner struct{}
+
+func (blockingRunner) Run(name string, args ...string) ([]byte, []byte, int32, error) {
+	return nil, nil, 0, nil
+}
+
+func (blockingRunner) RunContext(ctx context.Context, name string, args ...string) ([]byte, []byte, int32, error) {
+	<-ctx.Done()
+	return nil, nil, tools.ExitCodeTimeout, ctx.Err()
+}
+
+func TestSeedExecuteContextStopsOnDeadline(t *testing.T) {
+	testlog.Start(t)
+	seed := NewSeedWithRunner("mongod", blockingRunner{})
+	if got := seeds.OperationTimeout(seed, "restart"); got <= 0 {
+		t.Fatalf("restart should declare a default timeout, got %s", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := seed.ExecuteContext(ctx, "restart", nil)
+	if !errors.Is(err, ErrCommandFailed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected wrapped deadline error, got %v", err)
+	}
+	if res.Status != "error" || res.ExitCode != tools.ExitCodeTimeout {
+		t.Fatalf("unexpected timeout result: %+v", res)
+	}
+}
+
+type recordingSink struct {
+	output   map[string]string
+	progress []int
+}
+
+func (s *recordingSink) Output(stream string, chunk []byte) {
+	if s.output == nil {
+		s.output = make(map[string]string)
+	}
+	s.output[stream] += string(chunk)
+}
+
+func (s *recordingSink) Progress(percent int, _ string) {
+	s.progress = append(s.progress, percent)
+}
+
+func TestSeedExecuteStreamReportsOutputAndProgress(t *testing.T) {
+	testlog.Start(t)
+	r := &fakeRunner{stdout: []byte("db version v7.0.0\n"), stderr: []byte("warning\n")}
+	seed := NewSeedWithRunner("mongod", r)
+	sink := &recordingSink{}
+	res, err := seed.ExecuteStream(context.Background(), "version", nil, sink)
+	if err != nil {
+		t.Fatalf("version execute failed: %v", err)
+	}
+	if string(res.Stdout) != "db version v7.0.0\n" {
+		t.Fatalf("final result should keep full stdout: %q", res.Stdout)
+	}
+	if sink.output[seeds.StreamStdout] != "db version v7.0.0\n" || sink.output[seeds.StreamStderr] != "warning\n" {
+		t.Fatalf("unexpected streamed output: %+v", sink.output)
+	}
+	if len(sink.progress) != 2 || sink.progress[0] != 0 || sink.progress[1] != 100 {
+		t.Fatalf("unexpected progress: %v", sink.progress)
+	}
+}
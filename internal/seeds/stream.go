@@ -0,0 +1,33 @@
+package seeds
+
+import "io"
+
+// Seeds package writer forwarding every write to sink as one output chunk on stream.
+// A nil sink discards output.
+func SinkWriter(sink OutputSink, stream string) io.Writer {
+	if sink == nil {
+		return io.Discard
+	}
+	return sinkWriter{sink: sink, stream: stream}
+}
+
+// Seeds package io.Writer adapter over OutputSink.Output.
+type sinkWriter struct {
+	sink   OutputSink
+	stream string
+}
+
+// Seeds package write forwarding p as one chunk.
+func (w sinkWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.sink.Output(w.stream, p)
+	}
+	return len(p), nil
+}
+
+// Seeds package progress report that tolerates a nil sink.
+func ReportProgress(sink OutputSink, percent int, message string) {
+	if sink != nil {
+		sink.Progress(percent, message)
+	}
+}
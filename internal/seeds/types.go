@@ -1,5 +1,16 @@
 package seeds
 
+import (
+	"context"
+	"time"
+)
+
+// Output stream names passed to OutputSink.Output.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
 // SeedMetadata is the contract for seed identity and display data.
 type SeedMetadata struct {
 	ID          string
@@ -16,10 +27,12 @@ type SeedResult struct {
 }
 
 // OperationSpec defines one supported seed action.
+// Timeout is the default execution deadline; zero means no deadline.
 type OperationSpec struct {
 	Name        string
 	Description string
 	Idempotent  bool
+	Timeout     time.Duration
 }
 
 // Seed is the seed execution boundary used by Ghost-local dispatch.
@@ -28,3 +41,26 @@ type Seed interface {
 	Operations() []OperationSpec
 	Execute(action string, args map[string]string) (SeedResult, error)
 }
+
+// ContextSeed is the cancellable seed execution boundary.
+// ctx carries the operation deadline and Ghost-side cancellation; implementations
+// must stop work and release subprocesses once ctx is done.
+type ContextSeed interface {
+	Seed
+	ExecuteContext(ctx context.Context, action string, args map[string]string) (SeedResult, error)
+}
+
+// OutputSink receives incremental output and progress from a running seed operation.
+// Calls may arrive from multiple goroutines; implementations must not retain chunk.
+// percent is 0-100; values outside the range are clamped by the receiver.
+type OutputSink interface {
+	Output(stream string, chunk []byte)
+	Progress(percent int, message string)
+}
+
+// StreamingSeed is a ContextSeed that reports output while it runs.
+// The returned SeedResult still carries the complete stdout/stderr.
+type StreamingSeed interface {
+	ContextSeed
+	ExecuteStream(ctx context.Context, action string, args map[string]string, sink OutputSink) (SeedResult, error)
+}
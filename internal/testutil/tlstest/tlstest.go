@@ -71,13 +71,19 @@ func (a *Authority) CAFile() string {
 // tlstest server-certificate issuer using the test authority.
 func (a *Authority) IssueServerCert(t testing.TB, dir string, commonName string, dnsNames []string, ips []net.IP) (string, string) {
 	t.Helper()
-	return a.issueCert(t, dir, commonName, x509.ExtKeyUsageServerAuth, dnsNames, ips)
+	return a.issueCert(t, dir, commonName, x509.ExtKeyUsageServerAuth, dnsNames, ips, time.Now().Add(24*time.Hour))
 }
 
 // tlstest client-certificate issuer using the test authority.
 func (a *Authority) IssueClientCert(t testing.TB, dir string, commonName string) (string, string) {
 	t.Helper()
-	return a.issueCert(t, dir, commonName, x509.ExtKeyUsageClientAuth, nil, nil)
+	return a.issueCert(t, dir, commonName, x509.ExtKeyUsageClientAuth, nil, nil, time.Now().Add(24*time.Hour))
+}
+
+// tlstest short-lived client-certificate issuer for expiry tests.
+func (a *Authority) IssueClientCertUntil(t testing.TB, dir string, commonName string, notAfter time.Time) (string, string) {
+	t.Helper()
+	return a.issueCert(t, dir, commonName, x509.ExtKeyUsageClientAuth, nil, nil, notAfter)
 }
 
 // tlstest internal certificate issuer shared by client/server helpers.
@@ -88,6 +94,7 @@ func (a *Authority) issueCert(
 	usage x509.ExtKeyUsage,
 	dnsNames []string,
 	ips []net.IP,
+	notAfter time.Time,
 ) (string, string) {
 	t.Helper()
 
@@ -100,7 +107,7 @@ func (a *Authority) issueCert(
 		SerialNumber: big.NewInt(now.UnixNano()),
 		Subject:      pkix.Name{CommonName: commonName},
 		NotBefore:    now.Add(-time.Hour),
-		NotAfter:     now.Add(24 * time.Hour),
+		NotAfter:     notAfter,
 		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
 		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
 		DNSNames:     dnsNames,
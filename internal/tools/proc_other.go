@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tools
+
+import "os/exec"
+
+// tools fallback: exec.CommandContext kills only the direct child.
+func configureProcessGroup(_ *exec.Cmd) {}
@@ -2,30 +2,103 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"os/exec"
+	"time"
 )
 
+// Exit codes reported for commands stopped by their context, matching timeout(1)
+// and the shell's SIGINT convention.
+const (
+	ExitCodeTimeout   int32 = 124
+	ExitCodeCancelled int32 = 130
+)
+
+// Grace period for inherited stdout/stderr pipes to close after the process exits.
+const waitDelay = 2 * time.Second
+
 // CommandRunner abstracts shell command execution for runtime adapters.
 type CommandRunner interface {
 	Run(name string, args ...string) ([]byte, []byte, int32, error)
 }
 
+// ContextRunner is a CommandRunner whose commands stop when ctx is done.
+type ContextRunner interface {
+	CommandRunner
+	RunContext(ctx context.Context, name string, args ...string) ([]byte, []byte, int32, error)
+}
+
+// tools adapter: run through RunContext when supported, else fall back to Run.
+// Runners without context support cannot be interrupted.
+func RunContext(ctx context.Context, runner CommandRunner, name string, args ...string) ([]byte, []byte, int32, error) {
+	if cr, ok := runner.(ContextRunner); ok {
+		return cr.RunContext(ctx, name, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, contextExitCode(err), err
+	}
+	return runner.Run(name, args...)
+}
+
+// StreamRunner is a ContextRunner that also copies output to writers as it is produced.
+type StreamRunner interface {
+	ContextRunner
+	RunStream(ctx context.Context, stdout io.Writer, stderr io.Writer, name string, args ...string) ([]byte, []byte, int32, error)
+}
+
+// tools adapter: stream through RunStream when supported; other runners run to
+// completion and their buffered output is written to stdout/stderr afterwards.
+func RunStream(ctx context.Context, runner CommandRunner, stdout io.Writer, stderr io.Writer, name string, args ...string) ([]byte, []byte, int32, error) {
+	if sr, ok := runner.(StreamRunner); ok {
+		return sr.RunStream(ctx, stdout, stderr, name, args...)
+	}
+	outBytes, errBytes, code, err := RunContext(ctx, runner, name, args...)
+	if stdout != nil && len(outBytes) > 0 {
+		_, _ = stdout.Write(outBytes)
+	}
+	if stderr != nil && len(errBytes) > 0 {
+		_, _ = stderr.Write(errBytes)
+	}
+	return outBytes, errBytes, code, err
+}
+
 // ExecRunner executes commands on the local host.
 type ExecRunner struct{}
 
 // tools command-runner implementation backed by os/exec.
 func (r ExecRunner) Run(name string, args ...string) ([]byte, []byte, int32, error) {
-	cmd := exec.Command(name, args...)
+	return r.RunContext(context.Background(), name, args...)
+}
+
+// tools context-aware command runner backed by os/exec.
+// The command runs in its own process group; when ctx is done the whole group is
+// killed and the child is waited on, so no zombie or orphaned helper remains.
+// Returns ctx.Err() with ExitCodeTimeout or ExitCodeCancelled in that case.
+func (r ExecRunner) RunContext(ctx context.Context, name string, args ...string) ([]byte, []byte, int32, error) {
+	return r.RunStream(ctx, nil, nil, name, args...)
+}
+
+// tools streaming command runner backed by os/exec.
+// Output is buffered for the return values and also copied to stdout/stderr (when
+// non-nil) as the process writes it; cancellation behaves as in RunContext.
+func (r ExecRunner) RunStream(ctx context.Context, stdoutW io.Writer, stderrW io.Writer, name string, args ...string) ([]byte, []byte, int32, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stdout = teeOutput(&stdout, stdoutW)
+	cmd.Stderr = teeOutput(&stderr, stderrW)
+	configureProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
 
 	err := cmd.Run()
 	if err == nil {
 		return stdout.Bytes(), stderr.Bytes(), 0, nil
 	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return stdout.Bytes(), stderr.Bytes(), contextExitCode(ctxErr), ctxErr
+	}
 
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) {
@@ -39,3 +112,19 @@ func (r ExecRunner) Run(name string, args ...string) ([]byte, []byte, int32, err
 	}
 	return stdout.Bytes(), stderr.Bytes(), exitCode, err
 }
+
+// tools output destination buffering into buf and, when set, copying to w.
+func teeOutput(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
+// tools exit code for a context stop reason.
+func contextExitCode(err error) int32 {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ExitCodeTimeout
+	}
+	return ExitCodeCancelled
+}
@@ -0,0 +1,91 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/danmuck/edgectl/internal/testutil/testlog"
+)
+
+func TestExecRunnerRunContextTimeoutKillsAndReapsChild(t *testing.T) {
+	testlog.Start(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	stdout, _, exitCode, err := ExecRunner{}.RunContext(ctx, "sh", "-c", "echo $$; exec sleep 30")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if exitCode != ExitCodeTimeout {
+		t.Fatalf("unexpected exit code: %d", exitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("run did not stop promptly: %s", elapsed)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(stdout)))
+	if err != nil {
+		t.Fatalf("parse child pid from %q: %v", stdout, err)
+	}
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Fatalf("child pid %d still present (zombie or running): %v", pid, err)
+	}
+}
+
+func TestExecRunnerRunContextCancelled(t *testing.T) {
+	testlog.Start(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	_, _, exitCode, err := ExecRunner{}.RunContext(ctx, "sleep", "30")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if exitCode != ExitCodeCancelled {
+		t.Fatalf("unexpected exit code: %d", exitCode)
+	}
+}
+
+func TestExecRunnerRunStreamDeliversOutputBeforeExit(t *testing.T) {
+	testlog.Start(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := make(chan string, 1)
+	w := writerFunc(func(p []byte) (int, error) {
+		select {
+		case first <- string(p):
+		default:
+		}
+		return len(p), nil
+	})
+	done := make(chan []byte, 1)
+	go func() {
+		stdout, _, _, _ := ExecRunner{}.RunStream(ctx, w, nil, "sh", "-c", "echo first; exec sleep 30")
+		done <- stdout
+	}()
+
+	select {
+	case got := <-first:
+		if got != "first\n" {
+			t.Fatalf("unexpected streamed chunk: %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no output streamed while the command was running")
+	}
+	cancel()
+	if stdout := <-done; string(stdout) != "first\n" {
+		t.Fatalf("buffered stdout mismatch: %q", stdout)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }